@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func newBackupCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug            bool
+		backupMode       string
+		backupFormat     string
+		backupOutput     string
+		backupVisibility int32
+	)
+
+	backupCmd := &cobra.Command{
+		Use:   "backup <PROFILE>",
+		Short: "back up all messages in a queue to a local archive",
+		Long: `back up all messages in a queue to a local archive.
+
+Messages are archived with their attributes and metadata, either as a JSONL
+file (one message per line), or as a tar.gz file (one JSON file per message).
+
+In "peek" mode, messages stay in the queue; they're invisible to other consumers
+while the queue is being read, and are made visible again afterwards. In "drain"
+mode, messages are deleted from the queue once they're archived; only as many
+messages as the queue had when the backup started are drained (so that queues
+with a steady inflow don't keep it going), for up to 30 minutes.
+
+Peeking isn't read-only. Messages are hidden from the queue's consumers for up
+to --visibility-timeout seconds; if the backup takes longer than that, they're
+visible (and can be consumed) again before it's done (messages received again
+this way are only archived once). Every message peeked at also has its
+receive count raised; on a queue with a redrive policy, this can move messages
+to its dead-letter queue, and a warning is shown.
+`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getConfig(opts.configBytes, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.readOnly {
+				markReadOnly(&cfg)
+			}
+
+			mode, modeErr := queue.ParseBackupMode(backupMode)
+			format, formatErr := queue.ParseArchiveFormat(backupFormat)
+			if err := errors.Join(modeErr, formatErr); err != nil {
+				return err
+			}
+
+			if mode == queue.BackupModeDrain {
+				if err := cfg.EnsureWritable(); err != nil {
+					return err
+				}
+			}
+
+			outputPath := backupOutput
+			if outputPath == "" {
+				outputPath = queue.DefaultBackupPath(utils.QueueNameFromURL(cfg.QueueURL), format, time.Now())
+			} else if format, err = queue.ArchiveFormatFromPath(outputPath); err != nil {
+				return err
+			}
+
+			if debug {
+				fmt.Printf(`Debug info:
+===
+
+Profile
+---
+%s
+Backup
+---
+
+- mode                    %s
+- format                  %s
+- output                  %s
+`,
+					cfg.Display(),
+					mode.Display(),
+					format.Display(),
+					outputPath,
+				)
+				return nil
+			}
+
+			sqsClients, err := getSQSClients([]t.Config{cfg})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			backedUp, err := queue.Backup(ctx, sqsClients[cfg.AWSConfigSource.String()], cfg, queue.BackupOptions{
+				Path:              outputPath,
+				Format:            format,
+				Mode:              mode,
+				VisibilityTimeout: backupVisibility,
+				OnProgress:        progressPrinter(cmd.OutOrStdout(), "backed up"),
+				OnRedrivePolicy: func(maxReceiveCount int) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "warning: the queue has a redrive policy (maxReceiveCount: %d); peeking counts as receiving messages, and can move them to its dead-letter queue\n", maxReceiveCount)
+				},
+			})
+			if backedUp > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			if err != nil {
+				if backedUp > 0 {
+					fmt.Fprintf(cmd.ErrOrStderr(), "messages backed up so far are at %s\n", outputPath)
+				}
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "backed up %d messages to %s\n", backedUp, outputPath)
+
+			return nil
+		},
+	}
+
+	backupCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	backupCmd.Flags().StringVarP(&backupMode, "mode", "m", "peek", "backup mode; possible values: [peek, drain]")
+	backupCmd.Flags().StringVarP(&backupFormat, "format", "f", "jsonl", "archive format; possible values: [jsonl, tar.gz]")
+	backupCmd.Flags().StringVarP(&backupOutput, "output", "o", "", "path of the archive, ending in .jsonl or .tar.gz (overrides --format); defaults to backups/<QUEUE>/<TIMESTAMP>.<FORMAT>")
+	backupCmd.Flags().Int32Var(&backupVisibility, "visibility-timeout", queue.DefaultPeekVisibilityTimeoutSecs, "seconds messages stay invisible to other consumers while peeking, in case cueitup can't make them visible again")
+
+	return backupCmd
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/dhth/cueitup/internal/aws"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var errInvalidBrowseOptions = errors.New("invalid options for browsing queues")
+
+func newBrowseCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug            bool
+		awsConfigSource  string
+		queuePrefix      string
+		browseFormat     string
+		deleteMessages   bool
+		persistMessages  bool
+		skipMessages     bool
+		showMessageCount bool
+	)
+
+	browseCmd := &cobra.Command{
+		Use:   "browse",
+		Short: "browse the queues in an AWS account and open any of them in cueitup's TUI",
+		Long: `browse the queues in an AWS account and open any of them in cueitup's TUI.
+
+Queues can be saved as profiles in cueitup's config file from within the queue browser.
+`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			// the config file is only needed when saving a profile, and will be
+			// created if it doesn't exist
+			return opts.resolveConfigPath()
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			browserConfig, errs := t.ParseQueueBrowserConfig(awsConfigSource, queuePrefix, browseFormat, opts.configPathFull, opts.readOnly)
+			if len(errs) > 0 {
+				return invalidOptionsError(errInvalidBrowseOptions, errs)
+			}
+
+			behaviourFlags := t.TUIBehaviourFlags{
+				DeleteMessages:   changedBoolFlag(cmd, "delete-messages", deleteMessages),
+				PersistMessages:  changedBoolFlag(cmd, "persist-messages", persistMessages),
+				ShowMessageCount: changedBoolFlag(cmd, "show-message-count", showMessageCount),
+				SkipMessages:     changedBoolFlag(cmd, "skip-messages", skipMessages),
+			}
+
+			if debug {
+				behaviours, sources := t.Config{ReadOnly: browserConfig.ReadOnly}.TUIBehaviours(behaviourFlags)
+				fmt.Printf(`Debug info:
+===
+
+Queue browser
+---
+%s
+Behaviours 
+---
+%s`,
+					browserConfig.Display(),
+					behaviours.DisplayWithSources(sources),
+				)
+				return nil
+			}
+
+			sdkConfig, err := aws.GetAWSConfig(browserConfig.AWSConfigSource)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntLoadAWSConfig, err.Error())
+			}
+
+			sqsClient := sqs.NewFromConfig(sdkConfig)
+
+			return ui.RenderQueueBrowser(sqsClient, browserConfig, behaviourFlags)
+		},
+	}
+
+	defaultTUIBehaviours := t.DefaultTUIBehaviours()
+	browseCmd.Flags().StringVarP(&awsConfigSource, "aws-config-source", "a", "env", "AWS config source to list queues with; possible values: \"env\", \"profile:<aws-shared-config-profile-name>\"")
+	browseCmd.Flags().StringVarP(&queuePrefix, "prefix", "p", "", "only list queues whose names start with this prefix")
+	browseCmd.Flags().StringVarP(&browseFormat, "format", "f", "json", "format of the message bodies; possible values: [json, none]")
+	browseCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	browseCmd.Flags().BoolVarP(&deleteMessages, "delete-messages", "D", defaultTUIBehaviours.DeleteMessages, "whether to start the TUI with the setting \"delete messages\" ON")
+	browseCmd.Flags().BoolVarP(&persistMessages, "persist-messages", "P", defaultTUIBehaviours.PersistMessages, "whether to start the TUI with the setting \"persist messages\" ON")
+	browseCmd.Flags().BoolVarP(&skipMessages, "skip-messages", "S", defaultTUIBehaviours.SkipMessages, "whether to start the TUI with the setting \"skip messages\" ON")
+	browseCmd.Flags().BoolVarP(&showMessageCount, "show-message-count", "M", defaultTUIBehaviours.ShowMessageCount, "whether to start the TUI with the setting \"show message count\" ON")
+
+	return browseCmd
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	t "github.com/dhth/cueitup/internal/types"
@@ -39,11 +40,7 @@ func getConfig(configBytes []byte, profileName string) (t.Config, error) {
 					return zero, fmt.Errorf("%w: %s", errProfileConfigInvalid, errors[0].Error())
 				}
 
-				errorStrs := make([]string, len(errors))
-				for i, err := range errors {
-					errorStrs[i] = fmt.Sprintf("  - %s", err.Error())
-				}
-				return zero, fmt.Errorf("%w:\n%s", errProfileConfigInvalid, strings.Join(errorStrs, "\n"))
+				return zero, invalidOptionsError(errProfileConfigInvalid, errors)
 			}
 
 			return profile, nil
@@ -53,6 +50,43 @@ func getConfig(configBytes []byte, profileName string) (t.Config, error) {
 	return zero, fmt.Errorf("%w; available profiles: %v", errProfileNotFound, availableProfiles)
 }
 
+// getValidConfigs returns all profiles in the config that are valid. Invalid
+// ones are skipped, with a warning (naming the profile and what's wrong with
+// it) written to warnings for each of them.
+func getValidConfigs(configBytes []byte, warnings io.Writer) ([]t.Config, error) {
+	var cfg t.CueitupConfig
+
+	err := yaml.Unmarshal(configBytes, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntParseConfig, err.Error())
+	}
+
+	if len(cfg.Profiles) == 0 {
+		return nil, errNoProfilesDefined
+	}
+
+	configs := make([]t.Config, 0, len(cfg.Profiles))
+	for i, pc := range cfg.Profiles {
+		profile, errors := t.ParseProfileConfig(pc)
+		if len(errors) > 0 {
+			errorStrs := make([]string, len(errors))
+			for i, err := range errors {
+				errorStrs[i] = fmt.Sprintf("  - %s", err.Error())
+			}
+
+			name := fmt.Sprintf("at index %d", i+1)
+			if pc.Name != "" {
+				name = fmt.Sprintf("%q", pc.Name)
+			}
+			fmt.Fprintf(warnings, "warning: skipping profile %s, since its config is invalid:\n%s\n", name, strings.Join(errorStrs, "\n"))
+			continue
+		}
+		configs = append(configs, profile)
+	}
+
+	return configs, nil
+}
+
 func validateConfig(configBytes []byte) []error {
 	var cfg t.CueitupConfig
 
@@ -82,3 +116,11 @@ func validateConfig(configBytes []byte) []error {
 
 	return errors
 }
+
+// markReadOnly marks all the provided configs as read-only, regardless of what
+// their profiles in the config file say.
+func markReadOnly(configs ...*t.Config) {
+	for _, cfg := range configs {
+		cfg.ReadOnly = true
+	}
+}
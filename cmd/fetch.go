@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var errInvalidFetchOptions = errors.New("invalid options for fetching messages")
+
+func newFetchCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug           bool
+		fetchWhere      string
+		fetchMaxMatches int
+		fetchTimeout    time.Duration
+		fetchDelete     bool
+		fetchOutput     string
+		fetchAllowDLQ   bool
+	)
+
+	fetchCmd := &cobra.Command{
+		Use:   "fetch <PROFILE>",
+		Short: "fetch messages matching a predicate from a queue",
+		Long: `fetch messages matching a predicate from a queue, without the TUI.
+
+Messages are received till --max-matches of them match the predicate, the
+time limit is reached, or the queue has no more messages. Messages that don't
+match are kept in flight while fetching (so that they're only looked at once),
+and are made visible again afterwards. Matching messages are made visible
+again as well, unless --delete is used.
+
+Scanning still receives every message it looks at, which counts towards the
+message's maxReceiveCount. On a queue with a redrive policy, a few scans can
+move messages that were never consumed to its dead-letter queue, so such
+queues are only scanned with --allow-dlq.
+
+A predicate is one or more conditions joined by "and"; each condition compares
+a JSON path ("$.order.items[0].sku"), an attribute ("@ApproximateReceiveCount",
+or the name of a message attribute) or "body" with a value, via one of =, !=,
+>, >=, <, <=, ~ (matches a regex) and !~. For example:
+
+    $.kind = Created and @ApproximateReceiveCount > 3 and body ~ "agg-[0-9]+"
+
+Matching messages are written to stdout as JSON lines (in the same format as
+JSONL archives), or to an archive via --output; progress is shown on stderr.
+`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getConfig(opts.configBytes, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.readOnly {
+				markReadOnly(&cfg)
+			}
+
+			var optionErrs []error
+			var predicate *t.MessagePredicate
+			if fetchWhere != "" {
+				parsed, err := t.ParseMessagePredicate(fetchWhere)
+				if err != nil {
+					optionErrs = append(optionErrs, err)
+				} else {
+					predicate = &parsed
+				}
+			}
+			if fetchMaxMatches < 1 {
+				optionErrs = append(optionErrs, fmt.Errorf("max matches needs to be at least 1, got %d", fetchMaxMatches))
+			}
+			if fetchTimeout < time.Second || fetchTimeout > queue.MaxFetchTimeLimit {
+				optionErrs = append(optionErrs, fmt.Errorf("timeout needs to be between 1s and %s, got %s", queue.MaxFetchTimeLimit, fetchTimeout))
+			}
+			if fetchOutput != "" {
+				if _, err := queue.ArchiveFormatFromPath(fetchOutput); err != nil {
+					optionErrs = append(optionErrs, err)
+				}
+			}
+			if len(optionErrs) > 0 {
+				return invalidOptionsError(errInvalidFetchOptions, optionErrs)
+			}
+
+			if fetchDelete {
+				if err := cfg.EnsureWritable(); err != nil {
+					return err
+				}
+			}
+
+			if debug {
+				predicateInfo := "none (every message matches)"
+				if predicate != nil {
+					predicateInfo = predicate.String()
+				}
+				outputInfo := "stdout"
+				if fetchOutput != "" {
+					outputInfo = fetchOutput
+				}
+				fmt.Printf(`Debug info:
+===
+
+Profile
+---
+%s
+Fetch
+---
+
+- predicate               %s
+- max matches             %d
+- timeout                 %s
+- delete matches          %v
+- output                  %s
+- allow dead-lettering    %v
+`,
+					cfg.Display(),
+					predicateInfo,
+					fetchMaxMatches,
+					fetchTimeout,
+					fetchDelete,
+					outputInfo,
+					fetchAllowDLQ,
+				)
+				return nil
+			}
+
+			sqsClients, err := getSQSClients([]t.Config{cfg})
+			if err != nil {
+				return err
+			}
+			client := sqsClients[cfg.AWSConfigSource.String()]
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result, err := queue.FetchMatching(ctx, client, cfg, queue.FetchOptions{
+				Predicate:          predicate,
+				MaxMatches:         fetchMaxMatches,
+				TimeLimit:          fetchTimeout,
+				ReleaseMatches:     !fetchDelete,
+				OnProgress:         fetchProgressPrinter(cmd.ErrOrStderr()),
+				AllowDeadLettering: fetchAllowDLQ,
+			})
+			if result.Scanned > 0 {
+				fmt.Fprintln(cmd.ErrOrStderr())
+			}
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if fetchOutput != "" {
+				err = archiveFetchedMessages(fetchOutput, result.Messages, now)
+			} else {
+				err = writeFetchedMessages(cmd.OutOrStdout(), result.Messages, now)
+			}
+			if err != nil {
+				return err
+			}
+
+			summary := fmt.Sprintf("found %d matching messages (scanned %d)", len(result.Messages), result.Scanned)
+			if result.TimedOut {
+				summary += "; stopped at the time limit"
+			}
+			if fetchOutput != "" {
+				summary += fmt.Sprintf("; wrote them to %s", fetchOutput)
+			}
+
+			if fetchDelete && len(result.Messages) > 0 {
+				deleted, err := queue.DeleteFetched(ctx, client, cfg.QueueURL, result.Messages)
+				if err != nil {
+					fmt.Fprintln(cmd.ErrOrStderr(), summary)
+					return fmt.Errorf("%w (deleted %d)", err, deleted)
+				}
+				summary += fmt.Sprintf("; deleted %d from the queue", deleted)
+			}
+
+			fmt.Fprintln(cmd.ErrOrStderr(), summary)
+
+			return nil
+		},
+	}
+
+	fetchCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	fetchCmd.Flags().StringVarP(&fetchWhere, "where", "w", "", "predicate messages need to match (eg. \"$.kind = Created and @ApproximateReceiveCount > 3\"); every message matches if not provided")
+	fetchCmd.Flags().IntVarP(&fetchMaxMatches, "max-matches", "n", 1, "number of matching messages after which fetching stops")
+	fetchCmd.Flags().DurationVarP(&fetchTimeout, "timeout", "t", queue.DefaultFetchTimeLimit, "how long to keep fetching for, if fewer messages than --max-matches match")
+	fetchCmd.Flags().BoolVar(&fetchDelete, "delete", false, "whether to delete matching messages from the queue once they're written")
+	fetchCmd.Flags().StringVarP(&fetchOutput, "output", "o", "", "path of an archive to write matching messages to, ending in .jsonl or .tar.gz; messages are written to stdout if not provided")
+	fetchCmd.Flags().BoolVar(&fetchAllowDLQ, "allow-dlq", false, "whether to scan a queue with a redrive policy, even though scanning can move messages to its dead-letter queue")
+
+	return fetchCmd
+}
+
+// fetchProgressPrinter is like progressPrinter, but for fetching messages
+// matching a predicate.
+func fetchProgressPrinter(w io.Writer) func(int, int) {
+	return func(scanned, matched int) {
+		fmt.Fprintf(w, "\rscanned %d messages, %d matched", scanned, matched)
+	}
+}
+
+// writeFetchedMessages writes messages to w in the same format as JSONL
+// archives, one message per line.
+func writeFetchedMessages(w io.Writer, messages []sqstypes.Message, fetchedAt time.Time) error {
+	encoder := json.NewEncoder(w)
+	for _, message := range messages {
+		if err := encoder.Encode(queue.NewArchivedMessage(message, fetchedAt)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// archiveFetchedMessages writes messages to a new archive at path.
+func archiveFetchedMessages(path string, messages []sqstypes.Message, fetchedAt time.Time) error {
+	format, err := queue.ArchiveFormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	archive, err := queue.NewArchiveWriter(path, format)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := archive.Write(queue.NewArchivedMessage(message, fetchedAt)); err != nil {
+			archive.Close()
+			return err
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+)
+
+// progressPrinter returns a callback that keeps overwriting a single line on w
+// with the number of messages processed so far.
+func progressPrinter(w io.Writer, verb string) func(int) {
+	return func(count int) {
+		fmt.Fprintf(w, "\r%s %d messages", verb, count)
+	}
+}
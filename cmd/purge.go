@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var errCouldntReadConfirmation = errors.New("couldn't read confirmation")
+
+func newPurgeCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug           bool
+		purgeBackup     bool
+		purgeBackupPath string
+		purgeConfirm    string
+	)
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge <PROFILE>",
+		Short: "delete all messages in a queue",
+		Long: `delete all messages in a queue.
+
+The queue's name needs to be typed in to confirm the purge (or provided via
+--confirm). SQS only allows one purge per queue every 60 seconds.
+
+When backing up, messages are deleted once they're archived, and the queue
+itself isn't purged, so that messages that arrive (or are in flight) while the
+backup is running aren't lost; such messages are left on the queue.
+`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getConfig(opts.configBytes, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.readOnly {
+				markReadOnly(&cfg)
+			}
+
+			if err := cfg.EnsureWritable(); err != nil {
+				return err
+			}
+
+			queueName := utils.QueueNameFromURL(cfg.QueueURL)
+			backupPath := purgeBackupPath
+			if purgeBackup && backupPath == "" {
+				backupPath = queue.DefaultBackupPath(queueName, queue.ArchiveFormatJSONL, time.Now())
+			}
+
+			if debug {
+				backupInfo := "none"
+				if backupPath != "" {
+					backupInfo = backupPath
+				}
+				fmt.Printf(`Debug info:
+===
+
+Profile
+---
+%s
+Backup
+---
+
+- backup path             %s
+`,
+					cfg.Display(),
+					backupInfo,
+				)
+				return nil
+			}
+
+			confirmation := purgeConfirm
+			if confirmation == "" {
+				confirmation, err = promptForPurgeConfirmation(cmd.InOrStdin(), cmd.OutOrStdout(), cfg)
+				if err != nil {
+					return err
+				}
+			}
+
+			sqsClients, err := getSQSClients([]t.Config{cfg})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result, err := queue.Purge(ctx, sqsClients[cfg.AWSConfigSource.String()], cfg, queue.PurgeOptions{
+				Confirmation: confirmation,
+				BackupPath:   backupPath,
+				OnProgress:   progressPrinter(cmd.OutOrStdout(), "backed up"),
+			})
+			if result.BackedUp > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			if err != nil {
+				if result.BackupPath != "" {
+					fmt.Fprintf(cmd.ErrOrStderr(), "messages backed up so far are at %s\n", result.BackupPath)
+				}
+				return err
+			}
+
+			if result.BackupPath != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "backed up %d messages to %s\n", result.BackedUp, result.BackupPath)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "purged queue %q\n", queueName)
+
+			return nil
+		},
+	}
+
+	purgeCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	purgeCmd.Flags().BoolVarP(&purgeBackup, "backup", "b", false, "whether to back up all messages to a local JSONL file before purging the queue")
+	purgeCmd.Flags().StringVar(&purgeBackupPath, "backup-path", "", "path of the backup file, ending in .jsonl or .tar.gz (implies --backup); defaults to backups/<QUEUE>/<TIMESTAMP>.jsonl")
+	purgeCmd.Flags().StringVar(&purgeConfirm, "confirm", "", "queue name to confirm the purge with (skips the interactive prompt)")
+
+	return purgeCmd
+}
+
+func promptForPurgeConfirmation(in io.Reader, out io.Writer, config t.Config) (string, error) {
+	fmt.Fprintf(out, `This will delete ALL messages in the queue %q.
+Type the queue's name to confirm: `, utils.QueueNameFromURL(config.QueueURL))
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("%w: %s", errCouldntReadConfirmation, err.Error())
+	}
+
+	return strings.TrimSpace(line), nil
+}
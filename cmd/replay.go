@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errInvalidTimeBound   = errors.New("invalid time; expected a duration (eg. 2h) or an RFC3339 timestamp")
+	errCouldntEditMessage = errors.New("couldn't edit message")
+)
+
+func newReplayCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug         bool
+		replaySource  string
+		replayDir     string
+		filterGlob    string
+		filterSince   string
+		filterUntil   string
+		replayEdit    bool
+		replayDryRun  bool
+		replayGroupID string
+	)
+
+	replayCmd := &cobra.Command{
+		Use:   "replay <PROFILE> [FILE...]",
+		Short: "send messages persisted by the TUI to a queue",
+		Long: `send messages persisted by the TUI (via persist mode) to a queue.
+
+Files are picked from the directory the TUI persists messages to for the
+profile provided via --source (or for PROFILE itself, if not provided), and can
+be filtered by name (via --glob) and by the time they were persisted at (via
+--since and --until). Files can also be provided explicitly, in which case the
+filters aren't applied.
+
+Messages are sent in the order they were persisted in. With --edit, each
+message is opened in $EDITOR before it's sent; messages left empty are skipped.
+`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getConfig(opts.configBytes, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.readOnly {
+				markReadOnly(&cfg)
+			}
+
+			if err := cfg.EnsureWritable(); err != nil {
+				return err
+			}
+
+			dir := replayDir
+			if dir == "" {
+				sourceCfg := cfg
+				if replaySource != "" {
+					sourceCfg, err = getConfig(opts.configBytes, replaySource)
+					if err != nil {
+						return err
+					}
+				}
+				dir = sourceCfg.PersistDirectory()
+			}
+
+			now := time.Now()
+			since, sinceErr := parseTimeBound(filterSince, now)
+			until, untilErr := parseTimeBound(filterUntil, now)
+			if err := errors.Join(sinceErr, untilErr); err != nil {
+				return err
+			}
+
+			var messages []queue.PersistedMessage
+			if len(args) > 1 {
+				for _, path := range args[1:] {
+					info, err := os.Stat(path)
+					if err != nil {
+						return err
+					}
+					messages = append(messages, queue.NewPersistedMessage(path, info.ModTime()))
+				}
+			} else {
+				messages, err = queue.ListPersistedMessages(dir, queue.PersistedMessageFilter{
+					Glob:  filterGlob,
+					Since: since,
+					Until: until,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if debug {
+				fmt.Printf(`Debug info:
+===
+
+Profile
+---
+%s
+Replay
+---
+
+- directory               %s
+- files                   %d
+- edit                    %v
+- message group ID        %s
+`,
+					cfg.Display(),
+					dir,
+					len(messages),
+					replayEdit,
+					replayGroupID,
+				)
+				return nil
+			}
+
+			if len(messages) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "no persisted messages found in %s\n", dir)
+				return nil
+			}
+
+			if replayDryRun {
+				for _, message := range messages {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", message.PersistedAt.Format(time.RFC3339), message.Path)
+				}
+				return nil
+			}
+
+			toReplay := make([]queue.ReplayMessage, 0, len(messages))
+			for _, message := range messages {
+				body, err := queue.ReadPersistedMessage(message.Path)
+				if err != nil {
+					return err
+				}
+
+				if replayEdit {
+					body, err = editInEditor(message, body, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+					if err != nil {
+						return err
+					}
+					if strings.TrimSpace(body) == "" {
+						fmt.Fprintf(cmd.OutOrStdout(), "skipping %s\n", message.Path)
+						continue
+					}
+				}
+
+				toReplay = append(toReplay, queue.ReplayMessage{
+					MessageID: message.MessageID,
+					Path:      message.Path,
+					Body:      body,
+				})
+			}
+
+			sqsClients, err := getSQSClients([]t.Config{cfg})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			sent, err := queue.Replay(ctx, sqsClients[cfg.AWSConfigSource.String()], cfg, toReplay, queue.ReplayOptions{
+				MessageGroupID: replayGroupID,
+				OnProgress:     progressPrinter(cmd.OutOrStdout(), "sent"),
+			})
+			if sent > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "sent %d messages to %q\n", sent, utils.QueueNameFromURL(cfg.QueueURL))
+
+			return nil
+		},
+	}
+
+	replayCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	replayCmd.Flags().StringVarP(&replaySource, "source", "s", "", "profile whose persisted messages to pick from (defaults to PROFILE)")
+	replayCmd.Flags().StringVar(&replayDir, "dir", "", "directory to pick persisted messages from (overrides --source)")
+	replayCmd.Flags().StringVarP(&filterGlob, "glob", "g", "", "only pick files whose names match this glob pattern (eg. \"*.json\")")
+	replayCmd.Flags().StringVar(&filterSince, "since", "", "only pick messages persisted after this time; a duration (eg. 2h) or an RFC3339 timestamp")
+	replayCmd.Flags().StringVar(&filterUntil, "until", "", "only pick messages persisted before this time; a duration (eg. 30m) or an RFC3339 timestamp")
+	replayCmd.Flags().BoolVarP(&replayEdit, "edit", "e", false, "whether to edit each message in $EDITOR before sending it")
+	replayCmd.Flags().BoolVar(&replayDryRun, "dry-run", false, "whether to only list the messages that would be sent")
+	replayCmd.Flags().StringVar(&replayGroupID, "message-group-id", queue.DefaultReplayMessageGroupID, "message group ID to use when replaying to a FIFO queue")
+
+	return replayCmd
+}
+
+// parseTimeBound parses either a duration, which is treated as that long
+// before now, or an RFC3339 timestamp.
+func parseTimeBound(value string, now time.Time) (time.Time, error) {
+	var zero time.Time
+	if value == "" {
+		return zero, nil
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %q", errInvalidTimeBound, value)
+	}
+
+	return parsed, nil
+}
+
+// editInEditor lets the user edit a persisted message's body in their editor;
+// the persisted file itself is left untouched.
+func editInEditor(message queue.PersistedMessage, body string, stdin io.Reader, stdout, stderr io.Writer) (string, error) {
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("cueitup-*%s", filepath.Ext(message.Path)))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntEditMessage, err.Error())
+	}
+	defer os.Remove(tempFile.Name())
+
+	_, err = tempFile.WriteString(body)
+	closeErr := tempFile.Close()
+	if err := errors.Join(err, closeErr); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntEditMessage, err.Error())
+	}
+
+	editorCmd := utils.EditorCmd(tempFile.Name())
+	editorCmd.Stdin = stdin
+	editorCmd.Stdout = stdout
+	editorCmd.Stderr = stderr
+	if err := editorCmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntEditMessage, err.Error())
+	}
+
+	edited, err := os.ReadFile(tempFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntEditMessage, err.Error())
+	}
+
+	return strings.TrimRight(string(edited), "\n"), nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+func newRestoreCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug            bool
+		restoreFromStart bool
+		restoreGroupID   string
+	)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <PROFILE> <ARCHIVE>",
+		Short: "send all messages in an archive to a queue",
+		Long: `send all messages in an archive (created via "cueitup backup" or "cueitup purge")
+to a queue.
+
+Messages are sent in the order they were archived in, with their original
+message attributes. When restoring to a FIFO queue, messages keep their
+original message group ID, and their original message ID is used as the
+deduplication ID.
+
+Progress is tracked in <ARCHIVE>.progress, so that an interrupted restore can be
+resumed by running the same command again.
+`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := getConfig(opts.configBytes, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.readOnly {
+				markReadOnly(&cfg)
+			}
+
+			if err := cfg.EnsureWritable(); err != nil {
+				return err
+			}
+
+			archivePath := args[1]
+			format, err := queue.ArchiveFormatFromPath(archivePath)
+			if err != nil {
+				return err
+			}
+
+			if debug {
+				fmt.Printf(`Debug info:
+===
+
+Profile
+---
+%s
+Restore
+---
+
+- archive                 %s
+- format                  %s
+- progress file           %s
+- from start              %v
+- message group ID        %s
+`,
+					cfg.Display(),
+					archivePath,
+					format.Display(),
+					queue.RestoreProgressPath(archivePath),
+					restoreFromStart,
+					restoreGroupID,
+				)
+				return nil
+			}
+
+			sqsClients, err := getSQSClients([]t.Config{cfg})
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			result, err := queue.Restore(ctx, sqsClients[cfg.AWSConfigSource.String()], cfg, queue.RestoreOptions{
+				ArchivePath:    archivePath,
+				FromStart:      restoreFromStart,
+				MessageGroupID: restoreGroupID,
+				OnProgress:     progressPrinter(cmd.OutOrStdout(), "sent"),
+			})
+			if result.Sent > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			if err != nil {
+				if result.Sent > 0 || result.Skipped > 0 {
+					fmt.Fprintf(cmd.ErrOrStderr(), "run the same command again to resume the restore\n")
+				}
+				return err
+			}
+
+			if result.Skipped > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "sent %d messages to %q (skipped %d sent previously)\n", result.Sent, utils.QueueNameFromURL(cfg.QueueURL), result.Skipped)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "sent %d messages to %q\n", result.Sent, utils.QueueNameFromURL(cfg.QueueURL))
+			}
+
+			return nil
+		},
+	}
+
+	restoreCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	restoreCmd.Flags().BoolVar(&restoreFromStart, "from-start", false, "whether to discard the progress of a previous, incomplete restore of the archive")
+	restoreCmd.Flags().StringVar(&restoreGroupID, "message-group-id", queue.DefaultRestoreMessageGroupID, "message group ID for messages that don't have one, when restoring to a FIFO queue")
+
+	return restoreCmd
+}
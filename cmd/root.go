@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -17,7 +18,8 @@ import (
 )
 
 const (
-	configFileName = "cueitup/cueitup.yml"
+	configFileName  = "cueitup/cueitup.yml"
+	authTokenEnvVar = "CUEITUP_AUTH_TOKEN"
 )
 
 var (
@@ -26,8 +28,33 @@ var (
 	errCouldntGetUserHomeDir   = errors.New("couldn't get your home directory")
 	errCouldntGetUserConfigDir = errors.New("couldn't get your config directory")
 	ErrCouldntReadConfigFile   = errors.New("couldn't read config file")
+	errNoProfilesToServe       = errors.New("at least one profile needs to be provided (or use --all)")
+	errProfilesAndAllProvided  = errors.New("profiles cannot be provided when using --all")
+	errInvalidServerOptions    = errors.New("invalid options for the web server")
 )
 
+// rootOptions holds what's shared between commands: the global flags, and the
+// config file, which is read before commands that need it are run.
+type rootOptions struct {
+	configPath     string
+	configPathFull string
+	configBytes    []byte
+	homeDir        string
+	readOnly       bool
+}
+
+// resolveConfigPath checks that the config file is a YAML file, and expands
+// its path; the file itself isn't read.
+func (o *rootOptions) resolveConfigPath() error {
+	if !strings.HasSuffix(o.configPath, ".yml") && !strings.HasSuffix(o.configPath, ".yaml") {
+		return errConfigFileNotYAML
+	}
+
+	o.configPathFull = utils.ExpandTilde(o.configPath, o.homeDir)
+
+	return nil
+}
+
 func Execute() error {
 	rootCmd, err := NewRootCommand()
 	if err != nil {
@@ -37,12 +64,51 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// getSQSClients creates one SQS client per distinct AWS config source used by
+// the provided configs, keyed by the config source.
+func getSQSClients(configs []t.Config) (map[string]*sqs.Client, error) {
+	sqsClients := make(map[string]*sqs.Client)
+	for _, cfg := range configs {
+		source := cfg.AWSConfigSource.String()
+		if _, ok := sqsClients[source]; ok {
+			continue
+		}
+
+		sdkConfig, err := aws.GetAWSConfig(cfg.AWSConfigSource)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %s", errCouldntLoadAWSConfig, source, err.Error())
+		}
+
+		sqsClients[source] = sqs.NewFromConfig(sdkConfig)
+	}
+
+	return sqsClients, nil
+}
+
+// changedBoolFlag returns the value of a bool flag only if it was explicitly
+// set, so that it can take precedence over behaviours declared in profiles.
+func changedBoolFlag(cmd *cobra.Command, name string, value bool) *bool {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+
+	return &value
+}
+
+// invalidOptionsError reports all the problems with a command's options at
+// once, one per line.
+func invalidOptionsError(base error, errs []error) error {
+	errorStrs := make([]string, len(errs))
+	for i, err := range errs {
+		errorStrs[i] = fmt.Sprintf("  - %s", err.Error())
+	}
+
+	return fmt.Errorf("%w:\n%s", base, strings.Join(errorStrs, "\n"))
+}
+
 func NewRootCommand() (*cobra.Command, error) {
 	var (
-		configPath       string
-		configPathFull   string
-		configBytes      []byte
-		homeDir          string
+		opts             rootOptions
 		deleteMessages   bool
 		persistMessages  bool
 		skipMessages     bool
@@ -51,6 +117,13 @@ func NewRootCommand() (*cobra.Command, error) {
 		webOpen          bool
 		debug            bool
 		listConfig       bool
+		serveAll         bool
+		serverHost       string
+		serverPort       int
+		tlsCertPath      string
+		tlsKeyPath       string
+		authToken        string
+		allowedOrigins   []string
 	)
 
 	rootCmd := &cobra.Command{
@@ -63,13 +136,12 @@ own details related to authentication, deserialization, etc.
 `,
 		SilenceUsage: true,
 		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-			if !strings.HasSuffix(configPath, ".yml") && !strings.HasSuffix(configPath, ".yaml") {
-				return errConfigFileNotYAML
+			if err := opts.resolveConfigPath(); err != nil {
+				return err
 			}
 
 			var err error
-			configPathFull = utils.ExpandTilde(configPath, homeDir)
-			configBytes, err = os.ReadFile(configPathFull)
+			opts.configBytes, err = os.ReadFile(opts.configPathFull)
 			if err != nil {
 				return fmt.Errorf("%w: %w", ErrCouldntReadConfigFile, err)
 			}
@@ -90,9 +162,9 @@ own details related to authentication, deserialization, etc.
 		SilenceUsage: true,
 		RunE: func(_ *cobra.Command, _ []string) error {
 			if listConfig {
-				fmt.Printf("%s\n---\n\n", configBytes)
+				fmt.Printf("%s\n---\n\n", opts.configBytes)
 			}
-			errors := validateConfig(configBytes)
+			errors := validateConfig(opts.configBytes)
 			if len(errors) > 0 {
 				fmt.Println("config has some errors:")
 				for _, err := range errors {
@@ -108,24 +180,58 @@ own details related to authentication, deserialization, etc.
 	}
 
 	tuiCmd := &cobra.Command{
-		Use:          "tui <PROFILE>",
-		Short:        "open cueitup's TUI",
-		Args:         cobra.ExactArgs(1),
+		Use:   "tui [PROFILE]",
+		Short: "open cueitup's TUI",
+		Long: `open cueitup's TUI.
+
+All valid profiles in the config file can be opened in tabs from within the TUI.
+If PROFILE is provided, it's opened right away; otherwise the TUI starts with a
+profile picker.
+
+Behaviours (deleting, persisting, etc.) can be declared per profile in the
+config file; flags that are explicitly provided take precedence over them.
+`,
+		Args:         cobra.MaximumNArgs(1),
 		SilenceUsage: true,
-		RunE: func(_ *cobra.Command, args []string) error {
-			cfg, err := getConfig(configBytes, args[0])
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := getValidConfigs(opts.configBytes, os.Stderr)
 			if err != nil {
 				return err
 			}
 
-			behaviours := t.TUIBehaviours{
-				DeleteMessages:   deleteMessages,
-				PersistMessages:  persistMessages,
-				SkipMessages:     skipMessages,
-				ShowMessageCount: showMessageCount,
+			var initialProfile *t.Config
+			if len(args) > 0 {
+				cfg, err := getConfig(opts.configBytes, args[0])
+				if err != nil {
+					return err
+				}
+				initialProfile = &cfg
+			}
+
+			if opts.readOnly {
+				for i := range profiles {
+					markReadOnly(&profiles[i])
+				}
+				if initialProfile != nil {
+					markReadOnly(initialProfile)
+				}
+			}
+
+			behaviourFlags := t.TUIBehaviourFlags{
+				DeleteMessages:   changedBoolFlag(cmd, "delete-messages", deleteMessages),
+				PersistMessages:  changedBoolFlag(cmd, "persist-messages", persistMessages),
+				ShowMessageCount: changedBoolFlag(cmd, "show-message-count", showMessageCount),
+				SkipMessages:     changedBoolFlag(cmd, "skip-messages", skipMessages),
 			}
 
 			if debug {
+				profileInfo := fmt.Sprintf("\n- %d valid profiles available\n", len(profiles))
+				// profiles that don't declare any behaviours end up with these
+				behaviours, sources := t.Config{}.TUIBehaviours(behaviourFlags)
+				if initialProfile != nil {
+					profileInfo = initialProfile.Display()
+					behaviours, sources = initialProfile.TUIBehaviours(behaviourFlags)
+				}
 				fmt.Printf(`Debug info:
 ===
 
@@ -135,69 +241,132 @@ Profile
 Behaviours 
 ---
 %s`,
-					cfg.Display(),
-					behaviours.Display(),
+					profileInfo,
+					behaviours.DisplayWithSources(sources),
 				)
 				return nil
 			}
 
-			sdkConfig, err := aws.GetAWSConfig(cfg.AWSConfigSource)
-			if err != nil {
-				return fmt.Errorf("%w: %s", errCouldntLoadAWSConfig, err.Error())
+			sqsClients := make(map[string]*sqs.Client)
+			if initialProfile != nil {
+				sqsClients, err = getSQSClients([]t.Config{*initialProfile})
+				if err != nil {
+					return err
+				}
 			}
 
-			sqsClient := sqs.NewFromConfig(sdkConfig)
-
-			return ui.RenderUI(sqsClient, cfg.QueueURL, cfg, behaviours)
+			return ui.RenderUI(profiles, sqsClients, initialProfile, behaviourFlags)
 		},
 	}
 
 	serveCmd := &cobra.Command{
-		Use:          "serve <PROFILE>",
-		Short:        "open cueitup's web interface",
-		Args:         cobra.ExactArgs(1),
+		Use:   "serve [PROFILE...]",
+		Short: "open cueitup's web interface",
+		Long: `open cueitup's web interface.
+
+Several profiles can be served at once, and switched between in the web
+interface. Use --all to serve all valid profiles in the config file.
+
+Behaviours (deleting, selecting on hover, etc.) can be declared per profile in
+the config file; flags that are explicitly provided take precedence over them.
+`,
+		Args: func(_ *cobra.Command, args []string) error {
+			if !serveAll && len(args) == 0 {
+				return errNoProfilesToServe
+			}
+			if serveAll && len(args) > 0 {
+				return errProfilesAndAllProvided
+			}
+			return nil
+		},
 		SilenceUsage: true,
-		RunE: func(_ *cobra.Command, args []string) error {
-			cfg, err := getConfig(configBytes, args[0])
-			if err != nil {
-				return err
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if authToken == "" {
+				authToken = os.Getenv(authTokenEnvVar)
 			}
 
-			behaviours := t.WebBehaviours{
-				DeleteMessages:   deleteMessages,
-				SelectOnHover:    selectOnHover,
-				ShowMessageCount: showMessageCount,
+			serverConfig, errs := t.ParseServerConfig(
+				serverHost,
+				serverPort,
+				tlsCertPath,
+				tlsKeyPath,
+				authToken,
+				allowedOrigins,
+			)
+			if len(errs) > 0 {
+				return invalidOptionsError(errInvalidServerOptions, errs)
+			}
+
+			var configs []t.Config
+			if serveAll {
+				var err error
+				configs, err = getValidConfigs(opts.configBytes, os.Stderr)
+				if err != nil {
+					return err
+				}
+			} else {
+				for _, profileName := range args {
+					if slices.ContainsFunc(configs, func(c t.Config) bool { return c.ProfileName == profileName }) {
+						continue
+					}
+
+					cfg, err := getConfig(opts.configBytes, profileName)
+					if err != nil {
+						return err
+					}
+					configs = append(configs, cfg)
+				}
+			}
+
+			if opts.readOnly {
+				for i := range configs {
+					markReadOnly(&configs[i])
+				}
+			}
+
+			behaviourFlags := t.WebBehaviourFlags{
+				DeleteMessages:   changedBoolFlag(cmd, "delete-messages", deleteMessages),
+				PersistMessages:  changedBoolFlag(cmd, "persist-messages", persistMessages),
+				SelectOnHover:    changedBoolFlag(cmd, "select-on-hover", selectOnHover),
+				ShowMessageCount: changedBoolFlag(cmd, "show-message-count", showMessageCount),
 			}
 
 			if debug {
+				profileInfo := make([]string, len(configs))
+				for i, cfg := range configs {
+					behaviours, sources := cfg.WebBehaviours(behaviourFlags)
+					profileInfo[i] = fmt.Sprintf("%s\nBehaviours for %q\n---\n%s",
+						cfg.Display(),
+						cfg.ProfileName,
+						behaviours.DisplayWithSources(sources),
+					)
+				}
 				fmt.Printf(`Debug info:
 ===
 
-Profile
+Profiles
 ---
 %s
-Behaviours 
+Server
 ---
 %s`,
-					cfg.Display(),
-					behaviours.Display(),
+					strings.Join(profileInfo, "\n"),
+					serverConfig.Display(),
 				)
 				return nil
 			}
 
-			sdkConfig, err := aws.GetAWSConfig(cfg.AWSConfigSource)
+			sqsClients, err := getSQSClients(configs)
 			if err != nil {
-				return fmt.Errorf("%w: %s", errCouldntLoadAWSConfig, err.Error())
+				return err
 			}
 
-			sqsClient := sqs.NewFromConfig(sdkConfig)
-
-			return server.Serve(sqsClient, cfg, behaviours, webOpen)
+			return server.Serve(configs, sqsClients, behaviourFlags, serverConfig, webOpen)
 		},
 	}
 
 	var err error
-	homeDir, err = os.UserHomeDir()
+	opts.homeDir, err = os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", errCouldntGetUserHomeDir, err.Error())
 	}
@@ -209,20 +378,32 @@ Behaviours
 
 	defaultConfigPath := filepath.Join(configDir, configFileName)
 
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, "location of cueitup's config file")
+	rootCmd.PersistentFlags().StringVarP(&opts.configPath, "config-path", "c", defaultConfigPath, "location of cueitup's config file")
+	rootCmd.PersistentFlags().BoolVarP(&opts.readOnly, "read-only", "R", false, "whether to treat all queues as read-only; cueitup will refuse to delete messages, or take any other destructive action")
+
+	defaultTUIBehaviours := t.DefaultTUIBehaviours()
+	defaultWebBehaviours := t.DefaultWebBehaviours()
 
 	tuiCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
-	tuiCmd.Flags().BoolVarP(&deleteMessages, "delete-messages", "D", true, "whether to start the TUI with the setting \"delete messages\" ON")
-	tuiCmd.Flags().BoolVarP(&persistMessages, "persist-messages", "P", false, "whether to start the TUI with the setting \"persist messages\" ON")
-	tuiCmd.Flags().BoolVarP(&skipMessages, "skip-messages", "S", false, "whether to start the TUI with the setting \"skip messages\" ON")
-	tuiCmd.Flags().BoolVarP(&showMessageCount, "show-message-count", "M", true, "whether to start the TUI with the setting \"show message count\" ON")
-
-	serveCmd.Flags().StringVarP(&configPath, "config-path", "c", defaultConfigPath, "location of cueitup's config file")
-	serveCmd.Flags().BoolVarP(&deleteMessages, "delete-messages", "D", true, "whether to start the web interface with the setting \"delete messages\" ON")
-	serveCmd.Flags().BoolVarP(&selectOnHover, "select-on-hover", "S", false, "whether to start the web interface with the setting \"select on hover\" ON")
-	serveCmd.Flags().BoolVarP(&showMessageCount, "show-message-count", "M", true, "whether to start the web interface with the setting \"show message count\" ON")
+	tuiCmd.Flags().BoolVarP(&deleteMessages, "delete-messages", "D", defaultTUIBehaviours.DeleteMessages, "whether to start the TUI with the setting \"delete messages\" ON")
+	tuiCmd.Flags().BoolVarP(&persistMessages, "persist-messages", "P", defaultTUIBehaviours.PersistMessages, "whether to start the TUI with the setting \"persist messages\" ON")
+	tuiCmd.Flags().BoolVarP(&skipMessages, "skip-messages", "S", defaultTUIBehaviours.SkipMessages, "whether to start the TUI with the setting \"skip messages\" ON")
+	tuiCmd.Flags().BoolVarP(&showMessageCount, "show-message-count", "M", defaultTUIBehaviours.ShowMessageCount, "whether to start the TUI with the setting \"show message count\" ON")
+
+	serveCmd.Flags().StringVarP(&opts.configPath, "config-path", "c", defaultConfigPath, "location of cueitup's config file")
+	serveCmd.Flags().BoolVarP(&deleteMessages, "delete-messages", "D", defaultWebBehaviours.DeleteMessages, "whether to start the web interface with the setting \"delete messages\" ON")
+	serveCmd.Flags().BoolVarP(&persistMessages, "persist-messages", "P", defaultWebBehaviours.PersistMessages, "whether to start the web interface with the setting \"persist messages\" ON")
+	serveCmd.Flags().BoolVarP(&selectOnHover, "select-on-hover", "S", defaultWebBehaviours.SelectOnHover, "whether to start the web interface with the setting \"select on hover\" ON")
+	serveCmd.Flags().BoolVarP(&showMessageCount, "show-message-count", "M", defaultWebBehaviours.ShowMessageCount, "whether to start the web interface with the setting \"show message count\" ON")
 	serveCmd.Flags().BoolVarP(&webOpen, "open", "o", false, "whether to open web interface in browser automatically")
 	serveCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	serveCmd.Flags().BoolVarP(&serveAll, "all", "a", false, "whether to serve all valid profiles in the config file")
+	serveCmd.Flags().StringVar(&serverHost, "host", t.DefaultServerHost, "host to listen on")
+	serveCmd.Flags().IntVar(&serverPort, "port", 0, "port to listen on (if not provided, the first open port between 8500-9500 is used)")
+	serveCmd.Flags().StringVar(&tlsCertPath, "tls-cert", "", "location of a TLS certificate; serves the web interface over HTTPS (requires --tls-key)")
+	serveCmd.Flags().StringVar(&tlsKeyPath, "tls-key", "", "location of the TLS certificate's private key (requires --tls-cert)")
+	serveCmd.Flags().StringVar(&authToken, "auth-token", "", fmt.Sprintf("static token needed to access the web interface (can also be set via %s); if not provided, one is generated at startup", authTokenEnvVar))
+	serveCmd.Flags().StringSliceVar(&allowedOrigins, "allowed-origin", nil, "additional origin allowed to make cross-origin requests to the web interface's API (can be repeated)")
 
 	validateConfigCmd.Flags().BoolVarP(&listConfig, "list", "l", false, "whether to list the config as well")
 	configCmd.AddCommand(validateConfigCmd)
@@ -230,6 +411,13 @@ Behaviours
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(tuiCmd)
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(newBrowseCommand(&opts))
+	rootCmd.AddCommand(newPurgeCommand(&opts))
+	rootCmd.AddCommand(newBackupCommand(&opts))
+	rootCmd.AddCommand(newFetchCommand(&opts))
+	rootCmd.AddCommand(newRestoreCommand(&opts))
+	rootCmd.AddCommand(newReplayCommand(&opts))
+	rootCmd.AddCommand(newViewCommand(&opts))
 
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
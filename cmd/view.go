@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dhth/cueitup/internal/queue"
+	"github.com/dhth/cueitup/internal/server"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+const notProvided = "<NOT PROVIDED>"
+
+var errInvalidViewOptions = errors.New("invalid options for viewing messages")
+
+func newViewCommand(opts *rootOptions) *cobra.Command {
+	var (
+		debug          bool
+		viewProfile    string
+		viewFormat     string
+		viewContextKey string
+		viewSubsetKey  string
+		filterGlob     string
+		filterSince    string
+		filterUntil    string
+		viewWeb        bool
+		webOpen        bool
+		selectOnHover  bool
+		serverHost     string
+		serverPort     int
+		authToken      string
+	)
+
+	viewCmd := &cobra.Command{
+		Use:   "view <PATH>",
+		Short: "view messages persisted by the TUI, or an archive, without access to the queue",
+		Long: `view messages persisted by the TUI, or an archive, without access to the queue.
+
+PATH is either a directory of messages persisted by the TUI (via persist mode),
+a capture store (capture.jsonl or capture.db, for profiles that persist messages
+that way), or an archive created via "cueitup backup" or "cueitup purge".
+Messages are shown in the TUI (or in the web interface, via --web) the same way
+they would be for a queue; no AWS connection (or config file) is needed.
+
+How messages are displayed can be taken from a profile (via --profile), or set
+via --format, --context-key and --subset-key.
+`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			// the config file is only needed when display settings are taken
+			// from a profile
+			return opts.resolveConfigPath()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+
+			format := viewFormat
+			var contextKey, subsetKey *string
+			if viewProfile != "" {
+				configBytes, err := os.ReadFile(opts.configPathFull)
+				if err != nil {
+					return fmt.Errorf("%w: %w", ErrCouldntReadConfigFile, err)
+				}
+
+				profile, err := getConfig(configBytes, viewProfile)
+				if err != nil {
+					return err
+				}
+
+				if !cmd.Flags().Changed("format") {
+					format = profile.Format.Display()
+				}
+				contextKey = profile.ContextKey
+				subsetKey = profile.SubsetKey
+			}
+
+			if cmd.Flags().Changed("context-key") {
+				contextKey = &viewContextKey
+			}
+			if cmd.Flags().Changed("subset-key") {
+				subsetKey = &viewSubsetKey
+			}
+
+			cfg, errs := t.ParseOfflineConfig(filepath.Base(filepath.Clean(path)), format, contextKey, subsetKey)
+			if len(errs) > 0 {
+				return invalidOptionsError(errInvalidViewOptions, errs)
+			}
+
+			now := time.Now()
+			since, sinceErr := parseTimeBound(filterSince, now)
+			until, untilErr := parseTimeBound(filterUntil, now)
+			if err := errors.Join(sinceErr, untilErr); err != nil {
+				return err
+			}
+
+			var serverConfig t.ServerConfig
+			if viewWeb {
+				if authToken == "" {
+					authToken = os.Getenv(authTokenEnvVar)
+				}
+
+				serverConfig, errs = t.ParseServerConfig(serverHost, serverPort, "", "", authToken, nil)
+				if len(errs) > 0 {
+					return invalidOptionsError(errInvalidServerOptions, errs)
+				}
+			}
+
+			messages, err := queue.LoadMessages(path, queue.PersistedMessageFilter{
+				Glob:  filterGlob,
+				Since: since,
+				Until: until,
+			})
+			if err != nil {
+				return err
+			}
+
+			if debug {
+				serverInfo := "\n- not serving the web interface\n"
+				if viewWeb {
+					serverInfo = serverConfig.Display()
+				}
+				fmt.Printf(`Debug info:
+===
+
+Display
+---
+%s
+Messages
+---
+
+- path                    %s
+- messages                %d
+
+Server
+---
+%s`,
+					offlineDisplay(cfg),
+					path,
+					len(messages),
+					serverInfo,
+				)
+				return nil
+			}
+
+			if viewWeb {
+				behaviourFlags := t.WebBehaviourFlags{
+					SelectOnHover: changedBoolFlag(cmd, "select-on-hover", selectOnHover),
+				}
+				return server.ServeOffline(cfg, messages, behaviourFlags, serverConfig, webOpen)
+			}
+
+			return ui.RenderOffline(cfg, messages)
+		},
+	}
+
+	defaultWebBehaviours := t.DefaultWebBehaviours()
+	viewCmd.Flags().BoolVarP(&debug, "debug", "d", false, "whether to only display config picked up by cueitup")
+	viewCmd.Flags().StringVarP(&viewProfile, "profile", "p", "", "profile to take display settings (format, context key, subset key) from")
+	viewCmd.Flags().StringVarP(&viewFormat, "format", "f", "json", "format of the message bodies; possible values: [json, none]")
+	viewCmd.Flags().StringVarP(&viewContextKey, "context-key", "k", "", "key whose value is shown as context for JSON messages")
+	viewCmd.Flags().StringVarP(&viewSubsetKey, "subset-key", "s", "", "key of a nested object to show instead of the full JSON message")
+	viewCmd.Flags().StringVarP(&filterGlob, "glob", "g", "", "only show files whose names match this glob pattern (only for directories)")
+	viewCmd.Flags().StringVar(&filterSince, "since", "", "only show messages persisted (or archived) after this time; a duration (eg. 2h) or an RFC3339 timestamp")
+	viewCmd.Flags().StringVar(&filterUntil, "until", "", "only show messages persisted (or archived) before this time; a duration (eg. 30m) or an RFC3339 timestamp")
+	viewCmd.Flags().BoolVarP(&viewWeb, "web", "w", false, "whether to show messages in the web interface instead of the TUI")
+	viewCmd.Flags().BoolVarP(&webOpen, "open", "o", false, "whether to open web interface in browser automatically (with --web)")
+	viewCmd.Flags().BoolVarP(&selectOnHover, "select-on-hover", "S", defaultWebBehaviours.SelectOnHover, "whether to start the web interface with the setting \"select on hover\" ON (with --web)")
+	viewCmd.Flags().StringVar(&serverHost, "host", t.DefaultServerHost, "host to listen on (with --web)")
+	viewCmd.Flags().IntVar(&serverPort, "port", 0, "port to listen on (with --web); if not provided, the first open port between 8500-9500 is used")
+	viewCmd.Flags().StringVar(&authToken, "auth-token", "", fmt.Sprintf("static token needed to access the web interface (can also be set via %s); if not provided, one is generated at startup", authTokenEnvVar))
+
+	return viewCmd
+}
+
+// offlineDisplay shows the settings used to display messages loaded from disk;
+// unlike for profiles, there's no queue or AWS config to show.
+func offlineDisplay(cfg t.Config) string {
+	contextKey := notProvided
+	if cfg.ContextKey != nil {
+		contextKey = *cfg.ContextKey
+	}
+
+	subsetKey := notProvided
+	if cfg.SubsetKey != nil {
+		subsetKey = *cfg.SubsetKey
+	}
+
+	return fmt.Sprintf(`
+- name                    %s
+- format                  %s
+- context key             %s
+- subset key              %s
+`,
+		cfg.ProfileName,
+		cfg.Format.Display(),
+		contextKey,
+		subsetKey,
+	)
+}
@@ -0,0 +1,439 @@
+package queue
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	archiveFormatJSONL = "jsonl"
+	archiveFormatTarGz = "tar.gz"
+	// messages can be up to 1 MiB in size; attributes and JSON escaping add to
+	// that
+	maxArchiveLineBytes = 4 * 1024 * 1024
+	tarMessagesDir      = "messages"
+)
+
+var (
+	errCouldntCreateArchive    = errors.New("couldn't create archive")
+	errCouldntWriteToArchive   = errors.New("couldn't write to archive")
+	errCouldntOpenArchive      = errors.New("couldn't open archive")
+	errCouldntReadArchive      = errors.New("couldn't read archive")
+	errIncorrectArchiveFormat  = errors.New("incorrect archive format provided")
+	errUnknownArchiveExtension = errors.New("couldn't determine archive format from file extension")
+)
+
+// ArchiveFormat is the format of an archive file. JSONL archives contain one
+// message per line; tar.gz archives contain one JSON file per message.
+type ArchiveFormat uint
+
+const (
+	ArchiveFormatJSONL ArchiveFormat = iota
+	ArchiveFormatTarGz
+)
+
+func (f ArchiveFormat) Display() string {
+	var value string
+	switch f {
+	case ArchiveFormatJSONL:
+		value = archiveFormatJSONL
+	case ArchiveFormatTarGz:
+		value = archiveFormatTarGz
+	}
+
+	return value
+}
+
+func (f ArchiveFormat) Extension() string {
+	return f.Display()
+}
+
+func ParseArchiveFormat(value string) (ArchiveFormat, error) {
+	switch value {
+	case archiveFormatJSONL:
+		return ArchiveFormatJSONL, nil
+	case archiveFormatTarGz:
+		return ArchiveFormatTarGz, nil
+	default:
+		return ArchiveFormatJSONL, fmt.Errorf("%w: %q; possible values: [%s, %s]", errIncorrectArchiveFormat, value, archiveFormatJSONL, archiveFormatTarGz)
+	}
+}
+
+// ArchiveFormatFromPath determines an archive's format from its extension.
+func ArchiveFormatFromPath(path string) (ArchiveFormat, error) {
+	switch {
+	case strings.HasSuffix(path, ".jsonl"):
+		return ArchiveFormatJSONL, nil
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return ArchiveFormatTarGz, nil
+	default:
+		return ArchiveFormatJSONL, fmt.Errorf("%w: %q; expected .jsonl, .tar.gz or .tgz", errUnknownArchiveExtension, path)
+	}
+}
+
+// ArchivedMessage is a message as stored in an archive; it contains
+// everything needed to send the message to a queue again.
+type ArchivedMessage struct {
+	MessageID         string                      `json:"message_id"`
+	Body              string                      `json:"body"`
+	Attributes        map[string]string           `json:"attributes,omitempty"`
+	MessageAttributes map[string]MessageAttribute `json:"message_attributes,omitempty"`
+	ArchivedAt        time.Time                   `json:"archived_at"`
+}
+
+// MessageAttribute is a message attribute as stored in an archive.
+type MessageAttribute struct {
+	DataType    string  `json:"data_type"`
+	StringValue *string `json:"string_value,omitempty"`
+	BinaryValue []byte  `json:"binary_value,omitempty"`
+}
+
+// NewArchivedMessage converts a message received from SQS to an
+// ArchivedMessage.
+func NewArchivedMessage(message sqstypes.Message, archivedAt time.Time) ArchivedMessage {
+	var messageID, body string
+	if message.MessageId != nil {
+		messageID = *message.MessageId
+	}
+	if message.Body != nil {
+		body = *message.Body
+	}
+
+	return ArchivedMessage{
+		MessageID:         messageID,
+		Body:              body,
+		Attributes:        message.Attributes,
+		MessageAttributes: archivedMessageAttributes(message.MessageAttributes),
+		ArchivedAt:        archivedAt,
+	}
+}
+
+func archivedMessageAttributes(values map[string]sqstypes.MessageAttributeValue) map[string]MessageAttribute {
+	if len(values) == 0 {
+		return nil
+	}
+
+	attributes := make(map[string]MessageAttribute, len(values))
+	for name, value := range values {
+		var dataType string
+		if value.DataType != nil {
+			dataType = *value.DataType
+		}
+		attributes[name] = MessageAttribute{
+			DataType:    dataType,
+			StringValue: value.StringValue,
+			BinaryValue: value.BinaryValue,
+		}
+	}
+
+	return attributes
+}
+
+// SQSMessageAttributes converts an archived message's attributes to the form
+// expected by SQS when sending messages.
+func (m ArchivedMessage) SQSMessageAttributes() map[string]sqstypes.MessageAttributeValue {
+	if len(m.MessageAttributes) == 0 {
+		return nil
+	}
+
+	attributes := make(map[string]sqstypes.MessageAttributeValue, len(m.MessageAttributes))
+	for name, value := range m.MessageAttributes {
+		dataType := value.DataType
+		attributes[name] = sqstypes.MessageAttributeValue{
+			DataType:    &dataType,
+			StringValue: value.StringValue,
+			BinaryValue: value.BinaryValue,
+		}
+	}
+
+	return attributes
+}
+
+// ArchiveWriter writes messages to an archive.
+type ArchiveWriter interface {
+	Write(message ArchivedMessage) error
+	Close() error
+}
+
+// ArchiveReader reads messages from an archive, in the order they were
+// written in; Next returns io.EOF once all messages have been read.
+type ArchiveReader interface {
+	Next() (ArchivedMessage, error)
+	Close() error
+}
+
+// NewArchiveWriter creates an archive in the provided format; it fails if the
+// file already exists.
+func NewArchiveWriter(path string, format ArchiveFormat) (ArchiveWriter, error) {
+	switch format {
+	case ArchiveFormatTarGz:
+		return NewTarGzArchiveWriter(path)
+	default:
+		return NewJSONLArchiveWriter(path)
+	}
+}
+
+// OpenArchive opens an archive for reading; its format is determined from the
+// file's extension. Capture stores (see CapturedMessage) can be read as
+// archives as well.
+func OpenArchive(path string) (ArchiveReader, error) {
+	if isSQLiteCapture(path) {
+		return openSQLiteCapture(path)
+	}
+
+	format, err := ArchiveFormatFromPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCouldntOpenArchive, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenArchive, err.Error())
+	}
+
+	switch format {
+	case ArchiveFormatTarGz:
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%w: %s", errCouldntOpenArchive, err.Error())
+		}
+
+		return &tarGzArchiveReader{
+			file:       file,
+			gzipReader: gzipReader,
+			tarReader:  tar.NewReader(gzipReader),
+		}, nil
+	default:
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxArchiveLineBytes)
+
+		return &jsonlArchiveReader{
+			file:    file,
+			scanner: scanner,
+		}, nil
+	}
+}
+
+// JSONLArchiveWriter writes messages to a file, one JSON object per line.
+type JSONLArchiveWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// NewJSONLArchiveWriter creates the archive file (and its parent directories);
+// it fails if the file already exists.
+func NewJSONLArchiveWriter(path string) (*JSONLArchiveWriter, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateArchive, err.Error())
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateArchive, err.Error())
+	}
+
+	return &JSONLArchiveWriter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Write appends a message to the archive. The message is flushed to disk
+// before returning, so that it's safe to delete it from the queue afterwards.
+func (w *JSONLArchiveWriter) Write(message ArchivedMessage) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if _, err := w.writer.Write(append(bytes, '\n')); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	return nil
+}
+
+func (w *JSONLArchiveWriter) Close() error {
+	if err := w.writer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	return w.file.Close()
+}
+
+// TarGzArchiveWriter writes messages to a gzipped tar file, as one JSON file
+// per message.
+type TarGzArchiveWriter struct {
+	file       *os.File
+	gzipWriter *gzip.Writer
+	tarWriter  *tar.Writer
+	written    int
+}
+
+// NewTarGzArchiveWriter creates the archive file (and its parent directories);
+// it fails if the file already exists.
+func NewTarGzArchiveWriter(path string) (*TarGzArchiveWriter, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateArchive, err.Error())
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateArchive, err.Error())
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+
+	return &TarGzArchiveWriter{
+		file:       file,
+		gzipWriter: gzipWriter,
+		tarWriter:  tar.NewWriter(gzipWriter),
+	}, nil
+}
+
+// Write adds a message to the archive. Like JSONLArchiveWriter, it flushes the
+// message to disk before returning.
+func (w *TarGzArchiveWriter) Write(message ArchivedMessage) error {
+	bytes, err := json.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	w.written++
+	err = w.tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     fmt.Sprintf("%s/%08d.json", tarMessagesDir, w.written),
+		Mode:     0o644,
+		Size:     int64(len(bytes)),
+		ModTime:  message.ArchivedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if _, err := w.tarWriter.Write(bytes); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.tarWriter.Flush(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.gzipWriter.Flush(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	return nil
+}
+
+func (w *TarGzArchiveWriter) Close() error {
+	if err := w.tarWriter.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	if err := w.gzipWriter.Close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("%w: %s", errCouldntWriteToArchive, err.Error())
+	}
+
+	return w.file.Close()
+}
+
+type jsonlArchiveReader struct {
+	file    *os.File
+	scanner *bufio.Scanner
+}
+
+func (r *jsonlArchiveReader) Next() (ArchivedMessage, error) {
+	var message ArchivedMessage
+	for r.scanner.Scan() {
+		line := r.scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(line, &message); err != nil {
+			return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+		}
+
+		return message, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+	}
+
+	return message, io.EOF
+}
+
+func (r *jsonlArchiveReader) Close() error {
+	return r.file.Close()
+}
+
+type tarGzArchiveReader struct {
+	file       *os.File
+	gzipReader *gzip.Reader
+	tarReader  *tar.Reader
+}
+
+func (r *tarGzArchiveReader) Next() (ArchivedMessage, error) {
+	var message ArchivedMessage
+	for {
+		header, err := r.tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return message, io.EOF
+		}
+		if err != nil {
+			return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+		}
+
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(header.Name, tarMessagesDir+"/") {
+			continue
+		}
+
+		if err := json.NewDecoder(io.LimitReader(r.tarReader, maxArchiveLineBytes)).Decode(&message); err != nil {
+			return message, fmt.Errorf("%w: %s: %s", errCouldntReadArchive, header.Name, err.Error())
+		}
+
+		return message, nil
+	}
+}
+
+func (r *tarGzArchiveReader) Close() error {
+	r.gzipReader.Close()
+	return r.file.Close()
+}
+
+// DefaultBackupPath is where backups for a queue are written to, unless
+// specified otherwise.
+func DefaultBackupPath(queueName string, format ArchiveFormat, now time.Time) string {
+	return filepath.Join("backups", queueName, fmt.Sprintf("%s.%s", now.Format("20060102T150405"), format.Extension()))
+}
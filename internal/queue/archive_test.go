@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	archivedAt := time.Date(2025, 4, 16, 10, 0, 0, 0, time.UTC)
+	messages := []ArchivedMessage{
+		{
+			MessageID:  "id-000",
+			Body:       `{"seq": 0}`,
+			Attributes: map[string]string{"MessageGroupId": "group-a"},
+			MessageAttributes: map[string]MessageAttribute{
+				"source": {DataType: "String", StringValue: aws.String("cueitup")},
+				"blob":   {DataType: "Binary", BinaryValue: []byte{0x1, 0x2}},
+			},
+			ArchivedAt: archivedAt,
+		},
+		{
+			MessageID:  "id-001",
+			Body:       "plain text\nwith a newline",
+			ArchivedAt: archivedAt,
+		},
+	}
+
+	for _, format := range []ArchiveFormat{ArchiveFormatJSONL, ArchiveFormatTarGz} {
+		t.Run(format.Display(), func(t *testing.T) {
+			path := DefaultBackupPath("queue-a", format, archivedAt)
+			path = filepath.Join(t.TempDir(), path)
+
+			writer, err := NewArchiveWriter(path, format)
+			require.NoError(t, err)
+			for _, message := range messages {
+				require.NoError(t, writer.Write(message))
+			}
+			require.NoError(t, writer.Close())
+
+			reader, err := OpenArchive(path)
+			require.NoError(t, err)
+			defer reader.Close()
+
+			var got []ArchivedMessage
+			for {
+				message, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, message)
+			}
+
+			assert.Equal(t, messages, got)
+		})
+	}
+
+	t.Run("existing archives aren't overwritten", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.jsonl")
+		writer, err := NewArchiveWriter(path, ArchiveFormatJSONL)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		_, err = NewArchiveWriter(path, ArchiveFormatJSONL)
+
+		assert.ErrorIs(t, err, errCouldntCreateArchive)
+	})
+}
+
+func TestArchiveFormatFromPath(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected ArchiveFormat
+		err      error
+	}{
+		{path: "backups/queue-a/20250416T100000.jsonl", expected: ArchiveFormatJSONL},
+		{path: "backup.tar.gz", expected: ArchiveFormatTarGz},
+		{path: "backup.tgz", expected: ArchiveFormatTarGz},
+		{path: "backup.json", err: errUnknownArchiveExtension},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := ArchiveFormatFromPath(tc.path)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const (
+	backupModeDrain = "drain"
+	backupModePeek  = "peek"
+)
+
+var errIncorrectBackupMode = errors.New("incorrect backup mode provided")
+
+// BackupMode determines what happens to messages once they're backed up.
+type BackupMode uint
+
+const (
+	// BackupModePeek leaves messages in the queue.
+	BackupModePeek BackupMode = iota
+	// BackupModeDrain deletes messages from the queue once they're archived.
+	BackupModeDrain
+)
+
+func (m BackupMode) Display() string {
+	var value string
+	switch m {
+	case BackupModePeek:
+		value = backupModePeek
+	case BackupModeDrain:
+		value = backupModeDrain
+	}
+
+	return value
+}
+
+func ParseBackupMode(value string) (BackupMode, error) {
+	switch value {
+	case backupModePeek:
+		return BackupModePeek, nil
+	case backupModeDrain:
+		return BackupModeDrain, nil
+	default:
+		return BackupModePeek, fmt.Errorf("%w: %q; possible values: [%s, %s]", errIncorrectBackupMode, value, backupModePeek, backupModeDrain)
+	}
+}
+
+type BackupOptions struct {
+	Path   string
+	Format ArchiveFormat
+	Mode   BackupMode
+	// VisibilityTimeout is how long messages stay invisible while peeking;
+	// it's not used when draining.
+	VisibilityTimeout int32
+	// OnProgress, if provided, is called after every batch of messages with
+	// the number of messages backed up so far.
+	OnProgress func(backedUp int)
+	// OnRedrivePolicy, if provided, is called before peeking at a queue that
+	// has a redrive policy, with the policy's maxReceiveCount.
+	OnRedrivePolicy func(maxReceiveCount int)
+}
+
+// Backup writes all messages in a queue to an archive. Draining a queue isn't
+// allowed for read-only profiles.
+func Backup(ctx context.Context, client Client, config t.Config, opts BackupOptions) (int, error) {
+	if opts.Mode == BackupModeDrain {
+		if err := config.EnsureWritable(); err != nil {
+			return 0, err
+		}
+	}
+
+	// peeking counts towards every message's maxReceiveCount, so it can move
+	// messages to the dead-letter queue
+	if opts.Mode == BackupModePeek && opts.OnRedrivePolicy != nil {
+		maxReceiveCount, ok, err := MaxReceiveCount(ctx, client, config.QueueURL)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			opts.OnRedrivePolicy(maxReceiveCount)
+		}
+	}
+
+	archive, err := NewArchiveWriter(opts.Path, opts.Format)
+	if err != nil {
+		return 0, err
+	}
+
+	var backedUp int
+	switch opts.Mode {
+	case BackupModeDrain:
+		backedUp, err = Drain(ctx, client, config.QueueURL, archive, DrainOptions{OnProgress: opts.OnProgress})
+	default:
+		backedUp, err = Peek(ctx, client, config.QueueURL, archive, opts.VisibilityTimeout, opts.OnProgress)
+	}
+
+	closeErr := archive.Close()
+	if err != nil {
+		return backedUp, err
+	}
+
+	return backedUp, closeErr
+}
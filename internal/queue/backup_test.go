@@ -0,0 +1,140 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiringClient makes messages in flight visible again (with new receipt
+// handles) on the expireOn-th receive, as if their visibility timeout expired.
+type expiringClient struct {
+	*fakeClient
+	receives int
+	expireOn int
+}
+
+func (c *expiringClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	c.receives++
+	if c.receives == c.expireOn {
+		for handle, message := range c.inFlight {
+			delete(c.inFlight, handle)
+			message.ReceiptHandle = aws.String(handle + "-again")
+			c.messages = append(c.messages, message)
+		}
+	}
+
+	return c.fakeClient.ReceiveMessage(ctx, params, optFns...)
+}
+
+func TestBackup(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile-a",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+	}
+
+	t.Run("peeking leaves messages in the queue", func(t *testing.T) {
+		client := newFakeClient(25)
+		path := filepath.Join(t.TempDir(), "backup.tar.gz")
+
+		backedUp, err := Backup(context.Background(), client, config, BackupOptions{
+			Path:   path,
+			Format: ArchiveFormatTarGz,
+			Mode:   BackupModePeek,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 25, backedUp)
+		assert.Len(t, client.messages, 25)
+		assert.Empty(t, client.inFlight)
+		assert.Equal(t, 25, client.released)
+	})
+
+	t.Run("messages received again while peeking are only archived once", func(t *testing.T) {
+		client := &expiringClient{fakeClient: newFakeClient(25), expireOn: 3}
+		path := filepath.Join(t.TempDir(), "backup.jsonl")
+
+		backedUp, err := Backup(context.Background(), client, config, BackupOptions{
+			Path:   path,
+			Format: ArchiveFormatJSONL,
+			Mode:   BackupModePeek,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 25, backedUp)
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(contents)), "\n"), 25)
+		// messages are released via their latest receipt handles
+		assert.Empty(t, client.inFlight)
+		assert.Len(t, client.messages, 25)
+	})
+
+	t.Run("redrive policies are reported before peeking", func(t *testing.T) {
+		client := newFakeClient(5)
+		client.redrivePolicy = `{"deadLetterTargetArn":"arn","maxReceiveCount":4}`
+		var reported int
+
+		backedUp, err := Backup(context.Background(), client, config, BackupOptions{
+			Path:            filepath.Join(t.TempDir(), "backup.jsonl"),
+			Mode:            BackupModePeek,
+			OnRedrivePolicy: func(maxReceiveCount int) { reported = maxReceiveCount },
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, backedUp)
+		assert.Equal(t, 4, reported)
+	})
+
+	t.Run("draining deletes messages from the queue", func(t *testing.T) {
+		client := newFakeClient(25)
+		path := filepath.Join(t.TempDir(), "backup.jsonl")
+
+		backedUp, err := Backup(context.Background(), client, config, BackupOptions{
+			Path:   path,
+			Format: ArchiveFormatJSONL,
+			Mode:   BackupModeDrain,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 25, backedUp)
+		assert.Empty(t, client.messages)
+		assert.Empty(t, client.inFlight)
+	})
+
+	t.Run("read-only profiles can't be drained", func(t *testing.T) {
+		client := newFakeClient(5)
+		readOnlyConfig := config
+		readOnlyConfig.ReadOnly = true
+
+		_, err := Backup(context.Background(), client, readOnlyConfig, BackupOptions{
+			Path: filepath.Join(t.TempDir(), "backup.jsonl"),
+			Mode: BackupModeDrain,
+		})
+
+		require.ErrorIs(t, err, types.ErrProfileIsReadOnly)
+		assert.Len(t, client.messages, 5)
+	})
+
+	t.Run("read-only profiles can be peeked at", func(t *testing.T) {
+		client := newFakeClient(5)
+		readOnlyConfig := config
+		readOnlyConfig.ReadOnly = true
+
+		backedUp, err := Backup(context.Background(), client, readOnlyConfig, BackupOptions{
+			Path: filepath.Join(t.TempDir(), "backup.jsonl"),
+			Mode: BackupModePeek,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, backedUp)
+	})
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// CaptureFileJSONL is the name of the JSONL capture store within a
+	// persist directory.
+	CaptureFileJSONL = "capture.jsonl"
+	// CaptureFileSQLite is the name of the SQLite capture store within a
+	// persist directory.
+	CaptureFileSQLite = "capture.db"
+)
+
+var (
+	errCouldntOpenCaptureStore    = errors.New("couldn't open capture store")
+	errCouldntWriteToCaptureStore = errors.New("couldn't write to capture store")
+)
+
+// CapturedMessage is a message as stored in a capture store. It extends
+// ArchivedMessage with details that captures can be queried by, so that JSONL
+// captures can also be read like archives; ArchivedAt is when the message was
+// captured.
+type CapturedMessage struct {
+	ArchivedMessage
+	Queue        string  `json:"queue"`
+	Profile      string  `json:"profile"`
+	ContextValue *string `json:"context_value,omitempty"`
+	Error        *string `json:"error,omitempty"`
+}
+
+// NewCapturedMessage converts a message received from a queue to a
+// CapturedMessage. The raw body is captured, regardless of the profile's
+// subset key, so that captures can be sent to a queue again.
+func NewCapturedMessage(message PersistableMessage, profileName, queueName string) CapturedMessage {
+	captured := CapturedMessage{
+		ArchivedMessage: NewArchivedMessage(message.SQSMessage, message.ReceivedAt),
+		Queue:           queueName,
+		Profile:         profileName,
+		ContextValue:    message.Message.ContextValue,
+	}
+
+	if captured.MessageID == "" {
+		captured.MessageID = message.Message.ID
+	}
+
+	if message.Message.Err != nil {
+		errStr := message.Message.Err.Error()
+		captured.Error = &errStr
+	}
+
+	return captured
+}
+
+// sentAt returns when a message was sent to its queue, as per its
+// SentTimestamp attribute.
+func sentAt(message sqstypes.Message) (time.Time, bool) {
+	value, ok := message.Attributes[string(sqstypes.MessageSystemAttributeNameSentTimestamp)]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(millis), true
+}
+
+// isSQLiteCapture reports whether a path points to a SQLite capture store.
+func isSQLiteCapture(path string) bool {
+	return strings.HasSuffix(path, ".db") || strings.HasSuffix(path, ".sqlite")
+}
+
+// jsonlCaptureStore appends messages to a single file, one JSON object per
+// line.
+type jsonlCaptureStore struct {
+	mu          sync.Mutex
+	path        string
+	file        *os.File
+	profileName string
+	queueName   string
+}
+
+func newJSONLCaptureStore(path, profileName, queueName string) (Persister, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenCaptureStore, err.Error())
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenCaptureStore, err.Error())
+	}
+
+	return &jsonlCaptureStore{
+		path:        path,
+		file:        file,
+		profileName: profileName,
+		queueName:   queueName,
+	}, nil
+}
+
+// Persist appends a message to the capture file; like JSONLArchiveWriter, it
+// flushes the message to disk before returning.
+func (s *jsonlCaptureStore) Persist(message PersistableMessage) (string, error) {
+	bytes, err := json.Marshal(NewCapturedMessage(message, s.profileName, s.queueName))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(bytes, '\n')); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	return s.path, nil
+}
+
+func (s *jsonlCaptureStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
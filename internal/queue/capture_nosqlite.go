@@ -0,0 +1,17 @@
+//go:build !sqlite
+
+package queue
+
+import (
+	"fmt"
+
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+func newSQLiteCaptureStore(_, _, _ string) (Persister, error) {
+	return nil, fmt.Errorf("%w: %w", errCouldntOpenCaptureStore, t.ErrSQLiteNotSupported)
+}
+
+func openSQLiteCapture(_ string) (ArchiveReader, error) {
+	return nil, fmt.Errorf("%w: %w", errCouldntOpenArchive, t.ErrSQLiteNotSupported)
+}
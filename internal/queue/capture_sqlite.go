@@ -0,0 +1,200 @@
+//go:build sqlite
+
+package queue
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	// registers the "sqlite3" driver; needs cgo
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteCaptureSchema = `
+CREATE TABLE IF NOT EXISTS messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    queue TEXT NOT NULL,
+    profile TEXT NOT NULL,
+    context_value TEXT,
+    body TEXT NOT NULL,
+    attributes TEXT,
+    message_attributes TEXT,
+    sent_at TEXT,
+    captured_at TEXT NOT NULL,
+    error TEXT
+);
+CREATE INDEX IF NOT EXISTS messages_message_id ON messages (message_id);
+CREATE INDEX IF NOT EXISTS messages_context_value ON messages (context_value);
+CREATE INDEX IF NOT EXISTS messages_captured_at ON messages (captured_at);
+`
+
+// sqliteTimeLayout is used for timestamp columns; it sorts lexically, so that
+// time ranges can be queried with plain comparisons.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// sqliteCaptureStore inserts messages into a SQLite database, with a column
+// for each detail captures are commonly queried by (context value, timestamps,
+// errors); attributes are stored as JSON.
+type sqliteCaptureStore struct {
+	path        string
+	db          *sql.DB
+	profileName string
+	queueName   string
+}
+
+func newSQLiteCaptureStore(path, profileName, queueName string) (Persister, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenCaptureStore, err.Error())
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenCaptureStore, err.Error())
+	}
+	// SQLite allows a single writer at a time
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteCaptureSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenCaptureStore, err.Error())
+	}
+
+	return &sqliteCaptureStore{
+		path:        path,
+		db:          db,
+		profileName: profileName,
+		queueName:   queueName,
+	}, nil
+}
+
+func (s *sqliteCaptureStore) Persist(message PersistableMessage) (string, error) {
+	captured := NewCapturedMessage(message, s.profileName, s.queueName)
+
+	attributes, err := marshalNullable(captured.Attributes)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	messageAttributes, err := marshalNullable(captured.MessageAttributes)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	var sent *string
+	if at, ok := sentAt(message.SQSMessage); ok {
+		value := at.UTC().Format(sqliteTimeLayout)
+		sent = &value
+	}
+
+	_, err = s.db.Exec(
+		`INSERT INTO messages (message_id, queue, profile, context_value, body, attributes, message_attributes, sent_at, captured_at, error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		captured.MessageID,
+		captured.Queue,
+		captured.Profile,
+		captured.ContextValue,
+		captured.Body,
+		attributes,
+		messageAttributes,
+		sent,
+		captured.ArchivedAt.UTC().Format(sqliteTimeLayout),
+		captured.Error,
+	)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntWriteToCaptureStore, err.Error())
+	}
+
+	return s.path, nil
+}
+
+func (s *sqliteCaptureStore) Close() error {
+	return s.db.Close()
+}
+
+func marshalNullable[T any](value map[string]T) (*string, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	str := string(bytes)
+	return &str, nil
+}
+
+// sqliteCaptureReader reads messages from a SQLite capture store, in the
+// order they were captured in.
+type sqliteCaptureReader struct {
+	db   *sql.DB
+	rows *sql.Rows
+}
+
+func openSQLiteCapture(path string) (ArchiveReader, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenArchive, err.Error())
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenArchive, err.Error())
+	}
+
+	rows, err := db.Query(`SELECT message_id, body, attributes, message_attributes, captured_at FROM messages ORDER BY id`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenArchive, err.Error())
+	}
+
+	return &sqliteCaptureReader{db: db, rows: rows}, nil
+}
+
+func (r *sqliteCaptureReader) Next() (ArchivedMessage, error) {
+	var message ArchivedMessage
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+		}
+		return message, io.EOF
+	}
+
+	var attributes, messageAttributes sql.NullString
+	var capturedAt string
+	err := r.rows.Scan(&message.MessageID, &message.Body, &attributes, &messageAttributes, &capturedAt)
+	if err != nil {
+		return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+	}
+
+	if attributes.Valid {
+		if err := json.Unmarshal([]byte(attributes.String), &message.Attributes); err != nil {
+			return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+		}
+	}
+
+	if messageAttributes.Valid {
+		if err := json.Unmarshal([]byte(messageAttributes.String), &message.MessageAttributes); err != nil {
+			return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+		}
+	}
+
+	message.ArchivedAt, err = time.Parse(sqliteTimeLayout, capturedAt)
+	if err != nil {
+		return message, fmt.Errorf("%w: %s", errCouldntReadArchive, err.Error())
+	}
+
+	return message, nil
+}
+
+func (r *sqliteCaptureReader) Close() error {
+	r.rows.Close()
+	return r.db.Close()
+}
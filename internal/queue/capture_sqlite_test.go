@@ -0,0 +1,71 @@
+//go:build sqlite
+
+package queue
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteCaptureStore(t *testing.T) {
+	dir := t.TempDir()
+	config := types.Config{
+		ProfileName:  "profile-a",
+		QueueURL:     "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		PersistDir:   dir,
+		PersistStore: types.PersistStoreSQLite,
+	}
+	path := filepath.Join(dir, CaptureFileSQLite)
+
+	persister, err := NewPersister(config)
+	require.NoError(t, err)
+	for i, contextValue := range []string{"agg-1", "agg-2", "agg-1"} {
+		location, err := persister.Persist(PersistableMessage{
+			Message: types.Message{ID: []string{"id-a", "id-b", "id-c"}[i], ContextValue: &contextValue},
+			SQSMessage: sqstypes.Message{
+				MessageId:  aws.String([]string{"id-a", "id-b", "id-c"}[i]),
+				Body:       aws.String(`{"seq": 1}`),
+				Attributes: map[string]string{"SentTimestamp": "1700000000000"},
+			},
+			ReceivedAt: time.Unix(1700000100+int64(i)*100, 0),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, path, location)
+	}
+	require.NoError(t, persister.Close())
+
+	t.Run("captures can be queried by context value", func(t *testing.T) {
+		db, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		defer db.Close()
+
+		rows, err := db.Query(`SELECT message_id FROM messages WHERE context_value = ? AND sent_at IS NOT NULL ORDER BY captured_at`, "agg-1")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		var got []string
+		for rows.Next() {
+			var messageID string
+			require.NoError(t, rows.Scan(&messageID))
+			got = append(got, messageID)
+		}
+		assert.Equal(t, []string{"id-a", "id-c"}, got)
+	})
+
+	t.Run("captures can be loaded like archives", func(t *testing.T) {
+		got, err := LoadMessages(path, PersistedMessageFilter{Until: time.Unix(1700000250, 0)})
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "id-b", aws.ToString(got[1].MessageId))
+		assert.Equal(t, "1700000000000", got[1].Attributes["SentTimestamp"])
+	})
+}
@@ -0,0 +1,118 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLCaptureStore(t *testing.T) {
+	dir := t.TempDir()
+	config := types.Config{
+		ProfileName:  "profile-a",
+		QueueURL:     "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		PersistDir:   dir,
+		PersistStore: types.PersistStoreJSONL,
+	}
+	contextValue := "agg-1"
+	messages := []PersistableMessage{
+		{
+			Message: types.Message{ID: "id-a", Body: "{}", ContextValue: &contextValue},
+			SQSMessage: sqstypes.Message{
+				MessageId:  aws.String("id-a"),
+				Body:       aws.String(`{"aggregateId": "agg-1"}`),
+				Attributes: map[string]string{"SentTimestamp": "1700000000000"},
+			},
+			ReceivedAt: time.Unix(1700000100, 0),
+		},
+		{
+			Message: types.Message{ID: "id-b", Err: errors.New("couldn't unmarshal message body bytes as JSON")},
+			SQSMessage: sqstypes.Message{
+				MessageId: aws.String("id-b"),
+				Body:      aws.String("not json"),
+			},
+			ReceivedAt: time.Unix(1700000200, 0),
+		},
+	}
+
+	// messages are appended to the same file when the store is opened again
+	for _, message := range messages {
+		persister, err := NewPersister(config)
+		require.NoError(t, err)
+
+		location, err := persister.Persist(message)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, CaptureFileJSONL), location)
+		require.NoError(t, persister.Close())
+	}
+
+	t.Run("captures include details to query them by", func(t *testing.T) {
+		file, err := os.Open(filepath.Join(dir, CaptureFileJSONL))
+		require.NoError(t, err)
+		defer file.Close()
+
+		var got []CapturedMessage
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var captured CapturedMessage
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &captured))
+			got = append(got, captured)
+		}
+
+		require.Len(t, got, 2)
+		assert.Equal(t, "id-a", got[0].MessageID)
+		assert.Equal(t, `{"aggregateId": "agg-1"}`, got[0].Body)
+		assert.Equal(t, "queue-a", got[0].Queue)
+		assert.Equal(t, "profile-a", got[0].Profile)
+		require.NotNil(t, got[0].ContextValue)
+		assert.Equal(t, "agg-1", *got[0].ContextValue)
+		assert.Nil(t, got[0].Error)
+		require.NotNil(t, got[1].Error)
+		assert.Equal(t, "couldn't unmarshal message body bytes as JSON", *got[1].Error)
+	})
+
+	t.Run("captures can be loaded like archives", func(t *testing.T) {
+		got, err := LoadMessages(filepath.Join(dir, CaptureFileJSONL), PersistedMessageFilter{Since: time.Unix(1700000150, 0)})
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "id-b", aws.ToString(got[0].MessageId))
+		assert.Equal(t, "not json", aws.ToString(got[0].Body))
+	})
+}
+
+func TestNewPersisterForFiles(t *testing.T) {
+	dir := t.TempDir()
+	template, err := types.ParsePersistFilenameTemplate("{context}/{id}")
+	require.NoError(t, err)
+	config := types.Config{
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		Format:          types.None,
+		PersistDir:      dir,
+		PersistFilename: template,
+	}
+	contextValue := "agg-1"
+
+	persister, err := NewPersister(config)
+	require.NoError(t, err)
+	location, err := persister.Persist(PersistableMessage{
+		Message:    types.Message{ID: "id-a", Body: "seq 1", ContextValue: &contextValue},
+		SQSMessage: sqstypes.Message{MessageId: aws.String("id-a"), Body: aws.String("seq 1")},
+		ReceivedAt: time.Unix(1700000000, 0),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "agg-1", "id-a.txt"), location)
+	require.NoError(t, persister.Close())
+}
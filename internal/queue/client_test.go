@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// fakeClient is an in-memory queue; received messages are deleted from it
+// only when DeleteMessageBatch is called.
+type fakeClient struct {
+	messages   []sqstypes.Message
+	inFlight   map[string]sqstypes.Message
+	sent       []sqstypes.SendMessageBatchRequestEntry
+	released   int
+	purged     bool
+	purgeErr   error
+	failDelete bool
+	// deleteFailures is the number of messages that fail to be deleted (for
+	// reasons that aren't the sender's fault) before deleting succeeds
+	deleteFailures int
+	deleteRequests int
+	// inflow is the number of messages sent to the queue every time it's
+	// received from
+	inflow int
+	// approxCount, if set, is reported as the approximate number of messages
+	// in the queue, instead of the actual number
+	approxCount *int
+	// maxSends is the number of messages that can be sent before sending
+	// starts failing; -1 means there's no limit
+	maxSends int
+	// redrivePolicy, if set, is reported as the queue's redrive policy
+	redrivePolicy string
+}
+
+func newFakeClient(numMessages int) *fakeClient {
+	messages := make([]sqstypes.Message, numMessages)
+	for i := range messages {
+		id := fmt.Sprintf("id-%03d", i)
+		messages[i] = sqstypes.Message{
+			MessageId:     aws.String(id),
+			ReceiptHandle: aws.String("handle-" + id),
+			Body:          aws.String(fmt.Sprintf(`{"seq": %d}`, i)),
+			Attributes:    map[string]string{"SentTimestamp": "1700000000000"},
+		}
+	}
+
+	return &fakeClient{
+		messages: messages,
+		inFlight: make(map[string]sqstypes.Message),
+		maxSends: -1,
+	}
+}
+
+func (f *fakeClient) ReceiveMessage(_ context.Context, params *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	for range f.inflow {
+		id := fmt.Sprintf("id-inflow-%03d", len(f.messages)+len(f.inFlight))
+		f.messages = append(f.messages, sqstypes.Message{
+			MessageId:     aws.String(id),
+			ReceiptHandle: aws.String("handle-" + id),
+			Body:          aws.String(`{"inflow": true}`),
+		})
+	}
+	n := min(int(params.MaxNumberOfMessages), len(f.messages))
+	received := f.messages[:n]
+	f.messages = f.messages[n:]
+	for _, message := range received {
+		f.inFlight[*message.ReceiptHandle] = message
+	}
+
+	return &sqs.ReceiveMessageOutput{Messages: received}, nil
+}
+
+func (f *fakeClient) DeleteMessageBatch(_ context.Context, params *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	f.deleteRequests++
+	var output sqs.DeleteMessageBatchOutput
+	for _, entry := range params.Entries {
+		if f.failDelete || f.deleteFailures > 0 {
+			f.deleteFailures--
+			output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("InternalError"),
+				SenderFault: false,
+			})
+			continue
+		}
+		if _, ok := f.inFlight[*entry.ReceiptHandle]; !ok {
+			output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("ReceiptHandleIsInvalid"),
+				SenderFault: true,
+			})
+			continue
+		}
+		delete(f.inFlight, *entry.ReceiptHandle)
+		output.Successful = append(output.Successful, sqstypes.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return &output, nil
+}
+
+func (f *fakeClient) PurgeQueue(_ context.Context, _ *sqs.PurgeQueueInput, _ ...func(*sqs.Options)) (*sqs.PurgeQueueOutput, error) {
+	if f.purgeErr != nil {
+		return nil, f.purgeErr
+	}
+
+	f.purged = true
+	f.messages = nil
+	f.inFlight = make(map[string]sqstypes.Message)
+
+	return &sqs.PurgeQueueOutput{}, nil
+}
+
+func (f *fakeClient) ChangeMessageVisibilityBatch(_ context.Context, params *sqs.ChangeMessageVisibilityBatchInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	var output sqs.ChangeMessageVisibilityBatchOutput
+	for _, entry := range params.Entries {
+		message, ok := f.inFlight[*entry.ReceiptHandle]
+		if ok && entry.VisibilityTimeout == 0 {
+			delete(f.inFlight, *entry.ReceiptHandle)
+			f.messages = append(f.messages, message)
+			f.released++
+		}
+		output.Successful = append(output.Successful, sqstypes.ChangeMessageVisibilityBatchResultEntry{Id: entry.Id})
+	}
+
+	return &output, nil
+}
+
+func (f *fakeClient) GetQueueAttributes(_ context.Context, _ *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	count := len(f.messages)
+	if f.approxCount != nil {
+		count = *f.approxCount
+	}
+
+	attributes := map[string]string{
+		string(sqstypes.QueueAttributeNameApproximateNumberOfMessages):           strconv.Itoa(count),
+		string(sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible): strconv.Itoa(len(f.inFlight)),
+	}
+	if f.redrivePolicy != "" {
+		attributes[string(sqstypes.QueueAttributeNameRedrivePolicy)] = f.redrivePolicy
+	}
+
+	return &sqs.GetQueueAttributesOutput{Attributes: attributes}, nil
+}
+
+func (f *fakeClient) SendMessageBatch(_ context.Context, params *sqs.SendMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	var output sqs.SendMessageBatchOutput
+	for _, entry := range params.Entries {
+		if f.maxSends >= 0 && len(f.sent) >= f.maxSends {
+			output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    aws.String("InternalError"),
+				Message: aws.String("simulated failure"),
+			})
+			continue
+		}
+		f.sent = append(f.sent, entry)
+		output.Successful = append(output.Successful, sqstypes.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// deleteMaxAttempts is the number of times a message is tried to be
+	// deleted, if deleting it fails for reasons other than the sender's fault
+	deleteMaxAttempts = 3
+	// deleteRetryDelay is how long deleting waits before retrying; it grows
+	// with every attempt
+	deleteRetryDelay = 100 * time.Millisecond
+)
+
+var errNoReceiptHandle = errors.New("message has no receipt handle")
+
+// DeleteFailure is a message that couldn't be deleted from a queue.
+type DeleteFailure struct {
+	MessageID string
+	// Code and Message are the reason SQS gave for not deleting the message;
+	// Code is empty if the request to delete it failed as a whole.
+	Code    string
+	Message string
+	// SenderFault is true for failures retrying won't fix (eg. an expired
+	// receipt handle).
+	SenderFault bool
+}
+
+func (f DeleteFailure) Reason() string {
+	switch {
+	case f.Code == "":
+		return f.Message
+	case f.Message == "":
+		return f.Code
+	default:
+		return fmt.Sprintf("%s (%s)", f.Code, f.Message)
+	}
+}
+
+func (f DeleteFailure) String() string {
+	return fmt.Sprintf("%s: %s", f.MessageID, f.Reason())
+}
+
+type DeleteResult struct {
+	Deleted int
+	// Failed holds the messages that are still on the queue.
+	Failed []DeleteFailure
+}
+
+// Summary describes how many messages were deleted, and how many weren't.
+func (r DeleteResult) Summary() string {
+	total := r.Deleted + len(r.Failed)
+	if len(r.Failed) == 0 {
+		return fmt.Sprintf("deleted %d messages", total)
+	}
+
+	return fmt.Sprintf("couldn't delete %d of %d messages; they're still on the queue", len(r.Failed), total)
+}
+
+// Delete deletes messages from a queue in batches. A batch can partially fail
+// even if the request to delete it succeeds; messages that failed for reasons
+// other than the sender's fault (as well as whole batches, if the request
+// failed) are retried a few times. Every message that wasn't deleted is
+// reported in the result's Failed; the error returned is that of the last
+// request that failed as a whole, if any.
+func Delete(ctx context.Context, client Client, queueURL string, messages []sqstypes.Message) (DeleteResult, error) {
+	var result DeleteResult
+	var lastErr error
+	for start := 0; start < len(messages); start += drainBatchSize {
+		batch := messages[start:min(start+drainBatchSize, len(messages))]
+		deleted, failed, err := deleteBatch(ctx, client, queueURL, batch)
+		result.Deleted += deleted
+		result.Failed = append(result.Failed, failed...)
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return result, lastErr
+}
+
+func deleteBatch(ctx context.Context, client Client, queueURL string, batch []sqstypes.Message) (int, []DeleteFailure, error) {
+	var deleted int
+	var failed []DeleteFailure
+	var err error
+
+	// pending holds the indexes (in batch) of the messages yet to be deleted;
+	// messages without a receipt handle can't be deleted, so they're not sent
+	pending := make([]int, 0, len(batch))
+	for i, message := range batch {
+		if aws.ToString(message.ReceiptHandle) == "" {
+			failed = append(failed, DeleteFailure{
+				MessageID:   aws.ToString(message.MessageId),
+				Message:     errNoReceiptHandle.Error(),
+				SenderFault: true,
+			})
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for attempt := 1; attempt <= deleteMaxAttempts && len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			if err = waitToRetry(ctx, attempt); err != nil {
+				break
+			}
+		}
+
+		entries := make([]sqstypes.DeleteMessageBatchRequestEntry, len(pending))
+		for i, index := range pending {
+			entries[i] = sqstypes.DeleteMessageBatchRequestEntry{
+				Id:            aws.String(strconv.Itoa(index)),
+				ReceiptHandle: batch[index].ReceiptHandle,
+			}
+		}
+
+		var output *sqs.DeleteMessageBatchOutput
+		output, err = client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			err = fmt.Errorf("%w: %s", errCouldntDeleteMessages, err.Error())
+			continue
+		}
+
+		var retry []int
+		for _, entry := range output.Failed {
+			index, convErr := strconv.Atoi(aws.ToString(entry.Id))
+			if convErr != nil || index < 0 || index >= len(batch) {
+				continue
+			}
+
+			failure := DeleteFailure{
+				MessageID:   aws.ToString(batch[index].MessageId),
+				Code:        aws.ToString(entry.Code),
+				Message:     aws.ToString(entry.Message),
+				SenderFault: entry.SenderFault,
+			}
+			if failure.SenderFault || attempt == deleteMaxAttempts {
+				failed = append(failed, failure)
+				continue
+			}
+			retry = append(retry, index)
+		}
+		deleted += len(output.Successful)
+		pending = retry
+	}
+
+	// messages still pending at this point were part of a request that failed
+	// as a whole
+	if err != nil {
+		for _, index := range pending {
+			failed = append(failed, DeleteFailure{
+				MessageID: aws.ToString(batch[index].MessageId),
+				Message:   err.Error(),
+			})
+		}
+	}
+
+	return deleted, failed, err
+}
+
+func waitToRetry(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(deleteRetryDelay * time.Duration(attempt-1))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+package queue
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelete(t *testing.T) {
+	queueURL := "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a"
+	receive := func(t *testing.T, client *fakeClient, n int) []sqstypes.Message {
+		t.Helper()
+		var messages []sqstypes.Message
+		for len(messages) < n {
+			output, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{MaxNumberOfMessages: 10})
+			require.NoError(t, err)
+			messages = append(messages, output.Messages...)
+		}
+		return messages
+	}
+
+	t.Run("messages are deleted in batches", func(t *testing.T) {
+		client := newFakeClient(25)
+		messages := receive(t, client, 25)
+
+		result, err := Delete(context.Background(), client, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 25, result.Deleted)
+		assert.Empty(t, result.Failed)
+		assert.Empty(t, client.inFlight)
+		assert.Equal(t, 3, client.deleteRequests)
+		assert.Equal(t, "deleted 25 messages", result.Summary())
+	})
+
+	t.Run("messages that fail to be deleted are retried", func(t *testing.T) {
+		client := newFakeClient(5)
+		messages := receive(t, client, 5)
+		client.deleteFailures = 2
+
+		result, err := Delete(context.Background(), client, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.Deleted)
+		assert.Empty(t, result.Failed)
+		assert.Empty(t, client.inFlight)
+		assert.Equal(t, 2, client.deleteRequests)
+	})
+
+	t.Run("failures that are the sender's fault aren't retried", func(t *testing.T) {
+		client := newFakeClient(5)
+		messages := receive(t, client, 5)
+		messages[1].ReceiptHandle = aws.String("expired")
+
+		result, err := Delete(context.Background(), client, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Deleted)
+		require.Len(t, result.Failed, 1)
+		assert.Equal(t, DeleteFailure{
+			MessageID:   "id-001",
+			Code:        "ReceiptHandleIsInvalid",
+			SenderFault: true,
+		}, result.Failed[0])
+		assert.Equal(t, 1, client.deleteRequests)
+		assert.Equal(t, "couldn't delete 1 of 5 messages; they're still on the queue", result.Summary())
+	})
+
+	t.Run("mixed batches are counted correctly", func(t *testing.T) {
+		client := newFakeClient(6)
+		messages := receive(t, client, 6)
+		messages[0].ReceiptHandle = nil
+		messages[1].ReceiptHandle = aws.String("expired")
+		client.deleteFailures = 2
+
+		result, err := Delete(context.Background(), client, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 4, result.Deleted)
+		require.Len(t, result.Failed, 2)
+		assert.Equal(t, DeleteFailure{
+			MessageID:   "id-000",
+			Message:     "message has no receipt handle",
+			SenderFault: true,
+		}, result.Failed[0])
+		assert.Equal(t, DeleteFailure{
+			MessageID:   "id-001",
+			Code:        "ReceiptHandleIsInvalid",
+			SenderFault: true,
+		}, result.Failed[1])
+		assert.Equal(t, 2, client.deleteRequests)
+		assert.Len(t, client.inFlight, 2)
+		assert.Equal(t, "couldn't delete 2 of 6 messages; they're still on the queue", result.Summary())
+	})
+
+	t.Run("failures for unknown entries don't affect the count", func(t *testing.T) {
+		client := newFakeClient(5)
+		messages := receive(t, client, 5)
+
+		result, err := Delete(context.Background(), unknownFailureClient{client}, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, result.Deleted)
+		assert.Empty(t, result.Failed)
+	})
+
+	t.Run("messages still failing after retrying are reported", func(t *testing.T) {
+		client := newFakeClient(5)
+		messages := receive(t, client, 5)
+		client.failDelete = true
+
+		result, err := Delete(context.Background(), client, queueURL, messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Deleted)
+		require.Len(t, result.Failed, 5)
+		assert.Equal(t, "id-000: InternalError", result.Failed[0].String())
+		assert.Equal(t, deleteMaxAttempts, client.deleteRequests)
+		assert.Len(t, client.inFlight, 5)
+	})
+}
+
+// unknownFailureClient reports a failure for an entry that wasn't part of the
+// request, along with the actual outcome of deleting messages.
+type unknownFailureClient struct {
+	*fakeClient
+}
+
+func (c unknownFailureClient) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	output, err := c.fakeClient.DeleteMessageBatch(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{
+		Id:   aws.String("unknown"),
+		Code: aws.String("InvalidBatchEntryId"),
+	})
+
+	return output, nil
+}
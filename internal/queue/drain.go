@@ -0,0 +1,157 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	drainBatchSize             = 10
+	drainWaitTimeSecs          = 1
+	drainVisibilityTimeoutSecs = 60
+	// SQS can return no messages even if a queue isn't empty, so draining only
+	// stops after a few consecutive empty receives
+	drainMaxEmptyReceives = 3
+	// DefaultDrainTimeLimit is how long draining a queue goes on for, unless
+	// a different time limit is provided.
+	DefaultDrainTimeLimit = 30 * time.Minute
+)
+
+var (
+	errCouldntReceiveMessages = errors.New("couldn't receive messages")
+	errCouldntDeleteMessages  = errors.New("couldn't delete messages")
+	errCouldntGetMessageCount = errors.New("couldn't get the number of messages in the queue")
+	errDrainTimedOut          = errors.New("draining the queue didn't finish within the time limit")
+)
+
+type DrainOptions struct {
+	// TimeLimit is how long draining goes on for before giving up; it defaults
+	// to DefaultDrainTimeLimit.
+	TimeLimit time.Duration
+	// OnProgress, if provided, is called with the number of messages drained
+	// so far after every batch.
+	OnProgress func(drained int)
+}
+
+// Drain receives the messages in a queue, writes them to the archive, and
+// deletes them from the queue once they've been written. Since a queue with a
+// steady inflow never runs empty, only as many messages as the queue had when
+// draining started are drained; draining also stops with an error once the
+// time limit is reached.
+func Drain(ctx context.Context, client Client, queueURL string, archive ArchiveWriter, opts DrainOptions) (int, error) {
+	var drained int
+	var emptyReceives int
+
+	timeLimit := opts.TimeLimit
+	if timeLimit <= 0 {
+		timeLimit = DefaultDrainTimeLimit
+	}
+	deadline := time.Now().Add(timeLimit)
+
+	toDrain, err := approximateMessageCount(ctx, client, queueURL)
+	if err != nil {
+		return drained, err
+	}
+
+	for drained < toDrain && emptyReceives < drainMaxEmptyReceives {
+		if err := ctx.Err(); err != nil {
+			return drained, err
+		}
+		if time.Now().After(deadline) {
+			return drained, fmt.Errorf("%w (%s); %d of %d messages were drained", errDrainTimedOut, timeLimit, drained, toDrain)
+		}
+
+		result, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(queueURL),
+			MaxNumberOfMessages:         int32(min(drainBatchSize, toDrain-drained)),
+			WaitTimeSeconds:             drainWaitTimeSecs,
+			VisibilityTimeout:           drainVisibilityTimeoutSecs,
+			MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{sqstypes.MessageSystemAttributeNameAll},
+			MessageAttributeNames:       []string{"All"},
+		})
+		if err != nil {
+			return drained, fmt.Errorf("%w: %s", errCouldntReceiveMessages, err.Error())
+		}
+
+		if len(result.Messages) == 0 {
+			emptyReceives++
+			continue
+		}
+		emptyReceives = 0
+
+		now := time.Now()
+		for _, message := range result.Messages {
+			if err := archive.Write(NewArchivedMessage(message, now)); err != nil {
+				return drained, err
+			}
+		}
+
+		deleted, failed, err := deleteBatch(ctx, client, queueURL, result.Messages)
+		drained += deleted
+		if err != nil {
+			return drained, err
+		}
+		if len(failed) > 0 {
+			return drained, fmt.Errorf("%w: %d of %d messages in a batch couldn't be deleted (they're still archived)",
+				errCouldntDeleteMessages,
+				len(failed),
+				len(result.Messages),
+			)
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(drained)
+		}
+	}
+
+	return drained, nil
+}
+
+func approximateMessageCount(ctx context.Context, client Client, queueURL string) (int, error) {
+	output, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntGetMessageCount, err.Error())
+	}
+
+	count, err := strconv.Atoi(output.Attributes[string(sqstypes.QueueAttributeNameApproximateNumberOfMessages)])
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntGetMessageCount, err.Error())
+	}
+
+	return count, nil
+}
+
+// approximateDepth returns the approximate number of messages in a queue,
+// including the ones that are in flight.
+func approximateDepth(ctx context.Context, client Client, queueURL string) (int, error) {
+	visibleType := sqstypes.QueueAttributeNameApproximateNumberOfMessages
+	inFlightType := sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible
+	output, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{visibleType, inFlightType},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntGetMessageCount, err.Error())
+	}
+
+	var depth int
+	for _, attributeType := range []sqstypes.QueueAttributeName{visibleType, inFlightType} {
+		count, err := strconv.Atoi(output.Attributes[string(attributeType)])
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", errCouldntGetMessageCount, err.Error())
+		}
+		depth += count
+	}
+
+	return depth, nil
+}
@@ -0,0 +1,63 @@
+package queue
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	queueURL := "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a"
+	drain := func(t *testing.T, client *fakeClient, opts DrainOptions) (int, error) {
+		t.Helper()
+		archive, err := NewArchiveWriter(filepath.Join(t.TempDir(), "backup.jsonl"), ArchiveFormatJSONL)
+		require.NoError(t, err)
+		defer archive.Close()
+
+		return Drain(context.Background(), client, queueURL, archive, opts)
+	}
+
+	t.Run("only as many messages as the queue had at the start are drained", func(t *testing.T) {
+		client := newFakeClient(25)
+		client.inflow = 10
+
+		drained, err := drain(t, client, DrainOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 25, drained)
+		assert.Empty(t, client.inFlight)
+	})
+
+	t.Run("draining stops once the queue is empty", func(t *testing.T) {
+		client := newFakeClient(5)
+		approxCount := 8
+		client.approxCount = &approxCount
+
+		drained, err := drain(t, client, DrainOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, drained)
+	})
+
+	t.Run("draining stops at the time limit", func(t *testing.T) {
+		client := newFakeClient(25)
+		var progress []int
+
+		drained, err := drain(t, client, DrainOptions{
+			TimeLimit: 10 * time.Millisecond,
+			OnProgress: func(drained int) {
+				progress = append(progress, drained)
+				time.Sleep(20 * time.Millisecond)
+			},
+		})
+
+		require.ErrorIs(t, err, errDrainTimedOut)
+		assert.Equal(t, 10, drained)
+		assert.Equal(t, []int{10}, progress)
+		assert.Len(t, client.messages, 15)
+	})
+}
@@ -0,0 +1,183 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+// DefaultFetchTimeLimit is how long fetching messages matching a predicate
+// goes on for, unless specified otherwise.
+const DefaultFetchTimeLimit = 30 * time.Second
+
+const (
+	// fetchVisibilityTimeoutMarginSecs is added to the time limit to get the
+	// visibility timeout for received messages, so that they don't become
+	// visible again (and get received twice) while fetching is still going
+	// on.
+	fetchVisibilityTimeoutMarginSecs = 30
+	// maxVisibilityTimeoutSecs is the longest visibility timeout SQS allows.
+	maxVisibilityTimeoutSecs = 43200
+	// MaxFetchTimeLimit is the longest time limit for fetching messages that
+	// still leaves room for the visibility timeout's margin.
+	MaxFetchTimeLimit = (maxVisibilityTimeoutSecs - fetchVisibilityTimeoutMarginSecs) * time.Second
+)
+
+type FetchOptions struct {
+	// Predicate, if provided, decides which messages are kept; every message
+	// is kept otherwise.
+	Predicate *t.MessagePredicate
+	// MaxMatches is the number of matching messages after which fetching
+	// stops.
+	MaxMatches int
+	// TimeLimit is how long fetching goes on for, if there are fewer matching
+	// messages than MaxMatches; DefaultFetchTimeLimit is used if it's zero.
+	TimeLimit time.Duration
+	// ReleaseMatches makes matching messages visible again once fetching is
+	// done; otherwise, they stay in flight, so that they can be deleted.
+	ReleaseMatches bool
+	// OnProgress, if provided, is called after every batch of messages with
+	// the number of messages scanned, and the number of those that matched.
+	OnProgress func(scanned, matched int)
+	// AllowDeadLettering allows scanning queues with a redrive policy, where
+	// receiving messages that don't match can move them to the dead-letter
+	// queue.
+	AllowDeadLettering bool
+}
+
+type FetchResult struct {
+	// Messages are the messages that matched the predicate, in the order they
+	// were received in.
+	Messages []sqstypes.Message
+	Scanned  int
+	// TimedOut is true if fetching stopped because of the time limit.
+	TimedOut bool
+}
+
+// FetchMatching keeps receiving messages from a queue until MaxMatches of
+// them match the predicate, the time limit is reached, or the queue has no
+// more messages. Messages that don't match are kept in flight while fetching
+// (so that they're only received once), and are made visible again
+// afterwards. Since that still counts as receiving them, queues with a
+// redrive policy are only scanned if opts.AllowDeadLettering is set.
+func FetchMatching(ctx context.Context, client Client, config t.Config, opts FetchOptions) (FetchResult, error) {
+	var result FetchResult
+	if err := ensureScanAllowed(ctx, client, config.QueueURL, opts.AllowDeadLettering); err != nil {
+		return result, err
+	}
+
+	var emptyReceives int
+	var toRelease []string
+	seen := make(map[string]struct{})
+
+	timeLimit := opts.TimeLimit
+	if timeLimit <= 0 {
+		timeLimit = DefaultFetchTimeLimit
+	}
+	deadline := time.Now().Add(timeLimit)
+	visibilityTimeout := fetchVisibilityTimeout(timeLimit)
+
+	fetchErr := func() error {
+		for len(result.Messages) < opts.MaxMatches && emptyReceives < drainMaxEmptyReceives {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// the deadline isn't applied to the context, since received
+			// messages need to be accounted for to be released
+			if time.Now().After(deadline) {
+				result.TimedOut = true
+				return nil
+			}
+
+			output, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:                    aws.String(config.QueueURL),
+				MaxNumberOfMessages:         drainBatchSize,
+				WaitTimeSeconds:             drainWaitTimeSecs,
+				VisibilityTimeout:           visibilityTimeout,
+				MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{sqstypes.MessageSystemAttributeNameAll},
+				MessageAttributeNames:       []string{"All"},
+			})
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntReceiveMessages, err.Error())
+			}
+
+			if len(output.Messages) == 0 {
+				emptyReceives++
+				continue
+			}
+			emptyReceives = 0
+
+			for _, message := range output.Messages {
+				receiptHandle := aws.ToString(message.ReceiptHandle)
+
+				messageID := aws.ToString(message.MessageId)
+				if _, ok := seen[messageID]; ok {
+					toRelease = append(toRelease, receiptHandle)
+					continue
+				}
+				seen[messageID] = struct{}{}
+				result.Scanned++
+
+				matches := len(result.Messages) < opts.MaxMatches &&
+					(opts.Predicate == nil || opts.Predicate.Matches(t.GetMessageData(&message, config)))
+				if !matches || opts.ReleaseMatches {
+					toRelease = append(toRelease, receiptHandle)
+				}
+				if matches {
+					result.Messages = append(result.Messages, message)
+				}
+			}
+
+			if opts.OnProgress != nil {
+				opts.OnProgress(result.Scanned, len(result.Messages))
+			}
+		}
+
+		return nil
+	}()
+
+	// messages are released even if fetching was cancelled
+	releaseErr := release(context.WithoutCancel(ctx), client, config.QueueURL, toRelease)
+
+	if fetchErr != nil {
+		return result, fetchErr
+	}
+
+	return result, releaseErr
+}
+
+// DeleteFetched deletes messages kept in flight by FetchMatching from the
+// queue, and returns the number of messages deleted.
+func DeleteFetched(ctx context.Context, client Client, queueURL string, messages []sqstypes.Message) (int, error) {
+	result, err := Delete(ctx, client, queueURL, messages)
+	if err != nil {
+		return result.Deleted, err
+	}
+
+	if len(result.Failed) > 0 {
+		return result.Deleted, fmt.Errorf("%w: %d of %d messages couldn't be deleted (eg. %s)",
+			errCouldntDeleteMessages,
+			len(result.Failed),
+			len(messages),
+			result.Failed[0],
+		)
+	}
+
+	return result.Deleted, nil
+}
+
+// fetchVisibilityTimeout returns the visibility timeout for messages received
+// while fetching with a time limit; it's capped at the maximum SQS allows.
+func fetchVisibilityTimeout(timeLimit time.Duration) int32 {
+	if timeLimit >= MaxFetchTimeLimit {
+		return maxVisibilityTimeoutSecs
+	}
+
+	return int32(timeLimit/time.Second) + fetchVisibilityTimeoutMarginSecs
+}
@@ -0,0 +1,226 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchMatching(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile-a",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		Format:      types.JSON,
+	}
+
+	getPredicate := func(t *testing.T, value string) *types.MessagePredicate {
+		t.Helper()
+		predicate, err := types.ParseMessagePredicate(value)
+		require.NoError(t, err)
+		return &predicate
+	}
+
+	t.Run("matching messages stay in flight, and the rest are released", func(t *testing.T) {
+		client := newFakeClient(25)
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:  getPredicate(t, "$.seq >= 20"),
+			MaxMatches: 3,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, result.Messages, 3)
+		assert.Equal(t, "id-020", aws.ToString(result.Messages[0].MessageId))
+		assert.Equal(t, "id-022", aws.ToString(result.Messages[2].MessageId))
+		assert.Equal(t, 25, result.Scanned)
+		assert.False(t, result.TimedOut)
+		assert.Len(t, client.inFlight, 3)
+		assert.Len(t, client.messages, 22)
+		assert.Equal(t, 22, client.released)
+	})
+
+	t.Run("matching messages can be released as well", func(t *testing.T) {
+		client := newFakeClient(25)
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:      getPredicate(t, "$.seq < 2"),
+			MaxMatches:     10,
+			ReleaseMatches: true,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Messages, 2)
+		assert.Empty(t, client.inFlight)
+		assert.Len(t, client.messages, 25)
+	})
+
+	t.Run("fetching stops once the queue has no more messages", func(t *testing.T) {
+		client := newFakeClient(25)
+		var progress []int
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:  getPredicate(t, "$.seq > 100"),
+			MaxMatches: 1,
+			OnProgress: func(scanned, _ int) { progress = append(progress, scanned) },
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, result.Messages)
+		assert.Equal(t, 25, result.Scanned)
+		assert.False(t, result.TimedOut)
+		assert.Equal(t, []int{10, 20, 25}, progress)
+		assert.Len(t, client.messages, 25)
+	})
+
+	t.Run("every message matches without a predicate", func(t *testing.T) {
+		client := newFakeClient(25)
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			MaxMatches: 5,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Messages, 5)
+		assert.Equal(t, 10, result.Scanned)
+		assert.Len(t, client.inFlight, 5)
+	})
+
+	t.Run("fetching stops once the time limit is reached", func(t *testing.T) {
+		client := newFakeClient(25)
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:  getPredicate(t, "$.seq >= 0"),
+			MaxMatches: 1,
+			TimeLimit:  time.Nanosecond,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, result.TimedOut)
+		assert.Empty(t, result.Messages)
+		assert.Len(t, client.messages, 25)
+	})
+
+	t.Run("queues with a redrive policy aren't scanned", func(t *testing.T) {
+		client := newFakeClient(25)
+		client.redrivePolicy = `{"deadLetterTargetArn":"arn:aws:sqs:eu-central-1:000000000000:queue-a-dlq","maxReceiveCount":3}`
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:  getPredicate(t, "$.seq >= 20"),
+			MaxMatches: 1,
+		})
+
+		require.ErrorIs(t, err, ErrScanCouldDeadLetter)
+		assert.Contains(t, err.Error(), "maxReceiveCount: 3")
+		assert.Zero(t, result.Scanned)
+		assert.Len(t, client.messages, 25)
+	})
+
+	t.Run("queues with a redrive policy can be scanned if allowed", func(t *testing.T) {
+		client := newFakeClient(25)
+		client.redrivePolicy = `{"deadLetterTargetArn":"arn:aws:sqs:eu-central-1:000000000000:queue-a-dlq","maxReceiveCount":"3"}`
+
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{
+			Predicate:          getPredicate(t, "$.seq >= 20"),
+			MaxMatches:         1,
+			AllowDeadLettering: true,
+		})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Messages, 1)
+	})
+}
+
+func TestMaxReceiveCount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   string
+		expected int
+		ok       bool
+		err      bool
+	}{
+		{name: "no redrive policy", policy: ""},
+		{name: "numeric max receive count", policy: `{"deadLetterTargetArn":"arn","maxReceiveCount":5}`, expected: 5, ok: true},
+		{name: "string max receive count", policy: `{"deadLetterTargetArn":"arn","maxReceiveCount":"10"}`, expected: 10, ok: true},
+		{name: "incorrect max receive count", policy: `{"deadLetterTargetArn":"arn","maxReceiveCount":"ten"}`, err: true},
+		{name: "incorrect policy", policy: `{"deadLetterTargetArn"`, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newFakeClient(0)
+			client.redrivePolicy = tc.policy
+
+			got, ok, err := MaxReceiveCount(context.Background(), client, "queue-url")
+
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestFetchVisibilityTimeout(t *testing.T) {
+	testCases := []struct {
+		name      string
+		timeLimit time.Duration
+		expected  int32
+	}{
+		{name: "margin is added to the time limit", timeLimit: 30 * time.Second, expected: 60},
+		{name: "maximum time limit", timeLimit: MaxFetchTimeLimit, expected: 43200},
+		{name: "just under the maximum time limit", timeLimit: MaxFetchTimeLimit - time.Second, expected: 43199},
+		{name: "time limit over the maximum is capped", timeLimit: MaxFetchTimeLimit + time.Second, expected: 43200},
+		{name: "time limit that overflows is capped", timeLimit: time.Duration(math.MaxInt64), expected: 43200},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fetchVisibilityTimeout(tc.timeLimit))
+		})
+	}
+}
+
+func TestDeleteFetched(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile-a",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		Format:      types.JSON,
+	}
+
+	t.Run("messages are deleted in batches", func(t *testing.T) {
+		client := newFakeClient(25)
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{MaxMatches: 15})
+		require.NoError(t, err)
+		require.Len(t, result.Messages, 15)
+
+		deleted, err := DeleteFetched(context.Background(), client, config.QueueURL, result.Messages)
+
+		require.NoError(t, err)
+		assert.Equal(t, 15, deleted)
+		assert.Empty(t, client.inFlight)
+		assert.Len(t, client.messages, 10)
+	})
+
+	t.Run("failing to delete messages is reported", func(t *testing.T) {
+		client := newFakeClient(5)
+		result, err := FetchMatching(context.Background(), client, config, FetchOptions{MaxMatches: 5})
+		require.NoError(t, err)
+		client.failDelete = true
+
+		deleted, err := DeleteFetched(context.Background(), client, config.QueueURL, result.Messages)
+
+		assert.True(t, errors.Is(err, errCouldntDeleteMessages))
+		assert.Equal(t, 0, deleted)
+		assert.Len(t, client.inFlight, 5)
+	})
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+var (
+	errCouldntLoadMessages = errors.New("couldn't load messages")
+	errGlobForArchive      = errors.New("a glob pattern can only be used for a directory of persisted messages")
+)
+
+// LoadMessages loads messages from either a directory of persisted messages,
+// or an archive created via backup or purge, so that they can be viewed
+// without access to the queue they came from. For archives, the filter's time
+// bounds are applied to the time messages were archived at.
+func LoadMessages(path string, filter PersistedMessageFilter) ([]sqstypes.Message, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntLoadMessages, err.Error())
+	}
+
+	if info.IsDir() {
+		return loadPersistedMessages(path, filter)
+	}
+
+	if filter.Glob != "" {
+		return nil, errGlobForArchive
+	}
+
+	return loadArchivedMessages(path, filter)
+}
+
+func loadPersistedMessages(dir string, filter PersistedMessageFilter) ([]sqstypes.Message, error) {
+	persisted, err := ListPersistedMessages(dir, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]sqstypes.Message, len(persisted))
+	for i, message := range persisted {
+		body, err := ReadPersistedMessage(message.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		messages[i] = sqstypes.Message{
+			MessageId: aws.String(message.MessageID),
+			Body:      aws.String(body),
+		}
+	}
+
+	return messages, nil
+}
+
+func loadArchivedMessages(path string, filter PersistedMessageFilter) ([]sqstypes.Message, error) {
+	archive, err := OpenArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	var messages []sqstypes.Message
+	for {
+		message, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !filter.Since.IsZero() && message.ArchivedAt.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && message.ArchivedAt.After(filter.Until) {
+			continue
+		}
+
+		messages = append(messages, sqstypes.Message{
+			MessageId:         aws.String(message.MessageID),
+			Body:              aws.String(message.Body),
+			Attributes:        message.Attributes,
+			MessageAttributes: message.SQSMessageAttributes(),
+		})
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMessages(t *testing.T) {
+	t.Run("persisted messages are loaded from a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1700000200-id-b.txt"), []byte("seq 2"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1700000100-id-a.json"), []byte(`{"seq": 1}`), 0o644))
+
+		got, err := LoadMessages(dir, PersistedMessageFilter{})
+
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, "id-a", aws.ToString(got[0].MessageId))
+		assert.Equal(t, `{"seq": 1}`, aws.ToString(got[0].Body))
+		assert.Equal(t, "id-b", aws.ToString(got[1].MessageId))
+	})
+
+	t.Run("archived messages are loaded with their attributes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.tar.gz")
+		writer, err := NewArchiveWriter(path, ArchiveFormatTarGz)
+		require.NoError(t, err)
+		for i, archivedAt := range []time.Time{time.Unix(1700000100, 0), time.Unix(1700000200, 0)} {
+			require.NoError(t, writer.Write(ArchivedMessage{
+				MessageID:  []string{"id-a", "id-b"}[i],
+				Body:       `{"seq": 1}`,
+				Attributes: map[string]string{"MessageGroupId": "group-a"},
+				MessageAttributes: map[string]MessageAttribute{
+					"source": {DataType: "String", StringValue: aws.String("cueitup")},
+				},
+				ArchivedAt: archivedAt,
+			}))
+		}
+		require.NoError(t, writer.Close())
+
+		got, err := LoadMessages(path, PersistedMessageFilter{Since: time.Unix(1700000150, 0)})
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "id-b", aws.ToString(got[0].MessageId))
+		assert.Equal(t, "group-a", got[0].Attributes["MessageGroupId"])
+		assert.Equal(t, "cueitup", aws.ToString(got[0].MessageAttributes["source"].StringValue))
+	})
+
+	t.Run("glob patterns are rejected for archives", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.jsonl")
+		writer, err := NewArchiveWriter(path, ArchiveFormatJSONL)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		_, err = LoadMessages(path, PersistedMessageFilter{Glob: "*.json"})
+
+		assert.ErrorIs(t, err, errGlobForArchive)
+	})
+
+	t.Run("missing paths are reported", func(t *testing.T) {
+		_, err := LoadMessages(filepath.Join(t.TempDir(), "missing"), PersistedMessageFilter{})
+
+		assert.ErrorIs(t, err, errCouldntLoadMessages)
+	})
+}
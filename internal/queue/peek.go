@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// DefaultPeekVisibilityTimeoutSecs is how long messages stay invisible to
+// other consumers while a queue is being peeked at, unless specified
+// otherwise.
+const DefaultPeekVisibilityTimeoutSecs = 300
+
+var errCouldntReleaseMessages = errors.New("couldn't make messages visible again")
+
+// Peek receives all messages in a queue and writes them to the archive,
+// without deleting them. Received messages stay invisible until all of the
+// queue has been read, and are then made visible again; if that fails, they
+// become visible once the visibility timeout expires. Messages that are
+// received again (since the visibility timeout expired while peeking) are
+// only archived once.
+//
+// Peeking isn't read-only: every receive counts towards a message's
+// maxReceiveCount, and messages are hidden from other consumers while the
+// queue is being read.
+func Peek(ctx context.Context, client Client, queueURL string, archive ArchiveWriter, visibilityTimeout int32, onProgress func(peeked int)) (int, error) {
+	var peeked int
+	var emptyReceives int
+	// receiptHandles holds the latest receipt handle of every message
+	// received, since the ones from earlier receives can't be used anymore
+	receiptHandles := make(map[string]string)
+
+	peekErr := func() error {
+		for emptyReceives < drainMaxEmptyReceives {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			result, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:                    aws.String(queueURL),
+				MaxNumberOfMessages:         drainBatchSize,
+				WaitTimeSeconds:             drainWaitTimeSecs,
+				VisibilityTimeout:           visibilityTimeout,
+				MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{sqstypes.MessageSystemAttributeNameAll},
+				MessageAttributeNames:       []string{"All"},
+			})
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntReceiveMessages, err.Error())
+			}
+
+			if len(result.Messages) == 0 {
+				emptyReceives++
+				continue
+			}
+			emptyReceives = 0
+
+			now := time.Now()
+			for _, message := range result.Messages {
+				// messages can be received again if the visibility timeout
+				// expires before the queue has been read completely
+				messageID := aws.ToString(message.MessageId)
+				_, seen := receiptHandles[messageID]
+				receiptHandles[messageID] = aws.ToString(message.ReceiptHandle)
+				if seen {
+					continue
+				}
+
+				if err := archive.Write(NewArchivedMessage(message, now)); err != nil {
+					return err
+				}
+				peeked++
+			}
+
+			if onProgress != nil {
+				onProgress(peeked)
+			}
+		}
+
+		return nil
+	}()
+
+	// messages are released even if peeking was cancelled
+	releaseErr := release(context.WithoutCancel(ctx), client, queueURL, slices.Collect(maps.Values(receiptHandles)))
+
+	if peekErr != nil {
+		return peeked, peekErr
+	}
+
+	return peeked, releaseErr
+}
+
+// Release makes messages that were received earlier visible on the queue
+// again, rather than waiting for their visibility timeout to expire.
+func Release(ctx context.Context, client Client, queueURL string, messages []sqstypes.Message) error {
+	receiptHandles := make([]string, len(messages))
+	for i, message := range messages {
+		receiptHandles[i] = aws.ToString(message.ReceiptHandle)
+	}
+
+	return release(ctx, client, queueURL, receiptHandles)
+}
+
+func release(ctx context.Context, client Client, queueURL string, receiptHandles []string) error {
+	var failed int
+	for start := 0; start < len(receiptHandles); start += drainBatchSize {
+		end := min(start+drainBatchSize, len(receiptHandles))
+		entries := make([]sqstypes.ChangeMessageVisibilityBatchRequestEntry, end-start)
+		for i, receiptHandle := range receiptHandles[start:end] {
+			entries[i] = sqstypes.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				ReceiptHandle:     aws.String(receiptHandle),
+				VisibilityTimeout: 0,
+			}
+		}
+
+		output, err := client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: aws.String(queueURL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %s", errCouldntReleaseMessages, err.Error())
+		}
+		failed += len(output.Failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d messages will only become visible once their visibility timeout expires", errCouldntReleaseMessages, failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+// maxPersistCollisions is how many suffixed names are tried for a message
+// before giving up, when its rendered path already exists.
+const maxPersistCollisions = 100
+
+var (
+	// ErrNotDeletedUnpersisted is reported for messages that are left on the
+	// queue because they couldn't be persisted, when they'd otherwise have
+	// been deleted.
+	ErrNotDeletedUnpersisted = errors.New("not deleted, since it couldn't be persisted")
+	errCouldntPersistMessage = errors.New("couldn't persist message")
+	errTooManyCollisions     = errors.New("too many files with the same name")
+)
+
+// PersistMessage writes a message's body to dir, at the path (relative to
+// dir, without an extension) rendered from a persist filename template.
+// Existing files are never overwritten; if the path is taken, a numeric suffix
+// ("-2", "-3", ...) is added before the extension. It returns the path the
+// message was written to.
+func PersistMessage(dir, relPath, extension, body string) (string, error) {
+	base := filepath.Join(dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntPersistMessage, err.Error())
+	}
+
+	for i := 1; i <= maxPersistCollisions; i++ {
+		path := fmt.Sprintf("%s.%s", base, extension)
+		if i > 1 {
+			path = fmt.Sprintf("%s-%d.%s", base, i, extension)
+		}
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", errCouldntPersistMessage, err.Error())
+		}
+
+		// the message is synced to disk before returning, since callers
+		// delete it from the queue once it's persisted
+		_, err = file.WriteString(body)
+		if err == nil {
+			err = file.Sync()
+		}
+		closeErr := file.Close()
+		if err = errors.Join(err, closeErr); err != nil {
+			return "", fmt.Errorf("%w: %s", errCouldntPersistMessage, err.Error())
+		}
+
+		return path, nil
+	}
+
+	return "", fmt.Errorf("%w: %w: %s", errCouldntPersistMessage, errTooManyCollisions, base)
+}
+
+// PersistableMessage is a message received from a queue, along with its
+// parsed form, as handed to a Persister.
+type PersistableMessage struct {
+	Message    t.Message
+	SQSMessage sqstypes.Message
+	ReceivedAt time.Time
+}
+
+// Persister stores messages received from a queue, as per a profile's persist
+// settings. Persist returns where the message was stored, and is safe to call
+// concurrently.
+type Persister interface {
+	Persist(message PersistableMessage) (string, error)
+	Close() error
+}
+
+// NewPersister returns the Persister for a profile's persist store; capture
+// stores are created (or appended to) within the profile's persist directory.
+func NewPersister(config t.Config) (Persister, error) {
+	dir := config.PersistDirectory()
+	queueName := utils.QueueNameFromURL(config.QueueURL)
+
+	switch config.PersistStore {
+	case t.PersistStoreJSONL:
+		return newJSONLCaptureStore(filepath.Join(dir, CaptureFileJSONL), config.ProfileName, queueName)
+	case t.PersistStoreSQLite:
+		return newSQLiteCaptureStore(filepath.Join(dir, CaptureFileSQLite), config.ProfileName, queueName)
+	default:
+		return &filePersister{
+			dir:       dir,
+			template:  config.PersistFilename,
+			extension: config.Format.Extension(),
+			queueName: queueName,
+		}, nil
+	}
+}
+
+// filePersister writes each message to a file of its own, named as per the
+// profile's persist filename template.
+type filePersister struct {
+	dir       string
+	template  t.PersistFilenameTemplate
+	extension string
+	queueName string
+}
+
+// Persist writes the message's raw body, regardless of the profile's subset
+// key, so that persisted messages can be replayed as they were received.
+func (p *filePersister) Persist(message PersistableMessage) (string, error) {
+	values := t.NewPersistFilenameValues(message.Message, message.SQSMessage.Attributes, p.queueName, message.ReceivedAt)
+	return PersistMessage(p.dir, p.template.Render(values), p.extension, message.Message.ViewBody(t.BodyRaw))
+}
+
+func (p *filePersister) Close() error {
+	return nil
+}
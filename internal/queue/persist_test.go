@@ -0,0 +1,74 @@
+package queue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistMessage(t *testing.T) {
+	t.Run("message is written to a nested path", func(t *testing.T) {
+		dir := t.TempDir()
+
+		got, err := PersistMessage(dir, filepath.Join("2023-11-14", "1700000000-id-a"), "json", `{"seq": 1}`)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "2023-11-14", "1700000000-id-a.json"), got)
+		contents, err := os.ReadFile(got)
+		require.NoError(t, err)
+		assert.Equal(t, `{"seq": 1}`, string(contents))
+	})
+
+	t.Run("existing files get a numeric suffix instead of being overwritten", func(t *testing.T) {
+		dir := t.TempDir()
+
+		first, err := PersistMessage(dir, "agg-1", "txt", "first")
+		require.NoError(t, err)
+		second, err := PersistMessage(dir, "agg-1", "txt", "second")
+		require.NoError(t, err)
+		third, err := PersistMessage(dir, "agg-1", "txt", "third")
+		require.NoError(t, err)
+
+		assert.Equal(t, filepath.Join(dir, "agg-1.txt"), first)
+		assert.Equal(t, filepath.Join(dir, "agg-1-2.txt"), second)
+		assert.Equal(t, filepath.Join(dir, "agg-1-3.txt"), third)
+		contents, err := os.ReadFile(first)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(contents))
+	})
+}
+
+func TestFilePersister(t *testing.T) {
+	t.Run("raw bodies are persisted regardless of the subset key", func(t *testing.T) {
+		subsetKey := "Message"
+		config := types.Config{
+			ProfileName: "profile",
+			QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+			Format:      types.JSON,
+			SubsetKey:   &subsetKey,
+			PersistDir:  t.TempDir(),
+		}
+		rawBody := `{"Message": {"seq": 1}, "Type": "Notification"}`
+		sqsMessage := sqstypes.Message{MessageId: aws.String("id-a"), Body: aws.String(rawBody)}
+		persister, err := NewPersister(config)
+		require.NoError(t, err)
+
+		path, err := persister.Persist(PersistableMessage{
+			Message:    types.GetMessageData(&sqsMessage, config),
+			SQSMessage: sqsMessage,
+			ReceivedAt: time.Unix(1700000000, 0),
+		})
+
+		require.NoError(t, err)
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, rawBody, string(contents))
+	})
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+var (
+	ErrPurgeNotConfirmed  = errors.New("purge not confirmed; the queue name needs to be typed in to confirm")
+	ErrPurgeInProgress    = errors.New("a purge is already in progress for this queue; SQS only allows one purge every 60 seconds, try again in a minute")
+	ErrPurgeIncomplete    = errors.New("queue wasn't purged completely")
+	errCouldntPurgeQueue  = errors.New("couldn't purge queue")
+	errCouldntBackUpQueue = errors.New("couldn't back up queue before purging it")
+)
+
+type PurgeOptions struct {
+	// Confirmation needs to be the queue's name.
+	Confirmation string
+	// BackupPath, if provided, is where all messages in the queue are archived
+	// to before it's purged; the archive's format is determined from its
+	// extension.
+	BackupPath string
+	// TimeLimit is how long backing up goes on for before giving up; it
+	// defaults to DefaultDrainTimeLimit.
+	TimeLimit time.Duration
+	// OnProgress, if provided, is called while messages are being backed up.
+	OnProgress func(backedUp int)
+}
+
+type PurgeResult struct {
+	BackupPath string
+	BackedUp   int
+	// Remaining is the approximate number of messages left on the queue
+	// after backing up (and deleting) messages; it's only set when backing
+	// up.
+	Remaining int
+}
+
+// Purge deletes all messages in a queue, after optionally backing them up to a
+// local archive. It refuses to purge read-only profiles, and queues whose name
+// doesn't match the confirmation.
+//
+// When backing up, messages are deleted one batch at a time once they're
+// archived, and the queue itself is never purged: that would also delete
+// messages that arrived (or were in flight) while the backup was running,
+// without them being backed up. Such messages are left on the queue, and
+// reported via ErrPurgeIncomplete.
+func Purge(ctx context.Context, client Client, config t.Config, opts PurgeOptions) (PurgeResult, error) {
+	var result PurgeResult
+
+	if err := config.EnsureWritable(); err != nil {
+		return result, err
+	}
+
+	if opts.Confirmation != utils.QueueNameFromURL(config.QueueURL) {
+		return result, ErrPurgeNotConfirmed
+	}
+
+	if opts.BackupPath != "" {
+		format, err := ArchiveFormatFromPath(opts.BackupPath)
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", errCouldntBackUpQueue, err)
+		}
+
+		archive, err := NewArchiveWriter(opts.BackupPath, format)
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", errCouldntBackUpQueue, err)
+		}
+
+		result.BackupPath = opts.BackupPath
+		result.BackedUp, err = Drain(ctx, client, config.QueueURL, archive, DrainOptions{
+			TimeLimit:  opts.TimeLimit,
+			OnProgress: opts.OnProgress,
+		})
+		closeErr := archive.Close()
+		// messages that weren't backed up within the time limit are left on
+		// the queue, and reported as such below
+		timedOut := errors.Is(err, errDrainTimedOut)
+		if err != nil && !timedOut {
+			return result, fmt.Errorf("%w: %w", errCouldntBackUpQueue, err)
+		}
+		if closeErr != nil {
+			return result, fmt.Errorf("%w: %w", errCouldntBackUpQueue, closeErr)
+		}
+
+		result.Remaining, err = approximateDepth(ctx, client, config.QueueURL)
+		if err != nil {
+			return result, fmt.Errorf("%w: %w", ErrPurgeIncomplete, err)
+		}
+		if timedOut && result.Remaining > 0 {
+			return result, fmt.Errorf("%w: backing up didn't finish within the time limit; %d messages were left on the queue",
+				ErrPurgeIncomplete,
+				result.Remaining,
+			)
+		}
+		if result.Remaining > 0 {
+			return result, fmt.Errorf("%w: %d messages that weren't backed up (since they arrived or were in flight during the backup) were left on the queue",
+				ErrPurgeIncomplete,
+				result.Remaining,
+			)
+		}
+
+		return result, nil
+	}
+
+	_, err := client.PurgeQueue(ctx, &sqs.PurgeQueueInput{
+		QueueUrl: aws.String(config.QueueURL),
+	})
+	if err != nil {
+		var inProgress *sqstypes.PurgeQueueInProgress
+		if errors.As(err, &inProgress) {
+			return result, ErrPurgeInProgress
+		}
+		return result, fmt.Errorf("%w: %s", errCouldntPurgeQueue, err.Error())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurge(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile-a",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+	}
+
+	t.Run("queue name needs to be confirmed", func(t *testing.T) {
+		client := newFakeClient(5)
+
+		_, err := Purge(context.Background(), client, config, PurgeOptions{Confirmation: "queue-b"})
+
+		require.ErrorIs(t, err, ErrPurgeNotConfirmed)
+		assert.False(t, client.purged)
+	})
+
+	t.Run("read-only profiles can't be purged", func(t *testing.T) {
+		client := newFakeClient(5)
+		readOnlyConfig := config
+		readOnlyConfig.ReadOnly = true
+
+		_, err := Purge(context.Background(), client, readOnlyConfig, PurgeOptions{Confirmation: "queue-a"})
+
+		require.ErrorIs(t, err, types.ErrProfileIsReadOnly)
+		assert.False(t, client.purged)
+	})
+
+	t.Run("backed up messages are deleted without purging the queue", func(t *testing.T) {
+		client := newFakeClient(25)
+		backupPath := filepath.Join(t.TempDir(), "backups", "queue-a.jsonl")
+		var progress []int
+
+		result, err := Purge(context.Background(), client, config, PurgeOptions{
+			Confirmation: "queue-a",
+			BackupPath:   backupPath,
+			OnProgress:   func(n int) { progress = append(progress, n) },
+		})
+
+		require.NoError(t, err)
+		assert.False(t, client.purged)
+		assert.Empty(t, client.messages)
+		assert.Empty(t, client.inFlight)
+		assert.Equal(t, 25, result.BackedUp)
+		assert.Equal(t, []int{10, 20, 25}, progress)
+
+		file, err := os.Open(backupPath)
+		require.NoError(t, err)
+		defer file.Close()
+
+		var archived []ArchivedMessage
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var message ArchivedMessage
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &message))
+			archived = append(archived, message)
+		}
+		require.Len(t, archived, 25)
+		assert.Equal(t, "id-000", archived[0].MessageID)
+		assert.Equal(t, `{"seq": 0}`, archived[0].Body)
+		assert.Equal(t, "1700000000000", archived[0].Attributes["SentTimestamp"])
+	})
+
+	t.Run("messages that arrive while backing up are left on the queue", func(t *testing.T) {
+		client := newFakeClient(25)
+		client.inflow = 1
+
+		result, err := Purge(context.Background(), client, config, PurgeOptions{
+			Confirmation: "queue-a",
+			BackupPath:   filepath.Join(t.TempDir(), "queue-a.jsonl"),
+		})
+
+		require.ErrorIs(t, err, ErrPurgeIncomplete)
+		assert.False(t, client.purged)
+		assert.Equal(t, 25, result.BackedUp)
+		assert.Equal(t, 3, result.Remaining)
+		assert.Len(t, client.messages, 3)
+	})
+
+	t.Run("messages that aren't backed up within the time limit are left on the queue", func(t *testing.T) {
+		client := newFakeClient(25)
+
+		result, err := Purge(context.Background(), client, config, PurgeOptions{
+			Confirmation: "queue-a",
+			BackupPath:   filepath.Join(t.TempDir(), "queue-a.jsonl"),
+			TimeLimit:    time.Nanosecond,
+		})
+
+		require.ErrorIs(t, err, ErrPurgeIncomplete)
+		assert.False(t, client.purged)
+		assert.Zero(t, result.BackedUp)
+		assert.Equal(t, 25, result.Remaining)
+	})
+
+	t.Run("queues are purged right away without a backup", func(t *testing.T) {
+		client := newFakeClient(5)
+
+		result, err := Purge(context.Background(), client, config, PurgeOptions{Confirmation: "queue-a"})
+
+		require.NoError(t, err)
+		assert.True(t, client.purged)
+		assert.Zero(t, result.BackedUp)
+	})
+
+	t.Run("purge isn't attempted if backing up fails", func(t *testing.T) {
+		client := newFakeClient(5)
+		client.failDelete = true
+
+		_, err := Purge(context.Background(), client, config, PurgeOptions{
+			Confirmation: "queue-a",
+			BackupPath:   filepath.Join(t.TempDir(), "queue-a.jsonl"),
+		})
+
+		require.ErrorIs(t, err, errCouldntBackUpQueue)
+		assert.False(t, client.purged)
+	})
+
+	t.Run("purges in progress are surfaced clearly", func(t *testing.T) {
+		client := newFakeClient(5)
+		client.purgeErr = &sqstypes.PurgeQueueInProgress{}
+
+		_, err := Purge(context.Background(), client, config, PurgeOptions{Confirmation: "queue-a"})
+
+		require.ErrorIs(t, err, ErrPurgeInProgress)
+	})
+}
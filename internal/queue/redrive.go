@@ -0,0 +1,78 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+var (
+	// ErrScanCouldDeadLetter is returned when scanning a queue that has a
+	// redrive policy isn't explicitly allowed; every receive counts towards a
+	// message's maxReceiveCount, so scanning can move messages that were
+	// never consumed to the dead-letter queue.
+	ErrScanCouldDeadLetter       = errors.New("queue has a redrive policy; scanning it counts towards every message's maxReceiveCount, and can move messages to its dead-letter queue")
+	errCouldntGetRedrivePolicy   = errors.New("couldn't get the queue's redrive policy")
+	errCouldntParseRedrivePolicy = errors.New("couldn't parse the queue's redrive policy")
+)
+
+type redrivePolicy struct {
+	// SQS returns maxReceiveCount either as a number or as a string,
+	// depending on how the policy was set
+	MaxReceiveCount json.RawMessage `json:"maxReceiveCount"`
+}
+
+// MaxReceiveCount returns the number of times a message can be received from
+// a queue before SQS moves it to the queue's dead-letter queue; false is
+// returned if the queue doesn't have a redrive policy.
+func MaxReceiveCount(ctx context.Context, client Client, queueURL string) (int, bool, error) {
+	output, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(queueURL),
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameRedrivePolicy},
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %s", errCouldntGetRedrivePolicy, err.Error())
+	}
+
+	policyStr := output.Attributes[string(sqstypes.QueueAttributeNameRedrivePolicy)]
+	if policyStr == "" {
+		return 0, false, nil
+	}
+
+	var policy redrivePolicy
+	if err := json.Unmarshal([]byte(policyStr), &policy); err != nil {
+		return 0, false, fmt.Errorf("%w: %s", errCouldntParseRedrivePolicy, err.Error())
+	}
+
+	count, err := strconv.Atoi(strings.Trim(string(policy.MaxReceiveCount), `"`))
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: incorrect maxReceiveCount: %s", errCouldntParseRedrivePolicy, policy.MaxReceiveCount)
+	}
+
+	return count, true, nil
+}
+
+// ensureScanAllowed returns ErrScanCouldDeadLetter if the queue has a redrive
+// policy, unless scanning it is explicitly allowed.
+func ensureScanAllowed(ctx context.Context, client Client, queueURL string, allowDeadLettering bool) error {
+	if allowDeadLettering {
+		return nil
+	}
+
+	maxReceiveCount, ok, err := MaxReceiveCount(ctx, client, queueURL)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return fmt.Errorf("%w (maxReceiveCount: %d)", ErrScanCouldDeadLetter, maxReceiveCount)
+	}
+
+	return nil
+}
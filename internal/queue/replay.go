@@ -0,0 +1,231 @@
+package queue
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+// DefaultReplayMessageGroupID is used when replaying messages to a FIFO queue.
+const DefaultReplayMessageGroupID = "cueitup-replay"
+
+var (
+	errCouldntListPersistedMessages = errors.New("couldn't list persisted messages")
+	errCouldntReadPersistedMessage  = errors.New("couldn't read persisted message")
+	errInvalidGlob                  = errors.New("invalid glob pattern")
+)
+
+// PersistedMessage is a message file written by the TUI's persist mode, named
+// "<unix-timestamp>-<message-id>.<extension>" by default.
+type PersistedMessage struct {
+	Path        string
+	MessageID   string
+	PersistedAt time.Time
+}
+
+type PersistedMessageFilter struct {
+	// Glob is matched against file names.
+	Glob  string
+	Since time.Time
+	Until time.Time
+}
+
+func (f PersistedMessageFilter) matches(message PersistedMessage) (bool, error) {
+	if f.Glob != "" {
+		matched, err := filepath.Match(f.Glob, filepath.Base(message.Path))
+		if err != nil {
+			return false, fmt.Errorf("%w: %q", errInvalidGlob, f.Glob)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if !f.Since.IsZero() && message.PersistedAt.Before(f.Since) {
+		return false, nil
+	}
+
+	if !f.Until.IsZero() && message.PersistedAt.After(f.Until) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// NewPersistedMessage gets a persisted message's details from its file name;
+// files not named the way the TUI names them fall back to their modification
+// time.
+func NewPersistedMessage(path string, modTime time.Time) PersistedMessage {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	if timestamp, messageID, ok := strings.Cut(name, "-"); ok && messageID != "" {
+		if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			return PersistedMessage{
+				Path:        path,
+				MessageID:   messageID,
+				PersistedAt: time.Unix(seconds, 0),
+			}
+		}
+	}
+
+	return PersistedMessage{
+		Path:        path,
+		MessageID:   name,
+		PersistedAt: modTime,
+	}
+}
+
+// ListPersistedMessages returns the persisted messages in a directory (and
+// its subdirectories, for filename templates that nest messages) that match
+// the filter, oldest first.
+func ListPersistedMessages(dir string, filter PersistedMessageFilter) ([]PersistedMessage, error) {
+	var messages []PersistedMessage
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// capture stores are read via LoadMessages, like archives
+		if !entry.Type().IsRegular() || entry.Name() == CaptureFileJSONL || entry.Name() == CaptureFileSQLite {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		message := NewPersistedMessage(path, info.ModTime())
+		matches, err := filter.matches(message)
+		if err != nil {
+			return err
+		}
+		if matches {
+			messages = append(messages, message)
+		}
+
+		return nil
+	})
+	if errors.Is(err, errInvalidGlob) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntListPersistedMessages, err.Error())
+	}
+
+	slices.SortStableFunc(messages, func(a, b PersistedMessage) int {
+		if c := a.PersistedAt.Compare(b.PersistedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return messages, nil
+}
+
+func ReadPersistedMessage(path string) (string, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntReadPersistedMessage, err.Error())
+	}
+
+	return string(bytes), nil
+}
+
+// ReplayMessage is a message to be sent to a queue again.
+type ReplayMessage struct {
+	MessageID string
+	// Path is the file the message was read from, if any.
+	Path string
+	Body string
+	// MessageAttributes and MessageGroupID are the ones the message was
+	// originally received with, if known (persisted files don't hold them).
+	MessageAttributes map[string]sqstypes.MessageAttributeValue
+	MessageGroupID    string
+}
+
+type ReplayOptions struct {
+	// MessageGroupID is used when replaying to a FIFO queue, for messages that
+	// don't have one of their own.
+	MessageGroupID string
+	// OnProgress, if provided, is called after every batch of messages with
+	// the number of messages sent so far.
+	OnProgress func(sent int)
+}
+
+// Replay sends messages to a queue, in order. When replaying to a FIFO queue,
+// deduplication IDs are unique to each replay, so that replaying the same
+// messages again isn't deduplicated by SQS; they're hashed, since file names
+// can contain characters SQS doesn't allow in them.
+func Replay(ctx context.Context, client Client, config t.Config, messages []ReplayMessage, opts ReplayOptions) (int, error) {
+	if err := config.EnsureWritable(); err != nil {
+		return 0, err
+	}
+
+	fifo := strings.HasSuffix(config.QueueURL, fifoQueueSuffix)
+	groupID := opts.MessageGroupID
+	if groupID == "" {
+		groupID = DefaultReplayMessageGroupID
+	}
+
+	replayID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	toSend := make([]archivedMessageWithIndex, len(messages))
+	for i, message := range messages {
+		var attributes map[string]string
+		if message.MessageGroupID != "" {
+			attributes = map[string]string{string(sqstypes.MessageSystemAttributeNameMessageGroupId): message.MessageGroupID}
+		}
+
+		toSend[i] = archivedMessageWithIndex{
+			index: i,
+			message: ArchivedMessage{
+				MessageID:         replayDeduplicationID(message, replayID),
+				Body:              message.Body,
+				Attributes:        attributes,
+				MessageAttributes: archivedMessageAttributes(message.MessageAttributes),
+			},
+		}
+	}
+
+	var sent int
+	for _, batch := range splitIntoBatches(toSend) {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+
+		sentIndexes, err := send(ctx, client, config.QueueURL, batch, fifo, groupID)
+		sent += len(sentIndexes)
+		if opts.OnProgress != nil {
+			opts.OnProgress(sent)
+		}
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	return sent, nil
+}
+
+// replayDeduplicationID returns a deduplication ID for a message that's valid
+// as per SQS: 64 hex characters.
+func replayDeduplicationID(message ReplayMessage, replayID string) string {
+	source := message.Path
+	if source == "" {
+		source = message.MessageID
+	}
+
+	sum := sha256.Sum256([]byte(source + "\x00" + replayID))
+	return hex.EncodeToString(sum[:])
+}
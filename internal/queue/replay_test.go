@@ -0,0 +1,185 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPersistedMessages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1700000300-id-c.json": `{"seq": 3}`,
+		"1700000100-id-a.json": `{"seq": 1}`,
+		"1700000200-id-b.txt":  "seq 2",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o755))
+
+	names := func(messages []PersistedMessage) []string {
+		result := make([]string, len(messages))
+		for i, m := range messages {
+			result[i] = filepath.Base(m.Path)
+		}
+		return result
+	}
+
+	t.Run("all files are listed oldest first", func(t *testing.T) {
+		got, err := ListPersistedMessages(dir, PersistedMessageFilter{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1700000100-id-a.json", "1700000200-id-b.txt", "1700000300-id-c.json"}, names(got))
+		assert.Equal(t, "id-a", got[0].MessageID)
+		assert.Equal(t, time.Unix(1700000100, 0), got[0].PersistedAt)
+	})
+
+	t.Run("files can be filtered by glob", func(t *testing.T) {
+		got, err := ListPersistedMessages(dir, PersistedMessageFilter{Glob: "*.json"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1700000100-id-a.json", "1700000300-id-c.json"}, names(got))
+	})
+
+	t.Run("files can be filtered by time range", func(t *testing.T) {
+		got, err := ListPersistedMessages(dir, PersistedMessageFilter{
+			Since: time.Unix(1700000150, 0),
+			Until: time.Unix(1700000250, 0),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1700000200-id-b.txt"}, names(got))
+	})
+
+	t.Run("invalid globs are rejected", func(t *testing.T) {
+		_, err := ListPersistedMessages(dir, PersistedMessageFilter{Glob: "[a-"})
+
+		assert.ErrorIs(t, err, errInvalidGlob)
+	})
+
+	t.Run("files in subdirectories are listed", func(t *testing.T) {
+		nestedDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(nestedDir, "2023-11-14", "agg-1"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(nestedDir, "2023-11-14", "agg-1", "1700000100-id-a.json"), []byte(`{}`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(nestedDir, "1700000000-id-b.json"), []byte(`{}`), 0o644))
+
+		got, err := ListPersistedMessages(nestedDir, PersistedMessageFilter{})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1700000000-id-b.json", "1700000100-id-a.json"}, names(got))
+		assert.Equal(t, filepath.Join(nestedDir, "2023-11-14", "agg-1", "1700000100-id-a.json"), got[1].Path)
+	})
+}
+
+func TestNewPersistedMessage(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+
+	got := NewPersistedMessage("messages/queue-a/edited.json", modTime)
+
+	assert.Equal(t, PersistedMessage{
+		Path:        "messages/queue-a/edited.json",
+		MessageID:   "edited",
+		PersistedAt: modTime,
+	}, got)
+}
+
+// validDeduplicationID matches the deduplication IDs Replay generates, all of
+// which SQS accepts.
+var validDeduplicationID = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func TestReplay(t *testing.T) {
+	messages := []ReplayMessage{
+		{MessageID: "id-a", Body: "a"},
+		{MessageID: "id-b", Body: "b"},
+	}
+
+	t.Run("messages are sent in order", func(t *testing.T) {
+		client := newFakeClient(0)
+		config := types.Config{QueueURL: "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a"}
+
+		sent, err := Replay(context.Background(), client, config, messages, ReplayOptions{})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, sent)
+		require.Len(t, client.sent, 2)
+		assert.Equal(t, "a", *client.sent[0].MessageBody)
+		assert.Equal(t, "b", *client.sent[1].MessageBody)
+	})
+
+	t.Run("FIFO replays get unique deduplication IDs", func(t *testing.T) {
+		client := newFakeClient(0)
+		config := types.Config{QueueURL: "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a.fifo"}
+
+		_, err := Replay(context.Background(), client, config, messages, ReplayOptions{})
+		require.NoError(t, err)
+		_, err = Replay(context.Background(), client, config, messages, ReplayOptions{MessageGroupID: "incident-123"})
+		require.NoError(t, err)
+
+		require.Len(t, client.sent, 4)
+		assert.Equal(t, DefaultReplayMessageGroupID, *client.sent[0].MessageGroupId)
+		assert.Equal(t, "incident-123", *client.sent[2].MessageGroupId)
+		assert.Regexp(t, validDeduplicationID, *client.sent[0].MessageDeduplicationId)
+		assert.NotEqual(t, *client.sent[0].MessageDeduplicationId, *client.sent[1].MessageDeduplicationId)
+		assert.NotEqual(t, *client.sent[0].MessageDeduplicationId, *client.sent[2].MessageDeduplicationId)
+	})
+
+	t.Run("edited messages keep their attributes and group IDs", func(t *testing.T) {
+		client := newFakeClient(0)
+		config := types.Config{QueueURL: "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a.fifo"}
+
+		_, err := Replay(context.Background(), client, config, []ReplayMessage{
+			{
+				MessageID: "id-a",
+				Body:      "a",
+				MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+					"tenant": {DataType: aws.String("String"), StringValue: aws.String("tenant-a")},
+				},
+				MessageGroupID: "order-1",
+			},
+			{MessageID: "id-b", Body: "b"},
+		}, ReplayOptions{MessageGroupID: "incident-123"})
+
+		require.NoError(t, err)
+		require.Len(t, client.sent, 2)
+		assert.Equal(t, "order-1", *client.sent[0].MessageGroupId)
+		assert.Equal(t, "tenant-a", *client.sent[0].MessageAttributes["tenant"].StringValue)
+		assert.Equal(t, "incident-123", *client.sent[1].MessageGroupId)
+		assert.Empty(t, client.sent[1].MessageAttributes)
+	})
+
+	t.Run("FIFO deduplication IDs are valid for any file name", func(t *testing.T) {
+		client := newFakeClient(0)
+		config := types.Config{QueueURL: "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a.fifo"}
+		path := filepath.Join("messages", "queue-a", "réplay of "+strings.Repeat("a very long name ", 10)+".json")
+		persisted := NewPersistedMessage(path, time.Unix(1700000000, 0))
+
+		_, err := Replay(context.Background(), client, config, []ReplayMessage{
+			{MessageID: persisted.MessageID, Path: persisted.Path, Body: "a"},
+		}, ReplayOptions{})
+
+		require.NoError(t, err)
+		require.Len(t, client.sent, 1)
+		assert.Regexp(t, validDeduplicationID, *client.sent[0].MessageDeduplicationId)
+	})
+
+	t.Run("read-only profiles can't be replayed to", func(t *testing.T) {
+		client := newFakeClient(0)
+		config := types.Config{QueueURL: "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a", ReadOnly: true}
+
+		_, err := Replay(context.Background(), client, config, messages, ReplayOptions{})
+
+		require.ErrorIs(t, err, types.ErrProfileIsReadOnly)
+		assert.Empty(t, client.sent)
+	})
+}
@@ -0,0 +1,369 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const (
+	// DefaultRestoreMessageGroupID is used when restoring messages that don't
+	// have a group ID of their own to a FIFO queue.
+	DefaultRestoreMessageGroupID = "cueitup-restore"
+	restoreBatchSize             = 10
+	// SQS limits the total size of a batch of messages
+	restoreMaxBatchBytes  = 256 * 1024
+	restoreProgressSuffix = ".progress"
+	fifoQueueSuffix       = ".fifo"
+)
+
+var (
+	errCouldntSendMessages            = errors.New("couldn't send messages")
+	errCouldntTrackRestoreProgress    = errors.New("couldn't track restore progress")
+	errRestoreProgressForAnotherQueue = errors.New("archive was being restored to another queue")
+)
+
+type RestoreOptions struct {
+	ArchivePath string
+	// FromStart discards the progress of a previous, incomplete restore of the
+	// same archive.
+	FromStart bool
+	// MessageGroupID is used for messages without a group ID of their own,
+	// when restoring to a FIFO queue.
+	MessageGroupID string
+	// OnProgress, if provided, is called after every batch of messages with
+	// the number of messages sent so far.
+	OnProgress func(sent int)
+}
+
+type RestoreResult struct {
+	Sent int
+	// Skipped is the number of messages that were sent by a previous,
+	// incomplete restore.
+	Skipped int
+}
+
+// RestoreProgressPath is where the progress of restoring an archive is
+// tracked; the file is removed once the restore completes.
+func RestoreProgressPath(archivePath string) string {
+	return archivePath + restoreProgressSuffix
+}
+
+type archivedMessageWithIndex struct {
+	index   int
+	message ArchivedMessage
+}
+
+// Restore sends all messages in an archive to a queue, in the order they were
+// archived in, with their original message attributes. For FIFO queues,
+// messages keep their original group ID, and their original message ID is
+// used as the deduplication ID.
+//
+// Sent messages are tracked next to the archive (see RestoreProgressPath), so
+// that an incomplete restore can be resumed without sending messages twice.
+func Restore(ctx context.Context, client Client, config t.Config, opts RestoreOptions) (RestoreResult, error) {
+	var result RestoreResult
+
+	if err := config.EnsureWritable(); err != nil {
+		return result, err
+	}
+
+	archive, err := OpenArchive(opts.ArchivePath)
+	if err != nil {
+		return result, err
+	}
+	defer archive.Close()
+
+	progress, err := openRestoreProgress(RestoreProgressPath(opts.ArchivePath), config.QueueURL, opts.FromStart)
+	if err != nil {
+		return result, err
+	}
+	defer progress.close()
+
+	fifo := strings.HasSuffix(config.QueueURL, fifoQueueSuffix)
+	groupID := opts.MessageGroupID
+	if groupID == "" {
+		groupID = DefaultRestoreMessageGroupID
+	}
+
+	var batches batcher
+
+	sendBatch := func(batch []archivedMessageWithIndex) error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		sent, err := send(ctx, client, config.QueueURL, batch, fifo, groupID)
+		if recordErr := progress.record(sent); recordErr != nil {
+			return recordErr
+		}
+		result.Sent += len(sent)
+		if opts.OnProgress != nil {
+			opts.OnProgress(result.Sent)
+		}
+
+		return err
+	}
+
+	for index := 0; ; index++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		message, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if sendErr := sendBatch(batches.flush()); sendErr != nil {
+				return result, sendErr
+			}
+			return result, err
+		}
+
+		if progress.isSent(index) {
+			result.Skipped++
+			continue
+		}
+
+		full := batches.add(archivedMessageWithIndex{index: index, message: message})
+		if err := sendBatch(full); err != nil {
+			return result, err
+		}
+	}
+
+	if err := sendBatch(batches.flush()); err != nil {
+		return result, err
+	}
+
+	return result, progress.finish()
+}
+
+// send sends a batch of messages, and returns the archive indexes of the ones
+// that were sent successfully.
+func send(ctx context.Context, client Client, queueURL string, batch []archivedMessageWithIndex, fifo bool, groupID string) ([]int, error) {
+	entries := make([]sqstypes.SendMessageBatchRequestEntry, len(batch))
+	for i, m := range batch {
+		entries[i] = sqstypes.SendMessageBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(m.index)),
+			MessageBody:       aws.String(m.message.Body),
+			MessageAttributes: m.message.SQSMessageAttributes(),
+		}
+
+		if fifo {
+			messageGroupID := m.message.Attributes[string(sqstypes.MessageSystemAttributeNameMessageGroupId)]
+			if messageGroupID == "" {
+				messageGroupID = groupID
+			}
+			entries[i].MessageGroupId = aws.String(messageGroupID)
+			entries[i].MessageDeduplicationId = aws.String(m.message.MessageID)
+		}
+	}
+
+	output, err := client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: aws.String(queueURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntSendMessages, err.Error())
+	}
+
+	sent := make([]int, 0, len(output.Successful))
+	for _, entry := range output.Successful {
+		index, err := strconv.Atoi(aws.ToString(entry.Id))
+		if err != nil {
+			continue
+		}
+		sent = append(sent, index)
+	}
+
+	if len(output.Failed) > 0 {
+		return sent, fmt.Errorf("%w: %d of %d messages in a batch couldn't be sent (first error: %s)",
+			errCouldntSendMessages,
+			len(output.Failed),
+			len(entries),
+			aws.ToString(output.Failed[0].Message),
+		)
+	}
+
+	return sent, nil
+}
+
+// batcher groups messages into batches that are within SQS's limits, as
+// they're added.
+type batcher struct {
+	batch []archivedMessageWithIndex
+	bytes int
+}
+
+// add adds a message to the current batch; if the message doesn't fit in it,
+// the current batch is returned, and a new one is started with the message.
+func (b *batcher) add(m archivedMessageWithIndex) []archivedMessageWithIndex {
+	var full []archivedMessageWithIndex
+	size := messageSize(m.message)
+	if len(b.batch) == restoreBatchSize || (len(b.batch) > 0 && b.bytes+size > restoreMaxBatchBytes) {
+		full = b.flush()
+	}
+
+	b.batch = append(b.batch, m)
+	b.bytes += size
+
+	return full
+}
+
+// flush returns the current batch, and starts a new one.
+func (b *batcher) flush() []archivedMessageWithIndex {
+	batch := b.batch
+	b.batch = nil
+	b.bytes = 0
+
+	return batch
+}
+
+// splitIntoBatches splits messages into batches that are within SQS's limits.
+func splitIntoBatches(messages []archivedMessageWithIndex) [][]archivedMessageWithIndex {
+	var batches [][]archivedMessageWithIndex
+	var b batcher
+
+	for _, m := range messages {
+		if full := b.add(m); full != nil {
+			batches = append(batches, full)
+		}
+	}
+
+	if last := b.flush(); len(last) > 0 {
+		batches = append(batches, last)
+	}
+
+	return batches
+}
+
+func messageSize(message ArchivedMessage) int {
+	size := len(message.Body)
+	for name, attribute := range message.MessageAttributes {
+		size += len(name) + len(attribute.DataType) + len(aws.ToString(attribute.StringValue)) + len(attribute.BinaryValue)
+	}
+
+	return size
+}
+
+// restoreProgress tracks which messages in an archive have been sent. Its
+// file contains the URL of the queue being restored to, followed by the
+// archive index of every message sent, one per line.
+type restoreProgress struct {
+	path string
+	file *os.File
+	sent map[int]struct{}
+}
+
+func openRestoreProgress(path, queueURL string, fromStart bool) (*restoreProgress, error) {
+	progress := &restoreProgress{
+		path: path,
+		sent: make(map[int]struct{}),
+	}
+
+	if fromStart {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+		}
+	}
+
+	existing, err := os.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	default:
+		scanner := bufio.NewScanner(existing)
+		if scanner.Scan() && scanner.Text() != queueURL {
+			existing.Close()
+			return nil, fmt.Errorf("%w (%s); remove %s, or restore from the start", errRestoreProgressForAnotherQueue, scanner.Text(), path)
+		}
+		for scanner.Scan() {
+			index, err := strconv.Atoi(scanner.Text())
+			if err != nil {
+				continue
+			}
+			progress.sent[index] = struct{}{}
+		}
+		existing.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+		}
+	}
+
+	progress.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	}
+
+	info, err := progress.file.Stat()
+	if err != nil {
+		progress.file.Close()
+		return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	}
+	if info.Size() == 0 {
+		if _, err := fmt.Fprintln(progress.file, queueURL); err != nil {
+			progress.file.Close()
+			return nil, fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+		}
+	}
+
+	return progress, nil
+}
+
+func (p *restoreProgress) isSent(index int) bool {
+	_, ok := p.sent[index]
+	return ok
+}
+
+func (p *restoreProgress) record(indexes []int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	var builder strings.Builder
+	for _, index := range indexes {
+		p.sent[index] = struct{}{}
+		fmt.Fprintf(&builder, "%d\n", index)
+	}
+
+	if _, err := p.file.WriteString(builder.String()); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	}
+
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	}
+
+	return nil
+}
+
+func (p *restoreProgress) close() {
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+// finish removes the progress file once all messages have been sent.
+func (p *restoreProgress) finish() error {
+	p.close()
+	p.file = nil
+
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", errCouldntTrackRestoreProgress, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package queue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestArchive(t *testing.T, numMessages int, attributes map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "backup.jsonl")
+	writer, err := NewJSONLArchiveWriter(path)
+	require.NoError(t, err)
+	for i := range numMessages {
+		require.NoError(t, writer.Write(ArchivedMessage{
+			MessageID:  "id-" + string(rune('a'+i)),
+			Body:       string(rune('a' + i)),
+			Attributes: attributes,
+			MessageAttributes: map[string]MessageAttribute{
+				"source": {DataType: "String", StringValue: aws.String("cueitup")},
+			},
+			ArchivedAt: time.Now(),
+		}))
+	}
+	require.NoError(t, writer.Close())
+
+	return path
+}
+
+func TestRestore(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile-a",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+	}
+	fifoConfig := types.Config{
+		ProfileName: "profile-fifo",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a.fifo",
+	}
+
+	t.Run("messages are sent in order with their attributes", func(t *testing.T) {
+		client := newFakeClient(0)
+		archivePath := writeTestArchive(t, 15, nil)
+		var progress []int
+
+		result, err := Restore(context.Background(), client, config, RestoreOptions{
+			ArchivePath: archivePath,
+			OnProgress:  func(n int) { progress = append(progress, n) },
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, RestoreResult{Sent: 15}, result)
+		assert.Equal(t, []int{10, 15}, progress)
+		require.Len(t, client.sent, 15)
+		assert.Equal(t, "a", *client.sent[0].MessageBody)
+		assert.Equal(t, "o", *client.sent[14].MessageBody)
+		assert.Equal(t, "cueitup", *client.sent[0].MessageAttributes["source"].StringValue)
+		assert.Nil(t, client.sent[0].MessageGroupId)
+		assert.NoFileExists(t, RestoreProgressPath(archivePath))
+	})
+
+	t.Run("FIFO messages keep their group ID", func(t *testing.T) {
+		client := newFakeClient(0)
+		archivePath := writeTestArchive(t, 2, map[string]string{"MessageGroupId": "group-a"})
+
+		_, err := Restore(context.Background(), client, fifoConfig, RestoreOptions{ArchivePath: archivePath})
+
+		require.NoError(t, err)
+		require.Len(t, client.sent, 2)
+		assert.Equal(t, "group-a", *client.sent[0].MessageGroupId)
+		assert.Equal(t, "id-a", *client.sent[0].MessageDeduplicationId)
+	})
+
+	t.Run("FIFO messages without a group ID get the default one", func(t *testing.T) {
+		client := newFakeClient(0)
+		archivePath := writeTestArchive(t, 1, nil)
+
+		_, err := Restore(context.Background(), client, fifoConfig, RestoreOptions{ArchivePath: archivePath})
+
+		require.NoError(t, err)
+		require.Len(t, client.sent, 1)
+		assert.Equal(t, DefaultRestoreMessageGroupID, *client.sent[0].MessageGroupId)
+	})
+
+	t.Run("incomplete restores can be resumed", func(t *testing.T) {
+		client := newFakeClient(0)
+		client.maxSends = 13
+		archivePath := writeTestArchive(t, 20, nil)
+
+		result, err := Restore(context.Background(), client, config, RestoreOptions{ArchivePath: archivePath})
+
+		require.ErrorIs(t, err, errCouldntSendMessages)
+		assert.Equal(t, 13, result.Sent)
+		assert.FileExists(t, RestoreProgressPath(archivePath))
+
+		client.maxSends = -1
+		result, err = Restore(context.Background(), client, config, RestoreOptions{ArchivePath: archivePath})
+
+		require.NoError(t, err)
+		assert.Equal(t, RestoreResult{Sent: 7, Skipped: 13}, result)
+		require.Len(t, client.sent, 20)
+		assert.Equal(t, "t", *client.sent[19].MessageBody)
+		assert.NoFileExists(t, RestoreProgressPath(archivePath))
+	})
+
+	t.Run("progress for another queue isn't reused", func(t *testing.T) {
+		client := newFakeClient(0)
+		archivePath := writeTestArchive(t, 3, nil)
+		progressPath := RestoreProgressPath(archivePath)
+		require.NoError(t, os.WriteFile(progressPath, []byte("https://sqs.eu-central-1.amazonaws.com/000000000000/queue-b\n0\n"), 0o644))
+
+		_, err := Restore(context.Background(), client, config, RestoreOptions{ArchivePath: archivePath})
+		require.ErrorIs(t, err, errRestoreProgressForAnotherQueue)
+		assert.Empty(t, client.sent)
+
+		result, err := Restore(context.Background(), client, config, RestoreOptions{ArchivePath: archivePath, FromStart: true})
+		require.NoError(t, err)
+		assert.Equal(t, RestoreResult{Sent: 3}, result)
+	})
+
+	t.Run("read-only profiles can't be restored to", func(t *testing.T) {
+		client := newFakeClient(0)
+		readOnlyConfig := config
+		readOnlyConfig.ReadOnly = true
+
+		_, err := Restore(context.Background(), client, readOnlyConfig, RestoreOptions{ArchivePath: writeTestArchive(t, 1, nil)})
+
+		require.ErrorIs(t, err, types.ErrProfileIsReadOnly)
+		assert.Empty(t, client.sent)
+	})
+}
+
+func TestSplitIntoBatches(t *testing.T) {
+	messagesOfSize := func(sizes ...int) []archivedMessageWithIndex {
+		messages := make([]archivedMessageWithIndex, len(sizes))
+		for i, size := range sizes {
+			messages[i] = archivedMessageWithIndex{
+				index:   i,
+				message: ArchivedMessage{Body: strings.Repeat("a", size)},
+			}
+		}
+		return messages
+	}
+
+	testCases := []struct {
+		name     string
+		messages []archivedMessageWithIndex
+		expected []int
+	}{
+		{
+			name:     "no messages",
+			messages: nil,
+			expected: nil,
+		},
+		{
+			name:     "batches hold at most 10 messages",
+			messages: messagesOfSize(make([]int, 23)...),
+			expected: []int{10, 10, 3},
+		},
+		{
+			name:     "batches stay within the size limit",
+			messages: messagesOfSize(100*1024, 100*1024, 100*1024, 10),
+			expected: []int{2, 2},
+		},
+		{
+			name:     "messages as large as the limit get a batch of their own",
+			messages: messagesOfSize(10, restoreMaxBatchBytes, 10),
+			expected: []int{1, 1, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			batches := splitIntoBatches(tc.messages)
+
+			var got []int
+			var indexes []int
+			for _, batch := range batches {
+				got = append(got, len(batch))
+				for _, m := range batch {
+					indexes = append(indexes, m.index)
+				}
+			}
+			assert.Equal(t, tc.expected, got)
+			for i, index := range indexes {
+				assert.Equal(t, i, index)
+			}
+		})
+	}
+}
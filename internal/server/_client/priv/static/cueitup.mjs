@@ -267,6 +267,11 @@ function bitArrayPrintDeprecationWarning(name, message) {
   );
   isBitArrayDeprecationMessagePrinted[name] = true;
 }
+var UtfCodepoint = class {
+  constructor(value3) {
+    this.value = value3;
+  }
+};
 var Result = class _Result extends CustomType {
   // @internal
   static isResult(data) {
@@ -365,6 +370,23 @@ function makeError(variant, file, module, line, fn, message, extra) {
   return error;
 }
 
+function divideFloat(a2, b) {
+  if (b === 0) {
+    return 0;
+  } else {
+    return a2 / b;
+  }
+}
+function divideInt(a2, b) {
+  return Math.trunc(divideFloat(a2, b));
+}
+function remainderInt(a2, b) {
+  if (b === 0) {
+    return 0;
+  } else {
+    return a2 % b;
+  }
+}
 // build/dev/javascript/gleam_stdlib/gleam/option.mjs
 var Some = class extends CustomType {
   constructor($0) {
@@ -374,6 +396,9 @@ var Some = class extends CustomType {
 };
 var None = class extends CustomType {
 };
+function is_some(option) {
+  return !isEqual(option, new None());
+}
 function to_result(option, e) {
   if (option instanceof Some) {
     let a2 = option[0];
@@ -398,6 +423,14 @@ function map(option, fun) {
     return new None();
   }
 }
+function from_result(result) {
+  if (result instanceof Ok) {
+    let a2 = result[0];
+    return new Some(a2);
+  } else {
+    return new None();
+  }
+}
 
 // build/dev/javascript/gleam_stdlib/dict.mjs
 var referenceMap = /* @__PURE__ */ new WeakMap();
@@ -1128,6 +1161,14 @@ function string_length(string6) {
   }
 }
 var segmenter = void 0;
+function graphemes(string6) {
+  const iterator = graphemes_iterator(string6);
+  if (iterator) {
+    return List.fromArray(Array.from(iterator).map((item) => item.segment));
+  } else {
+    return List.fromArray(string6.match(/./gsu));
+  }
+}
 function graphemes_iterator(string6) {
   if (globalThis.Intl && Intl.Segmenter) {
     segmenter ||= new Intl.Segmenter();
@@ -1154,6 +1195,12 @@ function pop_codeunit(str) {
 function lowercase(string6) {
   return string6.toLowerCase();
 }
+function less_than(a2, b) {
+  return a2 < b;
+}
+function split(xs, pattern) {
+  return List.fromArray(xs.split(pattern));
+}
 function concat(xs) {
   let result = "";
   for (const x of xs) {
@@ -1183,6 +1230,22 @@ function string_slice(string6, idx, len) {
     return string6.match(/./gsu).slice(idx, idx + len).join("");
   }
 }
+function round(float4) {
+  return Math.round(float4);
+}
+function float_to_string(float4) {
+  const string6 = float4.toString().replace("+", "");
+  if (string6.indexOf(".") >= 0) {
+    return string6;
+  } else {
+    const index5 = string6.indexOf("e");
+    if (index5 >= 0) {
+      return string6.slice(0, index5) + ".0" + string6.slice(index5);
+    } else {
+      return string6 + ".0";
+    }
+  }
+}
 function string_codeunit_slice(str, from2, length4) {
   return str.slice(from2, from2 + length4);
 }
@@ -1213,6 +1276,18 @@ var trim_start_regex = /* @__PURE__ */ new RegExp(
   `^[${unicode_whitespaces}]*`
 );
 var trim_end_regex = /* @__PURE__ */ new RegExp(`[${unicode_whitespaces}]*$`);
+function trim(string6) {
+  return string6.replace(trim_start_regex, "").replace(trim_end_regex, "");
+}
+function codepoint(int3) {
+  return new UtfCodepoint(int3);
+}
+function string_to_codepoint_integer_list(string6) {
+  return List.fromArray(Array.from(string6).map((item) => item.codePointAt(0)));
+}
+function utf_codepoint_to_int(utf_codepoint) {
+  return utf_codepoint.value;
+}
 function new_map() {
   return Dict.new();
 }
@@ -1271,6 +1346,9 @@ function decode_string(data) {
 function decode_int(data) {
   return Number.isInteger(data) ? new Ok(data) : decoder_error("Int", data);
 }
+function percent_encode(string6) {
+  return encodeURIComponent(string6).replace("%2B", "+");
+}
 function decode_bool(data) {
   return typeof data === "boolean" ? new Ok(data) : decoder_error("Bool", data);
 }
@@ -1348,8 +1426,56 @@ function do_keys_loop(loop$list, loop$acc) {
 function keys(dict2) {
   return do_keys_loop(map_to_list(dict2), toList([]));
 }
+function fold_loop(loop$list, loop$initial, loop$fun) {
+  while (true) {
+    let list3 = loop$list;
+    let initial = loop$initial;
+    let fun = loop$fun;
+    if (list3 instanceof Empty) {
+      return initial;
+    } else {
+      let k = list3.head[0];
+      let v = list3.head[1];
+      let rest = list3.tail;
+      loop$list = rest;
+      loop$initial = fun(initial, k, v);
+      loop$fun = fun;
+    }
+  }
+}
+function fold2(dict2, initial, fun) {
+  return fold_loop(map_to_list(dict2), initial, fun);
+}
+
+// build/dev/javascript/gleam_stdlib/gleam/order.mjs
+var Lt = class extends CustomType {
+};
+var Eq = class extends CustomType {
+};
+var Gt = class extends CustomType {
+};
 
 // build/dev/javascript/gleam_stdlib/gleam/list.mjs
+var Ascending = class extends CustomType {
+};
+var Descending = class extends CustomType {
+};
+function length_loop(loop$list, loop$count) {
+  while (true) {
+    let list3 = loop$list;
+    let count = loop$count;
+    if (list3 instanceof Empty) {
+      return count;
+    } else {
+      let list$1 = list3.tail;
+      loop$list = list$1;
+      loop$count = count + 1;
+    }
+  }
+}
+function length(list3) {
+  return length_loop(list3, 0);
+}
 function reverse_and_prepend(loop$prefix, loop$suffix) {
   while (true) {
     let prefix = loop$prefix;
@@ -1367,6 +1493,14 @@ function reverse_and_prepend(loop$prefix, loop$suffix) {
 function reverse(list3) {
   return reverse_and_prepend(list3, toList([]));
 }
+function first(list3) {
+  if (list3 instanceof Empty) {
+    return new Error(void 0);
+  } else {
+    let first$1 = list3.head;
+    return new Ok(first$1);
+  }
+}
 function map_loop(loop$list, loop$fun, loop$acc) {
   while (true) {
     let list3 = loop$list;
@@ -1386,6 +1520,33 @@ function map_loop(loop$list, loop$fun, loop$acc) {
 function map2(list3, fun) {
   return map_loop(list3, fun, toList([]));
 }
+function take_loop(loop$list, loop$n, loop$acc) {
+  while (true) {
+    let list3 = loop$list;
+    let n = loop$n;
+    let acc = loop$acc;
+    let $ = n <= 0;
+    if ($) {
+      return reverse(acc);
+    } else {
+      if (list3 instanceof Empty) {
+        return reverse(acc);
+      } else {
+        let first$1 = list3.head;
+        let rest$1 = list3.tail;
+        loop$list = rest$1;
+        loop$n = n - 1;
+        loop$acc = prepend(first$1, acc);
+      }
+    }
+  }
+}
+function take(list3, n) {
+  return take_loop(list3, n, toList([]));
+}
+function is_empty(list3) {
+  return isEqual(list3, toList([]));
+}
 function index_map_loop(loop$list, loop$fun, loop$index, loop$acc) {
   while (true) {
     let list3 = loop$list;
@@ -1441,6 +1602,126 @@ function fold(loop$list, loop$initial, loop$fun) {
     }
   }
 }
+function all(loop$list, loop$predicate) {
+  while (true) {
+    let list3 = loop$list;
+    let predicate = loop$predicate;
+    if (list3 instanceof Empty) {
+      return true;
+    } else {
+      let first$1 = list3.head;
+      let rest$1 = list3.tail;
+      let $ = predicate(first$1);
+      if ($) {
+        loop$list = rest$1;
+        loop$predicate = predicate;
+      } else {
+        return false;
+      }
+    }
+  }
+}
+function split_while_loop(loop$list, loop$f, loop$acc) {
+  while (true) {
+    let list3 = loop$list;
+    let f = loop$f;
+    let acc = loop$acc;
+    if (list3 instanceof Empty) {
+      return [reverse(acc), toList([])];
+    } else {
+      let first$1 = list3.head;
+      let rest$1 = list3.tail;
+      let $ = f(first$1);
+      if (!$) {
+        return [reverse(acc), list3];
+      } else {
+        loop$list = rest$1;
+        loop$f = f;
+        loop$acc = prepend(first$1, acc);
+      }
+    }
+  }
+}
+function split_while(list3, predicate) {
+  return split_while_loop(list3, predicate, toList([]));
+}
+function count(list3, predicate) {
+  return fold(
+    list3,
+    0,
+    (acc, value3) => {
+      let $ = predicate(value3);
+      if ($) {
+        return acc + 1;
+      } else {
+        return acc;
+      }
+    }
+  );
+}
+function find2(loop$list, loop$is_desired) {
+  while (true) {
+    let list3 = loop$list;
+    let is_desired = loop$is_desired;
+    if (list3 instanceof Empty) {
+      return new Error(void 0);
+    } else {
+      let first$1 = list3.head;
+      let rest$1 = list3.tail;
+      let $ = is_desired(first$1);
+      if ($) {
+        return new Ok(first$1);
+      } else {
+        loop$list = rest$1;
+        loop$is_desired = is_desired;
+      }
+    }
+  }
+}
+function key_set_loop(loop$list, loop$key, loop$value, loop$inspected) {
+  while (true) {
+    let list3 = loop$list;
+    let key = loop$key;
+    let value3 = loop$value;
+    let inspected = loop$inspected;
+    if (list3 instanceof Empty) {
+      return reverse(prepend([key, value3], inspected));
+    } else {
+      let k = list3.head[0];
+      if (isEqual(k, key)) {
+        let rest$1 = list3.tail;
+        return reverse_and_prepend(inspected, prepend([k, value3], rest$1));
+      } else {
+        let first$1 = list3.head;
+        let rest$1 = list3.tail;
+        loop$list = rest$1;
+        loop$key = key;
+        loop$value = value3;
+        loop$inspected = prepend(first$1, inspected);
+      }
+    }
+  }
+}
+function key_set(list3, key, value3) {
+  return key_set_loop(list3, key, value3, toList([]));
+}
+function last(loop$list) {
+  while (true) {
+    let list3 = loop$list;
+    if (list3 instanceof Empty) {
+      return new Error(void 0);
+    } else {
+      let $ = list3.tail;
+      if ($ instanceof Empty) {
+        let last$1 = list3.head;
+        return new Ok(last$1);
+      } else {
+        let rest$1 = $;
+        loop$list = rest$1;
+      }
+    }
+  }
+}
 function index_fold_loop(loop$over, loop$acc, loop$with, loop$index) {
   while (true) {
     let over = loop$over;
@@ -1462,6 +1743,356 @@ function index_fold_loop(loop$over, loop$acc, loop$with, loop$index) {
 function index_fold(list3, initial, fun) {
   return index_fold_loop(list3, initial, fun, 0);
 }
+function sequences(loop$list, loop$compare, loop$growing, loop$direction, loop$prev, loop$acc) {
+  while (true) {
+    let list3 = loop$list;
+    let compare2 = loop$compare;
+    let growing = loop$growing;
+    let direction = loop$direction;
+    let prev = loop$prev;
+    let acc = loop$acc;
+    let growing$1 = prepend(prev, growing);
+    if (list3 instanceof Empty) {
+      if (direction instanceof Ascending) {
+        return prepend(reverse(growing$1), acc);
+      } else {
+        return prepend(growing$1, acc);
+      }
+    } else {
+      let new$3 = list3.head;
+      let rest$1 = list3.tail;
+      let $ = compare2(prev, new$3);
+      if ($ instanceof Lt) {
+        if (direction instanceof Ascending) {
+          loop$list = rest$1;
+          loop$compare = compare2;
+          loop$growing = growing$1;
+          loop$direction = direction;
+          loop$prev = new$3;
+          loop$acc = acc;
+        } else {
+          let _block;
+          if (direction instanceof Ascending) {
+            _block = prepend(reverse(growing$1), acc);
+          } else {
+            _block = prepend(growing$1, acc);
+          }
+          let acc$1 = _block;
+          if (rest$1 instanceof Empty) {
+            return prepend(toList([new$3]), acc$1);
+          } else {
+            let next = rest$1.head;
+            let rest$2 = rest$1.tail;
+            let _block$1;
+            let $1 = compare2(new$3, next);
+            if ($1 instanceof Lt) {
+              _block$1 = new Ascending();
+            } else if ($1 instanceof Eq) {
+              _block$1 = new Ascending();
+            } else {
+              _block$1 = new Descending();
+            }
+            let direction$1 = _block$1;
+            loop$list = rest$2;
+            loop$compare = compare2;
+            loop$growing = toList([new$3]);
+            loop$direction = direction$1;
+            loop$prev = next;
+            loop$acc = acc$1;
+          }
+        }
+      } else if ($ instanceof Eq) {
+        if (direction instanceof Ascending) {
+          loop$list = rest$1;
+          loop$compare = compare2;
+          loop$growing = growing$1;
+          loop$direction = direction;
+          loop$prev = new$3;
+          loop$acc = acc;
+        } else {
+          let _block;
+          if (direction instanceof Ascending) {
+            _block = prepend(reverse(growing$1), acc);
+          } else {
+            _block = prepend(growing$1, acc);
+          }
+          let acc$1 = _block;
+          if (rest$1 instanceof Empty) {
+            return prepend(toList([new$3]), acc$1);
+          } else {
+            let next = rest$1.head;
+            let rest$2 = rest$1.tail;
+            let _block$1;
+            let $1 = compare2(new$3, next);
+            if ($1 instanceof Lt) {
+              _block$1 = new Ascending();
+            } else if ($1 instanceof Eq) {
+              _block$1 = new Ascending();
+            } else {
+              _block$1 = new Descending();
+            }
+            let direction$1 = _block$1;
+            loop$list = rest$2;
+            loop$compare = compare2;
+            loop$growing = toList([new$3]);
+            loop$direction = direction$1;
+            loop$prev = next;
+            loop$acc = acc$1;
+          }
+        }
+      } else if (direction instanceof Descending) {
+        loop$list = rest$1;
+        loop$compare = compare2;
+        loop$growing = growing$1;
+        loop$direction = direction;
+        loop$prev = new$3;
+        loop$acc = acc;
+      } else {
+        let _block;
+        if (direction instanceof Ascending) {
+          _block = prepend(reverse(growing$1), acc);
+        } else {
+          _block = prepend(growing$1, acc);
+        }
+        let acc$1 = _block;
+        if (rest$1 instanceof Empty) {
+          return prepend(toList([new$3]), acc$1);
+        } else {
+          let next = rest$1.head;
+          let rest$2 = rest$1.tail;
+          let _block$1;
+          let $1 = compare2(new$3, next);
+          if ($1 instanceof Lt) {
+            _block$1 = new Ascending();
+          } else if ($1 instanceof Eq) {
+            _block$1 = new Ascending();
+          } else {
+            _block$1 = new Descending();
+          }
+          let direction$1 = _block$1;
+          loop$list = rest$2;
+          loop$compare = compare2;
+          loop$growing = toList([new$3]);
+          loop$direction = direction$1;
+          loop$prev = next;
+          loop$acc = acc$1;
+        }
+      }
+    }
+  }
+}
+function merge_ascendings(loop$list1, loop$list2, loop$compare, loop$acc) {
+  while (true) {
+    let list1 = loop$list1;
+    let list22 = loop$list2;
+    let compare2 = loop$compare;
+    let acc = loop$acc;
+    if (list1 instanceof Empty) {
+      let list3 = list22;
+      return reverse_and_prepend(list3, acc);
+    } else if (list22 instanceof Empty) {
+      let list3 = list1;
+      return reverse_and_prepend(list3, acc);
+    } else {
+      let first1 = list1.head;
+      let rest1 = list1.tail;
+      let first2 = list22.head;
+      let rest2 = list22.tail;
+      let $ = compare2(first1, first2);
+      if ($ instanceof Lt) {
+        loop$list1 = rest1;
+        loop$list2 = list22;
+        loop$compare = compare2;
+        loop$acc = prepend(first1, acc);
+      } else {
+        loop$list1 = list1;
+        loop$list2 = rest2;
+        loop$compare = compare2;
+        loop$acc = prepend(first2, acc);
+      }
+    }
+  }
+}
+function merge_ascending_pairs(loop$sequences, loop$compare, loop$acc) {
+  while (true) {
+    let sequences2 = loop$sequences;
+    let compare2 = loop$compare;
+    let acc = loop$acc;
+    if (sequences2 instanceof Empty) {
+      return reverse(acc);
+    } else {
+      let $ = sequences2.tail;
+      if ($ instanceof Empty) {
+        let sequence = sequences2.head;
+        return reverse(prepend(reverse(sequence), acc));
+      } else {
+        let ascending1 = sequences2.head;
+        let ascending2 = $.head;
+        let rest$1 = $.tail;
+        let descending = merge_ascendings(
+          ascending1,
+          ascending2,
+          compare2,
+          toList([])
+        );
+        loop$sequences = rest$1;
+        loop$compare = compare2;
+        loop$acc = prepend(descending, acc);
+      }
+    }
+  }
+}
+function merge_descendings(loop$list1, loop$list2, loop$compare, loop$acc) {
+  while (true) {
+    let list1 = loop$list1;
+    let list22 = loop$list2;
+    let compare2 = loop$compare;
+    let acc = loop$acc;
+    if (list1 instanceof Empty) {
+      let list3 = list22;
+      return reverse_and_prepend(list3, acc);
+    } else if (list22 instanceof Empty) {
+      let list3 = list1;
+      return reverse_and_prepend(list3, acc);
+    } else {
+      let first1 = list1.head;
+      let rest1 = list1.tail;
+      let first2 = list22.head;
+      let rest2 = list22.tail;
+      let $ = compare2(first1, first2);
+      if ($ instanceof Lt) {
+        loop$list1 = list1;
+        loop$list2 = rest2;
+        loop$compare = compare2;
+        loop$acc = prepend(first2, acc);
+      } else {
+        loop$list1 = rest1;
+        loop$list2 = list22;
+        loop$compare = compare2;
+        loop$acc = prepend(first1, acc);
+      }
+    }
+  }
+}
+function merge_descending_pairs(loop$sequences, loop$compare, loop$acc) {
+  while (true) {
+    let sequences2 = loop$sequences;
+    let compare2 = loop$compare;
+    let acc = loop$acc;
+    if (sequences2 instanceof Empty) {
+      return reverse(acc);
+    } else {
+      let $ = sequences2.tail;
+      if ($ instanceof Empty) {
+        let sequence = sequences2.head;
+        return reverse(prepend(reverse(sequence), acc));
+      } else {
+        let descending1 = sequences2.head;
+        let descending2 = $.head;
+        let rest$1 = $.tail;
+        let ascending = merge_descendings(
+          descending1,
+          descending2,
+          compare2,
+          toList([])
+        );
+        loop$sequences = rest$1;
+        loop$compare = compare2;
+        loop$acc = prepend(ascending, acc);
+      }
+    }
+  }
+}
+function merge_all(loop$sequences, loop$direction, loop$compare) {
+  while (true) {
+    let sequences2 = loop$sequences;
+    let direction = loop$direction;
+    let compare2 = loop$compare;
+    if (sequences2 instanceof Empty) {
+      return toList([]);
+    } else if (direction instanceof Ascending) {
+      let $ = sequences2.tail;
+      if ($ instanceof Empty) {
+        let sequence = sequences2.head;
+        return sequence;
+      } else {
+        let sequences$1 = merge_ascending_pairs(sequences2, compare2, toList([]));
+        loop$sequences = sequences$1;
+        loop$direction = new Descending();
+        loop$compare = compare2;
+      }
+    } else {
+      let $ = sequences2.tail;
+      if ($ instanceof Empty) {
+        let sequence = sequences2.head;
+        return reverse(sequence);
+      } else {
+        let sequences$1 = merge_descending_pairs(sequences2, compare2, toList([]));
+        loop$sequences = sequences$1;
+        loop$direction = new Ascending();
+        loop$compare = compare2;
+      }
+    }
+  }
+}
+function sort(list3, compare2) {
+  if (list3 instanceof Empty) {
+    return list3;
+  } else {
+    let $ = list3.tail;
+    if ($ instanceof Empty) {
+      return list3;
+    } else {
+      let x = list3.head;
+      let y = $.head;
+      let rest$1 = $.tail;
+      let _block;
+      let $1 = compare2(x, y);
+      if ($1 instanceof Lt) {
+        _block = new Ascending();
+      } else if ($1 instanceof Eq) {
+        _block = new Ascending();
+      } else {
+        _block = new Descending();
+      }
+      let direction = _block;
+      let sequences$1 = sequences(
+        rest$1,
+        compare2,
+        toList([x]),
+        direction,
+        y,
+        toList([])
+      );
+      return merge_all(sequences$1, new Ascending(), compare2);
+    }
+  }
+}
+
+// build/dev/javascript/gleam_stdlib/gleam/float.mjs
+
+function negate(x) {
+  return -1 * x;
+}
+function round2(x) {
+  let $ = x >= 0;
+  if ($) {
+    return round(x);
+  } else {
+    return 0 - round(negate(x));
+  }
+}
+
+// build/dev/javascript/gleam_stdlib/gleam/int.mjs
+
+function max(a2, b) {
+  let $ = a2 > b;
+  if ($) {
+    return a2;
+  } else {
+    return b;
+  }
+}
 
 // build/dev/javascript/gleam_stdlib/gleam/string.mjs
 function slice(string6, idx, len) {
@@ -1544,12 +2175,43 @@ function drop_start(loop$string, loop$num_graphemes) {
     }
   }
 }
-
-// build/dev/javascript/gleam_stdlib/gleam/result.mjs
-function map3(result, fun) {
-  if (result instanceof Ok) {
-    let x = result[0];
-    return new Ok(fun(x));
+function split2(x, substring) {
+  if (substring === "") {
+    return graphemes(x);
+  } else {
+    let _pipe = x;
+    let _pipe$1 = identity(_pipe);
+    let _pipe$2 = split(_pipe$1, substring);
+    return map2(_pipe$2, identity);
+  }
+}
+function compare(a2, b) {
+  let $ = a2 === b;
+  if ($) {
+    return new Eq();
+  } else {
+    let $1 = less_than(a2, b);
+    if ($1) {
+      return new Lt();
+    } else {
+      return new Gt();
+    }
+  }
+}
+function do_to_utf_codepoints(string6) {
+  let _pipe = string6;
+  let _pipe$1 = string_to_codepoint_integer_list(_pipe);
+  return map2(_pipe$1, codepoint);
+}
+function to_utf_codepoints(string6) {
+  return do_to_utf_codepoints(string6);
+}
+
+// build/dev/javascript/gleam_stdlib/gleam/result.mjs
+function map3(result, fun) {
+  if (result instanceof Ok) {
+    let x = result[0];
+    return new Ok(fun(x));
   } else {
     let e = result[0];
     return new Error(e);
@@ -1576,6 +2238,14 @@ function try$(result, fun) {
 function then$(result, fun) {
   return try$(result, fun);
 }
+function unwrap2(result, default$) {
+  if (result instanceof Ok) {
+    let v = result[0];
+    return v;
+  } else {
+    return default$;
+  }
+}
 
 // build/dev/javascript/gleam_stdlib/gleam/dynamic.mjs
 var DecodeError = class extends CustomType {
@@ -1597,6 +2267,9 @@ function map_errors(result, f) {
 function bool(data) {
   return decode_bool(data);
 }
+function string(data) {
+  return decode_string(data);
+}
 function do_any(decoders) {
   return (data) => {
     if (decoders instanceof Empty) {
@@ -1687,6 +2360,25 @@ function index2(data, key2) {
   }
   return new Error(key_is_int ? "Indexable" : "Dict");
 }
+function dict(data) {
+  if (data instanceof Dict) {
+    return new Ok(data);
+  }
+  if (data instanceof Map || data instanceof WeakMap) {
+    return new Ok(Dict.fromMap(data));
+  }
+  if (data == null) {
+    return new Error("Dict");
+  }
+  if (typeof data !== "object") {
+    return new Error("Dict");
+  }
+  const proto = Object.getPrototypeOf(data);
+  if (proto === Object.prototype || proto === null) {
+    return new Ok(Dict.fromObject(data));
+  }
+  return new Error("Dict");
+}
 function list(data, decode2, pushPath, index5, emptyList) {
   if (!(data instanceof List || Array.isArray(data))) {
     const error = new DecodeError2("List", classify_dynamic(data), emptyList);
@@ -1709,6 +2401,10 @@ function int(data) {
   if (Number.isInteger(data)) return new Ok(data);
   return new Error(0);
 }
+function float2(data) {
+  if (typeof data === "number") return new Ok(data);
+  return new Error(0);
+}
 function string2(data) {
   if (typeof data === "string") return new Ok(data);
   return new Error("");
@@ -1780,6 +2476,24 @@ function run_decoders(loop$data, loop$failure, loop$decoders) {
     }
   }
 }
+function then$2(decoder, next) {
+  return new Decoder(
+    (dynamic_data) => {
+      let $ = decoder.function(dynamic_data);
+      let data = $[0];
+      let errors = $[1];
+      let decoder$1 = next(data);
+      let $1 = decoder$1.function(dynamic_data);
+      let layer = $1;
+      let data$1 = $1[0];
+      if (errors instanceof Empty) {
+        return layer;
+      } else {
+        return [data$1, errors];
+      }
+    }
+  );
+}
 function one_of(first2, alternatives) {
   return new Decoder(
     (dynamic_data) => {
@@ -1794,6 +2508,19 @@ function one_of(first2, alternatives) {
     }
   );
 }
+function failure(zero, expected) {
+  return new Decoder((d) => {
+    return [zero, decode_error(expected, d)];
+  });
+}
+function recursive(inner) {
+  return new Decoder(
+    (data) => {
+      let decoder = inner();
+      return decoder.function(data);
+    }
+  );
+}
 function optional(inner) {
   return new Decoder(
     (data) => {
@@ -1845,10 +2572,58 @@ function decode_int2(data) {
 }
 var bool2 = /* @__PURE__ */ new Decoder(decode_bool2);
 var int2 = /* @__PURE__ */ new Decoder(decode_int2);
+function decode_float2(data) {
+  return run_dynamic_function(data, "Float", float2);
+}
+var float3 = /* @__PURE__ */ new Decoder(decode_float2);
 function decode_string2(data) {
   return run_dynamic_function(data, "String", string2);
 }
 var string3 = /* @__PURE__ */ new Decoder(decode_string2);
+function fold_dict(acc, key2, value3, key_decoder, value_decoder) {
+  let $ = key_decoder(key2);
+  let $1 = $[1];
+  if ($1 instanceof Empty) {
+    let key$1 = $[0];
+    let $2 = value_decoder(value3);
+    let $3 = $2[1];
+    if ($3 instanceof Empty) {
+      let value$1 = $2[0];
+      let dict$1 = insert(acc[0], key$1, value$1);
+      return [dict$1, acc[1]];
+    } else {
+      let errors = $3;
+      return push_path2([new_map(), errors], toList(["values"]));
+    }
+  } else {
+    let errors = $1;
+    return push_path2([new_map(), errors], toList(["keys"]));
+  }
+}
+function dict3(key2, value3) {
+  return new Decoder(
+    (data) => {
+      let $ = dict(data);
+      if ($ instanceof Ok) {
+        let dict$1 = $[0];
+        return fold2(
+          dict$1,
+          [new_map(), toList([])],
+          (a2, k, v) => {
+            let $1 = a2[1];
+            if ($1 instanceof Empty) {
+              return fold_dict(a2, k, v, key2.function, value3.function);
+            } else {
+              return a2;
+            }
+          }
+        );
+      } else {
+        return [new_map(), decode_error("Dict", data)];
+      }
+    }
+  );
+}
 function list2(inner) {
   return new Decoder(
     (data) => {
@@ -1969,6 +2744,39 @@ function subfield(field_path, field_decoder, next) {
 function field2(field_name, field_decoder, next) {
   return subfield(toList([field_name]), field_decoder, next);
 }
+function optional_field(key2, default$, field_decoder, next) {
+  return new Decoder(
+    (data) => {
+      let _block;
+      let _block$1;
+      let $2 = index2(data, key2);
+      if ($2 instanceof Ok) {
+        let $3 = $2[0];
+        if ($3 instanceof Some) {
+          let data$1 = $3[0];
+          _block$1 = field_decoder.function(data$1);
+        } else {
+          _block$1 = [default$, toList([])];
+        }
+      } else {
+        let kind = $2[0];
+        _block$1 = [
+          default$,
+          toList([new DecodeError2(kind, classify_dynamic(data), toList([]))])
+        ];
+      }
+      let _pipe = _block$1;
+      _block = push_path2(_pipe, toList([key2]));
+      let $ = _block;
+      let out = $[0];
+      let errors1 = $[1];
+      let $1 = next(out).function(data);
+      let out$1 = $1[0];
+      let errors2 = $1[1];
+      return [out$1, append(errors1, errors2)];
+    }
+  );
+}
 
 // build/dev/javascript/gleam_stdlib/gleam/bool.mjs
 function guard(requirement, consequence, alternative) {
@@ -2058,6 +2866,18 @@ function getPositionFromMultiline(line, column, string6) {
   });
   return position;
 }
+function json_to_string(json) {
+  return JSON.stringify(json);
+}
+function object(entries) {
+  return Object.fromEntries(entries);
+}
+function array(list3) {
+  return list3.toArray();
+}
+function identity2(x) {
+  return x;
+}
 
 // build/dev/javascript/gleam_json/gleam/json.mjs
 var UnexpectedEndOfInput = class extends CustomType {
@@ -2103,6 +2923,26 @@ function do_parse(json, decoder) {
 function parse(json, decoder) {
   return do_parse(json, decoder);
 }
+function to_string3(json) {
+  return json_to_string(json);
+}
+function string4(input2) {
+  return identity2(input2);
+}
+function bool3(input2) {
+  return identity2(input2);
+}
+function object2(entries) {
+  return object(entries);
+}
+function preprocessed_array(from2) {
+  return array(from2);
+}
+function array2(entries, inner_type) {
+  let _pipe = entries;
+  let _pipe$1 = map2(_pipe, inner_type);
+  return preprocessed_array(_pipe$1);
+}
 
 // build/dev/javascript/lustre/lustre/effect.mjs
 var Effect = class extends CustomType {
@@ -2242,6 +3082,20 @@ function property(name, value2) {
 function on(name, handler) {
   return new Event2("on" + name, handler);
 }
+function style(properties) {
+  return attribute(
+    "style",
+    fold(
+      properties,
+      "",
+      (styles, _use1) => {
+        let name = _use1[0];
+        let value2 = _use1[1];
+        return styles + name + ":" + value2 + ";";
+      }
+    )
+  );
+}
 function class$(name) {
   return attribute("class", name);
 }
@@ -2254,9 +3108,18 @@ function role(name) {
 function type_(name) {
   return attribute("type", name);
 }
+function value(val) {
+  return property("value", val);
+}
+function placeholder(text4) {
+  return attribute("placeholder", text4);
+}
 function checked(is_checked) {
   return property("checked", is_checked);
 }
+function selected(is_selected) {
+  return property("selected", is_selected);
+}
 function disabled(is_disabled) {
   return property("disabled", is_disabled);
 }
@@ -3978,6 +4841,43 @@ function to(url) {
   let _pipe$1 = parse2(_pipe);
   return then$(_pipe$1, from_uri);
 }
+function set_header(request, key, value3) {
+  let headers = key_set(request.headers, lowercase(key), value3);
+  let _record = request;
+  return new Request(
+    _record.method,
+    headers,
+    _record.body,
+    _record.scheme,
+    _record.host,
+    _record.port,
+    _record.path,
+    _record.query
+  );
+}
+function set_body(req, body2) {
+  let method = req.method;
+  let headers = req.headers;
+  let scheme = req.scheme;
+  let host = req.host;
+  let port = req.port;
+  let path = req.path;
+  let query = req.query;
+  return new Request(method, headers, body2, scheme, host, port, path, query);
+}
+function set_method(req, method) {
+  let _record = req;
+  return new Request(
+    method,
+    _record.headers,
+    _record.body,
+    _record.scheme,
+    _record.host,
+    _record.port,
+    _record.path,
+    _record.query
+  );
+}
 
 // build/dev/javascript/gleam_http/gleam/http/response.mjs
 var Response = class extends CustomType {
@@ -4178,6 +5078,28 @@ function get(url, expect) {
     }
   );
 }
+function send2(req, expect) {
+  return from((_capture) => {
+    return do_send(req, expect, _capture);
+  });
+}
+function post(url, body2, expect) {
+  return from(
+    (dispatch) => {
+      let $ = to(url);
+      if ($ instanceof Ok) {
+        let req = $[0];
+        let _pipe = req;
+        let _pipe$1 = set_method(_pipe, new Post());
+        let _pipe$2 = set_header(_pipe$1, "Content-Type", "application/json");
+        let _pipe$3 = set_body(_pipe$2, to_string3(body2));
+        return do_send(_pipe$3, expect, dispatch);
+      } else {
+        return dispatch(expect.run(new Error(new BadUrl(url))));
+      }
+    }
+  );
+}
 function response_to_result(response) {
   let status = response.status;
   if (200 <= status && status <= 299) {
@@ -4222,6 +5144,15 @@ function expect_json(decoder, to_msg) {
   );
 }
 
+// build/dev/javascript/plinth/clipboard_ffi.mjs
+async function writeText(clipText) {
+  try {
+    return new Ok(await window.navigator.clipboard.writeText(clipText));
+  } catch (error) {
+    return new Error(error.toString());
+  }
+}
+
 // build/dev/javascript/plinth/window_ffi.mjs
 function self() {
   return globalThis;
@@ -4366,40 +5297,167 @@ function setTimeout(delay, callback) {
 
 // build/dev/javascript/cueitup/types.mjs
 var Config = class extends CustomType {
-  constructor(profile_name, queue_url, aws_config_source, context_key, subset_key) {
+  constructor(profile_name, queue_url, aws_config_source, context_key, subset_key, read_only) {
     super();
     this.profile_name = profile_name;
     this.queue_url = queue_url;
     this.aws_config_source = aws_config_source;
     this.context_key = context_key;
     this.subset_key = subset_key;
+    this.read_only = read_only;
   }
 };
 var Behaviours = class extends CustomType {
-  constructor(delete_messages, select_on_hover, show_message_count) {
+  constructor(delete_messages, persist_messages, select_on_hover, show_message_count) {
     super();
     this.delete_messages = delete_messages;
+    this.persist_messages = persist_messages;
     this.select_on_hover = select_on_hover;
     this.show_message_count = show_message_count;
   }
 };
+var BodyFormatted = class extends CustomType {
+};
+var BodyRaw = class extends CustomType {
+};
+var BodySubset = class extends CustomType {
+};
 var Message = class extends CustomType {
-  constructor(id2, body2, context_key, context_value, error) {
+  constructor(id2, body2, raw_body, formatted_body, context_key, context_value, error, persisted_to, persist_error, delete_error) {
     super();
     this.id = id2;
     this.body = body2;
+    this.raw_body = raw_body;
+    this.formatted_body = formatted_body;
     this.context_key = context_key;
     this.context_value = context_value;
     this.error = error;
+    this.persisted_to = persisted_to;
+    this.persist_error = persist_error;
+    this.delete_error = delete_error;
+  }
+};
+var JsonObject = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonArray = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonString = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonInt = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonFloat = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonBool = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var JsonNull = class extends CustomType {
+};
+var QueueDepthSample = class extends CustomType {
+  constructor(visible, in_flight) {
+    super();
+    this.visible = visible;
+    this.in_flight = in_flight;
+  }
+};
+var QueueRates = class extends CustomType {
+  constructor(growth, shrinkage, known) {
+    super();
+    this.growth = growth;
+    this.shrinkage = shrinkage;
+    this.known = known;
   }
 };
 var MessageCount = class extends CustomType {
-  constructor(count) {
+  constructor(count, in_flight, history, rates) {
     super();
     this.count = count;
+    this.in_flight = in_flight;
+    this.history = history;
+    this.rates = rates;
+  }
+};
+var PurgeResult = class extends CustomType {
+  constructor(backup_path, backed_up, remaining) {
+    super();
+    this.backup_path = backup_path;
+    this.backed_up = backed_up;
+    this.remaining = remaining;
+  }
+};
+var PersistedMessage = class extends CustomType {
+  constructor(message_id, path, error) {
+    super();
+    this.message_id = message_id;
+    this.path = path;
+    this.error = error;
+  }
+};
+var DiffAdded = class extends CustomType {
+};
+var DiffRemoved = class extends CustomType {
+};
+var DiffChanged = class extends CustomType {
+};
+var DiffChange = class extends CustomType {
+  constructor(path, kind, before, after) {
+    super();
+    this.path = path;
+    this.kind = kind;
+    this.before = before;
+    this.after = after;
+  }
+};
+var DiffLineSame = class extends CustomType {
+};
+var DiffLineAdded = class extends CustomType {
+};
+var DiffLineRemoved = class extends CustomType {
+};
+var DiffLine = class extends CustomType {
+  constructor(kind, text3) {
+    super();
+    this.kind = kind;
+    this.text = text3;
   }
 };
-var ConfigFetched = class extends CustomType {
+var MessageDiff = class extends CustomType {
+  constructor(structural, changes, lines) {
+    super();
+    this.structural = structural;
+    this.changes = changes;
+    this.lines = lines;
+  }
+};
+var ProfilesFetched = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var ProfileChosen = class extends CustomType {
   constructor($0) {
     super();
     this[0] = $0;
@@ -4417,6 +5475,30 @@ var FetchMessages = class extends CustomType {
     this[0] = $0;
   }
 };
+var PredicateChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var TreeViewChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var BodyViewChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var CopyPath = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
 var ClearMessages = class extends CustomType {
 };
 var HoverSettingsChanged = class extends CustomType {
@@ -4431,6 +5513,12 @@ var DeleteSettingsChanged = class extends CustomType {
     this[0] = $0;
   }
 };
+var PersistSettingsChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
 var ShowMessageCountChanged = class extends CustomType {
   constructor($0) {
     super();
@@ -4461,49 +5549,104 @@ var GoToEnd = class extends CustomType {
 };
 var Tick = class extends CustomType {
 };
-function config_decoder() {
-  return field2(
-    "profile_name",
-    string3,
-    (profile_name) => {
-      return field2(
-        "queue_url",
-        string3,
-        (queue_url) => {
-          return field2(
-            "aws_config_source",
-            string3,
-            (aws_config_source) => {
-              return field2(
-                "context_key",
-                optional(string3),
-                (context_key) => {
-                  return field2(
-                    "subset_key",
-                    optional(string3),
-                    (subset_key) => {
-                      return success(
-                        new Config(
-                          profile_name,
-                          queue_url,
-                          aws_config_source,
-                          context_key,
-                          subset_key
-                        )
-                      );
-                    }
-                  );
-                }
-              );
-            }
-          );
-        }
-      );
+var PurgeConfirmationChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var PurgeBackupChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var PurgeQueue = class extends CustomType {
+};
+var QueuePurged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var PersistMessage = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var MessagesPersisted = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var CompareMessage = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
+var DiffFetched = class extends CustomType {
+  constructor($0, $1, $2) {
+    super();
+    this[0] = $0;
+    this[1] = $1;
+    this[2] = $2;
+  }
+};
+var CloseDiff = class extends CustomType {
+};
+function config_decoder() {
+  return field2(
+    "profile_name",
+    string3,
+    (profile_name) => {
+      return field2(
+        "queue_url",
+        string3,
+        (queue_url) => {
+          return field2(
+            "aws_config_source",
+            string3,
+            (aws_config_source) => {
+              return field2(
+                "context_key",
+                optional(string3),
+                (context_key) => {
+                  return field2(
+                    "subset_key",
+                    optional(string3),
+                    (subset_key) => {
+                      return field2(
+                        "read_only",
+                        bool2,
+                        (read_only) => {
+                          return success(
+                            new Config(
+                              profile_name,
+                              queue_url,
+                              aws_config_source,
+                              context_key,
+                              subset_key,
+                              read_only
+                            )
+                          );
+                        }
+                      );
+                    }
+                  );
+                }
+              );
+            }
+          );
+        }
+      );
     }
   );
 }
 function default_behaviours() {
-  return new Behaviours(true, false, false);
+  return new Behaviours(true, false, false, false);
 }
 function behaviours_decoder() {
   return field2(
@@ -4511,19 +5654,26 @@ function behaviours_decoder() {
     bool2,
     (delete_messages) => {
       return field2(
-        "select_on_hover",
+        "persist_messages",
         bool2,
-        (select_on_hover) => {
+        (persist_messages) => {
           return field2(
-            "show_message_count",
+            "select_on_hover",
             bool2,
-            (show_message_count) => {
-              return success(
-                new Behaviours(
-                  delete_messages,
-                  select_on_hover,
-                  show_message_count
-                )
+            (select_on_hover) => {
+              return field2(
+                "show_message_count",
+                bool2,
+                (show_message_count) => {
+                  return success(
+                    new Behaviours(
+                      delete_messages,
+                      persist_messages,
+                      select_on_hover,
+                      show_message_count
+                    )
+                  );
+                }
               );
             }
           );
@@ -4532,6 +5682,47 @@ function behaviours_decoder() {
     }
   );
 }
+function body_view_to_string(view2) {
+  if (view2 instanceof BodyFormatted) {
+    return "formatted";
+  } else if (view2 instanceof BodyRaw) {
+    return "raw";
+  } else {
+    return "subset";
+  }
+}
+function body_view_from_string(view2) {
+  if (view2 === "formatted") {
+    return new Ok(new BodyFormatted());
+  } else if (view2 === "raw") {
+    return new Ok(new BodyRaw());
+  } else if (view2 === "subset") {
+    return new Ok(new BodySubset());
+  } else {
+    return new Error(void 0);
+  }
+}
+function body_views(config) {
+  if (config instanceof Some) {
+    let $ = config[0].subset_key;
+    if ($ instanceof Some) {
+      return toList([new BodySubset(), new BodyFormatted(), new BodyRaw()]);
+    } else {
+      return toList([new BodyFormatted(), new BodyRaw()]);
+    }
+  } else {
+    return toList([new BodyFormatted(), new BodyRaw()]);
+  }
+}
+function default_body_view(config) {
+  let $ = body_views(config);
+  if ($ instanceof Empty) {
+    return new BodyFormatted();
+  } else {
+    let view2 = $.head;
+    return view2;
+  }
+}
 function message_details_decoder() {
   return field2(
     "id",
@@ -4541,20 +5732,66 @@ function message_details_decoder() {
         "body",
         string3,
         (body2) => {
-          return field2(
-            "context_key",
-            optional(string3),
-            (context_key) => {
-              return field2(
-                "context_value",
+          return optional_field(
+            "raw_body",
+            "",
+            string3,
+            (raw_body) => {
+              return optional_field(
+                "formatted_body",
+                new None(),
                 optional(string3),
-                (context_value) => {
+                (formatted_body) => {
                   return field2(
-                    "error",
+                    "context_key",
                     optional(string3),
-                    (error) => {
-                      return success(
-                        new Message(id2, body2, context_key, context_value, error)
+                    (context_key) => {
+                      return field2(
+                        "context_value",
+                        optional(string3),
+                        (context_value) => {
+                          return field2(
+                            "error",
+                            optional(string3),
+                            (error) => {
+                              return optional_field(
+                                "persisted_to",
+                                new None(),
+                                optional(string3),
+                                (persisted_to) => {
+                                  return optional_field(
+                                    "persist_error",
+                                    new None(),
+                                    optional(string3),
+                                    (persist_error) => {
+                                      return optional_field(
+                                        "delete_error",
+                                        new None(),
+                                        optional(string3),
+                                        (delete_error) => {
+                                          return success(
+                                            new Message(
+                                              id2,
+                                              body2,
+                                              raw_body,
+                                              formatted_body,
+                                              context_key,
+                                              context_value,
+                                              error,
+                                              persisted_to,
+                                              persist_error,
+                                              delete_error
+                                            )
+                                          );
+                                        }
+                                      );
+                                    }
+                                  );
+                                }
+                              );
+                            }
+                          );
+                        }
                       );
                     }
                   );
@@ -4567,15 +5804,303 @@ function message_details_decoder() {
     }
   );
 }
+function json_value_decoder() {
+  return recursive(
+    () => {
+      return one_of(
+        (() => {
+          let _pipe = string3;
+          return map4(_pipe, (var0) => {
+            return new JsonString(var0);
+          });
+        })(),
+        toList([
+          (() => {
+            let _pipe = int2;
+            return map4(_pipe, (var0) => {
+              return new JsonInt(var0);
+            });
+          })(),
+          (() => {
+            let _pipe = float3;
+            return map4(_pipe, (var0) => {
+              return new JsonFloat(var0);
+            });
+          })(),
+          (() => {
+            let _pipe = bool2;
+            return map4(_pipe, (var0) => {
+              return new JsonBool(var0);
+            });
+          })(),
+          (() => {
+            let _pipe = list2(json_value_decoder());
+            return map4(_pipe, (var0) => {
+              return new JsonArray(var0);
+            });
+          })(),
+          (() => {
+            let _pipe = dict3(string3, json_value_decoder());
+            return map4(
+              _pipe,
+              (d) => {
+                let _pipe$1 = d;
+                let _pipe$2 = map_to_list(_pipe$1);
+                let _pipe$3 = sort(
+                  _pipe$2,
+                  (a2, b) => {
+                    return compare(a2[0], b[0]);
+                  }
+                );
+                return new JsonObject(_pipe$3);
+              }
+            );
+          })(),
+          (() => {
+            let _pipe = optional(failure(new JsonNull(), "null"));
+            return map4(_pipe, (_) => {
+              return new JsonNull();
+            });
+          })()
+        ])
+      );
+    }
+  );
+}
+function queue_depth_sample_decoder() {
+  return field2(
+    "visible",
+    int2,
+    (visible) => {
+      return field2(
+        "in_flight",
+        int2,
+        (in_flight) => {
+          return success(new QueueDepthSample(visible, in_flight));
+        }
+      );
+    }
+  );
+}
+function queue_rates_decoder() {
+  return field2(
+    "growth",
+    float3,
+    (growth) => {
+      return field2(
+        "shrinkage",
+        float3,
+        (shrinkage) => {
+          return field2(
+            "known",
+            bool2,
+            (known) => {
+              return success(new QueueRates(growth, shrinkage, known));
+            }
+          );
+        }
+      );
+    }
+  );
+}
 function message_count_decoder() {
   return field2(
     "count",
     int2,
     (count) => {
-      return success(new MessageCount(count));
+      return field2(
+        "in_flight",
+        int2,
+        (in_flight) => {
+          return field2(
+            "history",
+            list2(queue_depth_sample_decoder()),
+            (history) => {
+              return field2(
+                "rates",
+                queue_rates_decoder(),
+                (rates) => {
+                  return success(
+                    new MessageCount(count, in_flight, history, rates)
+                  );
+                }
+              );
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function purge_result_decoder() {
+  return optional_field(
+    "backup_path",
+    new None(),
+    optional(string3),
+    (backup_path) => {
+      return field2(
+        "backed_up",
+        int2,
+        (backed_up) => {
+          return optional_field(
+            "remaining",
+            0,
+            int2,
+            (remaining) => {
+              return success(
+                new PurgeResult(backup_path, backed_up, remaining)
+              );
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function persisted_message_decoder() {
+  return field2(
+    "message_id",
+    string3,
+    (message_id) => {
+      return optional_field(
+        "path",
+        new None(),
+        optional(string3),
+        (path) => {
+          return optional_field(
+            "error",
+            new None(),
+            optional(string3),
+            (error) => {
+              return success(new PersistedMessage(message_id, path, error));
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function persist_response_decoder() {
+  return field2(
+    "messages",
+    list2(persisted_message_decoder()),
+    (messages) => {
+      return success(messages);
+    }
+  );
+}
+function diff_change_kind_decoder() {
+  return then$2(
+    string3,
+    (kind) => {
+      if (kind === "added") {
+        return success(new DiffAdded());
+      } else if (kind === "removed") {
+        return success(new DiffRemoved());
+      } else if (kind === "changed") {
+        return success(new DiffChanged());
+      } else {
+        return failure(new DiffChanged(), "DiffChangeKind");
+      }
+    }
+  );
+}
+function diff_change_decoder() {
+  return field2(
+    "path",
+    string3,
+    (path) => {
+      return field2(
+        "kind",
+        diff_change_kind_decoder(),
+        (kind) => {
+          return optional_field(
+            "before",
+            new None(),
+            optional(string3),
+            (before) => {
+              return optional_field(
+                "after",
+                new None(),
+                optional(string3),
+                (after) => {
+                  return success(new DiffChange(path, kind, before, after));
+                }
+              );
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function diff_line_kind_decoder() {
+  return then$2(
+    string3,
+    (kind) => {
+      if (kind === "same") {
+        return success(new DiffLineSame());
+      } else if (kind === "added") {
+        return success(new DiffLineAdded());
+      } else if (kind === "removed") {
+        return success(new DiffLineRemoved());
+      } else {
+        return failure(new DiffLineSame(), "DiffLineKind");
+      }
+    }
+  );
+}
+function diff_line_decoder() {
+  return field2(
+    "kind",
+    diff_line_kind_decoder(),
+    (kind) => {
+      return field2(
+        "text",
+        string3,
+        (text3) => {
+          return success(new DiffLine(kind, text3));
+        }
+      );
+    }
+  );
+}
+function message_diff_decoder() {
+  return field2(
+    "structural",
+    bool2,
+    (structural) => {
+      return optional_field(
+        "changes",
+        toList([]),
+        list2(diff_change_decoder()),
+        (changes) => {
+          return optional_field(
+            "lines",
+            toList([]),
+            list2(diff_line_decoder()),
+            (lines) => {
+              return success(new MessageDiff(structural, changes, lines));
+            }
+          );
+        }
+      );
     }
   );
 }
+function diff_is_identical(diff) {
+  let $ = diff.structural;
+  if ($) {
+    return is_empty(diff.changes);
+  } else {
+    return all(
+      diff.lines,
+      (l) => {
+        return isEqual(l.kind, new DiffLineSame());
+      }
+    );
+  }
+}
 
 // build/dev/javascript/cueitup/effects.mjs
 function schedule_next_tick(delay_seconds) {
@@ -4591,7 +6116,17 @@ function schedule_next_tick(delay_seconds) {
     }
   );
 }
+function copy_to_clipboard(text3) {
+  return from(
+    (_) => {
+      writeText(text3);
+      return void 0;
+    }
+  );
+}
+var FILEPATH = "src/effects.gleam";
 var dev = false;
+var dev_auth_token = "";
 function base_url() {
   let $ = dev;
   if ($) {
@@ -4600,60 +6135,177 @@ function base_url() {
     return location();
   }
 }
-function fetch_config() {
+function get3(url, expect) {
+  let $ = dev;
+  if ($) {
+    let $1 = to(url);
+    if (!($1 instanceof Ok)) {
+      throw makeError(
+        "let_assert",
+        FILEPATH,
+        "effects",
+        40,
+        "get",
+        "Pattern match failed, no pattern matched the value.",
+        { value: $1, start: 1012, end: 1048, pattern_start: 1023, pattern_end: 1030 }
+      );
+    }
+    let req = $1[0];
+    let _pipe = req;
+    let _pipe$1 = set_header(
+      _pipe,
+      "authorization",
+      "Bearer " + dev_auth_token
+    );
+    return send2(_pipe$1, expect);
+  } else {
+    return get(url, expect);
+  }
+}
+function post2(url, body2, expect) {
+  let $ = dev;
+  if ($) {
+    let $1 = to(url);
+    if (!($1 instanceof Ok)) {
+      throw makeError(
+        "let_assert",
+        FILEPATH,
+        "effects",
+        56,
+        "post",
+        "Pattern match failed, no pattern matched the value.",
+        { value: $1, start: 1378, end: 1414, pattern_start: 1389, pattern_end: 1396 }
+      );
+    }
+    let req = $1[0];
+    let _pipe = req;
+    let _pipe$1 = set_method(_pipe, new Post());
+    let _pipe$2 = set_header(_pipe$1, "content-type", "application/json");
+    let _pipe$3 = set_header(
+      _pipe$2,
+      "authorization",
+      "Bearer " + dev_auth_token
+    );
+    let _pipe$4 = set_body(_pipe$3, to_string3(body2));
+    return send2(_pipe$4, expect);
+  } else {
+    return post(url, body2, expect);
+  }
+}
+function profile_url(profile_name, path) {
+  return base_url() + "api/" + percent_encode(profile_name) + "/" + path;
+}
+function fetch_profiles() {
   let expect = expect_json(
-    config_decoder(),
+    list2(config_decoder()),
     (var0) => {
-      return new ConfigFetched(var0);
+      return new ProfilesFetched(var0);
     }
   );
-  return get(base_url() + "api/config", expect);
+  return get3(base_url() + "api/profiles", expect);
 }
-function fetch_behaviours() {
+function fetch_behaviours(profile_name) {
   let expect = expect_json(
     behaviours_decoder(),
     (var0) => {
       return new BehavioursFetched(var0);
     }
   );
-  return get(base_url() + "api/behaviours", expect);
+  return get3(profile_url(profile_name, "behaviours"), expect);
 }
-function fetch_message_count() {
+function fetch_message_count(profile_name) {
   let expect = expect_json(
     message_count_decoder(),
     (var0) => {
       return new MessageCountFetched(var0);
     }
   );
-  return get(base_url() + "api/message-count", expect);
+  return get3(profile_url(profile_name, "message-count"), expect);
+}
+function where_query_param(predicate) {
+  let $ = trim(predicate);
+  if ($ === "") {
+    return "";
+  } else {
+    let p2 = $;
+    return "&where=" + percent_encode(p2);
+  }
+}
+function bool_query_param(value3) {
+  if (value3) {
+    return "true";
+  } else {
+    return "false";
+  }
 }
-function fetch_messages(num, delete$2) {
+function fetch_messages(profile_name, num, delete$2, persist, predicate) {
   let expect = expect_json(
     list2(message_details_decoder()),
     (var0) => {
       return new MessagesFetched(var0);
     }
   );
-  let _block;
-  if (delete$2) {
-    _block = "true";
-  } else {
-    _block = "false";
-  }
-  let delete_query_param = _block;
-  return get(
-    base_url() + "api/fetch?num=" + (() => {
+  return get3(
+    profile_url(profile_name, "fetch") + "?num=" + (() => {
       let _pipe = num;
       return to_string(_pipe);
-    })() + "&delete=" + delete_query_param,
+    })() + "&delete=" + bool_query_param(delete$2) + "&persist=" + bool_query_param(
+      persist
+    ) + where_query_param(predicate),
     expect
   );
 }
-
+function persist_messages(profile_name, message_ids) {
+  let expect = expect_json(
+    persist_response_decoder(),
+    (var0) => {
+      return new MessagesPersisted(var0);
+    }
+  );
+  return post2(
+    profile_url(profile_name, "persist"),
+    object2(toList([["message_ids", array2(message_ids, string4)]])),
+    expect
+  );
+}
+function fetch_diff(profile_name, from2, to2) {
+  let expect = expect_json(
+    message_diff_decoder(),
+    (result) => {
+      return new DiffFetched(from2, to2, result);
+    }
+  );
+  return get3(
+    profile_url(profile_name, "diff") + "?from=" + percent_encode(from2) + "&to=" + percent_encode(
+      to2
+    ),
+    expect
+  );
+}
+function purge_queue(profile_name, confirmation, backup) {
+  let expect = expect_json(
+    purge_result_decoder(),
+    (var0) => {
+      return new QueuePurged(var0);
+    }
+  );
+  return post2(
+    profile_url(profile_name, "purge"),
+    object2(
+      toList([
+        ["confirm", string4(confirmation)],
+        ["backup", bool3(backup)]
+      ])
+    ),
+    expect
+  );
+}
+
 // build/dev/javascript/cueitup/model.mjs
 var Model2 = class extends CustomType {
-  constructor(config, behaviours, messages, messages_cache, http_error, current_message, message_count, fetching, debug) {
+  constructor(profiles, config, behaviours, messages, messages_cache, http_error, current_message, message_count, fetching, predicate, tree_view, body_view, diff_base, diff, purge_confirmation, purge_backup, purging, purge_result, debug) {
     super();
+    this.profiles = profiles;
     this.config = config;
     this.behaviours = behaviours;
     this.messages = messages;
@@ -4662,11 +6314,21 @@ var Model2 = class extends CustomType {
     this.current_message = current_message;
     this.message_count = message_count;
     this.fetching = fetching;
+    this.predicate = predicate;
+    this.tree_view = tree_view;
+    this.body_view = body_view;
+    this.diff_base = diff_base;
+    this.diff = diff;
+    this.purge_confirmation = purge_confirmation;
+    this.purge_backup = purge_backup;
+    this.purging = purging;
+    this.purge_result = purge_result;
     this.debug = debug;
   }
 };
 function init_model() {
   return new Model2(
+    toList([]),
     new None(),
     default_behaviours(),
     toList([]),
@@ -4675,6 +6337,15 @@ function init_model() {
     new None(),
     new None(),
     false,
+    "",
+    false,
+    new BodyFormatted(),
+    new None(),
+    new None(),
+    "",
+    false,
+    false,
+    new None(),
     false
   );
 }
@@ -4682,33 +6353,51 @@ function init_model() {
 // build/dev/javascript/cueitup/update.mjs
 var message_count_interval_secs = 5;
 function update(model, msg) {
-  if (msg instanceof ConfigFetched) {
+  if (msg instanceof ProfilesFetched) {
     let res = msg[0];
     if (res instanceof Ok) {
-      let c = res[0];
-      return [
+      let profiles = res[0];
+      let _block;
+      let _record = model;
+      _block = new Model2(
+        profiles,
         (() => {
-          let _record = model;
-          return new Model2(
-            new Some(c),
-            _record.behaviours,
-            _record.messages,
-            _record.messages_cache,
-            _record.http_error,
-            _record.current_message,
-            _record.message_count,
-            _record.fetching,
-            _record.debug
-          );
+          let _pipe = profiles;
+          let _pipe$1 = first(_pipe);
+          return from_result(_pipe$1);
         })(),
-        none()
-      ];
+        _record.behaviours,
+        _record.messages,
+        _record.messages_cache,
+        _record.http_error,
+        _record.current_message,
+        _record.message_count,
+        _record.fetching,
+        _record.predicate,
+        _record.tree_view,
+        (() => {
+          let _pipe = profiles;
+          let _pipe$1 = first(_pipe);
+          let _pipe$2 = from_result(_pipe$1);
+          return default_body_view(_pipe$2);
+        })(),
+        _record.diff_base,
+        _record.diff,
+        _record.purge_confirmation,
+        _record.purge_backup,
+        _record.purging,
+        _record.purge_result,
+        _record.debug
+      );
+      let model$1 = _block;
+      return [model$1, fetch_behaviours2(model$1)];
     } else {
       let e = res[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
@@ -4717,264 +6406,904 @@ function update(model, msg) {
             _record.current_message,
             _record.message_count,
             _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
         none()
       ];
     }
+  } else if (msg instanceof ProfileChosen) {
+    let profile_name = msg[0];
+    let $ = (() => {
+      let _pipe = model.profiles;
+      return find2(_pipe, (c) => {
+        return c.profile_name === profile_name;
+      });
+    })();
+    if ($ instanceof Ok) {
+      let c = $[0];
+      let _block;
+      let _record = model;
+      _block = new Model2(
+        _record.profiles,
+        new Some(c),
+        _record.behaviours,
+        toList([]),
+        new_map(),
+        new None(),
+        new None(),
+        new None(),
+        _record.fetching,
+        _record.predicate,
+        _record.tree_view,
+        default_body_view(new Some(c)),
+        new None(),
+        new None(),
+        "",
+        false,
+        _record.purging,
+        new None(),
+        _record.debug
+      );
+      let model$1 = _block;
+      return [model$1, fetch_behaviours2(model$1)];
+    } else {
+      return [model, none()];
+    }
   } else if (msg instanceof BehavioursFetched) {
     let res = msg[0];
     if (res instanceof Ok) {
       let b = res[0];
       let $ = b.show_message_count;
+      let $1 = model.behaviours.show_message_count;
       if ($) {
+        if ($1) {
+          return [
+            (() => {
+              let _record = model;
+              return new Model2(
+                _record.profiles,
+                _record.config,
+                b,
+                _record.messages,
+                _record.messages_cache,
+                _record.http_error,
+                _record.current_message,
+                _record.message_count,
+                _record.fetching,
+                _record.predicate,
+                _record.tree_view,
+                _record.body_view,
+                _record.diff_base,
+                _record.diff,
+                _record.purge_confirmation,
+                _record.purge_backup,
+                _record.purging,
+                _record.purge_result,
+                _record.debug
+              );
+            })(),
+            fetch_message_count2(model)
+          ];
+        } else {
+          return [
+            (() => {
+              let _record = model;
+              return new Model2(
+                _record.profiles,
+                _record.config,
+                b,
+                _record.messages,
+                _record.messages_cache,
+                _record.http_error,
+                _record.current_message,
+                _record.message_count,
+                _record.fetching,
+                _record.predicate,
+                _record.tree_view,
+                _record.body_view,
+                _record.diff_base,
+                _record.diff,
+                _record.purge_confirmation,
+                _record.purge_backup,
+                _record.purging,
+                _record.purge_result,
+                _record.debug
+              );
+            })(),
+            batch(
+              toList([
+                fetch_message_count2(model),
+                schedule_next_tick(message_count_interval_secs)
+              ])
+            )
+          ];
+        }
+      } else {
         return [
           (() => {
             let _record = model;
             return new Model2(
+              _record.profiles,
               _record.config,
               b,
               _record.messages,
               _record.messages_cache,
               _record.http_error,
               _record.current_message,
-              _record.message_count,
+              new None(),
               _record.fetching,
+              _record.predicate,
+              _record.tree_view,
+              _record.body_view,
+              _record.diff_base,
+              _record.diff,
+              _record.purge_confirmation,
+              _record.purge_backup,
+              _record.purging,
+              _record.purge_result,
               _record.debug
             );
           })(),
-          batch(
-            toList([
-              fetch_message_count(),
-              schedule_next_tick(message_count_interval_secs)
-            ])
-          )
+          none()
         ];
-      } else {
-        return [
+      }
+    } else {
+      return [model, none()];
+    }
+  } else if (msg instanceof FetchMessages) {
+    let num = msg[0];
+    let $ = model.config;
+    if ($ instanceof Some) {
+      let c = $[0];
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            _record.messages,
+            _record.messages_cache,
+            new None(),
+            _record.current_message,
+            _record.message_count,
+            true,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        fetch_messages(
+          c.profile_name,
+          num,
+          model.behaviours.delete_messages && !c.read_only,
+          model.behaviours.persist_messages,
+          model.predicate
+        )
+      ];
+    } else {
+      return [model, none()];
+    }
+  } else if (msg instanceof PredicateChanged) {
+    let predicate = msg[0];
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          _record.behaviours,
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof TreeViewChanged) {
+    let selected = msg[0];
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          _record.behaviours,
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          selected,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof BodyViewChanged) {
+    let view2 = msg[0];
+    let $ = body_view_from_string(view2);
+    if ($ instanceof Ok) {
+      let v = $[0];
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            _record.messages,
+            _record.messages_cache,
+            _record.http_error,
+            _record.current_message,
+            _record.message_count,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            v,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        none()
+      ];
+    } else {
+      return [model, none()];
+    }
+  } else if (msg instanceof CopyPath) {
+    let path = msg[0];
+    return [model, copy_to_clipboard(path)];
+  } else if (msg instanceof ClearMessages) {
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          _record.behaviours,
+          toList([]),
+          new_map(),
+          new None(),
+          new None(),
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          new None(),
+          new None(),
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof HoverSettingsChanged) {
+    let selected = msg[0];
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
           (() => {
-            let _record = model;
-            return new Model2(
-              _record.config,
-              b,
-              _record.messages,
-              _record.messages_cache,
-              _record.http_error,
-              _record.current_message,
-              _record.message_count,
-              _record.fetching,
-              _record.debug
+            let _record$1 = model.behaviours;
+            return new Behaviours(
+              _record$1.delete_messages,
+              _record$1.persist_messages,
+              selected,
+              _record$1.show_message_count
             );
           })(),
-          none()
-        ];
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof DeleteSettingsChanged) {
+    let selected = msg[0];
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          (() => {
+            let _record$1 = model.behaviours;
+            return new Behaviours(
+              selected,
+              _record$1.persist_messages,
+              _record$1.select_on_hover,
+              _record$1.show_message_count
+            );
+          })(),
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof PersistSettingsChanged) {
+    let selected = msg[0];
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          (() => {
+          let _record$1 = model.behaviours;
+          return new Behaviours(
+            _record$1.delete_messages,
+            selected,
+            _record$1.select_on_hover,
+            _record$1.show_message_count
+          );
+        })(),
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else if (msg instanceof ShowMessageCountChanged) {
+    let selected = msg[0];
+    if (selected) {
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            (() => {
+              let _record$1 = model.behaviours;
+              return new Behaviours(
+                _record$1.delete_messages,
+                _record$1.persist_messages,
+                _record$1.select_on_hover,
+                selected
+              );
+            })(),
+            _record.messages,
+            _record.messages_cache,
+            _record.http_error,
+            _record.current_message,
+            _record.message_count,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        batch(
+          toList([
+            fetch_message_count2(model),
+            schedule_next_tick(message_count_interval_secs)
+          ])
+        )
+      ];
+    } else {
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            (() => {
+              let _record$1 = model.behaviours;
+              return new Behaviours(
+                _record$1.delete_messages,
+                _record$1.persist_messages,
+                _record$1.select_on_hover,
+                selected
+              );
+            })(),
+            _record.messages,
+            _record.messages_cache,
+            _record.http_error,
+            _record.current_message,
+            new None(),
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        none()
+      ];
+    }
+  } else if (msg instanceof MessageChosen) {
+    let index5 = msg[0];
+    let _block;
+    let _pipe = model.messages_cache;
+    _block = map_get(_pipe, index5);
+    let maybe_message = _block;
+    if (maybe_message instanceof Ok) {
+      let msg$1 = maybe_message[0];
+      let _block$1;
+      let $ = model.current_message;
+      if ($ instanceof Some) {
+        let i = $[0][0];
+        if (i === index5) {
+          _block$1 = model.diff;
+        } else {
+          _block$1 = new None();
+        }
+      } else {
+        _block$1 = new None();
       }
+      let diff = _block$1;
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            _record.messages,
+            _record.messages_cache,
+            _record.http_error,
+            new Some([index5, msg$1]),
+            _record.message_count,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        none()
+      ];
     } else {
       return [model, none()];
     }
-  } else if (msg instanceof FetchMessages) {
-    let num = msg[0];
-    if (num === 1) {
+  } else if (msg instanceof MessagesFetched) {
+    let result = msg[0];
+    if (result instanceof Ok) {
+      let messages = result[0];
+      let _block;
+      let _pipe = model.messages;
+      _block = append(_pipe, messages);
+      let updated_messages = _block;
+      let _block$1;
+      let _pipe$1 = updated_messages;
+      let _pipe$2 = index_map(_pipe$1, (m, i) => {
+        return [i, m];
+      });
+      _block$1 = from_list(_pipe$2);
+      let messages_cache = _block$1;
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            updated_messages,
+            messages_cache,
+            _record.http_error,
+            _record.current_message,
+            _record.message_count,
+            false,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        none()
+      ];
+    } else {
+      let e = result[0];
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            _record.messages,
+            _record.messages_cache,
+            new Some(e),
+            _record.current_message,
+            _record.message_count,
+            false,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
+            _record.debug
+          );
+        })(),
+        none()
+      ];
+    }
+  } else if (msg instanceof MessageCountFetched) {
+    let res = msg[0];
+    if (res instanceof Ok) {
+      let c = res[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
             _record.messages_cache,
-            new None(),
+            _record.http_error,
             _record.current_message,
-            _record.message_count,
-            true,
+            new Some(c),
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
-        fetch_messages(num, model.behaviours.delete_messages)
+        none()
       ];
     } else {
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
             _record.messages_cache,
-            new None(),
+            _record.http_error,
             _record.current_message,
-            _record.message_count,
-            true,
+            new None(),
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
-        fetch_messages(num, model.behaviours.delete_messages)
+        none()
       ];
     }
-  } else if (msg instanceof ClearMessages) {
+  } else if (msg instanceof GoToStart) {
+    return [model, none()];
+  } else if (msg instanceof GoToEnd) {
+    return [model, none()];
+  } else if (msg instanceof PurgeConfirmationChanged) {
+    let confirmation = msg[0];
     return [
       (() => {
         let _record = model;
         return new Model2(
+          _record.profiles,
           _record.config,
           _record.behaviours,
-          toList([]),
-          new_map(),
-          new None(),
-          new None(),
-          _record.message_count,
-          _record.fetching,
-          _record.debug
-        );
-      })(),
-      none()
-    ];
-  } else if (msg instanceof HoverSettingsChanged) {
-    let selected = msg[0];
-    return [
-      (() => {
-        let _record = model;
-        return new Model2(
-          _record.config,
-          (() => {
-            let _record$1 = model.behaviours;
-            return new Behaviours(
-              _record$1.delete_messages,
-              selected,
-              _record$1.show_message_count
-            );
-          })(),
           _record.messages,
           _record.messages_cache,
           _record.http_error,
           _record.current_message,
           _record.message_count,
           _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
           _record.debug
         );
       })(),
       none()
     ];
-  } else if (msg instanceof DeleteSettingsChanged) {
+  } else if (msg instanceof PurgeBackupChanged) {
     let selected = msg[0];
     return [
       (() => {
         let _record = model;
         return new Model2(
+          _record.profiles,
           _record.config,
-          (() => {
-            let _record$1 = model.behaviours;
-            return new Behaviours(
-              selected,
-              _record$1.select_on_hover,
-              _record$1.show_message_count
-            );
-          })(),
+          _record.behaviours,
           _record.messages,
           _record.messages_cache,
           _record.http_error,
           _record.current_message,
           _record.message_count,
           _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          _record.diff,
+          _record.purge_confirmation,
+          selected,
+          _record.purging,
+          _record.purge_result,
           _record.debug
         );
       })(),
       none()
     ];
-  } else if (msg instanceof ShowMessageCountChanged) {
-    let selected = msg[0];
-    if (selected) {
+  } else if (msg instanceof PurgeQueue) {
+    let $ = model.config;
+    if ($ instanceof Some) {
+      let c = $[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
-            (() => {
-              let _record$1 = model.behaviours;
-              return new Behaviours(
-                _record$1.delete_messages,
-                _record$1.select_on_hover,
-                selected
-              );
-            })(),
+            _record.behaviours,
             _record.messages,
             _record.messages_cache,
-            _record.http_error,
+            new None(),
             _record.current_message,
             _record.message_count,
             _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            true,
+            new None(),
             _record.debug
           );
         })(),
-        batch(
-          toList([
-            fetch_message_count(),
-            schedule_next_tick(message_count_interval_secs)
-          ])
+        purge_queue(
+          c.profile_name,
+          model.purge_confirmation,
+          model.purge_backup
         )
       ];
     } else {
+      return [model, none()];
+    }
+  } else if (msg instanceof QueuePurged) {
+    let result = msg[0];
+    if (result instanceof Ok) {
+      let r = result[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
-            (() => {
-              let _record$1 = model.behaviours;
-              return new Behaviours(
-                _record$1.delete_messages,
-                _record$1.select_on_hover,
-                selected
-              );
-            })(),
+            _record.behaviours,
             _record.messages,
             _record.messages_cache,
             _record.http_error,
             _record.current_message,
-            new None(),
+            _record.message_count,
             _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            "",
+            _record.purge_backup,
+            false,
+            new Some(r),
+            _record.debug
+          );
+        })(),
+        fetch_message_count2(model)
+      ];
+    } else {
+      let e = result[0];
+      return [
+        (() => {
+          let _record = model;
+          return new Model2(
+            _record.profiles,
+            _record.config,
+            _record.behaviours,
+            _record.messages,
+            _record.messages_cache,
+            new Some(e),
+            _record.current_message,
+            _record.message_count,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            false,
+            _record.purge_result,
             _record.debug
           );
         })(),
         none()
       ];
     }
-  } else if (msg instanceof MessageChosen) {
-    let index5 = msg[0];
-    let _block;
-    let _pipe = model.messages_cache;
-    _block = map_get(_pipe, index5);
-    let maybe_message = _block;
-    if (maybe_message instanceof Ok) {
-      let msg$1 = maybe_message[0];
+  } else if (msg instanceof PersistMessage) {
+    let message_id = msg[0];
+    let $ = model.config;
+    if ($ instanceof Some) {
+      let c = $[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
             _record.messages_cache,
-            _record.http_error,
-            new Some([index5, msg$1]),
+            new None(),
+            _record.current_message,
             _record.message_count,
             _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
-        none()
+        persist_messages(c.profile_name, toList([message_id]))
       ];
     } else {
       return [model, none()];
     }
-  } else if (msg instanceof MessagesFetched) {
+  } else if (msg instanceof MessagesPersisted) {
     let result = msg[0];
     if (result instanceof Ok) {
-      let messages = result[0];
+      let persisted = result[0];
       let _block;
       let _pipe = model.messages;
-      _block = append(_pipe, messages);
+      _block = map2(_pipe, (m) => {
+        return with_persist_outcome(m, persisted);
+      });
       let updated_messages = _block;
       let _block$1;
       let _pipe$1 = updated_messages;
@@ -4983,18 +7312,39 @@ function update(model, msg) {
       });
       _block$1 = from_list(_pipe$2);
       let messages_cache = _block$1;
+      let _block$2;
+      let _pipe$3 = model.current_message;
+      _block$2 = map(
+        _pipe$3,
+        (current) => {
+          let i = current[0];
+          let m = current[1];
+          return [i, with_persist_outcome(m, persisted)];
+        }
+      );
+      let current_message = _block$2;
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             updated_messages,
             messages_cache,
             _record.http_error,
-            _record.current_message,
+            current_message,
             _record.message_count,
-            false,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
@@ -5006,6 +7356,7 @@ function update(model, msg) {
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
@@ -5013,72 +7364,280 @@ function update(model, msg) {
             new Some(e),
             _record.current_message,
             _record.message_count,
-            false,
+            _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
         none()
       ];
     }
-  } else if (msg instanceof MessageCountFetched) {
-    let res = msg[0];
-    if (res instanceof Ok) {
-      let c = res[0];
+  } else if (msg instanceof CompareMessage) {
+    let message_id = msg[0];
+    let $ = model.config;
+    let $1 = model.diff_base;
+    if ($ instanceof Some) {
+      if ($1 instanceof Some) {
+        let base = $1[0];
+        if (base === message_id) {
+          return [
+            (() => {
+              let _record = model;
+              return new Model2(
+                _record.profiles,
+                _record.config,
+                _record.behaviours,
+                _record.messages,
+                _record.messages_cache,
+                _record.http_error,
+                _record.current_message,
+                _record.message_count,
+                _record.fetching,
+                _record.predicate,
+                _record.tree_view,
+                _record.body_view,
+                new None(),
+                new None(),
+                _record.purge_confirmation,
+                _record.purge_backup,
+                _record.purging,
+                _record.purge_result,
+                _record.debug
+              );
+            })(),
+            none()
+          ];
+        } else {
+          let c = $[0];
+          let base$1 = $1[0];
+          return [
+            (() => {
+              let _record = model;
+              return new Model2(
+                _record.profiles,
+                _record.config,
+                _record.behaviours,
+                _record.messages,
+                _record.messages_cache,
+                new None(),
+                _record.current_message,
+                _record.message_count,
+                _record.fetching,
+                _record.predicate,
+                _record.tree_view,
+                _record.body_view,
+                _record.diff_base,
+                _record.diff,
+                _record.purge_confirmation,
+                _record.purge_backup,
+                _record.purging,
+                _record.purge_result,
+                _record.debug
+              );
+            })(),
+            fetch_diff(c.profile_name, base$1, message_id)
+          ];
+        }
+      } else {
+        return [
+          (() => {
+            let _record = model;
+            return new Model2(
+              _record.profiles,
+              _record.config,
+              _record.behaviours,
+              _record.messages,
+              _record.messages_cache,
+              _record.http_error,
+              _record.current_message,
+              _record.message_count,
+              _record.fetching,
+              _record.predicate,
+              _record.tree_view,
+              _record.body_view,
+              new Some(message_id),
+              _record.diff,
+              _record.purge_confirmation,
+              _record.purge_backup,
+              _record.purging,
+              _record.purge_result,
+              _record.debug
+            );
+          })(),
+          none()
+        ];
+      }
+    } else {
+      return [model, none()];
+    }
+  } else if (msg instanceof DiffFetched) {
+    let from2 = msg[0];
+    let to2 = msg[1];
+    let result = msg[2];
+    let $ = model.diff_base;
+    if (result instanceof Ok) {
+      if ($ instanceof Some) {
+        let d = result[0];
+        let base = $[0];
+        if (base === from2) {
+          return [
+            (() => {
+              let _record = model;
+              return new Model2(
+                _record.profiles,
+                _record.config,
+                _record.behaviours,
+                _record.messages,
+                _record.messages_cache,
+                _record.http_error,
+                _record.current_message,
+                _record.message_count,
+                _record.fetching,
+                _record.predicate,
+                _record.tree_view,
+                _record.body_view,
+                _record.diff_base,
+                new Some([from2, to2, d]),
+                _record.purge_confirmation,
+                _record.purge_backup,
+                _record.purging,
+                _record.purge_result,
+                _record.debug
+              );
+            })(),
+            none()
+          ];
+        } else {
+          return [model, none()];
+        }
+      } else {
+        return [model, none()];
+      }
+    } else {
+      let e = result[0];
       return [
         (() => {
           let _record = model;
           return new Model2(
+            _record.profiles,
             _record.config,
             _record.behaviours,
             _record.messages,
             _record.messages_cache,
-            _record.http_error,
+            new Some(e),
             _record.current_message,
-            new Some(c.count),
+            _record.message_count,
             _record.fetching,
+            _record.predicate,
+            _record.tree_view,
+            _record.body_view,
+            _record.diff_base,
+            _record.diff,
+            _record.purge_confirmation,
+            _record.purge_backup,
+            _record.purging,
+            _record.purge_result,
             _record.debug
           );
         })(),
         none()
       ];
-    } else {
+    }
+  } else if (msg instanceof CloseDiff) {
+    return [
+      (() => {
+        let _record = model;
+        return new Model2(
+          _record.profiles,
+          _record.config,
+          _record.behaviours,
+          _record.messages,
+          _record.messages_cache,
+          _record.http_error,
+          _record.current_message,
+          _record.message_count,
+          _record.fetching,
+          _record.predicate,
+          _record.tree_view,
+          _record.body_view,
+          _record.diff_base,
+          new None(),
+          _record.purge_confirmation,
+          _record.purge_backup,
+          _record.purging,
+          _record.purge_result,
+          _record.debug
+        );
+      })(),
+      none()
+    ];
+  } else {
+    let $ = model.behaviours.show_message_count;
+    if ($) {
       return [
-        (() => {
-          let _record = model;
-          return new Model2(
-            _record.config,
-            _record.behaviours,
-            _record.messages,
-            _record.messages_cache,
-            _record.http_error,
-            _record.current_message,
-            new None(),
-            _record.fetching,
-            _record.debug
-          );
-        })(),
-        none()
+        model,
+        batch(
+          toList([
+            fetch_message_count2(model),
+            schedule_next_tick(message_count_interval_secs)
+          ])
+        )
       ];
+    } else {
+      return [model, none()];
     }
-  } else if (msg instanceof GoToStart) {
-    return [model, none()];
-  } else if (msg instanceof GoToEnd) {
-    return [model, none()];
+  }
+}
+function with_persist_outcome(message, persisted) {
+  let $ = (() => {
+    let _pipe = persisted;
+    return find2(_pipe, (p2) => {
+      return p2.message_id === message.id;
+    });
+  })();
+  if ($ instanceof Ok) {
+    let p2 = $[0];
+    let _record = message;
+    return new Message(
+      _record.id,
+      _record.body,
+      _record.raw_body,
+      _record.formatted_body,
+      _record.context_key,
+      _record.context_value,
+      _record.error,
+      p2.path,
+      p2.error,
+      _record.delete_error
+    );
+  } else {
+    return message;
+  }
+}
+function fetch_behaviours2(model) {
+  let $ = model.config;
+  if ($ instanceof Some) {
+    let c = $[0];
+    return fetch_behaviours(c.profile_name);
+  } else {
+    return none();
+  }
+}
+function fetch_message_count2(model) {
+  let $ = model.config;
+  if ($ instanceof Some) {
+    let c = $[0];
+    return fetch_message_count(c.profile_name);
   } else {
-    let $ = model.behaviours.show_message_count;
-    if ($) {
-      return [
-        model,
-        batch(
-          toList([
-            fetch_message_count(),
-            schedule_next_tick(message_count_interval_secs)
-          ])
-        )
-      ];
-    } else {
-      return [model, none()];
-    }
+    return none();
   }
 }
 
@@ -5107,6 +7666,24 @@ function span(attrs, children2) {
 function strong(attrs, children2) {
   return element("strong", attrs, children2);
 }
+function table(attrs, children2) {
+  return element("table", attrs, children2);
+}
+function tbody(attrs, children2) {
+  return element("tbody", attrs, children2);
+}
+function td(attrs, children2) {
+  return element("td", attrs, children2);
+}
+function th(attrs, children2) {
+  return element("th", attrs, children2);
+}
+function thead(attrs, children2) {
+  return element("thead", attrs, children2);
+}
+function tr(attrs, children2) {
+  return element("tr", attrs, children2);
+}
 function button(attrs, children2) {
   return element("button", attrs, children2);
 }
@@ -5116,6 +7693,18 @@ function input(attrs) {
 function label(attrs, children2) {
   return element("label", attrs, children2);
 }
+function option(attrs, label2) {
+  return element("option", attrs, toList([text(label2)]));
+}
+function select(attrs, children2) {
+  return element("select", attrs, children2);
+}
+function details(attrs, children2) {
+  return element("details", attrs, children2);
+}
+function summary(attrs, children2) {
+  return element("summary", attrs, children2);
+}
 
 // build/dev/javascript/lustre/lustre/event.mjs
 function on2(name, handler) {
@@ -5131,6 +7720,21 @@ function on_mouse_over(msg) {
     return new Ok(msg);
   });
 }
+function value2(event2) {
+  let _pipe = event2;
+  return field("target", field("value", string))(
+    _pipe
+  );
+}
+function on_input(msg) {
+  return on2(
+    "input",
+    (event2) => {
+      let _pipe = value2(event2);
+      return map3(_pipe, msg);
+    }
+  );
+}
 function checked2(event2) {
   let _pipe = event2;
   return field("target", field("checked", bool))(
@@ -5187,7 +7791,7 @@ function http_error_to_string(error) {
     let body2 = error[1];
     return "non success HTTP response; status: " + to_string(code2) + ", body: " + body2;
   } else {
-    return "unauthorized";
+    return "unauthorized; open the URL printed by cueitup (which includes the access token)";
   }
 }
 
@@ -5251,7 +7855,22 @@ function message_list_item(message, index5, current_index, select_on_hover) {
                 return message.id;
               }
             })()
-          )
+          ),
+          (() => {
+            let $ = message.delete_error;
+            if ($ instanceof Some) {
+              let e = $[0];
+              return span(
+                toList([
+                  class$("text-[#fb4934] font-normal"),
+                  attribute("title", e)
+                ]),
+                toList([text2(" (not deleted)")])
+              );
+            } else {
+              return none2();
+            }
+          })()
         ])
       ),
       (() => {
@@ -5266,15 +7885,558 @@ function message_list_item(message, index5, current_index, select_on_hover) {
               toList([p(toList([]), toList([text2(k + ": " + v)]))])
             );
           } else {
-            return none2();
+            return none2();
+          }
+        } else {
+          return none2();
+        }
+      })()
+    ])
+  );
+}
+function is_identifier_start(c) {
+  return c >= 65 && c <= 90 || c === 95 || c >= 97 && c <= 122;
+}
+function is_identifier(key2) {
+  let $ = to_utf_codepoints(key2);
+  if ($ instanceof Empty) {
+    return false;
+  } else {
+    let first2 = $.head;
+    let rest = $.tail;
+    return is_identifier_start(utf_codepoint_to_int(first2)) && all(
+      rest,
+      (c) => {
+        let c$1 = utf_codepoint_to_int(c);
+        return is_identifier_start(c$1) || c$1 >= 48 && c$1 <= 57;
+      }
+    );
+  }
+}
+function child_key_path(path, key2) {
+  let $ = is_identifier(key2);
+  if ($) {
+    return path + "." + key2;
+  } else {
+    return path + "[" + to_string3(string4(key2)) + "]";
+  }
+}
+function children_count(count, noun) {
+  return to_string(count) + " " + noun + (() => {
+    if (count === 1) {
+      return "";
+    } else {
+      return "s";
+    }
+  })();
+}
+function copy_path_button(path) {
+  return button(
+    toList([
+      class$(
+        "text-xs px-1 text-[#928374] hover:text-[#fabd2f] invisible group-hover:visible"
+      ),
+      attribute("title", "copy path " + path),
+      on_click(new CopyPath(path))
+    ]),
+    toList([text("copy path")])
+  );
+}
+function json_scalar(value3) {
+  let _block;
+  if (value3 instanceof JsonString) {
+    let s = value3[0];
+    _block = [
+      "text-[#b8bb26]",
+      (() => {
+        let _pipe = string4(s);
+        return to_string3(_pipe);
+      })()
+    ];
+  } else if (value3 instanceof JsonInt) {
+    let i = value3[0];
+    _block = ["text-[#fabd2f]", to_string(i)];
+  } else if (value3 instanceof JsonFloat) {
+    let f = value3[0];
+    _block = ["text-[#fabd2f]", float_to_string(f)];
+  } else if (value3 instanceof JsonBool) {
+    let $1 = value3[0];
+    if ($1) {
+      _block = ["text-[#d3869b]", "true"];
+    } else {
+      _block = ["text-[#d3869b]", "false"];
+    }
+  } else {
+    _block = ["text-[#d3869b]", "null"];
+  }
+  let $ = _block;
+  let class$2 = $[0];
+  let text3 = $[1];
+  return span(
+    toList([class$(class$2 + " break-all")]),
+    toList([text2(text3)])
+  );
+}
+function json_tree_key(key2) {
+  if (key2 instanceof Some) {
+    let k = key2[0];
+    return span(
+      toList([class$("text-[#83a598] font-semibold")]),
+      toList([text2(k + ":")])
+    );
+  } else {
+    return none2();
+  }
+}
+function json_tree(value3, key2, path) {
+  if (value3 instanceof JsonObject) {
+    let entries = value3[0];
+    return json_tree_node(
+      key2,
+      path,
+      "{ " + children_count(length(entries), "key") + " }",
+      (() => {
+        let _pipe = entries;
+        return map2(
+          _pipe,
+          (entry) => {
+            let k = entry[0];
+            let v = entry[1];
+            return [v, k, child_key_path(path, k)];
+          }
+        );
+      })()
+    );
+  } else if (value3 instanceof JsonArray) {
+    let items = value3[0];
+    return json_tree_node(
+      key2,
+      path,
+      "[ " + children_count(length(items), "item") + " ]",
+      (() => {
+        let _pipe = items;
+        return index_map(
+          _pipe,
+          (v, i) => {
+            let index5 = to_string(i);
+            return [v, index5, path + "[" + index5 + "]"];
+          }
+        );
+      })()
+    );
+  } else {
+    return div(
+      toList([class$("pl-4 flex items-center space-x-2 group")]),
+      toList([json_tree_key(key2), json_scalar(value3), copy_path_button(path)])
+    );
+  }
+}
+var tree_auto_expand_max_children = 20;
+var tree_max_children = 100;
+function json_tree_node(key2, path, summary2, children2) {
+  let num_children = length(children2);
+  let hidden = num_children - tree_max_children;
+  return details(
+    (() => {
+      let $ = num_children <= tree_auto_expand_max_children;
+      if ($) {
+        return toList([attribute("open", "")]);
+      } else {
+        return toList([]);
+      }
+    })(),
+    toList([
+      summary(
+        toList([class$("cursor-pointer group")]),
+        toList([
+          span(
+            toList([class$("space-x-2")]),
+            toList([
+              json_tree_key(key2),
+              span(
+                toList([class$("text-[#928374]")]),
+                toList([text2(summary2)])
+              ),
+              copy_path_button(path)
+            ])
+          )
+        ])
+      ),
+      div(
+        toList([
+          class$("pl-4 border-l border-[#928374] border-opacity-40 ml-1")
+        ]),
+        append(
+          (() => {
+            let _pipe = children2;
+            let _pipe$1 = take(_pipe, tree_max_children);
+            return map2(
+              _pipe$1,
+              (child) => {
+                let v = child[0];
+                let k = child[1];
+                let p2 = child[2];
+                return json_tree(v, new Some(k), p2);
+              }
+            );
+          })(),
+          (() => {
+            let $ = hidden > 0;
+            if ($) {
+              return toList([
+                div(
+                  toList([class$("pl-4 text-[#928374]")]),
+                  toList([
+                    text2("\u2026 " + children_count(hidden, "more item"))
+                  ])
+                )
+              ]);
+            } else {
+              return toList([]);
+            }
+          })()
+        )
+      )
+    ])
+  );
+}
+function persist_details(msg) {
+  return div(
+    toList([class$("flex items-center space-x-4")]),
+    toList([
+      button(
+        toList([
+          class$(
+            "font-semibold px-4 py-1 bg-[#83a598] text-[#282828] hover:bg-[#fabd2f]"
+          ),
+          on_click(new PersistMessage(msg.id))
+        ]),
+        toList([text("Persist")])
+      ),
+      (() => {
+        let $ = msg.persisted_to;
+        let $1 = msg.persist_error;
+        if ($1 instanceof Some) {
+          let e = $1[0];
+          return p(
+            toList([class$("text-[#fb4934]")]),
+            toList([text2("couldn't persist message: " + e)])
+          );
+        } else if ($ instanceof Some) {
+          let path = $[0];
+          return p(
+            toList([class$("text-[#b8bb26]")]),
+            toList([text2("persisted to " + path)])
+          );
+        } else {
+          return none2();
+        }
+      })()
+    ])
+  );
+}
+function compare_button(diff_base, msg) {
+  let _block;
+  if (diff_base instanceof Some) {
+    let base = diff_base[0];
+    if (base === msg.id) {
+      _block = ["Unmark", "stop comparing other messages with this one"];
+    } else {
+      let base$1 = diff_base[0];
+      _block = ["Diff", "show how this message differs from " + base$1];
+    }
+  } else {
+    _block = [
+      "Compare",
+      "mark this message, to compare other messages with it"
+    ];
+  }
+  let $ = _block;
+  let label2 = $[0];
+  let title = $[1];
+  let $1 = msg.error;
+  if ($1 instanceof Some) {
+    return none2();
+  } else {
+    return button(
+      toList([
+        class$(
+          "font-semibold px-4 py-1 bg-[#bdae93] text-[#282828] hover:bg-[#fabd2f]"
+        ),
+        attribute("title", title),
+        on_click(new CompareMessage(msg.id))
+      ]),
+      toList([text(label2)])
+    );
+  }
+}
+function zip_diff_lines(removed, added) {
+  if (removed instanceof Empty) {
+    if (added instanceof Empty) {
+      return toList([]);
+    } else {
+      let a2 = added.head;
+      let as_ = added.tail;
+      return prepend(
+        [new None(), new Some(a2)],
+        zip_diff_lines(toList([]), as_)
+      );
+    }
+  } else if (added instanceof Empty) {
+    let r = removed.head;
+    let rs = removed.tail;
+    return prepend([new Some(r), new None()], zip_diff_lines(rs, toList([])));
+  } else {
+    let r = removed.head;
+    let rs = removed.tail;
+    let a2 = added.head;
+    let as_ = added.tail;
+    return prepend([new Some(r), new Some(a2)], zip_diff_lines(rs, as_));
+  }
+}
+function pair_diff_lines(lines) {
+  if (lines instanceof Empty) {
+    return toList([]);
+  } else {
+    let $ = lines.head.kind;
+    if ($ instanceof DiffLineSame) {
+      let line = lines.head;
+      let rest = lines.tail;
+      return prepend([new Some(line), new Some(line)], pair_diff_lines(rest));
+    } else {
+      let $1 = split_while(
+        lines,
+        (l) => {
+          return isEqual(l.kind, new DiffLineRemoved());
+        }
+      );
+      let removed = $1[0];
+      let rest = $1[1];
+      let $2 = split_while(
+        rest,
+        (l) => {
+          return isEqual(l.kind, new DiffLineAdded());
+        }
+      );
+      let added = $2[0];
+      let rest$1 = $2[1];
+      return append(zip_diff_lines(removed, added), pair_diff_lines(rest$1));
+    }
+  }
+}
+function diff_line_cell(line, class$2) {
+  let _block;
+  if (line instanceof Some) {
+    let $ = line[0].kind;
+    if ($ instanceof DiffLineSame) {
+      let text3 = line[0].text;
+      _block = ["  " + text3, "text-[#928374]"];
+    } else if ($ instanceof DiffLineAdded) {
+      let text3 = line[0].text;
+      _block = ["+ " + text3, "text-[#b8bb26]"];
+    } else {
+      let text3 = line[0].text;
+      _block = ["- " + text3, "text-[#fb4934]"];
+    }
+  } else {
+    _block = ["", ""];
+  }
+  let $ = _block;
+  let text3 = $[0];
+  let color = $[1];
+  return td(
+    toList([
+      class$("whitespace-pre-wrap break-all " + color + " " + class$2)
+    ]),
+    toList([text2(text3)])
+  );
+}
+function line_diff_table(lines) {
+  return table(
+    toList([class$("font-mono text-sm w-full table-fixed")]),
+    toList([
+      thead(
+        toList([]),
+        toList([
+          tr(
+            toList([class$("text-left text-[#83a598]")]),
+            toList([
+              th(toList([class$("pr-4")]), toList([text2("before")])),
+              th(toList([]), toList([text2("after")]))
+            ])
+          )
+        ])
+      ),
+      tbody(
+        toList([]),
+        (() => {
+          let _pipe = pair_diff_lines(lines);
+          return map2(
+            _pipe,
+            (pair) => {
+              let before = pair[0];
+              let after = pair[1];
+              return tr(
+                toList([class$("align-top")]),
+                toList([
+                  diff_line_cell(before, "pr-4"),
+                  diff_line_cell(after, "")
+                ])
+              );
+            }
+          );
+        })()
+      )
+    ])
+  );
+}
+function structural_diff_table(changes) {
+  return table(
+    toList([class$("font-mono text-sm w-full table-fixed")]),
+    toList([
+      thead(
+        toList([]),
+        toList([
+          tr(
+            toList([class$("text-left text-[#83a598]")]),
+            toList([
+              th(toList([class$("w-4")]), toList([])),
+              th(toList([class$("w-1/4 pr-4")]), toList([text2("path")])),
+              th(toList([class$("pr-4")]), toList([text2("before")])),
+              th(toList([]), toList([text2("after")]))
+            ])
+          )
+        ])
+      ),
+      tbody(
+        toList([]),
+        (() => {
+          let _pipe = changes;
+          return map2(
+            _pipe,
+            (change) => {
+              let _block;
+              let $ = change.kind;
+              if ($ instanceof DiffAdded) {
+                _block = ["+", "text-[#b8bb26]"];
+              } else if ($ instanceof DiffRemoved) {
+                _block = ["-", "text-[#fb4934]"];
+              } else {
+                _block = ["~", "text-[#fabd2f]"];
+              }
+              let $1 = _block;
+              let marker = $1[0];
+              let class$2 = $1[1];
+              return tr(
+                toList([class$("align-top " + class$2)]),
+                toList([
+                  td(toList([]), toList([text2(marker)])),
+                  td(
+                    toList([class$("pr-4 break-all")]),
+                    toList([text2(change.path)])
+                  ),
+                  td(
+                    toList([class$("pr-4 break-all")]),
+                    toList([
+                      text2(
+                        (() => {
+                          let _pipe$1 = change.before;
+                          return unwrap(_pipe$1, "");
+                        })()
+                      )
+                    ])
+                  ),
+                  td(
+                    toList([class$("break-all")]),
+                    toList([
+                      text2(
+                        (() => {
+                          let _pipe$1 = change.after;
+                          return unwrap(_pipe$1, "");
+                        })()
+                      )
+                    ])
+                  )
+                ])
+              );
+            }
+          );
+        })()
+      )
+    ])
+  );
+}
+function message_diff_view(from2, to2, diff) {
+  return div(
+    toList([class$("mb-4")]),
+    toList([
+      div(
+        toList([class$("flex items-center space-x-4 mb-4")]),
+        toList([
+          p(
+            toList([class$("text-[#fabd2f] font-semibold")]),
+            toList([text2("Diff: " + from2 + " \u2192 " + to2)])
+          ),
+          button(
+            toList([
+              class$(
+                "text-sm px-2 py-1 border border-[#928374] text-[#928374] hover:text-[#fabd2f]"
+              ),
+              on_click(new CloseDiff())
+            ]),
+            toList([text("Show message")])
+          )
+        ])
+      ),
+      (() => {
+        let $ = diff_is_identical(diff);
+        let $1 = diff.structural;
+        if ($) {
+          if ($1) {
+            return p(
+              toList([class$("text-[#928374]")]),
+              toList([
+                text2(
+                  "The messages are identical (ignoring formatting and the order of keys)."
+                )
+              ])
+            );
+          } else {
+            return p(
+              toList([class$("text-[#928374]")]),
+              toList([text2("The messages are identical.")])
+            );
           }
+        } else if ($1) {
+          return structural_diff_table(diff.changes);
         } else {
-          return none2();
+          return line_diff_table(diff.lines);
         }
       })()
     ])
   );
 }
+function body_for_view(msg, view2) {
+  let $ = msg.raw_body;
+  let $1 = msg.formatted_body;
+  if (view2 instanceof BodyRaw) {
+    if ($ === "") {
+      return msg.body;
+    } else {
+      let raw_body = $;
+      return raw_body;
+    }
+  } else if (view2 instanceof BodyFormatted) {
+    if ($1 instanceof Some) {
+      let formatted_body = $1[0];
+      return formatted_body;
+    } else {
+      return msg.body;
+    }
+  } else {
+    return msg.body;
+  }
+}
 function message_details_pane(model) {
   let _block;
   let $ = model.current_message;
@@ -5285,19 +8447,86 @@ function message_details_pane(model) {
       toList([
         (() => {
           let $1 = msg.error;
+          let $2 = model.diff;
           if ($1 instanceof Some) {
             let e = $1[0];
             return pre(
               toList([class$("text-[#fb4934] text-base mb-4")]),
               toList([text2(e)])
             );
+          } else if ($2 instanceof Some) {
+            let from2 = $2[0][0];
+            let to2 = $2[0][1];
+            let diff = $2[0][2];
+            if (to2 === msg.id) {
+              return message_diff_view(from2, to2, diff);
+            } else {
+              let body2 = body_for_view(msg, model.body_view);
+              let $3 = model.tree_view;
+              let $4 = parse(body2, json_value_decoder());
+              if ($3) {
+                if ($4 instanceof Ok) {
+                  let value3 = $4[0];
+                  return div(
+                    toList([class$("font-mono text-base mb-4")]),
+                    toList([json_tree(value3, new None(), "$")])
+                  );
+                } else {
+                  return pre(
+                    toList([class$("text-[#d5c4a1] text-base mb-4")]),
+                    toList([text2(body2)])
+                  );
+                }
+              } else {
+                return pre(
+                  toList([class$("text-[#d5c4a1] text-base mb-4")]),
+                  toList([text2(body2)])
+                );
+              }
+            }
           } else {
-            return pre(
-              toList([class$("text-[#d5c4a1] text-base mb-4")]),
-              toList([text2(msg.body)])
+            let body2 = body_for_view(msg, model.body_view);
+            let $3 = model.tree_view;
+            let $4 = parse(body2, json_value_decoder());
+            if ($3) {
+              if ($4 instanceof Ok) {
+                let value3 = $4[0];
+                return div(
+                  toList([class$("font-mono text-base mb-4")]),
+                  toList([json_tree(value3, new None(), "$")])
+                );
+              } else {
+                return pre(
+                  toList([class$("text-[#d5c4a1] text-base mb-4")]),
+                  toList([text2(body2)])
+                );
+              }
+            } else {
+              return pre(
+                toList([class$("text-[#d5c4a1] text-base mb-4")]),
+                toList([text2(body2)])
+              );
+            }
+          }
+        })(),
+        (() => {
+          let $1 = msg.delete_error;
+          if ($1 instanceof Some) {
+            let e = $1[0];
+            return p(
+              toList([class$("text-[#fb4934] mb-4")]),
+              toList([
+                text2("couldn't delete message (it's still on the queue): " + e)
+              ])
             );
+          } else {
+            return none2();
           }
-        })()
+        })(),
+        div(
+          toList([class$("flex items-center space-x-4")]),
+          toList([persist_details(msg), compare_button(model.diff_base, msg)])
+        )
       ])
     );
   } else {
@@ -5329,6 +8558,24 @@ function message_details_pane(model) {
     ])
   );
 }
+function undeleted_summary(messages) {
+  let $ = count(messages, (m) => {
+    return is_some(m.delete_error);
+  });
+  if ($ === 0) {
+    return none2();
+  } else {
+    let n = $;
+    return p(
+      toList([class$("text-[#fb4934] mb-4")]),
+      toList([
+        text2(
+          to_string(n) + " of these messages couldn't be deleted, and are still on the" + " queue"
+        )
+      ])
+    );
+  }
+}
 function messages_section_with_messages(model, height_class) {
   let _block;
   let _pipe = model.current_message;
@@ -5359,6 +8606,7 @@ function messages_section_with_messages(model, height_class) {
                 ]),
                 toList([text2("Messages")])
               ),
+              undeleted_summary(model.messages),
               div(
                 toList([]),
                 (() => {
@@ -5458,32 +8706,297 @@ function error_section(model) {
   }
 }
 var profile_name_max_width = 60;
-function consumer_info(config) {
-  let _block;
+function truncate_profile_name(profile_name) {
   let $ = (() => {
-    let _pipe = config.profile_name;
+    let _pipe = profile_name;
     return string_length(_pipe);
   })();
   let n = $;
   if (n <= 60) {
-    _block = config.profile_name;
+    return profile_name;
   } else {
-    let _pipe = config.profile_name;
-    _block = slice(_pipe, 0, profile_name_max_width);
+    let _pipe = profile_name;
+    return slice(_pipe, 0, profile_name_max_width);
   }
-  let profile_name = _block;
-  return div(
+}
+function profile_picker(profiles, config) {
+  return select(
+    toList([
+      class$(
+        "font-bold px-4 py-1 bg-[#282828] text-[#fabd2f] border-2 border-[#928374] border-opacity-40 cursor-pointer"
+      ),
+      id("profile-picker"),
+      on_input((var0) => {
+        return new ProfileChosen(var0);
+      })
+    ]),
+    (() => {
+      let _pipe = profiles;
+      return map2(
+        _pipe,
+        (c) => {
+          return option(
+            toList([
+              value(c.profile_name),
+              selected(c.profile_name === config.profile_name)
+            ]),
+            truncate_profile_name(c.profile_name)
+          );
+        }
+      );
+    })()
+  );
+}
+function body_view_picker(model) {
+  return select(
     toList([
-      class$("font-bold px-4 py-1 flex items-center space-x-2")
+      class$(
+        "px-2 bg-[#282828] text-[#fabd2f] border border-[#928374] border-opacity-40 cursor-pointer"
+      ),
+      id("body-view"),
+      on_input((var0) => {
+        return new BodyViewChanged(var0);
+      })
     ]),
+    (() => {
+      let _pipe = body_views(model.config);
+      return map2(
+        _pipe,
+        (view2) => {
+          let name = body_view_to_string(view2);
+          return option(
+            toList([value(name), selected(isEqual(view2, model.body_view))]),
+            name
+          );
+        }
+      );
+    })()
+  );
+}
+function consumer_info(profiles, config) {
+  if (profiles instanceof Empty) {
+    return div(
+      toList([
+        class$("font-bold px-4 py-1 flex items-center space-x-2")
+      ]),
+      toList([
+        p(
+          toList([class$("text-[#fabd2f]")]),
+          toList([text(truncate_profile_name(config.profile_name))])
+        )
+      ])
+    );
+  } else {
+    let $ = profiles.tail;
+    if ($ instanceof Empty) {
+      return div(
+        toList([
+          class$("font-bold px-4 py-1 flex items-center space-x-2")
+        ]),
+        toList([
+          p(
+            toList([class$("text-[#fabd2f]")]),
+            toList([text(truncate_profile_name(config.profile_name))])
+          )
+        ])
+      );
+    } else {
+      return profile_picker(profiles, config);
+    }
+  }
+}
+function read_only_badge(config) {
+  let $ = config.read_only;
+  if ($) {
+    return p(
+      toList([
+        class$("font-bold px-2 py-1 bg-[#8ec07c] text-[#282828]"),
+        attribute("title", "cueitup won't delete messages from this queue")
+      ]),
+      toList([text("read-only")])
+    );
+  } else {
+    return none2();
+  }
+}
+function format_rate(rate) {
+  let tenths = round2(rate * 10);
+  return to_string(divideInt(tenths, 10)) + "." + to_string(
+    remainderInt(tenths, 10)
+  );
+}
+function queue_depth_chart(message_count) {
+  let _block;
+  let _pipe = message_count.history;
+  _block = map2(_pipe, (s) => {
+    return s.visible + s.in_flight;
+  });
+  let totals = _block;
+  let _block$1;
+  let _pipe$1 = totals;
+  _block$1 = fold(_pipe$1, 0, max);
+  let highest = _block$1;
+  let _block$2;
+  let _pipe$2 = totals;
+  _block$2 = map2(
+    _pipe$2,
+    (total) => {
+      let _block$3;
+      let $ = highest;
+      if ($ === 0) {
+        _block$3 = 0;
+      } else {
+        let h = $;
+        _block$3 = divideInt(total * 100, h);
+      }
+      let height = _block$3;
+      return div(
+        toList([
+          class$("w-1 bg-[#b8bb26]"),
+          style(toList([["height", to_string(max(height, 4)) + "%"]])),
+          attribute("title", to_string(total))
+        ]),
+        toList([])
+      );
+    }
+  );
+  let bars = _block$2;
+  let rates = message_count.rates;
+  let _block$3;
+  let $ = rates.known;
+  if ($) {
+    _block$3 = "net change: +" + format_rate(rates.growth) + "/s, -" + format_rate(
+      rates.shrinkage
+    ) + "/s" + (() => {
+      let $1 = rates.shrinkage > rates.growth;
+      if ($1) {
+        return " (draining)";
+      } else {
+        return "";
+      }
+    })();
+  } else {
+    _block$3 = "";
+  }
+  let rates_text = _block$3;
+  return div(
+    toList([class$("flex items-center space-x-2")]),
     toList([
-      p(
-        toList([class$("text-[#fabd2f]")]),
-        toList([text(profile_name)])
+      div(toList([class$("flex items-end h-6 space-x-px")]), bars),
+      p(toList([class$("text-[#b8bb26]")]), toList([text(rates_text)]))
+    ])
+  );
+}
+function queue_name_from_url(queue_url) {
+  let _pipe = queue_url;
+  let _pipe$1 = split2(_pipe, "/");
+  let _pipe$2 = last(_pipe$1);
+  return unwrap2(_pipe$2, "");
+}
+function purge_result_info(result) {
+  return p(
+    toList([class$("text-[#b8bb26]")]),
+    toList([
+      text(
+        (() => {
+          let $ = result.backup_path;
+          if ($ instanceof Some) {
+            let p2 = $[0];
+            return "backed up and deleted " + to_string(result.backed_up) + " messages (to " + p2 + "); " + to_string(result.remaining) + " remain";
+          } else {
+            return "purged";
+          }
+        })()
       )
     ])
   );
 }
+function purge_controls(model, config) {
+  let $ = config.read_only;
+  if ($) {
+    return none2();
+  } else {
+    let queue_name = queue_name_from_url(config.queue_url);
+    return div(
+      toList([
+        class$(
+          "border-2 border-[#fb4934] border-opacity-40 border-dashed font-semibold px-4 py-1 flex items-center space-x-4"
+        )
+      ]),
+      toList([
+        input(
+          toList([
+            class$(
+              "px-2 bg-[#282828] text-[#ebdbb2] border border-[#928374] border-opacity-40 placeholder-[#928374]"
+            ),
+            id("purge-confirmation"),
+            placeholder('type "' + queue_name + '" to purge'),
+            value(model.purge_confirmation),
+            on_input(
+              (var0) => {
+                return new PurgeConfirmationChanged(var0);
+              }
+            )
+          ])
+        ),
+        div(
+          toList([class$("flex items-center space-x-2")]),
+          toList([
+            label(
+              toList([class$("cursor-pointer"), for$("purge-backup")]),
+              toList([text("backup")])
+            ),
+            input(
+              toList([
+                class$(
+                  "w-4 h-4 text-[#fabd2f] bg-[#282828] focus:ring-[#fabd2f] cursor-pointer"
+                ),
+                id("purge-backup"),
+                type_("checkbox"),
+                on_check(
+                  (var0) => {
+                    return new PurgeBackupChanged(var0);
+                  }
+                ),
+                checked(model.purge_backup)
+              ])
+            )
+          ])
+        ),
+        button(
+          toList([
+            class$(
+              "font-semibold px-4 py-1 bg-[#fb4934] text-[#282828] hover:bg-[#fabd2f]"
+            ),
+            disabled(model.purging || model.purge_confirmation !== queue_name),
+            on_click(new PurgeQueue())
+          ]),
+          toList([
+            text(
+              (() => {
+                let $1 = model.purging;
+                if ($1) {
+                  return "Purging...";
+                } else {
+                  return "Purge";
+                }
+              })()
+            )
+          ])
+        ),
+        (() => {
+          let $1 = model.purge_result;
+          if ($1 instanceof Some) {
+            let r = $1[0];
+            return purge_result_info(r);
+          } else {
+            return none2();
+          }
+        })()
+      ])
+    );
+  }
+}
 function controls_div_with_config(model, config) {
   return div(
     toList([class$("flex items-center space-x-2 mt-4")]),
@@ -5505,7 +9018,8 @@ function controls_div_with_config(model, config) {
           )
         ])
       ),
-      consumer_info(config),
+      consumer_info(model.profiles, config),
+      read_only_badge(config),
       button(
         toList([
           class$(
@@ -5526,6 +9040,25 @@ function controls_div_with_config(model, config) {
         ]),
         toList([text("Fetch multiple")])
       ),
+      input(
+        toList([
+          class$(
+            "px-2 py-1 w-80 bg-[#282828] text-[#ebdbb2] border border-[#928374] border-opacity-40 placeholder-[#928374]"
+          ),
+          id("fetch-predicate"),
+          placeholder("only fetch where: $.kind = Created"),
+          attribute(
+            "title",
+            'conditions joined by "and"; each compares a JSON path ($.a.b), an attribute (@Name) or body with a value via =, !=, >, >=, <, <=, ~ (regex) or !~'
+          ),
+          value(model.predicate),
+          on_input(
+            (var0) => {
+              return new PredicateChanged(var0);
+            }
+          )
+        ])
+      ),
       button(
         toList([
           class$(
@@ -5592,11 +9125,89 @@ function controls_div_with_config(model, config) {
                       return new DeleteSettingsChanged(var0);
                     }
                   ),
-                  checked(model.behaviours.delete_messages)
+                  checked(
+                    model.behaviours.delete_messages && !config.read_only
+                  ),
+                  disabled(config.read_only)
+                ])
+              )
+            ])
+          ),
+          div(
+            toList([class$("flex items-center space-x-2")]),
+            toList([
+              label(
+                toList([
+                  class$("cursor-pointer"),
+                  for$("persist-messages")
+                ]),
+                toList([text("persist")])
+              ),
+              input(
+                toList([
+                  class$(
+                    "w-4 h-4 text-[#fabd2f] bg-[#282828] focus:ring-[#fabd2f] cursor-pointer"
+                  ),
+                  id("persist-messages"),
+                  type_("checkbox"),
+                  on_check(
+                    (var0) => {
+                      return new PersistSettingsChanged(var0);
+                    }
+                  ),
+                  checked(model.behaviours.persist_messages)
+                ])
+              )
+            ])
+          ),
+          div(
+            toList([class$("flex items-center space-x-2")]),
+            toList([
+              label(
+                toList([
+                  class$("cursor-pointer"),
+                  for$("tree-view"),
+                  attribute(
+                    "title",
+                    "show JSON messages as a tree of collapsible objects and arrays (keys are sorted)"
+                  )
+                ]),
+                toList([text("tree view")])
+              ),
+              input(
+                toList([
+                  class$(
+                    "w-4 h-4 text-[#fabd2f] bg-[#282828] focus:ring-[#fabd2f] cursor-pointer"
+                  ),
+                  id("tree-view"),
+                  type_("checkbox"),
+                  on_check(
+                    (var0) => {
+                      return new TreeViewChanged(var0);
+                    }
+                  ),
+                  checked(model.tree_view)
                 ])
               )
             ])
           ),
+          div(
+            toList([class$("flex items-center space-x-2")]),
+            toList([
+              label(
+                toList([
+                  class$("cursor-pointer"),
+                  for$("body-view"),
+                  attribute(
+                    "title",
+                    "show the whole body of JSON messages formatted, the body exactly as it was received, or only the subset (if the profile has a subset key)"
+                  )
+                ]),
+                toList([text("body")])
+              ),
+              body_view_picker(model)
+            ])
+          ),
           div(
             toList([class$("flex items-center space-x-2")]),
             toList([
@@ -5650,9 +9261,12 @@ function controls_div_with_config(model, config) {
                       toList([
                         text(
                           "(" + (() => {
-                            let _pipe = c;
+                            let _pipe = c.count;
+                            return to_string(_pipe);
+                          })() + " available, " + (() => {
+                            let _pipe = c.in_flight;
                             return to_string(_pipe);
-                          })() + " available)"
+                          })() + " in flight)"
                         )
                       ])
                     );
@@ -5662,11 +9276,26 @@ function controls_div_with_config(model, config) {
                 } else {
                   return none2();
                 }
+              })(),
+              (() => {
+                let $ = model.message_count;
+                let $1 = model.behaviours.show_message_count;
+                if ($1) {
+                  if ($ instanceof Some) {
+                    let c = $[0];
+                    return queue_depth_chart(c);
+                  } else {
+                    return none2();
+                  }
+                } else {
+                  return none2();
+                }
               })()
             ])
           )
         ])
-      )
+      ),
+      purge_controls(model, config)
     ])
   );
 }
@@ -5701,11 +9330,11 @@ function view(model) {
 }
 
 // build/dev/javascript/cueitup/cueitup.mjs
-var FILEPATH = "src/cueitup.gleam";
+var FILEPATH2 = "src/cueitup.gleam";
 function init2(_) {
   return [
     init_model(),
-    batch(toList([fetch_config(), fetch_behaviours()]))
+    fetch_profiles()
   ];
 }
 function main() {
@@ -5714,12 +9343,12 @@ function main() {
   if (!($ instanceof Ok)) {
     throw makeError(
       "let_assert",
-      FILEPATH,
+      FILEPATH2,
       "cueitup",
       11,
       "main",
       "Pattern match failed, no pattern matched the value.",
-      { value: $, start: 253, end: 302, pattern_start: 264, pattern_end: 269 }
+      { value: $, start: 237, end: 286, pattern_start: 248, pattern_end: 253 }
     );
   }
   return $;
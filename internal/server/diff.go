@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+var errMessageUnreadable = errors.New("message couldn't be read")
+
+// messageLookup finds a message that was shown in the web interface by its ID.
+type messageLookup func(id string) (t.Message, bool)
+
+// diffMessages compares the bodies of two messages that were fetched earlier;
+// see t.DiffMessages.
+func diffMessages(lookup messageLookup, config t.Config) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queryParams := r.URL.Query()
+		ids := []string{queryParams.Get("from"), queryParams.Get("to")}
+		if ids[0] == "" || ids[1] == "" {
+			http.Error(w, "incorrect query params: both \"from\" and \"to\" need to be provided", http.StatusBadRequest)
+			return
+		}
+
+		messages := make([]t.Message, len(ids))
+		for i, id := range ids {
+			message, ok := lookup(id)
+			if !ok {
+				http.Error(w, fmt.Sprintf("%s: %q", errMessageNotFetched.Error(), id), http.StatusNotFound)
+				return
+			}
+
+			if message.Err != nil {
+				http.Error(w, fmt.Sprintf("%s: %q", errMessageUnreadable.Error(), id), http.StatusUnprocessableEntity)
+				return
+			}
+			messages[i] = message
+		}
+
+		jsonBytes, err := json.Marshal(t.DiffMessages(messages[0].Body, messages[1].Body, config.Format))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(contentType, applicationJSON)
+		if _, err := w.Write(jsonBytes); err != nil {
+			log.Printf("failed to write bytes to HTTP connection: %s", err.Error())
+		}
+	}
+}
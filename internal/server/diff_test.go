@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffMessages(t *testing.T) {
+	config := getPersistTestConfig(t)
+	fetched := newFetchedMessages(maxFetchedMessages)
+	client := &fixedQueueClient{
+		messages: []sqstypes.Message{
+			{MessageId: aws.String("id-a"), Body: aws.String(`{"a": 1, "b": "x"}`)},
+			{MessageId: aws.String("id-b"), Body: aws.String(`{"b": "y", "a": 1, "c": true}`)},
+		},
+	}
+	persister := newProfilePersister(config)
+	defer persister.close()
+
+	fetchReq := httptest.NewRequest(http.MethodGet, "/api/profile/fetch?num=2", nil)
+	getMessages(client, config, fetched, persister)(httptest.NewRecorder(), fetchReq)
+
+	handler := diffMessages(fetched.lookup, config)
+
+	diff := func(t *testing.T, query string) (int, types.MessageDiff) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/profile/diff"+query, nil)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		var got types.MessageDiff
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		}
+		return rec.Code, got
+	}
+
+	t.Run("fetched messages are compared structurally", func(t *testing.T) {
+		code, got := diff(t, "?from=id-a&to=id-b")
+
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, got.Structural)
+		require.Len(t, got.Changes, 2)
+		assert.Equal(t, "$.b", got.Changes[0].Path)
+		assert.Equal(t, types.DiffChanged, got.Changes[0].Kind)
+		assert.Equal(t, "$.c", got.Changes[1].Path)
+		assert.Equal(t, types.DiffAdded, got.Changes[1].Kind)
+		require.NotNil(t, got.Changes[1].After)
+		assert.Equal(t, "true", *got.Changes[1].After)
+	})
+
+	t.Run("comparing a message with itself shows no changes", func(t *testing.T) {
+		code, got := diff(t, "?from=id-a&to=id-a")
+
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, got.Identical())
+	})
+
+	t.Run("messages that weren't fetched are reported", func(t *testing.T) {
+		code, _ := diff(t, "?from=id-a&to=id-unknown")
+
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("both message IDs need to be provided", func(t *testing.T) {
+		for _, query := range []string{"", "?from=id-a", "?to=id-b"} {
+			code, _ := diff(t, query)
+
+			assert.Equal(t, http.StatusBadRequest, code, query)
+		}
+	})
+}
@@ -3,14 +3,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/dhth/cueitup/internal/queue"
 	t "github.com/dhth/cueitup/internal/types"
 )
 
@@ -18,73 +22,175 @@ const (
 	contentType     = "Content-Type"
 	applicationJSON = "application/json; charset=utf-8"
 	unexpected      = "something unexpected happened (let @dhth know about this via https://github.com/dhth/cueitup/issues)"
+	// maxFetchTimeLimitSecs caps how long a single request can keep fetching
+	// messages matching a predicate; it needs to stay within
+	// queue.MaxFetchTimeLimit
+	maxFetchTimeLimitSecs = 120
+	// depthSampleInterval is how often a queue's depth is sampled for its
+	// history
+	depthSampleInterval = 5 * time.Second
 )
 
 type MessageCount struct {
-	Count int `json:"count"`
+	Count    int                  `json:"count"`
+	InFlight int                  `json:"in_flight"`
+	History  []t.QueueDepthSample `json:"history"`
+	Rates    t.QueueRates         `json:"rates"`
 }
 
-func getMessages(client *sqs.Client, config t.Config) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		queryParams := r.URL.Query()
-		numMessagesStr := queryParams.Get("num")
-
-		numMessages := 1
-		if numMessagesStr != "" {
-			num, err := strconv.Atoi(numMessagesStr)
-			if err != nil || num < 1 {
-				http.Error(w, fmt.Sprintf("incorrect value provided for query param \"num\": %s", err.Error()), http.StatusBadRequest)
-				return
-			}
-			numMessages = num
-		}
-		if numMessages > 10 {
-			numMessages = 10
-		}
+// depthTracker samples a queue's depth at a fixed interval, regardless of how
+// often clients ask for it, so that the rates derived from its history don't
+// depend on how many browser tabs are open.
+type depthTracker struct {
+	mu      sync.Mutex
+	history t.QueueDepthHistory
+	once    sync.Once
+	sample  func(ctx context.Context) (t.QueueDepth, error)
+}
 
-		deleteStr := queryParams.Get("delete")
-		var deleteMessages bool
-		if deleteStr != "" {
-			parsed, err := strconv.ParseBool(deleteStr)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("incorrect value provided for query param \"delete\": %s", err.Error()), http.StatusBadRequest)
+func newDepthTracker(sample func(ctx context.Context) (t.QueueDepth, error)) *depthTracker {
+	return &depthTracker{
+		history: t.NewQueueDepthHistory(t.DefaultQueueDepthHistorySize),
+		sample:  sample,
+	}
+}
+
+// start begins sampling in the background the first time it's called, and
+// keeps doing so until ctx is done.
+func (d *depthTracker) start(ctx context.Context) {
+	d.once.Do(func() {
+		go d.run(ctx)
+	})
+}
+
+func (d *depthTracker) run(ctx context.Context) {
+	ticker := time.NewTicker(depthSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		depth, err := d.sample(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
 				return
 			}
-			deleteMessages = parsed
+			log.Printf("failed to sample queue depth: %s", err.Error())
+		} else {
+			d.mu.Lock()
+			d.history.Add(t.QueueDepthSample{At: time.Now(), QueueDepth: depth})
+			d.mu.Unlock()
 		}
 
-		result, err := client.ReceiveMessage(context.TODO(),
-			&sqs.ReceiveMessageInput{
-				QueueUrl:            aws.String(config.QueueURL),
-				MaxNumberOfMessages: int32(numMessages),
-				WaitTimeSeconds:     0,
-				VisibilityTimeout:   30,
-			})
-		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to fetch messages: %s", err.Error()), http.StatusInternalServerError)
+		select {
+		case <-ctx.Done():
 			return
+		case <-ticker.C:
 		}
+	}
+}
+
+func (d *depthTracker) snapshot() ([]t.QueueDepthSample, t.QueueRates) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.history.Samples(), d.history.Rates()
+}
 
-		messages := make([]t.SerializableMessage, len(result.Messages))
-		for i, message := range result.Messages {
-			messages[i] = t.GetMessageData(&message, config).ToSerializable()
+func getMessages(client queue.Client, config t.Config, fetched *fetchedMessages, persister *profilePersister) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, ok := parseFetchParams(w, r, config)
+		if !ok {
+			return
 		}
 
-		if deleteMessages && len(messages) > 0 {
-			deleteEntries := make([]sqstypes.DeleteMessageBatchRequestEntry, len(messages))
-			for i := range result.Messages {
-				deleteEntries[i].Id = aws.String(fmt.Sprintf("%v", i))
-				deleteEntries[i].ReceiptHandle = result.Messages[i].ReceiptHandle
+		var sqsMessages []sqstypes.Message
+		if params.predicate != nil {
+			result, err := queue.FetchMatching(r.Context(), client, config, queue.FetchOptions{
+				Predicate:  params.predicate,
+				MaxMatches: params.numMessages,
+				TimeLimit:  params.timeLimit,
+				// matching messages are only kept in flight if they're to be
+				// deleted
+				ReleaseMatches:     !params.deleteMessages,
+				AllowDeadLettering: params.allowDeadLettering,
+			})
+			if errors.Is(err, queue.ErrScanCouldDeadLetter) {
+				http.Error(w, fmt.Sprintf("refusing to fetch messages: %s (set \"allow_dlq\" to scan it anyway)", err.Error()), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				http.Error(w, fmt.Sprintf("failed to fetch messages: %s", err.Error()), http.StatusInternalServerError)
+				return
 			}
-			_, err = client.DeleteMessageBatch(context.TODO(),
-				&sqs.DeleteMessageBatchInput{
-					Entries:  deleteEntries,
-					QueueUrl: aws.String(config.QueueURL),
+			sqsMessages = result.Messages
+		} else {
+			result, err := client.ReceiveMessage(context.TODO(),
+				&sqs.ReceiveMessageInput{
+					QueueUrl:            aws.String(config.QueueURL),
+					MaxNumberOfMessages: int32(params.numMessages),
+					WaitTimeSeconds:     0,
+					VisibilityTimeout:   30,
+					MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{
+						sqstypes.MessageSystemAttributeNameApproximateReceiveCount,
+						sqstypes.MessageSystemAttributeNameMessageGroupId,
+						sqstypes.MessageSystemAttributeNameSentTimestamp,
+					},
 				})
 			if err != nil {
-				http.Error(w, fmt.Sprintf("failed to delete messages on SQS: %s", err.Error()), http.StatusInternalServerError)
+				http.Error(w, fmt.Sprintf("failed to fetch messages: %s", err.Error()), http.StatusInternalServerError)
 				return
 			}
+			sqsMessages = result.Messages
+		}
+
+		now := time.Now()
+		// toDelete excludes messages that couldn't be persisted, so that they
+		// aren't lost; those are released back to the queue instead
+		var toDelete, toRelease []sqstypes.Message
+		messages := make([]t.SerializableMessage, len(sqsMessages))
+		for i, sqsMessage := range sqsMessages {
+			message := t.GetMessageData(&sqsMessage, config)
+			messages[i] = message.ToSerializable()
+
+			persistable := queue.PersistableMessage{
+				Message:    message,
+				SQSMessage: sqsMessage,
+				ReceivedAt: now,
+			}
+			fetched.add(persistable)
+
+			// messages are persisted before being deleted, so that a failure
+			// to persist doesn't lose them
+			if params.persistMessages {
+				path, err := persister.persist(persistable)
+				if err != nil {
+					errStr := err.Error()
+					messages[i].PersistError = &errStr
+					if params.deleteMessages {
+						reason := queue.ErrNotDeletedUnpersisted.Error()
+						messages[i].DeleteError = &reason
+						toRelease = append(toRelease, sqsMessage)
+					}
+					continue
+				}
+				messages[i].PersistedTo = &path
+			}
+			toDelete = append(toDelete, sqsMessage)
+		}
+
+		// messages that couldn't be deleted are still returned, marked as such,
+		// since they've been received (and possibly persisted) already
+		if params.deleteMessages && len(toDelete) > 0 {
+			result, err := queue.Delete(context.TODO(), client, config.QueueURL, toDelete)
+			if err != nil {
+				log.Printf("failed to delete messages on SQS: %s", err.Error())
+			}
+			markUndeleted(messages, sqsMessages, result.Failed)
+		}
+
+		if len(toRelease) > 0 {
+			if err := queue.Release(context.TODO(), client, config.QueueURL, toRelease); err != nil {
+				log.Printf("failed to release messages that couldn't be persisted: %s", err.Error())
+			}
 		}
 
 		jsonBytes, err := json.Marshal(messages)
@@ -100,27 +206,159 @@ func getMessages(client *sqs.Client, config t.Config) func(w http.ResponseWriter
 	}
 }
 
-func getMessageCount(client *sqs.Client, config t.Config) func(w http.ResponseWriter, r *http.Request) {
-	return func(w http.ResponseWriter, _ *http.Request) {
+// markUndeleted sets why messages couldn't be deleted; they're matched via
+// the SQS messages they were received as, since messages that couldn't be
+// parsed don't have an ID.
+func markUndeleted(messages []t.SerializableMessage, sqsMessages []sqstypes.Message, failed []queue.DeleteFailure) {
+	reasons := make(map[string]string, len(failed))
+	for _, failure := range failed {
+		reasons[failure.MessageID] = failure.Reason()
+	}
+
+	for i := range messages {
+		if reason, ok := reasons[aws.ToString(sqsMessages[i].MessageId)]; ok {
+			messages[i].DeleteError = &reason
+		}
+	}
+}
+
+type fetchParams struct {
+	numMessages     int
+	deleteMessages  bool
+	persistMessages bool
+	// predicate, if provided, makes fetching go on till numMessages matching
+	// messages are found, or timeLimit is reached
+	predicate *t.MessagePredicate
+	timeLimit time.Duration
+	// allowDeadLettering allows predicates to scan queues with a redrive
+	// policy
+	allowDeadLettering bool
+}
+
+// parseFetchParams parses the query params for fetching messages; if they're
+// invalid, an error response is written, and false is returned.
+func parseFetchParams(w http.ResponseWriter, r *http.Request, config t.Config) (fetchParams, bool) {
+	queryParams := r.URL.Query()
+	numMessagesStr := queryParams.Get("num")
+
+	params := fetchParams{numMessages: 1, timeLimit: queue.DefaultFetchTimeLimit}
+	if numMessagesStr != "" {
+		num, err := strconv.Atoi(numMessagesStr)
+		if err != nil || num < 1 {
+			http.Error(w, fmt.Sprintf("incorrect value provided for query param \"num\": %q", numMessagesStr), http.StatusBadRequest)
+			return params, false
+		}
+		params.numMessages = num
+	}
+	if params.numMessages > 10 {
+		params.numMessages = 10
+	}
+
+	boolParams := []struct {
+		name  string
+		value *bool
+	}{
+		{"delete", &params.deleteMessages},
+		{"persist", &params.persistMessages},
+		{"allow_dlq", &params.allowDeadLettering},
+	}
+	for _, param := range boolParams {
+		str := queryParams.Get(param.name)
+		if str == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(str)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("incorrect value provided for query param %q: %s", param.name, err.Error()), http.StatusBadRequest)
+			return params, false
+		}
+		*param.value = parsed
+	}
+
+	if where := queryParams.Get("where"); where != "" {
+		predicate, err := t.ParseMessagePredicate(where)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("incorrect value provided for query param \"where\": %s", err.Error()), http.StatusBadRequest)
+			return params, false
+		}
+		params.predicate = &predicate
+	}
+
+	if timeoutStr := queryParams.Get("timeout"); timeoutStr != "" {
+		timeout, err := strconv.Atoi(timeoutStr)
+		if err != nil || timeout < 1 || timeout > maxFetchTimeLimitSecs {
+			http.Error(w, fmt.Sprintf("incorrect value provided for query param \"timeout\": %q; needs to be between 1 and %d", timeoutStr, maxFetchTimeLimitSecs), http.StatusBadRequest)
+			return params, false
+		}
+		params.timeLimit = time.Duration(timeout) * time.Second
+	}
+
+	if params.deleteMessages {
+		if err := config.EnsureWritable(); err != nil {
+			http.Error(w, fmt.Sprintf("refusing to delete messages: %s", err.Error()), http.StatusForbidden)
+			return params, false
+		}
+	}
+
+	return params, true
+}
+
+func getQueueDepth(client *sqs.Client, queueURL string) func(ctx context.Context) (t.QueueDepth, error) {
+	return func(ctx context.Context) (t.QueueDepth, error) {
 		approxMsgCountType := sqstypes.QueueAttributeNameApproximateNumberOfMessages
-		attribute, err := client.GetQueueAttributes(context.TODO(),
+		approxInFlightCountType := sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible
+		attribute, err := client.GetQueueAttributes(ctx,
 			&sqs.GetQueueAttributesInput{
-				QueueUrl:       aws.String(config.QueueURL),
-				AttributeNames: []sqstypes.QueueAttributeName{approxMsgCountType},
+				QueueUrl:       aws.String(queueURL),
+				AttributeNames: []sqstypes.QueueAttributeName{approxMsgCountType, approxInFlightCountType},
 			})
 		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to get message message count: %s", err.Error()), http.StatusInternalServerError)
-			return
+			return t.QueueDepth{}, fmt.Errorf("failed to get message count: %w", err)
 		}
 
-		countStr := attribute.Attributes[string(approxMsgCountType)]
-		count, err := strconv.Atoi(countStr)
+		count, err := strconv.Atoi(attribute.Attributes[string(approxMsgCountType)])
 		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to convert message count to an int: %s", err.Error()), http.StatusInternalServerError)
-			return
+			return t.QueueDepth{}, fmt.Errorf("failed to convert message count to an int: %w", err)
+		}
+
+		inFlightCount, err := strconv.Atoi(attribute.Attributes[string(approxInFlightCountType)])
+		if err != nil {
+			return t.QueueDepth{}, fmt.Errorf("failed to convert in flight message count to an int: %w", err)
+		}
+
+		return t.QueueDepth{
+			Visible:  count,
+			InFlight: inFlightCount,
+		}, nil
+	}
+}
+
+func getMessageCount(ctx context.Context, tracker *depthTracker) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tracker.start(ctx)
+
+		history, rates := tracker.snapshot()
+		// the latest sample is served, rather than sampling per request; the
+		// queue is only sampled directly if the tracker hasn't sampled it yet
+		var depth t.QueueDepth
+		if len(history) > 0 {
+			depth = history[len(history)-1].QueueDepth
+		} else {
+			var err error
+			depth, err = tracker.sample(r.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 		}
 
-		jsonBytes, err := json.Marshal(MessageCount{count})
+		jsonBytes, err := json.Marshal(MessageCount{
+			Count:    depth.Visible,
+			InFlight: depth.InFlight,
+			History:  history,
+			Rates:    rates,
+		})
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
 			return
@@ -148,6 +386,36 @@ func getConfig(config t.Config) func(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+func getProfiles(profiles []t.Config) func(w http.ResponseWriter, _ *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		jsonBytes, err := json.Marshal(profiles)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(contentType, applicationJSON)
+		if _, err := w.Write(jsonBytes); err != nil {
+			log.Printf("failed to write bytes to HTTP connection: %s", err.Error())
+		}
+	}
+}
+
+// scopedToProfile dispatches requests for routes containing a {profile} path
+// value to the handler registered for that profile.
+func scopedToProfile(handlers map[string]http.HandlerFunc) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profileName := r.PathValue("profile")
+		handler, ok := handlers[profileName]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown profile: %q", profileName), http.StatusNotFound)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
 func getBehaviours(behaviours t.WebBehaviours) func(w http.ResponseWriter, _ *http.Request) {
 	return func(w http.ResponseWriter, _ *http.Request) {
 		jsonBytes, err := json.Marshal(behaviours)
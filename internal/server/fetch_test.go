@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/dhth/cueitup/internal/queue"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMessagesMatchingPredicate(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue",
+		Format:      types.JSON,
+	}
+	readOnlyConfig := config
+	readOnlyConfig.ReadOnly = true
+
+	fetch := func(t *testing.T, config types.Config, query url.Values) (*fixedQueueClient, int, []types.SerializableMessage) {
+		t.Helper()
+		client := &fixedQueueClient{
+			messages: []sqstypes.Message{
+				{MessageId: aws.String("id-a"), Body: aws.String(`{"kind": "Created"}`), ReceiptHandle: aws.String("rh-a")},
+				{MessageId: aws.String("id-b"), Body: aws.String(`{"kind": "Deleted"}`), ReceiptHandle: aws.String("rh-b")},
+			},
+		}
+		handler := getMessages(client, config, newFetchedMessages(maxFetchedMessages), nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/profile/fetch?"+query.Encode(), nil)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		var got []types.SerializableMessage
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		}
+		return client, rec.Code, got
+	}
+
+	t.Run("only matching messages are returned", func(t *testing.T) {
+		client, code, got := fetch(t, config, url.Values{"where": {"$.kind = Deleted"}, "timeout": {"1"}})
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got, 1)
+		assert.Equal(t, "id-b", got[0].ID)
+		assert.Equal(t, 0, client.deleted)
+	})
+
+	t.Run("matching messages can be deleted", func(t *testing.T) {
+		client, code, got := fetch(t, config, url.Values{"where": {"$.kind = Created"}, "delete": {"true"}})
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got, 1)
+		assert.Equal(t, "id-a", got[0].ID)
+		assert.Equal(t, 1, client.deleted)
+	})
+
+	t.Run("incorrect predicate is rejected", func(t *testing.T) {
+		_, code, _ := fetch(t, config, url.Values{"where": {"kind = Created"}})
+
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("incorrect timeout is rejected", func(t *testing.T) {
+		_, code, _ := fetch(t, config, url.Values{"where": {"$.kind = Created"}, "timeout": {"0"}})
+
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("timeout at the limit is accepted", func(t *testing.T) {
+		_, code, _ := fetch(t, config, url.Values{"where": {"$.kind = Created"}, "timeout": {strconv.Itoa(maxFetchTimeLimitSecs)}})
+
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("timeout over the limit is rejected", func(t *testing.T) {
+		_, code, _ := fetch(t, config, url.Values{"where": {"$.kind = Created"}, "timeout": {strconv.Itoa(maxFetchTimeLimitSecs + 1)}})
+
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("timeout that doesn't fit in an int is rejected", func(t *testing.T) {
+		_, code, _ := fetch(t, config, url.Values{"where": {"$.kind = Created"}, "timeout": {"99999999999999999999"}})
+
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("deleting is refused for read-only profiles", func(t *testing.T) {
+		client, code, _ := fetch(t, readOnlyConfig, url.Values{"where": {"$.kind = Created"}, "delete": {"true"}})
+
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, 0, client.deleted)
+	})
+
+	t.Run("queues with a redrive policy are only scanned if allowed", func(t *testing.T) {
+		client := &fixedQueueClient{
+			emptyQueueClient: emptyQueueClient{redrivePolicy: `{"deadLetterTargetArn":"arn","maxReceiveCount":2}`},
+			messages: []sqstypes.Message{
+				{MessageId: aws.String("id-a"), Body: aws.String(`{"kind": "Created"}`), ReceiptHandle: aws.String("rh-a")},
+			},
+		}
+		handler := getMessages(client, config, newFetchedMessages(maxFetchedMessages), nil)
+		query := url.Values{"where": {"$.kind = Created"}, "timeout": {"1"}}
+
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/api/profile/fetch?"+query.Encode(), nil))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Empty(t, client.releasedHandles)
+
+		query.Set("allow_dlq", "true")
+		rec = httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/api/profile/fetch?"+query.Encode(), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+// failingDeleteClient fails to delete messages with the receipt handles in
+// expired.
+type failingDeleteClient struct {
+	fixedQueueClient
+	expired map[string]bool
+}
+
+func (c *failingDeleteClient) DeleteMessageBatch(_ context.Context, params *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	var output sqs.DeleteMessageBatchOutput
+	for _, entry := range params.Entries {
+		if c.expired[aws.ToString(entry.ReceiptHandle)] {
+			output.Failed = append(output.Failed, sqstypes.BatchResultErrorEntry{
+				Id:          entry.Id,
+				Code:        aws.String("ReceiptHandleIsInvalid"),
+				SenderFault: true,
+			})
+			continue
+		}
+		c.deleted++
+		output.Successful = append(output.Successful, sqstypes.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return &output, nil
+}
+
+func TestGetMessagesMarksUndeletedMessages(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue",
+		Format:      types.JSON,
+	}
+	client := &failingDeleteClient{
+		fixedQueueClient: fixedQueueClient{
+			messages: []sqstypes.Message{
+				{MessageId: aws.String("id-a"), Body: aws.String(`{"a": 1}`), ReceiptHandle: aws.String("rh-a")},
+				{MessageId: aws.String("id-b"), Body: aws.String(`{"b": 2}`), ReceiptHandle: aws.String("rh-b")},
+			},
+		},
+		expired: map[string]bool{"rh-b": true},
+	}
+	handler := getMessages(client, config, newFetchedMessages(maxFetchedMessages), nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/fetch?num=2&delete=true", nil)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got []types.SerializableMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	assert.Nil(t, got[0].DeleteError)
+	require.NotNil(t, got[1].DeleteError)
+	assert.Equal(t, "ReceiptHandleIsInvalid", *got[1].DeleteError)
+	assert.Equal(t, 1, client.deleted)
+}
+
+func TestGetMessagesDoesntDeleteUnpersistedMessages(t *testing.T) {
+	config := getPersistTestConfig(t)
+	template, err := types.ParsePersistFilenameTemplate("{id}/message")
+	require.NoError(t, err)
+	config.PersistFilename = template
+	// a file where id-b's directory would go makes persisting it fail
+	require.NoError(t, os.WriteFile(filepath.Join(config.PersistDir, "id-b"), nil, 0o644))
+	client := &fixedQueueClient{
+		messages: []sqstypes.Message{
+			{MessageId: aws.String("id-a"), Body: aws.String(`{"a": 1}`), ReceiptHandle: aws.String("rh-a")},
+			{MessageId: aws.String("id-b"), Body: aws.String(`{"b": 2}`), ReceiptHandle: aws.String("rh-b")},
+		},
+	}
+	persister := newProfilePersister(config)
+	defer persister.close()
+	handler := getMessages(client, config, newFetchedMessages(maxFetchedMessages), persister)
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/fetch?num=2&delete=true&persist=true", nil)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got []types.SerializableMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	assert.NotNil(t, got[0].PersistedTo)
+	assert.Nil(t, got[0].DeleteError)
+	require.NotNil(t, got[1].PersistError)
+	require.NotNil(t, got[1].DeleteError)
+	assert.Equal(t, queue.ErrNotDeletedUnpersisted.Error(), *got[1].DeleteError)
+	assert.Equal(t, []string{"rh-a"}, client.deletedHandles)
+	assert.NotContains(t, client.deletedHandles, "rh-b")
+	assert.Equal(t, []string{"rh-b"}, client.releasedHandles)
+}
+
+func TestGetMessageCountDoesntSamplePerRequest(t *testing.T) {
+	var mu sync.Mutex
+	depth := types.QueueDepth{Visible: 10, InFlight: 2}
+	var sampleErr error
+	var samples int
+	tracker := newDepthTracker(func(_ context.Context) (types.QueueDepth, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		samples++
+		return depth, sampleErr
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler := getMessageCount(ctx, tracker)
+
+	get := func(t *testing.T) MessageCount {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/profile/message-count", nil)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got MessageCount
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		return got
+	}
+
+	get(t)
+	require.Eventually(t, func() bool {
+		history, _ := tracker.snapshot()
+		return len(history) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	depth = types.QueueDepth{Visible: 4, InFlight: 1}
+	// a throttled sample doesn't fail requests while a recent sample exists
+	sampleErr = errors.New("throttled")
+	samplesBefore := samples
+	mu.Unlock()
+
+	var got MessageCount
+	for range 5 {
+		got = get(t)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, samplesBefore, samples)
+	assert.Equal(t, 10, got.Count)
+	assert.Equal(t, 2, got.InFlight)
+	assert.Len(t, got.History, 1)
+	assert.False(t, got.Rates.Known)
+}
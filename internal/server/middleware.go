@@ -1,12 +1,45 @@
 package server
 
-import "net/http"
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
 
-func corsMiddleware(next http.Handler) http.Handler {
+const (
+	authCookieName      = "cueitup_token"
+	authQueryParam      = "token"
+	loginCodeQueryParam = "code"
+	bearerPrefix        = "Bearer "
+	// loginCodeTTL is how long a login code can be exchanged for the auth
+	// cookie
+	loginCodeTTL = time.Minute
+)
+
+// corsMiddleware only lets the server's own origin, and the explicitly allowed
+// ones, make requests to it; cross-origin requests from other origins are
+// rejected outright.
+func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		origin := r.Header.Get("Origin")
+		if origin == "" || isOwnOrigin(origin, r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !slices.Contains(allowedOrigins, origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Add("Vary", "Origin")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -16,3 +49,113 @@ func corsMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authMiddleware requires the auth token for all API routes. The token is
+// accepted either via an "Authorization: Bearer <token>" header, or via a
+// cookie, which is set when the index page is opened with "?token=<token>",
+// or with "?code=<code>" for a login code issued via codes.
+func authMiddleware(token string, codes *loginCodes, secureCookie bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" && (r.URL.Query().Has(authQueryParam) || r.URL.Query().Has(loginCodeQueryParam)) {
+			if r.URL.Query().Has(authQueryParam) && !tokenMatches(token, r.URL.Query().Get(authQueryParam)) {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Has(loginCodeQueryParam) && !codes.redeem(r.URL.Query().Get(loginCodeQueryParam)) {
+				http.Error(w, "invalid or expired code; open the URL printed by cueitup (which includes the access token)", http.StatusUnauthorized)
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookieName,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   secureCookie,
+				SameSite: http.SameSiteStrictMode,
+			})
+			// keeps the token out of the browser's address bar and history
+			http.Redirect(w, r, "/", http.StatusSeeOther)
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, bearerPrefix) {
+			if tokenMatches(token, strings.TrimPrefix(authHeader, bearerPrefix)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if cookie, err := r.Cookie(authCookieName); err == nil && tokenMatches(token, cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		http.Error(w, "unauthorized; open the URL printed by cueitup (which includes the access token)", http.StatusUnauthorized)
+	})
+}
+
+// loginCodes are single-use, short-lived codes that can be exchanged for the
+// auth cookie. They're used when opening the web interface in the browser, so
+// that the token itself isn't passed on the command line, where it's visible
+// to other users on the machine.
+type loginCodes struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	codes map[string]time.Time
+}
+
+func newLoginCodes(ttl time.Duration) *loginCodes {
+	return &loginCodes{
+		ttl:   ttl,
+		codes: make(map[string]time.Time),
+	}
+}
+
+func (c *loginCodes) issue() (string, error) {
+	code, err := generateAuthToken()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.codes[code] = time.Now().Add(c.ttl)
+
+	return code, nil
+}
+
+// redeem reports whether a code is valid; codes can only be redeemed once.
+func (c *loginCodes) redeem(code string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt, ok := c.codes[code]
+	if !ok {
+		return false
+	}
+	delete(c.codes, code)
+
+	return time.Now().Before(expiresAt)
+}
+
+func tokenMatches(expected, provided string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) == 1
+}
+
+// isOwnOrigin reports whether an origin is the server's own. Only hosts are
+// compared, since the scheme the browser used can't be told from the request
+// when TLS is terminated by a proxy in front of the server.
+func isOwnOrigin(origin string, r *http.Request) bool {
+	originURL, err := url.Parse(origin)
+	if err != nil || originURL.Host == "" {
+		return false
+	}
+
+	return strings.EqualFold(originURL.Host, r.Host)
+}
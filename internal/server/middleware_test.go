@@ -0,0 +1,208 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "0123456789abcdef0123456789abcdef"
+
+func testHandler(allowedOrigins []string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return corsMiddleware(allowedOrigins, authMiddleware(testToken, newLoginCodes(time.Minute), false, mux))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	handler := testHandler(nil)
+
+	testCases := []struct {
+		name     string
+		path     string
+		header   http.Header
+		cookie   *http.Cookie
+		expected int
+	}{
+		{
+			name:     "index doesn't need a token",
+			path:     "/",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "API needs a token",
+			path:     "/api/profiles",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "API accepts bearer token",
+			path:     "/api/profiles",
+			header:   http.Header{"Authorization": []string{"Bearer " + testToken}},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "API rejects incorrect bearer token",
+			path:     "/api/profiles",
+			header:   http.Header{"Authorization": []string{"Bearer incorrect"}},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "API accepts token cookie",
+			path:     "/api/profiles",
+			cookie:   &http.Cookie{Name: authCookieName, Value: testToken},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "incorrect token in URL is rejected",
+			path:     "/?token=incorrect",
+			expected: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+		})
+	}
+
+	t.Run("correct token in URL sets cookie and redirects", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?token="+testToken, nil)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/", rec.Header().Get("Location"))
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, authCookieName, cookies[0].Name)
+		assert.Equal(t, testToken, cookies[0].Value)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+	})
+}
+
+func TestLoginCodes(t *testing.T) {
+	t.Run("code sets cookie once", func(t *testing.T) {
+		codes := newLoginCodes(time.Minute)
+		handler := authMiddleware(testToken, codes, false, http.NotFoundHandler())
+		code, err := codes.issue()
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?code="+code, nil))
+
+		require.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/", rec.Header().Get("Location"))
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, testToken, cookies[0].Value)
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?code="+code, nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+	})
+
+	t.Run("expired code is rejected", func(t *testing.T) {
+		codes := newLoginCodes(-time.Second)
+		handler := authMiddleware(testToken, codes, false, http.NotFoundHandler())
+		code, err := codes.issue()
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?code="+code, nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("unknown code is rejected", func(t *testing.T) {
+		handler := authMiddleware(testToken, newLoginCodes(time.Minute), false, http.NotFoundHandler())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?code="+testToken, nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	handler := testHandler([]string{"http://localhost:1234"})
+	authHeader := "Bearer " + testToken
+
+	t.Run("own origin is allowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8500/api/profiles", nil)
+		req.Header.Set("Origin", "http://127.0.0.1:8500")
+		req.Header.Set("Authorization", authHeader)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("own origin is allowed behind a TLS-terminating proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://cueitup.example.com/api/profiles", nil)
+		req.Header.Set("Origin", "https://cueitup.example.com")
+		req.Header.Set("Authorization", authHeader)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("origins on other ports are rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8500/api/profiles", nil)
+		req.Header.Set("Origin", "http://127.0.0.1:8501")
+		req.Header.Set("Authorization", authHeader)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("other origins are rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://127.0.0.1:8500/api/profiles", nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Authorization", authHeader)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("allowed origins get CORS headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "http://127.0.0.1:8500/api/profiles", nil)
+		req.Header.Set("Origin", "http://localhost:1234")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "http://localhost:1234", rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+// offlineMessages hands out messages loaded from disk the way a queue would;
+// each message is handed out only once.
+type offlineMessages struct {
+	mu       sync.Mutex
+	messages []t.SerializableMessage
+	next     int
+}
+
+func newOfflineMessages(messages []sqstypes.Message, config t.Config) *offlineMessages {
+	serializable := make([]t.SerializableMessage, len(messages))
+	for i, message := range messages {
+		serializable[i] = t.GetMessageData(&message, config).ToSerializable()
+	}
+
+	return &offlineMessages{messages: serializable}
+}
+
+func (o *offlineMessages) take(num int) []t.SerializableMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	end := min(o.next+num, len(o.messages))
+	taken := o.messages[o.next:end]
+	o.next = end
+
+	return taken
+}
+
+// lookup only finds messages that were handed out already.
+func (o *offlineMessages) lookup(id string) (t.Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, message := range o.messages[:o.next] {
+		if message.ID == id {
+			return message.Message, true
+		}
+	}
+
+	return t.Message{}, false
+}
+
+func (o *offlineMessages) remaining() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return len(o.messages) - o.next
+}
+
+// ServeOffline starts cueitup's web interface for messages loaded from disk
+// (persisted messages or an archive), without any AWS connection. The config
+// determines how messages are displayed; it's always treated as read-only.
+func ServeOffline(
+	config t.Config,
+	messages []sqstypes.Message,
+	behaviourFlags t.WebBehaviourFlags,
+	serverConfig t.ServerConfig,
+	open bool,
+) error {
+	config.ReadOnly = true
+	behaviours, _ := config.WebBehaviours(behaviourFlags)
+	// messages viewed offline are already on disk
+	behaviours.PersistMessages = false
+	offline := newOfflineMessages(messages, config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	routes := newProfileRoutes()
+	routes.config[config.ProfileName] = getConfig(config)
+	routes.behaviours[config.ProfileName] = getBehaviours(behaviours)
+	routes.fetch[config.ProfileName] = getOfflineMessages(offline, config)
+	routes.messageCount[config.ProfileName] = getMessageCount(ctx, newDepthTracker(getOfflineQueueDepth(offline)))
+	routes.purge[config.ProfileName] = func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "purging isn't available when viewing messages offline", http.StatusForbidden)
+	}
+	routes.persist[config.ProfileName] = func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "persisting isn't available when viewing messages offline", http.StatusForbidden)
+	}
+	routes.diff[config.ProfileName] = diffMessages(offline.lookup, config)
+
+	return serve([]t.Config{config}, routes, serverConfig, open)
+}
+
+func getOfflineMessages(offline *offlineMessages, config t.Config) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, ok := parseFetchParams(w, r, config)
+		if !ok {
+			return
+		}
+
+		if params.persistMessages {
+			http.Error(w, "persisting isn't available when viewing messages offline", http.StatusForbidden)
+			return
+		}
+
+		if params.predicate != nil {
+			http.Error(w, "fetching messages matching a predicate isn't available when viewing messages offline", http.StatusForbidden)
+			return
+		}
+
+		jsonBytes, err := json.Marshal(offline.take(params.numMessages))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(contentType, applicationJSON)
+		if _, err := w.Write(jsonBytes); err != nil {
+			log.Printf("failed to write bytes to HTTP connection: %s", err.Error())
+		}
+	}
+}
+
+func getOfflineQueueDepth(offline *offlineMessages) func(ctx context.Context) (t.QueueDepth, error) {
+	return func(_ context.Context) (t.QueueDepth, error) {
+		return t.QueueDepth{Visible: offline.remaining()}, nil
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOfflineMessages(t *testing.T) {
+	contextKey := "seq"
+	config := types.Config{
+		ProfileName: "offline",
+		Format:      types.JSON,
+		ContextKey:  &contextKey,
+		ReadOnly:    true,
+	}
+	messages := []sqstypes.Message{
+		{MessageId: aws.String("id-a"), Body: aws.String(`{"seq": "1"}`)},
+		{MessageId: aws.String("id-b"), Body: aws.String(`{"seq": "2"}`)},
+		{MessageId: aws.String("id-c"), Body: aws.String(`{"seq": "3"}`)},
+	}
+	offline := newOfflineMessages(messages, config)
+	handler := getOfflineMessages(offline, config)
+
+	fetch := func(t *testing.T, query string) (int, []types.SerializableMessage) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/offline/fetch"+query, nil)
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		var got []types.SerializableMessage
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		}
+		return rec.Code, got
+	}
+
+	t.Run("messages are handed out in order, only once", func(t *testing.T) {
+		code, got := fetch(t, "?num=2")
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got, 2)
+		assert.Equal(t, "id-a", got[0].ID)
+		require.NotNil(t, got[0].ContextValue)
+		assert.Equal(t, "1", *got[0].ContextValue)
+		assert.Equal(t, "id-b", got[1].ID)
+		assert.Equal(t, 1, offline.remaining())
+
+		code, got = fetch(t, "?num=10")
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got, 1)
+		assert.Equal(t, "id-c", got[0].ID)
+	})
+
+	t.Run("no messages are returned once all have been handed out", func(t *testing.T) {
+		code, got := fetch(t, "")
+
+		require.Equal(t, http.StatusOK, code)
+		assert.Empty(t, got)
+	})
+
+	t.Run("deleting is refused", func(t *testing.T) {
+		code, _ := fetch(t, "?delete=true")
+
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("persisting is refused", func(t *testing.T) {
+		code, _ := fetch(t, "?persist=true")
+
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("fetching messages matching a predicate is refused", func(t *testing.T) {
+		code, _ := fetch(t, "?where=%24.seq%3D1")
+
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("invalid number of messages is rejected", func(t *testing.T) {
+		code, _ := fetch(t, "?num=0")
+
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestOfflineMessagesLookup(t *testing.T) {
+	config := types.Config{ProfileName: "offline", Format: types.JSON, ReadOnly: true}
+	messages := []sqstypes.Message{
+		{MessageId: aws.String("id-a"), Body: aws.String(`{"seq": "1"}`)},
+		{MessageId: aws.String("id-b"), Body: aws.String(`{"seq": "2"}`)},
+	}
+	offline := newOfflineMessages(messages, config)
+
+	_, ok := offline.lookup("id-a")
+	assert.False(t, ok, "messages that weren't handed out yet aren't found")
+
+	offline.take(1)
+
+	message, ok := offline.lookup("id-a")
+	require.True(t, ok)
+	assert.Equal(t, "id-a", message.ID)
+	_, ok = offline.lookup("id-b")
+	assert.False(t, ok)
+}
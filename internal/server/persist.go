@@ -0,0 +1,175 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const (
+	// maxFetchedMessages is how many fetched messages are kept around per
+	// profile, so that they can be persisted (or compared) after being
+	// fetched.
+	maxFetchedMessages      = 1000
+	maxPersistRequestBytes  = 64 * 1024
+	maxPersistRequestNumIDs = 100
+)
+
+var errMessageNotFetched = errors.New("message isn't among the ones fetched recently")
+
+type PersistRequest struct {
+	MessageIDs []string `json:"message_ids"`
+}
+
+// PersistedMessage is the outcome of persisting a single message; exactly one
+// of Path and Error is set.
+type PersistedMessage struct {
+	MessageID string  `json:"message_id"`
+	Path      *string `json:"path,omitempty"`
+	Error     *string `json:"error,omitempty"`
+}
+
+type PersistResponse struct {
+	Messages []PersistedMessage `json:"messages"`
+}
+
+// fetchedMessages holds the messages fetched most recently for a profile,
+// keyed by message ID; the oldest ones are evicted first.
+type fetchedMessages struct {
+	mu       sync.Mutex
+	messages map[string]queue.PersistableMessage
+	order    []string
+	limit    int
+}
+
+func newFetchedMessages(limit int) *fetchedMessages {
+	return &fetchedMessages{
+		messages: make(map[string]queue.PersistableMessage),
+		limit:    limit,
+	}
+}
+
+func (f *fetchedMessages) add(message queue.PersistableMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := message.Message.ID
+	if _, ok := f.messages[id]; !ok {
+		f.order = append(f.order, id)
+	}
+	f.messages[id] = message
+
+	for len(f.order) > f.limit {
+		delete(f.messages, f.order[0])
+		f.order = f.order[1:]
+	}
+}
+
+func (f *fetchedMessages) get(id string) (queue.PersistableMessage, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	message, ok := f.messages[id]
+	return message, ok
+}
+
+func (f *fetchedMessages) lookup(id string) (t.Message, bool) {
+	message, ok := f.get(id)
+	return message.Message, ok
+}
+
+// profilePersister opens a profile's persister the first time a message is
+// persisted, and keeps it open till the server shuts down.
+type profilePersister struct {
+	mu        sync.Mutex
+	config    t.Config
+	persister queue.Persister
+}
+
+func newProfilePersister(config t.Config) *profilePersister {
+	return &profilePersister{config: config}
+}
+
+func (p *profilePersister) persist(message queue.PersistableMessage) (string, error) {
+	p.mu.Lock()
+	if p.persister == nil {
+		persister, err := queue.NewPersister(p.config)
+		if err != nil {
+			p.mu.Unlock()
+			return "", err
+		}
+		p.persister = persister
+	}
+	persister := p.persister
+	p.mu.Unlock()
+
+	return persister.Persist(message)
+}
+
+func (p *profilePersister) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.persister == nil {
+		return nil
+	}
+
+	err := p.persister.Close()
+	p.persister = nil
+	return err
+}
+
+// persistMessages persists messages that were fetched earlier, using the same
+// persistence layout as the TUI (as per the profile's persist settings).
+func persistMessages(fetched *fetchedMessages, persister *profilePersister) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PersistRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPersistRequestBytes)).Decode(&req)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("incorrect request body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		if len(req.MessageIDs) == 0 || len(req.MessageIDs) > maxPersistRequestNumIDs {
+			http.Error(w, fmt.Sprintf("incorrect request body: between 1 and %d message IDs need to be provided", maxPersistRequestNumIDs), http.StatusBadRequest)
+			return
+		}
+
+		response := PersistResponse{Messages: make([]PersistedMessage, len(req.MessageIDs))}
+		for i, id := range req.MessageIDs {
+			response.Messages[i] = PersistedMessage{MessageID: id}
+
+			message, ok := fetched.get(id)
+			if !ok {
+				errStr := fmt.Sprintf("%s: %q", errMessageNotFetched.Error(), id)
+				response.Messages[i].Error = &errStr
+				continue
+			}
+
+			path, err := persister.persist(message)
+			if err != nil {
+				errStr := err.Error()
+				response.Messages[i].Error = &errStr
+				continue
+			}
+			response.Messages[i].Path = &path
+		}
+
+		jsonBytes, err := json.Marshal(response)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(contentType, applicationJSON)
+		if _, err := w.Write(jsonBytes); err != nil {
+			log.Printf("failed to write bytes to HTTP connection: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/dhth/cueitup/internal/queue"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedQueueClient is an SQS client for a queue that returns the same
+// messages every time it's polled.
+type fixedQueueClient struct {
+	emptyQueueClient
+	messages []sqstypes.Message
+	deleted  int
+	// deletedHandles and releasedHandles are the receipt handles of the
+	// messages deleted and released
+	deletedHandles  []string
+	releasedHandles []string
+}
+
+func (c *fixedQueueClient) ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return &sqs.ReceiveMessageOutput{Messages: c.messages}, nil
+}
+
+func (c *fixedQueueClient) DeleteMessageBatch(_ context.Context, params *sqs.DeleteMessageBatchInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	c.deleted += len(params.Entries)
+	for _, entry := range params.Entries {
+		c.deletedHandles = append(c.deletedHandles, aws.ToString(entry.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (c *fixedQueueClient) ChangeMessageVisibilityBatch(_ context.Context, params *sqs.ChangeMessageVisibilityBatchInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	for _, entry := range params.Entries {
+		c.releasedHandles = append(c.releasedHandles, aws.ToString(entry.ReceiptHandle))
+	}
+	return &sqs.ChangeMessageVisibilityBatchOutput{}, nil
+}
+
+func getPersistTestConfig(t *testing.T) types.Config {
+	t.Helper()
+
+	template, err := types.ParsePersistFilenameTemplate("{id}")
+	require.NoError(t, err)
+
+	return types.Config{
+		ProfileName:     "profile",
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/queue",
+		Format:          types.JSON,
+		PersistDir:      t.TempDir(),
+		PersistFilename: template,
+	}
+}
+
+func TestGetMessagesWithPersistence(t *testing.T) {
+	config := getPersistTestConfig(t)
+	client := &fixedQueueClient{
+		messages: []sqstypes.Message{
+			{MessageId: aws.String("id-a"), Body: aws.String(`{"a": 1}`), ReceiptHandle: aws.String("rh-a")},
+			{MessageId: aws.String("id-b"), Body: aws.String(`{"b": 2}`), ReceiptHandle: aws.String("rh-b")},
+		},
+	}
+	fetched := newFetchedMessages(maxFetchedMessages)
+	persister := newProfilePersister(config)
+	defer persister.close()
+	handler := getMessages(client, config, fetched, persister)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/profile/fetch?num=2&delete=true&persist=true", nil)
+	rec := httptest.NewRecorder()
+
+	handler(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got []types.SerializableMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+
+	for _, message := range got {
+		require.NotNil(t, message.PersistedTo)
+		assert.Nil(t, message.PersistError)
+		assert.Equal(t, filepath.Join(config.PersistDir, message.ID+".json"), *message.PersistedTo)
+
+		contents, err := os.ReadFile(*message.PersistedTo)
+		require.NoError(t, err)
+		assert.Equal(t, message.RawBody, string(contents))
+
+		_, ok := fetched.get(message.ID)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 2, client.deleted)
+}
+
+func TestPersistMessages(t *testing.T) {
+	config := getPersistTestConfig(t)
+	fetched := newFetchedMessages(maxFetchedMessages)
+	client := &fixedQueueClient{
+		messages: []sqstypes.Message{
+			{MessageId: aws.String("id-a"), Body: aws.String(`{"a": 1}`)},
+		},
+	}
+	persister := newProfilePersister(config)
+	defer persister.close()
+
+	fetchReq := httptest.NewRequest(http.MethodGet, "/api/profile/fetch", nil)
+	getMessages(client, config, fetched, persister)(httptest.NewRecorder(), fetchReq)
+
+	handler := persistMessages(fetched, persister)
+
+	persist := func(t *testing.T, body string) (int, PersistResponse) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/profile/persist", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		handler(rec, req)
+
+		var got PersistResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		}
+		return rec.Code, got
+	}
+
+	t.Run("fetched messages are persisted", func(t *testing.T) {
+		code, got := persist(t, `{"message_ids": ["id-a"]}`)
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got.Messages, 1)
+		assert.Equal(t, "id-a", got.Messages[0].MessageID)
+		assert.Nil(t, got.Messages[0].Error)
+		require.NotNil(t, got.Messages[0].Path)
+		assert.Equal(t, filepath.Join(config.PersistDir, "id-a.json"), *got.Messages[0].Path)
+	})
+
+	t.Run("persisting a message again doesn't overwrite it", func(t *testing.T) {
+		code, got := persist(t, `{"message_ids": ["id-a"]}`)
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got.Messages, 1)
+		require.NotNil(t, got.Messages[0].Path)
+		assert.Equal(t, filepath.Join(config.PersistDir, "id-a-2.json"), *got.Messages[0].Path)
+	})
+
+	t.Run("messages that weren't fetched are reported individually", func(t *testing.T) {
+		code, got := persist(t, `{"message_ids": ["id-a", "id-unknown"]}`)
+
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, got.Messages, 2)
+		assert.NotNil(t, got.Messages[0].Path)
+		assert.Nil(t, got.Messages[1].Path)
+		require.NotNil(t, got.Messages[1].Error)
+		assert.Contains(t, *got.Messages[1].Error, errMessageNotFetched.Error())
+	})
+
+	t.Run("incorrect request bodies are rejected", func(t *testing.T) {
+		for _, body := range []string{`not json`, `{}`, `{"message_ids": []}`} {
+			code, _ := persist(t, body)
+
+			assert.Equal(t, http.StatusBadRequest, code, body)
+		}
+	})
+}
+
+func TestFetchedMessagesEvictsOldest(t *testing.T) {
+	fetched := newFetchedMessages(2)
+	for _, id := range []string{"id-a", "id-b", "id-a", "id-c"} {
+		fetched.add(queue.PersistableMessage{Message: types.Message{ID: id}})
+	}
+
+	_, ok := fetched.get("id-a")
+	assert.False(t, ok)
+	_, ok = fetched.get("id-b")
+	assert.True(t, ok)
+	_, ok = fetched.get("id-c")
+	assert.True(t, ok)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+const (
+	maxPurgeRequestBytes = 1024
+	// purgeBackupTimeLimit caps how long backing up messages before a purge
+	// goes on for within a single request, so that the response is sent before
+	// proxies (or browsers) give up on the request; messages that aren't
+	// backed up by then are left on the queue, and reported via "remaining"
+	purgeBackupTimeLimit = 50 * time.Second
+)
+
+type PurgeRequest struct {
+	Confirm string `json:"confirm"`
+	Backup  bool   `json:"backup"`
+}
+
+type PurgeResponse struct {
+	BackupPath string `json:"backup_path,omitempty"`
+	BackedUp   int    `json:"backed_up"`
+	// Remaining is the approximate number of messages left on the queue after
+	// backing up (and deleting) messages.
+	Remaining int `json:"remaining"`
+}
+
+func purgeQueue(client queue.Client, config t.Config) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PurgeRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPurgeRequestBytes)).Decode(&req)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("incorrect request body: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		var backupPath string
+		if req.Backup {
+			backupPath = queue.DefaultBackupPath(utils.QueueNameFromURL(config.QueueURL), queue.ArchiveFormatJSONL, time.Now())
+		}
+
+		// the purge isn't stopped halfway through if the client disconnects,
+		// since messages are deleted as they're backed up
+		ctx := context.WithoutCancel(r.Context())
+		result, err := queue.Purge(ctx, client, config, queue.PurgeOptions{
+			Confirmation: req.Confirm,
+			BackupPath:   backupPath,
+			TimeLimit:    purgeBackupTimeLimit,
+		})
+		if result.BackupPath != "" {
+			log.Printf("purge of %q: backed up and deleted %d messages (to %s); %d remain",
+				config.ProfileName,
+				result.BackedUp,
+				result.BackupPath,
+				result.Remaining,
+			)
+		}
+		// messages left on the queue after backing up are reported in the
+		// response, the same way the TUI does
+		leftOnQueue := errors.Is(err, queue.ErrPurgeIncomplete) && result.Remaining > 0
+		if err != nil && !leftOnQueue {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, t.ErrProfileIsReadOnly):
+				status = http.StatusForbidden
+			case errors.Is(err, queue.ErrPurgeNotConfirmed):
+				status = http.StatusBadRequest
+			case errors.Is(err, queue.ErrPurgeInProgress):
+				status = http.StatusConflict
+			}
+
+			message := err.Error()
+			if result.BackupPath != "" {
+				message = fmt.Sprintf("%s (%d messages were backed up to %s)", message, result.BackedUp, result.BackupPath)
+			}
+			http.Error(w, message, status)
+			return
+		}
+
+		jsonBytes, err := json.Marshal(PurgeResponse{
+			BackupPath: result.BackupPath,
+			BackedUp:   result.BackedUp,
+			Remaining:  result.Remaining,
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode JSON: %s", err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(contentType, applicationJSON)
+		if _, err := w.Write(jsonBytes); err != nil {
+			log.Printf("failed to write bytes to HTTP connection: %s", err.Error())
+		}
+	}
+}
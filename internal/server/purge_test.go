@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyQueueClient is an SQS client for an empty queue.
+type emptyQueueClient struct {
+	purged   bool
+	purgeErr error
+	// redrivePolicy, if set, is reported as the queue's redrive policy
+	redrivePolicy string
+	// inFlight is the number of messages reported as in flight, which can't
+	// be received
+	inFlight int
+}
+
+func (c *emptyQueueClient) ReceiveMessage(context.Context, *sqs.ReceiveMessageInput, ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	return &sqs.ReceiveMessageOutput{}, nil
+}
+
+func (c *emptyQueueClient) DeleteMessageBatch(context.Context, *sqs.DeleteMessageBatchInput, ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (c *emptyQueueClient) ChangeMessageVisibilityBatch(context.Context, *sqs.ChangeMessageVisibilityBatchInput, ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	return &sqs.ChangeMessageVisibilityBatchOutput{}, nil
+}
+
+func (c *emptyQueueClient) SendMessageBatch(context.Context, *sqs.SendMessageBatchInput, ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	return &sqs.SendMessageBatchOutput{}, nil
+}
+
+func (c *emptyQueueClient) GetQueueAttributes(context.Context, *sqs.GetQueueAttributesInput, ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	attributes := map[string]string{
+		string(sqstypes.QueueAttributeNameApproximateNumberOfMessages):           "0",
+		string(sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible): strconv.Itoa(c.inFlight),
+	}
+	if c.redrivePolicy != "" {
+		attributes[string(sqstypes.QueueAttributeNameRedrivePolicy)] = c.redrivePolicy
+	}
+
+	return &sqs.GetQueueAttributesOutput{Attributes: attributes}, nil
+}
+
+func (c *emptyQueueClient) PurgeQueue(context.Context, *sqs.PurgeQueueInput, ...func(*sqs.Options)) (*sqs.PurgeQueueOutput, error) {
+	if c.purgeErr != nil {
+		return nil, c.purgeErr
+	}
+
+	c.purged = true
+	return &sqs.PurgeQueueOutput{}, nil
+}
+
+// cancellableClient is an emptyQueueClient whose requests fail once their
+// context is done, like the SDK's.
+type cancellableClient struct {
+	emptyQueueClient
+}
+
+func (c *cancellableClient) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.emptyQueueClient.GetQueueAttributes(ctx, params, optFns...)
+}
+
+func TestPurgeQueue(t *testing.T) {
+	config := types.Config{
+		ProfileName: "profile",
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/queue",
+	}
+	readOnlyConfig := config
+	readOnlyConfig.ReadOnly = true
+
+	testCases := []struct {
+		name     string
+		config   types.Config
+		body     string
+		purgeErr error
+		expected int
+		purged   bool
+	}{
+		{
+			name:     "correct confirmation purges the queue",
+			config:   config,
+			body:     `{"confirm": "queue"}`,
+			expected: http.StatusOK,
+			purged:   true,
+		},
+		{
+			name:     "incorrect confirmation is rejected",
+			config:   config,
+			body:     `{"confirm": "another-queue"}`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed body is rejected",
+			config:   config,
+			body:     `confirm=queue`,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "read-only profiles can't be purged",
+			config:   readOnlyConfig,
+			body:     `{"confirm": "queue"}`,
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "purge in progress results in a conflict",
+			config:   config,
+			body:     `{"confirm": "queue"}`,
+			purgeErr: &sqstypes.PurgeQueueInProgress{},
+			expected: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &emptyQueueClient{purgeErr: tt.purgeErr}
+			req := httptest.NewRequest(http.MethodPost, "/api/profile/purge", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			purgeQueue(client, tt.config)(rec, req)
+
+			assert.Equal(t, tt.expected, rec.Code)
+			assert.Equal(t, tt.purged, client.purged)
+		})
+	}
+
+	t.Run("response includes the backup's details", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		client := &emptyQueueClient{}
+		req := httptest.NewRequest(http.MethodPost, "/api/profile/purge", strings.NewReader(`{"confirm": "queue", "backup": true}`))
+		rec := httptest.NewRecorder()
+
+		purgeQueue(client, config)(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got PurgeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Contains(t, got.BackupPath, "backups/queue/")
+		assert.Equal(t, 0, got.BackedUp)
+	})
+
+	t.Run("messages left on the queue after backing up are reported", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		client := &emptyQueueClient{inFlight: 3}
+		req := httptest.NewRequest(http.MethodPost, "/api/profile/purge", strings.NewReader(`{"confirm": "queue", "backup": true}`))
+		rec := httptest.NewRecorder()
+
+		purgeQueue(client, config)(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got PurgeResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, 3, got.Remaining)
+		assert.False(t, client.purged)
+	})
+
+	t.Run("purges aren't stopped when the client disconnects", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		client := &cancellableClient{}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/profile/purge", strings.NewReader(`{"confirm": "queue", "backup": true}`))
+		rec := httptest.NewRecorder()
+
+		purgeQueue(client, config)(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
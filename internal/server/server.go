@@ -2,9 +2,12 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -17,14 +20,81 @@ import (
 var (
 	errCouldntStartServer     = errors.New("couldn't start server")
 	errForcefulShutdownFailed = errors.New("forceful shutdown failed")
+	errNoSQSClientForProfile  = errors.New("no SQS client available for profile")
+	errCouldntLoadTLSKeyPair  = errors.New("couldn't load TLS certificate and key")
 )
 
+// Serve starts cueitup's web interface for the provided profiles. Each
+// profile's SQS client is looked up in sqsClients via its AWS config source.
 func Serve(
-	sqsClient *sqs.Client,
-	config t.Config,
-	initialBehaviours t.WebBehaviours,
+	profiles []t.Config,
+	sqsClients map[string]*sqs.Client,
+	behaviourFlags t.WebBehaviourFlags,
+	serverConfig t.ServerConfig,
 	open bool,
 ) error {
+	// queue depths are sampled in the background until the server stops
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	routes := newProfileRoutes()
+	persisters := make([]*profilePersister, 0, len(profiles))
+	for _, config := range profiles {
+		sqsClient, ok := sqsClients[config.AWSConfigSource.String()]
+		if !ok {
+			return fmt.Errorf("%w: %q", errNoSQSClientForProfile, config.ProfileName)
+		}
+
+		behaviours, _ := config.WebBehaviours(behaviourFlags)
+		fetched := newFetchedMessages(maxFetchedMessages)
+		persister := newProfilePersister(config)
+		persisters = append(persisters, persister)
+
+		routes.config[config.ProfileName] = getConfig(config)
+		routes.behaviours[config.ProfileName] = getBehaviours(behaviours)
+		routes.fetch[config.ProfileName] = getMessages(sqsClient, config, fetched, persister)
+		routes.messageCount[config.ProfileName] = getMessageCount(ctx, newDepthTracker(getQueueDepth(sqsClient, config.QueueURL)))
+		routes.purge[config.ProfileName] = purgeQueue(sqsClient, config)
+		routes.persist[config.ProfileName] = persistMessages(fetched, persister)
+		routes.diff[config.ProfileName] = diffMessages(fetched.lookup, config)
+	}
+
+	defer func() {
+		for _, persister := range persisters {
+			if err := persister.close(); err != nil {
+				fmt.Fprintf(os.Stderr, "couldn't close persist store: %s\n", err.Error())
+			}
+		}
+	}()
+
+	return serve(profiles, routes, serverConfig, open)
+}
+
+// profileRoutes holds the handlers for routes scoped to a profile, keyed by
+// the profile's name.
+type profileRoutes struct {
+	config       map[string]http.HandlerFunc
+	behaviours   map[string]http.HandlerFunc
+	fetch        map[string]http.HandlerFunc
+	messageCount map[string]http.HandlerFunc
+	purge        map[string]http.HandlerFunc
+	persist      map[string]http.HandlerFunc
+	diff         map[string]http.HandlerFunc
+}
+
+func newProfileRoutes() profileRoutes {
+	return profileRoutes{
+		config:       make(map[string]http.HandlerFunc),
+		behaviours:   make(map[string]http.HandlerFunc),
+		fetch:        make(map[string]http.HandlerFunc),
+		messageCount: make(map[string]http.HandlerFunc),
+		purge:        make(map[string]http.HandlerFunc),
+		persist:      make(map[string]http.HandlerFunc),
+		diff:         make(map[string]http.HandlerFunc),
+	}
+}
+
+func serve(profiles []t.Config, routes profileRoutes, serverConfig t.ServerConfig, open bool) error {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /", getIndex)
@@ -32,34 +102,79 @@ func Serve(
 	mux.HandleFunc("GET /priv/static/cueitup.css", getCSS)
 	mux.HandleFunc("GET /priv/static/custom.css", getCustomCSS)
 	mux.HandleFunc("GET /priv/static/cueitup.mjs", getJS)
-	mux.HandleFunc("GET /api/config", getConfig(config))
-	mux.HandleFunc("GET /api/behaviours", getBehaviours(initialBehaviours))
-	mux.HandleFunc("GET /api/fetch", getMessages(sqsClient, config))
-	mux.HandleFunc("GET /api/message-count", getMessageCount(sqsClient, config))
-	muxWithCors := corsMiddleware(mux)
-
-	port, ok := findOpenPort(startPort, endPort)
-	if !ok {
-		return fmt.Errorf("%w; checked between %d-%d", errNoPortOpen, startPort, endPort)
+	mux.HandleFunc("GET /api/profiles", getProfiles(profiles))
+	mux.HandleFunc("GET /api/{profile}/config", scopedToProfile(routes.config))
+	mux.HandleFunc("GET /api/{profile}/behaviours", scopedToProfile(routes.behaviours))
+	mux.HandleFunc("GET /api/{profile}/fetch", scopedToProfile(routes.fetch))
+	mux.HandleFunc("GET /api/{profile}/message-count", scopedToProfile(routes.messageCount))
+	mux.HandleFunc("POST /api/{profile}/purge", scopedToProfile(routes.purge))
+	mux.HandleFunc("POST /api/{profile}/persist", scopedToProfile(routes.persist))
+	mux.HandleFunc("GET /api/{profile}/diff", scopedToProfile(routes.diff))
+
+	authToken := serverConfig.AuthToken
+	if authToken == "" {
+		var err error
+		authToken, err = generateAuthToken()
+		if err != nil {
+			return err
+		}
+	}
+
+	codes := newLoginCodes(loginCodeTTL)
+	handler := corsMiddleware(serverConfig.AllowedOrigins, authMiddleware(authToken, codes, serverConfig.TLSEnabled(), mux))
+
+	var tlsConfig *tls.Config
+	if serverConfig.TLSEnabled() {
+		cert, err := tls.LoadX509KeyPair(serverConfig.TLSCertPath, serverConfig.TLSKeyPath)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errCouldntLoadTLSKeyPair, err.Error())
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	listener, err := listen(serverConfig)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errCouldntStartServer, err)
+	}
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("%w: %s", errCouldntStartServer, err.Error())
 	}
 
-	addr := fmt.Sprintf("127.0.0.1:%d", port)
 	server := &http.Server{
-		Addr:    addr,
-		Handler: muxWithCors,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
 	}
 
-	addrWithProtocol := fmt.Sprintf("http://%s", addr)
+	addrWithProtocol := fmt.Sprintf("%s://%s", serverConfig.Scheme(), net.JoinHostPort(serverConfig.BrowserHost(), port))
+	urlWithToken := fmt.Sprintf("%s/?%s=%s", addrWithProtocol, authQueryParam, url.QueryEscape(authToken))
+	// a token provided by the user isn't echoed back, since the output might
+	// end up somewhere more public than the user's terminal (eg. CI logs)
+	urlToShow := urlWithToken
+	if serverConfig.AuthToken != "" {
+		urlToShow = fmt.Sprintf("%s/?%s=<YOUR-TOKEN>", addrWithProtocol, authQueryParam)
+	}
 
 	serverErrChan := make(chan error)
 
 	go func(errChan chan<- error) {
 		if open {
-			fmt.Printf("Starting server at %s.\n", addrWithProtocol)
+			fmt.Printf("Starting server at %s.\n", urlToShow)
 		} else {
-			fmt.Printf("Starting server. Open %s in your browser.\n", addrWithProtocol)
+			fmt.Printf("Starting server. Open %s in your browser.\n", urlToShow)
+		}
+
+		var err error
+		if tlsConfig != nil {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
 		}
-		err := server.ListenAndServe()
 		if !errors.Is(err, http.ErrServerClosed) {
 			errChan <- err
 		}
@@ -68,7 +183,12 @@ func Serve(
 	if open {
 		go func() {
 			time.Sleep(time.Millisecond * 1000)
-			err := openURL(addrWithProtocol)
+			// the URL is passed to a command, so it holds a single-use code
+			// rather than the token itself
+			code, err := codes.issue()
+			if err == nil {
+				err = openURL(fmt.Sprintf("%s/?%s=%s", addrWithProtocol, loginCodeQueryParam, code))
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "couldn't open URL: %s", err.Error())
 			}
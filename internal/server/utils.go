@@ -1,34 +1,63 @@
 package server
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
 	"os/exec"
 	"runtime"
+	"strconv"
+
+	t "github.com/dhth/cueitup/internal/types"
 )
 
 const (
 	startPort = 8500
 	endPort   = 9500
+
+	authTokenNumBytes = 24
 )
 
 var (
 	errNoPortOpen                    = errors.New("no open port found")
+	errPortNotAvailable              = errors.New("requested port is not available")
+	errCouldntGenerateAuthToken      = errors.New("couldn't generate auth token")
 	errUnsupportedPlatformForURLOpen = errors.New("opening URL is not supported on this platform")
 	errOpenURLCmdFailed              = errors.New("command for opening URL failed")
 )
 
-func findOpenPort(startPort, endPort int) (int, bool) {
+// listen binds to the port in the server config on its host; if no port is
+// configured, the first open port between startPort and endPort is used.
+func listen(config t.ServerConfig) (net.Listener, error) {
+	if config.Port != 0 {
+		address := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %s", errPortNotAvailable, address, err.Error())
+		}
+		return listener, nil
+	}
+
 	for port := startPort; port <= endPort; port++ {
-		address := fmt.Sprintf("127.0.0.1:%d", port)
+		address := net.JoinHostPort(config.Host, strconv.Itoa(port))
 		listener, err := net.Listen("tcp", address)
 		if err == nil {
-			defer listener.Close()
-			return port, true
+			return listener, nil
 		}
 	}
-	return 0, false
+
+	return nil, fmt.Errorf("%w on %s; checked between %d-%d", errNoPortOpen, config.Host, startPort, endPort)
+}
+
+func generateAuthToken() (string, error) {
+	b := make([]byte, authTokenNumBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%w: %s", errCouldntGenerateAuthToken, err.Error())
+	}
+
+	return hex.EncodeToString(b), nil
 }
 
 func openURL(url string) error {
@@ -0,0 +1,173 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errDeletingEnabledForReadOnlyProfile = errors.New("delete_messages can't be enabled for a read-only profile")
+
+// BehaviourSource is where the effective value of a behaviour comes from.
+type BehaviourSource uint
+
+const (
+	BehaviourSourceDefault BehaviourSource = iota
+	BehaviourSourceProfile
+	BehaviourSourceFlag
+	BehaviourSourceReadOnly
+)
+
+func (s BehaviourSource) Display() string {
+	var value string
+	switch s {
+	case BehaviourSourceDefault:
+		value = "default"
+	case BehaviourSourceProfile:
+		value = "profile"
+	case BehaviourSourceFlag:
+		value = "flag"
+	case BehaviourSourceReadOnly:
+		value = "read-only"
+	}
+
+	return value
+}
+
+// ProfileBehaviours are the default behaviours declared by a profile in the
+// config file; unset values fall back to cueitup's defaults.
+type ProfileBehaviours struct {
+	DeleteMessages   *bool `yaml:"delete_messages,omitempty"`
+	PersistMessages  *bool `yaml:"persist_messages,omitempty"`
+	ShowMessageCount *bool `yaml:"show_message_count,omitempty"`
+	SkipMessages     *bool `yaml:"skip_messages,omitempty"`
+	SelectOnHover    *bool `yaml:"select_on_hover,omitempty"`
+}
+
+// TUIBehaviourFlags holds the TUI behaviours explicitly set via CLI flags;
+// these take precedence over a profile's behaviours.
+type TUIBehaviourFlags struct {
+	DeleteMessages   *bool
+	PersistMessages  *bool
+	ShowMessageCount *bool
+	SkipMessages     *bool
+}
+
+// WebBehaviourFlags holds the web behaviours explicitly set via CLI flags;
+// these take precedence over a profile's behaviours.
+type WebBehaviourFlags struct {
+	DeleteMessages   *bool
+	PersistMessages  *bool
+	SelectOnHover    *bool
+	ShowMessageCount *bool
+}
+
+func DefaultTUIBehaviours() TUIBehaviours {
+	return TUIBehaviours{
+		DeleteMessages:   true,
+		PersistMessages:  false,
+		ShowMessageCount: true,
+		SkipMessages:     false,
+	}
+}
+
+func DefaultWebBehaviours() WebBehaviours {
+	return WebBehaviours{
+		DeleteMessages:   true,
+		PersistMessages:  false,
+		SelectOnHover:    false,
+		ShowMessageCount: true,
+	}
+}
+
+type TUIBehaviourSources struct {
+	DeleteMessages   BehaviourSource
+	PersistMessages  BehaviourSource
+	ShowMessageCount BehaviourSource
+	SkipMessages     BehaviourSource
+}
+
+type WebBehaviourSources struct {
+	DeleteMessages   BehaviourSource
+	PersistMessages  BehaviourSource
+	SelectOnHover    BehaviourSource
+	ShowMessageCount BehaviourSource
+}
+
+// TUIBehaviours resolves the TUI behaviours for a profile; flags take
+// precedence over the profile's behaviours, which take precedence over
+// cueitup's defaults. Messages are never deleted for read-only profiles.
+func (p Config) TUIBehaviours(flags TUIBehaviourFlags) (TUIBehaviours, TUIBehaviourSources) {
+	defaults := DefaultTUIBehaviours()
+	var b TUIBehaviours
+	var s TUIBehaviourSources
+
+	b.DeleteMessages, s.DeleteMessages = resolveBehaviour(defaults.DeleteMessages, p.Behaviours.DeleteMessages, flags.DeleteMessages)
+	b.PersistMessages, s.PersistMessages = resolveBehaviour(defaults.PersistMessages, p.Behaviours.PersistMessages, flags.PersistMessages)
+	b.ShowMessageCount, s.ShowMessageCount = resolveBehaviour(defaults.ShowMessageCount, p.Behaviours.ShowMessageCount, flags.ShowMessageCount)
+	b.SkipMessages, s.SkipMessages = resolveBehaviour(defaults.SkipMessages, p.Behaviours.SkipMessages, flags.SkipMessages)
+
+	if p.ReadOnly {
+		b.DeleteMessages, s.DeleteMessages = false, BehaviourSourceReadOnly
+	}
+
+	return b, s
+}
+
+// WebBehaviours resolves the web behaviours for a profile the same way
+// TUIBehaviours does.
+func (p Config) WebBehaviours(flags WebBehaviourFlags) (WebBehaviours, WebBehaviourSources) {
+	defaults := DefaultWebBehaviours()
+	var b WebBehaviours
+	var s WebBehaviourSources
+
+	b.DeleteMessages, s.DeleteMessages = resolveBehaviour(defaults.DeleteMessages, p.Behaviours.DeleteMessages, flags.DeleteMessages)
+	b.PersistMessages, s.PersistMessages = resolveBehaviour(defaults.PersistMessages, p.Behaviours.PersistMessages, flags.PersistMessages)
+	b.SelectOnHover, s.SelectOnHover = resolveBehaviour(defaults.SelectOnHover, p.Behaviours.SelectOnHover, flags.SelectOnHover)
+	b.ShowMessageCount, s.ShowMessageCount = resolveBehaviour(defaults.ShowMessageCount, p.Behaviours.ShowMessageCount, flags.ShowMessageCount)
+
+	if p.ReadOnly {
+		b.DeleteMessages, s.DeleteMessages = false, BehaviourSourceReadOnly
+	}
+
+	return b, s
+}
+
+func (b TUIBehaviours) DisplayWithSources(s TUIBehaviourSources) string {
+	return fmt.Sprintf(`
+- delete messages         %v (%s)
+- persist messages        %v (%s)
+- show message count      %v (%s)
+- skip messages           %v (%s)
+`,
+		b.DeleteMessages, s.DeleteMessages.Display(),
+		b.PersistMessages, s.PersistMessages.Display(),
+		b.ShowMessageCount, s.ShowMessageCount.Display(),
+		b.SkipMessages, s.SkipMessages.Display(),
+	)
+}
+
+func (b WebBehaviours) DisplayWithSources(s WebBehaviourSources) string {
+	return fmt.Sprintf(`
+- delete messages         %v (%s)
+- persist messages        %v (%s)
+- select on hover         %v (%s)
+- show message count      %v (%s)
+`,
+		b.DeleteMessages, s.DeleteMessages.Display(),
+		b.PersistMessages, s.PersistMessages.Display(),
+		b.SelectOnHover, s.SelectOnHover.Display(),
+		b.ShowMessageCount, s.ShowMessageCount.Display(),
+	)
+}
+
+func resolveBehaviour(defaultValue bool, profileValue, flagValue *bool) (bool, BehaviourSource) {
+	if flagValue != nil {
+		return *flagValue, BehaviourSourceFlag
+	}
+
+	if profileValue != nil {
+		return *profileValue, BehaviourSourceProfile
+	}
+
+	return defaultValue, BehaviourSourceDefault
+}
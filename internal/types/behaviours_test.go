@@ -0,0 +1,85 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigTUIBehaviours(t *testing.T) {
+	yes := true
+	no := false
+
+	t.Run("defaults are used when neither profile nor flags set behaviours", func(t *testing.T) {
+		got, sources := Config{}.TUIBehaviours(TUIBehaviourFlags{})
+
+		assert.Equal(t, DefaultTUIBehaviours(), got)
+		assert.Equal(t, TUIBehaviourSources{}, sources)
+	})
+
+	t.Run("profile behaviours override defaults, and flags override both", func(t *testing.T) {
+		config := Config{
+			Behaviours: ProfileBehaviours{
+				DeleteMessages:  &no,
+				PersistMessages: &yes,
+				SkipMessages:    &yes,
+			},
+		}
+
+		got, sources := config.TUIBehaviours(TUIBehaviourFlags{SkipMessages: &no})
+
+		expected := TUIBehaviours{
+			DeleteMessages:   false,
+			PersistMessages:  true,
+			ShowMessageCount: true,
+			SkipMessages:     false,
+		}
+		expectedSources := TUIBehaviourSources{
+			DeleteMessages:   BehaviourSourceProfile,
+			PersistMessages:  BehaviourSourceProfile,
+			ShowMessageCount: BehaviourSourceDefault,
+			SkipMessages:     BehaviourSourceFlag,
+		}
+		assert.Equal(t, expected, got)
+		assert.Equal(t, expectedSources, sources)
+	})
+
+	t.Run("read-only profiles never delete messages", func(t *testing.T) {
+		config := Config{ReadOnly: true}
+
+		got, sources := config.TUIBehaviours(TUIBehaviourFlags{DeleteMessages: &yes})
+
+		assert.False(t, got.DeleteMessages)
+		assert.Equal(t, BehaviourSourceReadOnly, sources.DeleteMessages)
+	})
+}
+
+func TestConfigWebBehaviours(t *testing.T) {
+	yes := true
+	no := false
+
+	config := Config{
+		Behaviours: ProfileBehaviours{
+			PersistMessages:  &yes,
+			SelectOnHover:    &yes,
+			ShowMessageCount: &no,
+		},
+	}
+
+	got, sources := config.WebBehaviours(WebBehaviourFlags{ShowMessageCount: &yes})
+
+	expected := WebBehaviours{
+		DeleteMessages:   true,
+		PersistMessages:  true,
+		SelectOnHover:    true,
+		ShowMessageCount: true,
+	}
+	expectedSources := WebBehaviourSources{
+		DeleteMessages:   BehaviourSourceDefault,
+		PersistMessages:  BehaviourSourceProfile,
+		SelectOnHover:    BehaviourSourceProfile,
+		ShowMessageCount: BehaviourSourceFlag,
+	}
+	assert.Equal(t, expected, got)
+	assert.Equal(t, expectedSources, sources)
+}
@@ -1,10 +1,15 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/dhth/cueitup/internal/utils"
+	yaml "github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
 )
 
 const (
@@ -28,6 +33,17 @@ func (cs ConfigSource) Display() string {
 	return cs.Value
 }
 
+// String returns the config source in the form it is written in cueitup's
+// config file.
+func (cs ConfigSource) String() string {
+	switch cs.Kind {
+	case SharedProfile:
+		return cfgSrcSharedProfilePrefix + cs.Value
+	default:
+		return cs.Value
+	}
+}
+
 func (cs ConfigSource) MarshalJSON() ([]byte, error) {
 	return json.Marshal(cs.Value)
 }
@@ -42,15 +58,56 @@ var (
 	errSubsetKeyCannotBeUsed       = errors.New("subset key can only be used when message format is JSON")
 	errContextKeyEmpty             = errors.New("context key is empty")
 	errSubsetKeyEmpty              = errors.New("subset key is empty")
+	errProfileAlreadyExists        = errors.New("a profile with this name already exists")
+	errCouldntParseConfigFile      = errors.New("couldn't parse config file")
+	errCouldntAddProfileToConfig   = errors.New("couldn't add profile to config")
+	ErrProfileIsReadOnly           = errors.New("profile is read-only")
 )
 
 type Config struct {
-	ProfileName     string        `json:"profile_name"`
-	QueueURL        string        `json:"queue_url"`
-	AWSConfigSource ConfigSource  `json:"aws_config_source"`
-	Format          MessageFormat `json:"-"`
-	ContextKey      *string       `json:"context_key"`
-	SubsetKey       *string       `json:"subset_key"`
+	ProfileName     string                  `json:"profile_name"`
+	QueueURL        string                  `json:"queue_url"`
+	AWSConfigSource ConfigSource            `json:"aws_config_source"`
+	Format          MessageFormat           `json:"-"`
+	ContextKey      *string                 `json:"context_key"`
+	SubsetKey       *string                 `json:"subset_key"`
+	ReadOnly        bool                    `json:"read_only"`
+	Behaviours      ProfileBehaviours       `json:"-"`
+	PersistDir      string                  `json:"-"`
+	PersistFilename PersistFilenameTemplate `json:"-"`
+	PersistStore    PersistStore            `json:"-"`
+}
+
+// PersistDirectory is where messages for the profile's queue are persisted.
+func (p Config) PersistDirectory() string {
+	if p.PersistDir != "" {
+		return p.PersistDir
+	}
+
+	return DefaultPersistDir(utils.QueueNameFromURL(p.QueueURL))
+}
+
+// EnsureWritable returns an error if the profile is read-only, and thus
+// mustn't be used for destructive actions (deleting, purging, sending, etc.).
+func (p Config) EnsureWritable() error {
+	if p.ReadOnly {
+		return fmt.Errorf("%w: %q", ErrProfileIsReadOnly, p.ProfileName)
+	}
+
+	return nil
+}
+
+// BodyViews are the ways messages of the profile can be shown in; the first
+// one is the default.
+func (p Config) BodyViews() []BodyView {
+	switch {
+	case p.Format != JSON:
+		return []BodyView{BodyRaw}
+	case p.SubsetKey != nil:
+		return []BodyView{BodySubset, BodyFormatted, BodyRaw}
+	default:
+		return []BodyView{BodyFormatted, BodyRaw}
+	}
 }
 
 func (p Config) Display() string {
@@ -77,6 +134,10 @@ func (p Config) Display() string {
 - format                  %v
 - context key             %s
 - subset key              %s
+- read only               %v
+- persist directory       %s
+- persist store           %s
+- persist filename        %s
         `,
 			p.ProfileName,
 			p.QueueURL,
@@ -84,6 +145,10 @@ func (p Config) Display() string {
 			p.Format.Display(),
 			contextKey,
 			subsetKey,
+			p.ReadOnly,
+			p.PersistDirectory(),
+			p.PersistStore.Display(),
+			p.PersistFilename.String(),
 		)
 	case None:
 		value = fmt.Sprintf(`
@@ -91,11 +156,19 @@ func (p Config) Display() string {
 - queue URL               %s
 - AWS config source       %s
 - format                  %v
+- read only               %v
+- persist directory       %s
+- persist store           %s
+- persist filename        %s
         `,
 			p.ProfileName,
 			p.QueueURL,
 			p.AWSConfigSource.Display(),
 			p.Format.Display(),
+			p.ReadOnly,
+			p.PersistDirectory(),
+			p.PersistStore.Display(),
+			p.PersistFilename.String(),
 		)
 	}
 
@@ -103,16 +176,166 @@ func (p Config) Display() string {
 }
 
 type CueitupConfig struct {
-	Profiles []ProfileConfig
+	Profiles []ProfileConfig `yaml:"profiles"`
 }
 
 type ProfileConfig struct {
-	Name            string  `yaml:"name"`
-	QueueURL        string  `yaml:"queue_url"`
-	AWSConfigSource string  `yaml:"aws_config_source"`
-	Format          string  `yaml:"format"`
-	ContextKey      *string `yaml:"context_key"`
-	SubsetKey       *string `yaml:"subset_key"`
+	Name            string             `yaml:"name"`
+	QueueURL        string             `yaml:"queue_url"`
+	AWSConfigSource string             `yaml:"aws_config_source"`
+	Format          string             `yaml:"format"`
+	ContextKey      *string            `yaml:"context_key,omitempty"`
+	SubsetKey       *string            `yaml:"subset_key,omitempty"`
+	ReadOnly        bool               `yaml:"read_only,omitempty"`
+	Behaviours      *ProfileBehaviours `yaml:"behaviours,omitempty"`
+	PersistDir      *string            `yaml:"persist_dir,omitempty"`
+	PersistFilename *string            `yaml:"persist_filename,omitempty"`
+	PersistStore    *string            `yaml:"persist_store,omitempty"`
+}
+
+type QueueBrowserConfig struct {
+	AWSConfigSource ConfigSource
+	QueuePrefix     string
+	Format          MessageFormat
+	ConfigPath      string
+	ReadOnly        bool
+}
+
+func (c QueueBrowserConfig) Display() string {
+	prefix := c.QueuePrefix
+	if prefix == "" {
+		prefix = notProvided
+	}
+
+	return fmt.Sprintf(`
+- AWS config source       %s
+- queue prefix            %s
+- format                  %v
+- config path             %s
+- read only               %v
+`,
+		c.AWSConfigSource.Display(),
+		prefix,
+		c.Format.Display(),
+		c.ConfigPath,
+		c.ReadOnly,
+	)
+}
+
+func ParseQueueBrowserConfig(awsConfigSource, queuePrefix, format, configPath string, readOnly bool) (QueueBrowserConfig, []error) {
+	var errors []error
+
+	cfgSrc, err := parseConfigSource(awsConfigSource)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	msgFmt, err := parseMessageFormat(format)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return QueueBrowserConfig{}, errors
+	}
+
+	return QueueBrowserConfig{
+		AWSConfigSource: cfgSrc,
+		QueuePrefix:     queuePrefix,
+		Format:          msgFmt,
+		ConfigPath:      configPath,
+		ReadOnly:        readOnly,
+	}, nil
+}
+
+// ParseOfflineConfig parses the config used to display messages loaded from
+// disk (rather than received from a queue); it's always read-only.
+func ParseOfflineConfig(name, format string, contextKey, subsetKey *string) (Config, []error) {
+	pc := ProfileConfig{
+		Format:     format,
+		ContextKey: contextKey,
+		SubsetKey:  subsetKey,
+	}
+
+	var errors []error
+
+	msgFmt, err := pc.validateMessageFormat()
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = pc.validateContextKey(msgFmt)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = pc.validateSubsetKey(msgFmt)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return Config{}, errors
+	}
+
+	return Config{
+		ProfileName: name,
+		Format:      msgFmt,
+		ContextKey:  contextKey,
+		SubsetKey:   subsetKey,
+		ReadOnly:    true,
+	}, nil
+}
+
+// AddProfileToConfig appends a profile to the contents of a config file,
+// preserving the comments and formatting of the existing profiles.
+func AddProfileToConfig(configBytes []byte, profile ProfileConfig) ([]byte, error) {
+	if _, errs := ParseProfileConfig(profile); len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", errCouldntAddProfileToConfig, errors.Join(errs...))
+	}
+
+	if len(bytes.TrimSpace(configBytes)) == 0 {
+		return yaml.Marshal(CueitupConfig{Profiles: []ProfileConfig{profile}})
+	}
+
+	var cfg CueitupConfig
+	err := yaml.Unmarshal(configBytes, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
+	}
+
+	for _, pc := range cfg.Profiles {
+		if pc.Name == profile.Name {
+			return nil, fmt.Errorf("%w: %q", errProfileAlreadyExists, profile.Name)
+		}
+	}
+
+	if len(cfg.Profiles) == 0 {
+		cfg.Profiles = []ProfileConfig{profile}
+		return yaml.Marshal(cfg)
+	}
+
+	file, err := parser.ParseBytes(configBytes, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntParseConfigFile, err.Error())
+	}
+
+	profileBytes, err := yaml.Marshal([]ProfileConfig{profile})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntAddProfileToConfig, err.Error())
+	}
+
+	profilesPath, err := yaml.PathString("$.profiles")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntAddProfileToConfig, err.Error())
+	}
+
+	err = profilesPath.MergeFromReader(file, bytes.NewReader(profileBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntAddProfileToConfig, err.Error())
+	}
+
+	return []byte(strings.TrimRight(file.String(), "\n") + "\n"), nil
 }
 
 func (pc *ProfileConfig) validateProfileName() (string, error) {
@@ -125,14 +348,7 @@ func (pc *ProfileConfig) validateProfileName() (string, error) {
 }
 
 func (pc *ProfileConfig) validateMessageFormat() (MessageFormat, error) {
-	switch pc.Format {
-	case typeJSON:
-		return JSON, nil
-	case typeNone:
-		return None, nil
-	default:
-		return JSON, fmt.Errorf("%w: %q; possible values: [%s, %s]", errIncorrectMessageFmtProvided, pc.Format, typeJSON, typeNone)
-	}
+	return parseMessageFormat(pc.Format)
 }
 
 func (pc *ProfileConfig) validateQueueURL() error {
@@ -167,6 +383,42 @@ func (pc *ProfileConfig) validateSubsetKey(format MessageFormat) error {
 	return nil
 }
 
+func (pc *ProfileConfig) validatePersistDir() error {
+	if pc.PersistDir != nil && strings.TrimSpace(*pc.PersistDir) == "" {
+		return errPersistDirEmpty
+	}
+
+	return nil
+}
+
+func (pc *ProfileConfig) validatePersistStore() (PersistStore, error) {
+	if pc.PersistStore == nil {
+		return PersistStoreFiles, nil
+	}
+
+	return parsePersistStore(*pc.PersistStore)
+}
+
+func (pc *ProfileConfig) validatePersistFilename(store PersistStore) (PersistFilenameTemplate, error) {
+	if pc.PersistFilename == nil {
+		return PersistFilenameTemplate{}, nil
+	}
+
+	if store != PersistStoreFiles {
+		return PersistFilenameTemplate{}, errPersistFilenameForCaptureStore
+	}
+
+	return ParsePersistFilenameTemplate(*pc.PersistFilename)
+}
+
+func (pc *ProfileConfig) validateBehaviours() error {
+	if pc.ReadOnly && pc.Behaviours != nil && pc.Behaviours.DeleteMessages != nil && *pc.Behaviours.DeleteMessages {
+		return errDeletingEnabledForReadOnlyProfile
+	}
+
+	return nil
+}
+
 func ParseProfileConfig(config ProfileConfig) (Config, []error) {
 	var errors []error
 
@@ -200,6 +452,36 @@ func ParseProfileConfig(config ProfileConfig) (Config, []error) {
 		errors = append(errors, err)
 	}
 
+	err = config.validateBehaviours()
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = config.validatePersistDir()
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	persistStore, err := config.validatePersistStore()
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	persistFilename, err := config.validatePersistFilename(persistStore)
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	var persistDir string
+	if config.PersistDir != nil {
+		persistDir = *config.PersistDir
+	}
+
+	var behaviours ProfileBehaviours
+	if config.Behaviours != nil {
+		behaviours = *config.Behaviours
+	}
+
 	if len(errors) > 0 {
 		return Config{}, errors
 	}
@@ -211,9 +493,25 @@ func ParseProfileConfig(config ProfileConfig) (Config, []error) {
 		Format:          msgFmt,
 		ContextKey:      config.ContextKey,
 		SubsetKey:       config.SubsetKey,
+		ReadOnly:        config.ReadOnly,
+		Behaviours:      behaviours,
+		PersistDir:      persistDir,
+		PersistFilename: persistFilename,
+		PersistStore:    persistStore,
 	}, nil
 }
 
+func parseMessageFormat(value string) (MessageFormat, error) {
+	switch value {
+	case typeJSON:
+		return JSON, nil
+	case typeNone:
+		return None, nil
+	default:
+		return JSON, fmt.Errorf("%w: %q; possible values: [%s, %s]", errIncorrectMessageFmtProvided, value, typeJSON, typeNone)
+	}
+}
+
 func parseConfigSource(value string) (ConfigSource, error) {
 	var zero ConfigSource
 	if strings.TrimSpace(value) == "" {
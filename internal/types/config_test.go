@@ -0,0 +1,230 @@
+package types
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddProfileToConfig(t *testing.T) {
+	profile := ProfileConfig{
+		Name:            "queue-b",
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-b",
+		AWSConfigSource: "profile:local-profile",
+		Format:          "json",
+	}
+
+	t.Run("profile is appended while keeping comments", func(t *testing.T) {
+		config := `# cueitup config
+profiles:
+    # the first queue
+  - name: queue-a
+    queue_url: https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a
+    aws_config_source: env
+    format: none
+`
+
+		got, err := AddProfileToConfig([]byte(config), profile)
+
+		require.NoError(t, err)
+		expected := `# cueitup config
+profiles:
+  # the first queue
+  - name: queue-a
+    queue_url: https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a
+    aws_config_source: env
+    format: none
+  - name: queue-b
+    queue_url: https://sqs.eu-central-1.amazonaws.com/000000000000/queue-b
+    aws_config_source: profile:local-profile
+    format: json
+`
+		assert.Equal(t, expected, string(got))
+	})
+
+	t.Run("empty config gets a profiles list", func(t *testing.T) {
+		got, err := AddProfileToConfig(nil, profile)
+
+		require.NoError(t, err)
+		expected := `profiles:
+- name: queue-b
+  queue_url: https://sqs.eu-central-1.amazonaws.com/000000000000/queue-b
+  aws_config_source: profile:local-profile
+  format: json
+`
+		assert.Equal(t, expected, string(got))
+	})
+
+	t.Run("duplicate profile names are rejected", func(t *testing.T) {
+		config := `profiles:
+  - name: queue-b
+    queue_url: https://sqs.eu-central-1.amazonaws.com/000000000000/queue-b
+    aws_config_source: env
+    format: json
+`
+
+		_, err := AddProfileToConfig([]byte(config), profile)
+
+		require.ErrorIs(t, err, errProfileAlreadyExists)
+	})
+}
+
+func TestParseProfileConfigReadOnly(t *testing.T) {
+	profile := ProfileConfig{
+		Name:            "prod-queue",
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/prod-queue",
+		AWSConfigSource: "env",
+		Format:          "json",
+		ReadOnly:        true,
+	}
+
+	got, errs := ParseProfileConfig(profile)
+
+	require.Empty(t, errs)
+	assert.True(t, got.ReadOnly)
+	assert.ErrorIs(t, got.EnsureWritable(), ErrProfileIsReadOnly)
+
+	got.ReadOnly = false
+	assert.NoError(t, got.EnsureWritable())
+}
+
+func TestParseProfileConfigBehaviours(t *testing.T) {
+	yes := true
+	profile := ProfileConfig{
+		Name:            "prod-queue",
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/prod-queue",
+		AWSConfigSource: "env",
+		Format:          "json",
+		ReadOnly:        true,
+		Behaviours: &ProfileBehaviours{
+			DeleteMessages: &yes,
+		},
+	}
+
+	_, errs := ParseProfileConfig(profile)
+
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], errDeletingEnabledForReadOnlyProfile)
+}
+
+func TestParseProfileConfigPersistence(t *testing.T) {
+	profile := ProfileConfig{
+		Name:            "queue-a",
+		QueueURL:        "https://sqs.eu-central-1.amazonaws.com/000000000000/queue-a",
+		AWSConfigSource: "env",
+		Format:          "json",
+	}
+
+	t.Run("persistence defaults to the queue's directory", func(t *testing.T) {
+		got, errs := ParseProfileConfig(profile)
+
+		require.Empty(t, errs)
+		assert.Equal(t, filepath.Join("messages", "queue-a"), got.PersistDirectory())
+		assert.Equal(t, DefaultPersistFilename, got.PersistFilename.String())
+	})
+
+	t.Run("persistence can be configured", func(t *testing.T) {
+		dir := "/var/tmp/cueitup"
+		filename := "{date}/{timestamp}-{id}"
+		withPersistence := profile
+		withPersistence.PersistDir = &dir
+		withPersistence.PersistFilename = &filename
+
+		got, errs := ParseProfileConfig(withPersistence)
+
+		require.Empty(t, errs)
+		assert.Equal(t, dir, got.PersistDirectory())
+		assert.Equal(t, filename, got.PersistFilename.String())
+	})
+
+	t.Run("invalid persistence config is reported", func(t *testing.T) {
+		dir := ""
+		filename := "{date}"
+		withPersistence := profile
+		withPersistence.PersistDir = &dir
+		withPersistence.PersistFilename = &filename
+
+		_, errs := ParseProfileConfig(withPersistence)
+
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], errPersistDirEmpty)
+		assert.ErrorIs(t, errs[1], errPersistFilenameWithoutID)
+	})
+
+	t.Run("capture stores can be used", func(t *testing.T) {
+		store := "jsonl"
+		withPersistence := profile
+		withPersistence.PersistStore = &store
+
+		got, errs := ParseProfileConfig(withPersistence)
+
+		require.Empty(t, errs)
+		assert.Equal(t, PersistStoreJSONL, got.PersistStore)
+	})
+
+	t.Run("sqlite capture stores need a build that supports them", func(t *testing.T) {
+		store := "sqlite"
+		withPersistence := profile
+		withPersistence.PersistStore = &store
+
+		got, errs := ParseProfileConfig(withPersistence)
+
+		if SQLiteSupported {
+			require.Empty(t, errs)
+			assert.Equal(t, PersistStoreSQLite, got.PersistStore)
+			return
+		}
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrSQLiteNotSupported)
+	})
+
+	t.Run("filename templates can't be used with capture stores", func(t *testing.T) {
+		store := "jsonl"
+		filename := "{date}/{timestamp}-{id}"
+		withPersistence := profile
+		withPersistence.PersistStore = &store
+		withPersistence.PersistFilename = &filename
+
+		_, errs := ParseProfileConfig(withPersistence)
+
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], errPersistFilenameForCaptureStore)
+	})
+
+	t.Run("unknown persist stores are rejected", func(t *testing.T) {
+		store := "postgres"
+		withPersistence := profile
+		withPersistence.PersistStore = &store
+
+		_, errs := ParseProfileConfig(withPersistence)
+
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], errIncorrectPersistStore)
+	})
+}
+
+func TestParseOfflineConfig(t *testing.T) {
+	t.Run("offline config is read-only", func(t *testing.T) {
+		contextKey := "transactionId"
+
+		got, errs := ParseOfflineConfig("queue-a", "json", &contextKey, nil)
+
+		require.Empty(t, errs)
+		assert.Equal(t, "queue-a", got.ProfileName)
+		assert.Equal(t, JSON, got.Format)
+		assert.True(t, got.ReadOnly)
+	})
+
+	t.Run("keys can't be used with plain text messages", func(t *testing.T) {
+		contextKey := "transactionId"
+		subsetKey := "metadata"
+
+		_, errs := ParseOfflineConfig("queue-a", "none", &contextKey, &subsetKey)
+
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], errContextKeyCannotBeUsed)
+		assert.ErrorIs(t, errs[1], errSubsetKeyCannotBeUsed)
+	})
+}
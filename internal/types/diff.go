@@ -0,0 +1,243 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxLineDiffCells limits the size of the table used to find the longest
+// common subsequence of two bodies' lines; lines beyond it are shown as
+// removed and added.
+const maxLineDiffCells = 1_000_000
+
+var jsonIdentifierKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type DiffChangeKind string
+
+const (
+	DiffAdded   DiffChangeKind = "added"
+	DiffRemoved DiffChangeKind = "removed"
+	DiffChanged DiffChangeKind = "changed"
+)
+
+// DiffChange is a difference between two JSON documents at a path; Before
+// and After are compact JSON, and are only set if the path exists in the
+// respective document.
+type DiffChange struct {
+	Path   string         `json:"path"`
+	Kind   DiffChangeKind `json:"kind"`
+	Before *string        `json:"before,omitempty"`
+	After  *string        `json:"after,omitempty"`
+}
+
+type DiffLineKind string
+
+const (
+	DiffLineSame    DiffLineKind = "same"
+	DiffLineAdded   DiffLineKind = "added"
+	DiffLineRemoved DiffLineKind = "removed"
+)
+
+type DiffLine struct {
+	Kind DiffLineKind `json:"kind"`
+	Text string       `json:"text"`
+}
+
+// MessageDiff is the difference between two message bodies: a structural one
+// (Changes) if both bodies are JSON, and a line based one (Lines) otherwise.
+type MessageDiff struct {
+	Structural bool         `json:"structural"`
+	Changes    []DiffChange `json:"changes,omitempty"`
+	Lines      []DiffLine   `json:"lines,omitempty"`
+}
+
+// Identical reports whether the bodies compared had no differences.
+func (d MessageDiff) Identical() bool {
+	if d.Structural {
+		return len(d.Changes) == 0
+	}
+
+	return !slices.ContainsFunc(d.Lines, func(line DiffLine) bool {
+		return line.Kind != DiffLineSame
+	})
+}
+
+// DiffMessages compares two message bodies; JSON bodies are compared
+// structurally (object keys are compared regardless of their order, and array
+// items by their index), unless either of them isn't valid JSON.
+func DiffMessages(before, after string, format MessageFormat) MessageDiff {
+	if format == JSON {
+		beforeData, beforeErr := decodeJSONForDiff(before)
+		afterData, afterErr := decodeJSONForDiff(after)
+		if beforeErr == nil && afterErr == nil {
+			var changes []DiffChange
+			diffJSONValues("$", beforeData, afterData, &changes)
+			return MessageDiff{Structural: true, Changes: changes}
+		}
+	}
+
+	return MessageDiff{Lines: diffLines(splitLines(before), splitLines(after))}
+}
+
+// JSONChildPath is the path of a key in the object at path; dot notation is
+// used for keys that allow it, and bracket notation otherwise.
+func JSONChildPath(path, key string) string {
+	if jsonIdentifierKeyRegex.MatchString(key) {
+		return path + "." + key
+	}
+
+	quoted, _ := json.Marshal(key)
+	return fmt.Sprintf("%s[%s]", path, quoted)
+}
+
+func decodeJSONForDiff(body string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func diffJSONValues(path string, before, after any, changes *[]DiffChange) {
+	switch beforeValue := before.(type) {
+	case map[string]any:
+		afterValue, ok := after.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := slices.Collect(maps.Keys(beforeValue))
+		for key := range afterValue {
+			if _, ok := beforeValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			childPath := JSONChildPath(path, key)
+			beforeChild, inBefore := beforeValue[key]
+			afterChild, inAfter := afterValue[key]
+			switch {
+			case !inAfter:
+				*changes = append(*changes, DiffChange{Path: childPath, Kind: DiffRemoved, Before: compactJSON(beforeChild)})
+			case !inBefore:
+				*changes = append(*changes, DiffChange{Path: childPath, Kind: DiffAdded, After: compactJSON(afterChild)})
+			default:
+				diffJSONValues(childPath, beforeChild, afterChild, changes)
+			}
+		}
+		return
+	case []any:
+		afterValue, ok := after.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(beforeValue), len(afterValue)) {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(afterValue):
+				*changes = append(*changes, DiffChange{Path: childPath, Kind: DiffRemoved, Before: compactJSON(beforeValue[i])})
+			case i >= len(beforeValue):
+				*changes = append(*changes, DiffChange{Path: childPath, Kind: DiffAdded, After: compactJSON(afterValue[i])})
+			default:
+				diffJSONValues(childPath, beforeValue[i], afterValue[i], changes)
+			}
+		}
+		return
+	}
+
+	beforeJSON := compactJSON(before)
+	afterJSON := compactJSON(after)
+	if *beforeJSON != *afterJSON {
+		*changes = append(*changes, DiffChange{Path: path, Kind: DiffChanged, Before: beforeJSON, After: afterJSON})
+	}
+}
+
+func compactJSON(value any) *string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	// values decoded from JSON can always be encoded again
+	_ = encoder.Encode(value)
+
+	encoded := strings.TrimSuffix(buf.String(), "\n")
+	return &encoded
+}
+
+func splitLines(body string) []string {
+	if body == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+}
+
+// diffLines finds the lines common to both sides via their longest common
+// subsequence, after skipping the common prefix and suffix.
+func diffLines(before, after []string) []DiffLine {
+	var prefix, suffix []DiffLine
+	for len(before) > 0 && len(after) > 0 && before[0] == after[0] {
+		prefix = append(prefix, DiffLine{Kind: DiffLineSame, Text: before[0]})
+		before, after = before[1:], after[1:]
+	}
+	for len(before) > 0 && len(after) > 0 && before[len(before)-1] == after[len(after)-1] {
+		suffix = append([]DiffLine{{Kind: DiffLineSame, Text: before[len(before)-1]}}, suffix...)
+		before, after = before[:len(before)-1], after[:len(after)-1]
+	}
+
+	lines := prefix
+	if len(before)*len(after) > maxLineDiffCells {
+		for _, line := range before {
+			lines = append(lines, DiffLine{Kind: DiffLineRemoved, Text: line})
+		}
+		for _, line := range after {
+			lines = append(lines, DiffLine{Kind: DiffLineAdded, Text: line})
+		}
+		return append(lines, suffix...)
+	}
+
+	// lengths[i][j] is the length of the longest common subsequence of
+	// before[i:] and after[j:]
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			lines = append(lines, DiffLine{Kind: DiffLineSame, Text: before[i]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lengths[i+1][j] >= lengths[i][j+1]):
+			lines = append(lines, DiffLine{Kind: DiffLineRemoved, Text: before[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Kind: DiffLineAdded, Text: after[j]})
+			j++
+		}
+	}
+
+	return append(lines, suffix...)
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffMessagesJSON(t *testing.T) {
+	before := `{"kind": "Created", "seq": 1, "tags": ["a", "b"], "order": {"status": "new", "total": 10}, "my key": 1}`
+	after := `{"seq": 2, "kind": "Created", "tags": ["a"], "order": {"status": "paid", "total": 10, "paidAt": "2024-06-01"}, "owner": null}`
+
+	got := DiffMessages(before, after, JSON)
+
+	str := func(value string) *string { return &value }
+	expected := []DiffChange{
+		{Path: `$["my key"]`, Kind: DiffRemoved, Before: str("1")},
+		{Path: "$.order.paidAt", Kind: DiffAdded, After: str(`"2024-06-01"`)},
+		{Path: "$.order.status", Kind: DiffChanged, Before: str(`"new"`), After: str(`"paid"`)},
+		{Path: "$.owner", Kind: DiffAdded, After: str("null")},
+		{Path: "$.seq", Kind: DiffChanged, Before: str("1"), After: str("2")},
+		{Path: "$.tags[1]", Kind: DiffRemoved, Before: str(`"b"`)},
+	}
+	assert.True(t, got.Structural)
+	assert.Equal(t, expected, got.Changes)
+	assert.False(t, got.Identical())
+}
+
+func TestDiffMessagesJSONTypeChange(t *testing.T) {
+	got := DiffMessages(`{"a": {"b": 1}}`, `{"a": [1]}`, JSON)
+
+	before := `{"b":1}`
+	after := `[1]`
+	assert.Equal(t, []DiffChange{{Path: "$.a", Kind: DiffChanged, Before: &before, After: &after}}, got.Changes)
+}
+
+func TestDiffMessagesIdenticalJSONWithDifferentFormatting(t *testing.T) {
+	got := DiffMessages(`{"a": 1, "b": [1, 2]}`, "{\n  \"b\": [1,2],\n  \"a\": 1.0\n}", JSON)
+
+	// numbers are compared as they appear in the message
+	assert.Len(t, got.Changes, 1)
+
+	got = DiffMessages(`{"a": 1, "b": [1, 2]}`, "{\n  \"b\": [1,2],\n  \"a\": 1\n}", JSON)
+
+	assert.True(t, got.Identical())
+}
+
+func TestDiffMessagesFallsBackToLinesForInvalidJSON(t *testing.T) {
+	got := DiffMessages(`{"a": 1}`, `not json`, JSON)
+
+	assert.False(t, got.Structural)
+	assert.Equal(t, []DiffLine{
+		{Kind: DiffLineRemoved, Text: `{"a": 1}`},
+		{Kind: DiffLineAdded, Text: "not json"},
+	}, got.Lines)
+}
+
+func TestDiffMessagesLines(t *testing.T) {
+	before := "one\ntwo\nthree\nfour\nfive\n"
+	after := "one\nthree\nfour\nFIVE\nsix\n"
+
+	got := DiffMessages(before, after, None)
+
+	assert.False(t, got.Structural)
+	assert.Equal(t, []DiffLine{
+		{Kind: DiffLineSame, Text: "one"},
+		{Kind: DiffLineRemoved, Text: "two"},
+		{Kind: DiffLineSame, Text: "three"},
+		{Kind: DiffLineSame, Text: "four"},
+		{Kind: DiffLineRemoved, Text: "five"},
+		{Kind: DiffLineAdded, Text: "FIVE"},
+		{Kind: DiffLineAdded, Text: "six"},
+	}, got.Lines)
+	assert.False(t, got.Identical())
+	assert.True(t, DiffMessages(before, before, None).Identical())
+}
+
+func TestJSONChildPath(t *testing.T) {
+	assert.Equal(t, "$.order", JSONChildPath("$", "order"))
+	assert.Equal(t, "$.items[0]._id", JSONChildPath("$.items[0]", "_id"))
+	assert.Equal(t, `$["content-type"]`, JSONChildPath("$", "content-type"))
+	assert.Equal(t, `$["1st"]`, JSONChildPath("$", "1st"))
+}
@@ -0,0 +1,203 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errFilterEmpty            = errors.New("filter is empty")
+	errFilterRegexIncorrect   = errors.New("filter regex is incorrect")
+	errFilterJSONPathInvalid  = errors.New("filter JSON path is incorrect")
+	errFilterJSONPathNoValue  = errors.New("filter JSON path needs a value to compare against")
+	jsonPathIndexSegmentRegex = regexp.MustCompile(`^\[(\d+)\]`)
+	jsonPathKeySegmentRegex   = regexp.MustCompile(`^\.([^.\[]+)`)
+)
+
+type messageFilterKind uint
+
+const (
+	filterSubstring messageFilterKind = iota
+	filterRegex
+	filterJSONPath
+)
+
+// MessageFilter matches messages by their body, context value and attributes.
+// Filters are written as:
+//
+//   - "text": case-insensitive substring
+//   - "/pattern/": regular expression
+//   - "$.path.to[0].key=value": JSON path equality (against the message body)
+type MessageFilter struct {
+	raw      string
+	kind     messageFilterKind
+	regex    *regexp.Regexp
+	path     []jsonPathSegment
+	expected string
+}
+
+// jsonPathSegment is either an object key, or an array index.
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func ParseMessageFilter(value string) (MessageFilter, error) {
+	var zero MessageFilter
+
+	query := strings.TrimSpace(value)
+	if query == "" {
+		return zero, errFilterEmpty
+	}
+
+	switch {
+	case len(query) >= 2 && strings.HasPrefix(query, "/") && strings.HasSuffix(query, "/"):
+		regex, err := regexp.Compile(query[1 : len(query)-1])
+		if err != nil {
+			return zero, fmt.Errorf("%w: %s", errFilterRegexIncorrect, err.Error())
+		}
+
+		return MessageFilter{raw: query, kind: filterRegex, regex: regex}, nil
+	case strings.HasPrefix(query, "$.") || strings.HasPrefix(query, "$["):
+		pathStr, expected, found := strings.Cut(query, "=")
+		if !found {
+			return zero, fmt.Errorf("%w: %q", errFilterJSONPathNoValue, query)
+		}
+		// "==" is accepted as well
+		expected = strings.TrimPrefix(expected, "=")
+
+		path, err := parseJSONPath(strings.TrimSpace(pathStr))
+		if err != nil {
+			return zero, err
+		}
+
+		return MessageFilter{
+			raw:      query,
+			kind:     filterJSONPath,
+			path:     path,
+			expected: strings.TrimSpace(expected),
+		}, nil
+	default:
+		return MessageFilter{raw: query, kind: filterSubstring}, nil
+	}
+}
+
+func parseJSONPath(value string) ([]jsonPathSegment, error) {
+	var segments []jsonPathSegment
+
+	rest := strings.TrimPrefix(value, "$")
+	for rest != "" {
+		if match := jsonPathIndexSegmentRegex.FindStringSubmatch(rest); match != nil {
+			index, err := strconv.Atoi(match[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", errFilterJSONPathInvalid, value)
+			}
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			rest = rest[len(match[0]):]
+			continue
+		}
+
+		if match := jsonPathKeySegmentRegex.FindStringSubmatch(rest); match != nil {
+			segments = append(segments, jsonPathSegment{key: match[1]})
+			rest = rest[len(match[0]):]
+			continue
+		}
+
+		return nil, fmt.Errorf("%w: %q", errFilterJSONPathInvalid, value)
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%w: %q", errFilterJSONPathInvalid, value)
+	}
+
+	return segments, nil
+}
+
+func (f MessageFilter) String() string {
+	return f.raw
+}
+
+func (f MessageFilter) Matches(message Message) bool {
+	switch f.kind {
+	case filterRegex:
+		return f.regex.MatchString(message.FilterValue())
+	case filterJSONPath:
+		return f.matchesJSONPath(message.Body)
+	default:
+		return strings.Contains(strings.ToLower(message.FilterValue()), strings.ToLower(f.raw))
+	}
+}
+
+// matchesJSONPath reports whether the value at the filter's path equals the
+// expected value.
+func (f MessageFilter) matchesJSONPath(body string) bool {
+	value, ok := lookupJSONPath(body, f.path)
+	if !ok {
+		return false
+	}
+
+	return jsonValueEquals(value, f.expected)
+}
+
+// lookupJSONPath returns the value at a path in a JSON document.
+func lookupJSONPath(body string, path []jsonPathSegment) (any, bool) {
+	var data any
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return nil, false
+	}
+
+	for _, segment := range path {
+		switch node := data.(type) {
+		case map[string]any:
+			if segment.isIndex {
+				return nil, false
+			}
+			value, ok := node[segment.key]
+			if !ok {
+				return nil, false
+			}
+			data = value
+		case []any:
+			if !segment.isIndex || segment.index >= len(node) {
+				return nil, false
+			}
+			data = node[segment.index]
+		default:
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+// jsonValueEquals compares a value from a JSON document with one provided by
+// the user. Strings are compared without quotes; everything else is compared
+// as JSON (so "1.0" matches 1, and "true" matches true).
+func jsonValueEquals(value any, expected string) bool {
+	if str, ok := value.(string); ok {
+		return str == unquote(expected)
+	}
+
+	var expectedValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(value, expectedValue)
+}
+
+// unquote removes the quotes around a value, if it's a quoted string.
+func unquote(value string) string {
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return value
+	}
+
+	return unquoted
+}
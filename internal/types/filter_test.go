@@ -0,0 +1,98 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessageFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected messageFilterKind
+		err      error
+	}{
+		// success
+		{name: "substring", value: "agg-1", expected: filterSubstring},
+		{name: "regex", value: "/agg-[0-9]+/", expected: filterRegex},
+		{name: "JSON path", value: "$.nested.arr[1]=2", expected: filterJSONPath},
+		{name: "JSON path with ==", value: "$.seq == 1", expected: filterJSONPath},
+		{name: "single slash is a substring", value: "/", expected: filterSubstring},
+		// failures
+		{name: "empty", value: "  ", err: errFilterEmpty},
+		{name: "incorrect regex", value: "/agg-[/", err: errFilterRegexIncorrect},
+		{name: "JSON path without a value", value: "$.seq", err: errFilterJSONPathNoValue},
+		{name: "incorrect JSON path", value: "$.nested..k=v", err: errFilterJSONPathInvalid},
+		{name: "JSON path without segments", value: "$.=v", err: errFilterJSONPathInvalid},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMessageFilter(tt.value)
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got.kind)
+		})
+	}
+}
+
+func TestMessageFilterMatches(t *testing.T) {
+	contextValue := "agg-1"
+	message := Message{
+		ID:           "id-001",
+		Body:         `{"seq": 1, "kind": "Created", "nested": {"k": "v1", "arr": [1, 2, 3]}, "ok": true}`,
+		ContextValue: &contextValue,
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "3",
+			"tenant":                  "acme",
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		filter   string
+		expected bool
+	}{
+		{name: "substring in body", filter: "created", expected: true},
+		{name: "substring in context value", filter: "AGG-1", expected: true},
+		{name: "substring in attributes", filter: "tenant=acme", expected: true},
+		{name: "substring not present", filter: "deleted", expected: false},
+		{name: "regex", filter: `/"seq": [0-9]/`, expected: true},
+		{name: "regex over attributes", filter: `/ApproximateReceiveCount=[3-9]/`, expected: true},
+		{name: "regex not matching", filter: `/^agg/`, expected: false},
+		{name: "JSON path string", filter: "$.kind=Created", expected: true},
+		{name: "JSON path quoted string", filter: `$.kind == "Created"`, expected: true},
+		{name: "JSON path nested", filter: "$.nested.k=v1", expected: true},
+		{name: "JSON path array index", filter: "$.nested.arr[2]=3", expected: true},
+		{name: "JSON path number", filter: "$.seq=1.0", expected: true},
+		{name: "JSON path bool", filter: "$.ok=true", expected: true},
+		{name: "JSON path different value", filter: "$.seq=2", expected: false},
+		{name: "JSON path missing key", filter: "$.absent=1", expected: false},
+		{name: "JSON path index out of range", filter: "$.nested.arr[3]=1", expected: false},
+		{name: "JSON path index on object", filter: "$.nested[0]=1", expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseMessageFilter(tt.filter)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, filter.Matches(message))
+		})
+	}
+
+	t.Run("JSON path doesn't match bodies that aren't JSON", func(t *testing.T) {
+		filter, err := ParseMessageFilter("$.kind=Created")
+		require.NoError(t, err)
+
+		assert.False(t, filter.Matches(Message{ID: "id-002", Body: "kind=Created"}))
+	})
+}
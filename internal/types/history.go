@@ -0,0 +1,110 @@
+package types
+
+import (
+	"time"
+)
+
+const DefaultQueueDepthHistorySize = 60
+
+type QueueDepth struct {
+	Visible  int `json:"visible"`
+	InFlight int `json:"in_flight"`
+}
+
+func (d QueueDepth) Total() int {
+	return d.Visible + d.InFlight
+}
+
+type QueueDepthSample struct {
+	At time.Time `json:"at"`
+	QueueDepth
+}
+
+// QueueDepthHistory keeps a rolling window of queue depth samples. It is not
+// safe for concurrent use.
+type QueueDepthHistory struct {
+	samples []QueueDepthSample
+	size    int
+}
+
+func NewQueueDepthHistory(size int) QueueDepthHistory {
+	if size < 2 {
+		size = 2
+	}
+
+	return QueueDepthHistory{
+		samples: make([]QueueDepthSample, 0, size),
+		size:    size,
+	}
+}
+
+func (h *QueueDepthHistory) Add(sample QueueDepthSample) {
+	if len(h.samples) == h.size {
+		copy(h.samples, h.samples[1:])
+		h.samples = h.samples[:len(h.samples)-1]
+	}
+	h.samples = append(h.samples, sample)
+}
+
+func (h *QueueDepthHistory) Samples() []QueueDepthSample {
+	samples := make([]QueueDepthSample, len(h.samples))
+	copy(samples, h.samples)
+	return samples
+}
+
+func (h *QueueDepthHistory) Latest() (QueueDepthSample, bool) {
+	if len(h.samples) == 0 {
+		return QueueDepthSample{}, false
+	}
+
+	return h.samples[len(h.samples)-1], true
+}
+
+func (h *QueueDepthHistory) Reset() {
+	h.samples = h.samples[:0]
+}
+
+// Rates estimates how quickly the queue's depth grows and shrinks over the
+// recorded window. SQS only exposes approximate depths, so these are net
+// changes between consecutive samples: messages added and removed between two
+// samples cancel each other out, and the rates aren't actual enqueue or dequeue
+// rates.
+func (h *QueueDepthHistory) Rates() QueueRates {
+	var rates QueueRates
+	if len(h.samples) < 2 {
+		return rates
+	}
+
+	elapsed := h.samples[len(h.samples)-1].At.Sub(h.samples[0].At).Seconds()
+	if elapsed <= 0 {
+		return rates
+	}
+
+	var grown, shrunk int
+	for i := 1; i < len(h.samples); i++ {
+		delta := h.samples[i].Total() - h.samples[i-1].Total()
+		if delta > 0 {
+			grown += delta
+		} else {
+			shrunk -= delta
+		}
+	}
+
+	rates.Growth = float64(grown) / elapsed
+	rates.Shrinkage = float64(shrunk) / elapsed
+	rates.Known = true
+
+	return rates
+}
+
+// QueueRates holds the net rates, in messages per second, at which a queue's
+// depth grew and shrank.
+type QueueRates struct {
+	Growth    float64 `json:"growth"`
+	Shrinkage float64 `json:"shrinkage"`
+	Known     bool    `json:"known"`
+}
+
+func (r QueueRates) Draining() bool {
+	return r.Known && r.Shrinkage > r.Growth
+}
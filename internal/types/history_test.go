@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueueDepthHistory(t *testing.T) {
+	start := time.Date(2025, 4, 16, 10, 0, 0, 0, time.UTC)
+	sample := func(offsetSecs, visible, inFlight int) QueueDepthSample {
+		return QueueDepthSample{
+			At:         start.Add(time.Duration(offsetSecs) * time.Second),
+			QueueDepth: QueueDepth{Visible: visible, InFlight: inFlight},
+		}
+	}
+
+	t.Run("rates are unknown with less than two samples", func(t *testing.T) {
+		h := NewQueueDepthHistory(5)
+		h.Add(sample(0, 10, 0))
+
+		assert.False(t, h.Rates().Known)
+	})
+
+	t.Run("rolling window drops oldest samples", func(t *testing.T) {
+		h := NewQueueDepthHistory(3)
+		for i := range 5 {
+			h.Add(sample(i, i, 0))
+		}
+
+		got := h.Samples()
+		assert.Len(t, got, 3)
+		assert.Equal(t, 2, got[0].Visible)
+		assert.Equal(t, 4, got[2].Visible)
+	})
+
+	t.Run("rates are net changes in visible and in flight messages", func(t *testing.T) {
+		h := NewQueueDepthHistory(10)
+		h.Add(sample(0, 100, 0))
+		h.Add(sample(5, 80, 10))
+		h.Add(sample(10, 70, 10))
+		h.Add(sample(15, 75, 10))
+
+		got := h.Rates()
+		assert.True(t, got.Known)
+		assert.InDelta(t, 5.0/15.0, got.Growth, 0.0001)
+		assert.InDelta(t, 20.0/15.0, got.Shrinkage, 0.0001)
+		assert.True(t, got.Draining())
+	})
+}
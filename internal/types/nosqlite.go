@@ -0,0 +1,7 @@
+//go:build !sqlite
+
+package types
+
+// SQLiteSupported is whether this build can use SQLite capture stores, which
+// needs cgo; cueitup's release builds are built without it.
+const SQLiteSupported = false
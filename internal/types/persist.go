@@ -0,0 +1,220 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// DefaultPersistFilename is the template persisted messages are named with,
+// unless a profile declares its own; the extension is always appended.
+const DefaultPersistFilename = "{timestamp}-{id}"
+
+const (
+	persistStoreFiles  = "files"
+	persistStoreJSONL  = "jsonl"
+	persistStoreSQLite = "sqlite"
+)
+
+// persistValueMissing is used for placeholders whose value isn't available
+// for a message (eg. the message group for a standard queue).
+const persistValueMissing = "none"
+
+var (
+	errPersistDirEmpty                   = errors.New("persist directory is empty")
+	errPersistFilenameEmpty              = errors.New("persist filename template is empty")
+	errPersistFilenamePlaceholderUnknown = errors.New("persist filename template has an unknown placeholder")
+	errPersistFilenameUnclosed           = errors.New("persist filename template has an unclosed placeholder")
+	errPersistFilenameNotRelative        = errors.New("persist filename template needs to be a relative path within the persist directory")
+	errPersistFilenameWithoutID          = errors.New("persist filename template needs to include {id} or {timestamp}")
+	errPersistFilenameForCaptureStore    = errors.New("persist filename can only be used when the persist store is \"files\"")
+	errIncorrectPersistStore             = errors.New("incorrect persist store provided")
+	// ErrSQLiteNotSupported is returned when using a SQLite capture store
+	// with a build that doesn't support them (see SQLiteSupported).
+	ErrSQLiteNotSupported = errors.New(`this build of cueitup doesn't support SQLite capture stores; build it with "-tags sqlite" (needs cgo) to use them`)
+
+	persistPlaceholderRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+	unsafeFilenameChars     = regexp.MustCompile(`[^a-zA-Z0-9._=-]+`)
+)
+
+var persistPlaceholders = []string{
+	"id",
+	"timestamp",
+	"date",
+	"year",
+	"month",
+	"day",
+	"hour",
+	"minute",
+	"queue",
+	"context",
+	"group",
+	"receive_count",
+}
+
+// PersistStore determines how persisted messages are stored: as one file per
+// message, or in a single capture store (an append-only JSONL file, or a
+// SQLite database) within the persist directory.
+type PersistStore uint
+
+const (
+	PersistStoreFiles PersistStore = iota
+	PersistStoreJSONL
+	PersistStoreSQLite
+)
+
+func (s PersistStore) Display() string {
+	var value string
+	switch s {
+	case PersistStoreFiles:
+		value = persistStoreFiles
+	case PersistStoreJSONL:
+		value = persistStoreJSONL
+	case PersistStoreSQLite:
+		value = persistStoreSQLite
+	}
+
+	return value
+}
+
+func parsePersistStore(value string) (PersistStore, error) {
+	switch value {
+	case persistStoreFiles:
+		return PersistStoreFiles, nil
+	case persistStoreJSONL:
+		return PersistStoreJSONL, nil
+	case persistStoreSQLite:
+		if !SQLiteSupported {
+			return PersistStoreFiles, fmt.Errorf("%w: %q: %w", errIncorrectPersistStore, value, ErrSQLiteNotSupported)
+		}
+		return PersistStoreSQLite, nil
+	default:
+		return PersistStoreFiles, fmt.Errorf("%w: %q; possible values: [%s, %s, %s]", errIncorrectPersistStore, value, persistStoreFiles, persistStoreJSONL, persistStoreSQLite)
+	}
+}
+
+// DefaultPersistDir is where messages for a queue are persisted, unless a
+// profile declares its own directory.
+func DefaultPersistDir(queueName string) string {
+	return filepath.Join("messages", queueName)
+}
+
+// PersistFilenameTemplate determines the path (relative to the persist
+// directory) a message is persisted at. Placeholders are written as
+// "{placeholder}"; a template can contain "/" to persist messages in nested
+// directories (eg. "{date}/{context}/{timestamp}-{id}").
+type PersistFilenameTemplate struct {
+	raw string
+}
+
+func (p PersistFilenameTemplate) String() string {
+	if p.raw == "" {
+		return DefaultPersistFilename
+	}
+
+	return p.raw
+}
+
+func ParsePersistFilenameTemplate(value string) (PersistFilenameTemplate, error) {
+	var zero PersistFilenameTemplate
+
+	if strings.TrimSpace(value) == "" {
+		return zero, errPersistFilenameEmpty
+	}
+
+	withoutPlaceholders := persistPlaceholderRegex.ReplaceAllString(value, "")
+	if strings.ContainsAny(withoutPlaceholders, "{}") {
+		return zero, fmt.Errorf("%w: %q", errPersistFilenameUnclosed, value)
+	}
+
+	var hasIdentifier bool
+	for _, match := range persistPlaceholderRegex.FindAllStringSubmatch(value, -1) {
+		if !slices.Contains(persistPlaceholders, match[1]) {
+			return zero, fmt.Errorf("%w: %q; possible values: %v", errPersistFilenamePlaceholderUnknown, match[0], persistPlaceholders)
+		}
+		if match[1] == "id" || match[1] == "timestamp" {
+			hasIdentifier = true
+		}
+	}
+
+	if !hasIdentifier {
+		return zero, fmt.Errorf("%w: %q", errPersistFilenameWithoutID, value)
+	}
+
+	if filepath.IsAbs(value) || slices.Contains(strings.Split(filepath.ToSlash(value), "/"), "..") {
+		return zero, fmt.Errorf("%w: %q", errPersistFilenameNotRelative, value)
+	}
+
+	return PersistFilenameTemplate{raw: value}, nil
+}
+
+// PersistFilenameValues are the values placeholders in a persist filename
+// template are replaced with.
+type PersistFilenameValues struct {
+	ID           string
+	Queue        string
+	ContextValue *string
+	GroupID      string
+	ReceiveCount string
+	At           time.Time
+}
+
+// NewPersistFilenameValues gets the values for a message received from a
+// queue; attributes are the message's system attributes.
+func NewPersistFilenameValues(message Message, attributes map[string]string, queueName string, at time.Time) PersistFilenameValues {
+	return PersistFilenameValues{
+		ID:           message.ID,
+		Queue:        queueName,
+		ContextValue: message.ContextValue,
+		GroupID:      attributes[string(sqstypes.MessageSystemAttributeNameMessageGroupId)],
+		ReceiveCount: attributes[string(sqstypes.MessageSystemAttributeNameApproximateReceiveCount)],
+		At:           at,
+	}
+}
+
+// Render returns the path a message is to be persisted at, relative to the
+// persist directory, without an extension. Values are sanitized, so that they
+// can't introduce directories of their own.
+func (p PersistFilenameTemplate) Render(values PersistFilenameValues) string {
+	var contextValue string
+	if values.ContextValue != nil {
+		contextValue = *values.ContextValue
+	}
+
+	replacements := map[string]string{
+		"id":            values.ID,
+		"timestamp":     strconv.FormatInt(values.At.Unix(), 10),
+		"date":          values.At.Format("2006-01-02"),
+		"year":          values.At.Format("2006"),
+		"month":         values.At.Format("01"),
+		"day":           values.At.Format("02"),
+		"hour":          values.At.Format("15"),
+		"minute":        values.At.Format("04"),
+		"queue":         values.Queue,
+		"context":       contextValue,
+		"group":         values.GroupID,
+		"receive_count": values.ReceiveCount,
+	}
+
+	rendered := persistPlaceholderRegex.ReplaceAllStringFunc(p.String(), func(placeholder string) string {
+		return sanitizePersistValue(replacements[strings.Trim(placeholder, "{}")])
+	})
+
+	return filepath.FromSlash(rendered)
+}
+
+func sanitizePersistValue(value string) string {
+	value = unsafeFilenameChars.ReplaceAllString(value, "_")
+	if value == "" || strings.Trim(value, ".") == "" {
+		return persistValueMissing
+	}
+
+	return value
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePersistFilenameTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		err      error
+	}{
+		{name: "default template", template: DefaultPersistFilename},
+		{name: "nested template", template: "{date}/{context}/{group}-{receive_count}-{id}"},
+		{name: "empty template", template: " ", err: errPersistFilenameEmpty},
+		{name: "unknown placeholder", template: "{timestamp}-{unknown}", err: errPersistFilenamePlaceholderUnknown},
+		{name: "unclosed placeholder", template: "{timestamp}-{id", err: errPersistFilenameUnclosed},
+		{name: "no identifying placeholder", template: "{date}/{context}", err: errPersistFilenameWithoutID},
+		{name: "absolute path", template: "/tmp/{id}", err: errPersistFilenameNotRelative},
+		{name: "parent directory", template: "../{id}", err: errPersistFilenameNotRelative},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePersistFilenameTemplate(tc.template)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.template, got.String())
+		})
+	}
+}
+
+func TestPersistFilenameTemplateRender(t *testing.T) {
+	contextValue := "orders/eu 1"
+	values := PersistFilenameValues{
+		ID:           "id-a",
+		Queue:        "queue-a.fifo",
+		ContextValue: &contextValue,
+		GroupID:      "group-a",
+		ReceiveCount: "2",
+		At:           time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+	}
+
+	t.Run("zero template renders the default filename", func(t *testing.T) {
+		got := PersistFilenameTemplate{}.Render(values)
+
+		assert.Equal(t, "1700000000-id-a", got)
+	})
+
+	t.Run("placeholders are replaced with sanitized values", func(t *testing.T) {
+		template, err := ParsePersistFilenameTemplate("{queue}/{year}/{month}/{day}/{context}/{hour}{minute}-{group}-{receive_count}-{id}")
+		require.NoError(t, err)
+
+		got := template.Render(values)
+
+		assert.Equal(t, filepath.Join("queue-a.fifo", "2023", "11", "14", "orders_eu_1", "2213-group-a-2-id-a"), got)
+	})
+
+	t.Run("missing values are replaced with a placeholder", func(t *testing.T) {
+		template, err := ParsePersistFilenameTemplate("{context}/{group}/{id}")
+		require.NoError(t, err)
+
+		got := template.Render(PersistFilenameValues{ID: "id-a"})
+
+		assert.Equal(t, filepath.Join("none", "none", "id-a"), got)
+	})
+}
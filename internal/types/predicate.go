@@ -0,0 +1,251 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	errPredicateEmpty               = errors.New("predicate is empty")
+	errPredicateConditionIncorrect  = errors.New("predicate condition is incorrect")
+	errPredicateSubjectUnknown      = errors.New("predicate condition needs to start with a JSON path (\"$.\"), an attribute (\"@\"), or \"body\"")
+	errPredicateOperatorUnsupported = errors.New("predicate operator isn't supported")
+	errPredicateRegexIncorrect      = errors.New("predicate regex is incorrect")
+)
+
+// predicateOperators is ordered so that longer operators are matched first.
+var predicateOperators = []string{"==", "!=", ">=", "<=", "!~", "=", ">", "<", "~"}
+
+// predicateConjunctionRegex splits a predicate into its conditions.
+var predicateConjunctionRegex = regexp.MustCompile(`(?i)\s+(?:and|&&)\s+`)
+
+type predicateSubject uint
+
+const (
+	predicateSubjectJSONPath predicateSubject = iota
+	predicateSubjectAttribute
+	predicateSubjectBody
+)
+
+// MessagePredicate decides which messages are kept when fetching messages
+// until some match. A predicate is one or more conditions joined by "and" (or
+// "&&"); each condition compares a subject with a value:
+//
+//   - "$.path.to[0].key <op> value": a value in the message body
+//   - "@Name <op> value": a system attribute (eg. ApproximateReceiveCount), or
+//     a message attribute with a string value
+//   - "body ~ pattern": the whole message body
+//
+// Operators are "=" (or "=="), "!=", ">", ">=", "<", "<=", "~" (matches a
+// regex) and "!~". Values can be quoted. Comparisons are numeric when both
+// sides are numbers; a condition on a missing path or attribute never matches.
+type MessagePredicate struct {
+	raw        string
+	conditions []predicateCondition
+}
+
+type predicateCondition struct {
+	subject   predicateSubject
+	path      []jsonPathSegment
+	attribute string
+	operator  string
+	value     string
+	regex     *regexp.Regexp
+}
+
+func ParseMessagePredicate(value string) (MessagePredicate, error) {
+	var zero MessagePredicate
+
+	raw := strings.TrimSpace(value)
+	if raw == "" {
+		return zero, errPredicateEmpty
+	}
+
+	parts := predicateConjunctionRegex.Split(raw, -1)
+	conditions := make([]predicateCondition, len(parts))
+	for i, part := range parts {
+		condition, err := parsePredicateCondition(strings.TrimSpace(part))
+		if err != nil {
+			return zero, err
+		}
+		conditions[i] = condition
+	}
+
+	return MessagePredicate{raw: raw, conditions: conditions}, nil
+}
+
+func parsePredicateCondition(value string) (predicateCondition, error) {
+	var condition predicateCondition
+
+	subjectEnd := strings.IndexAny(value, "=!<>~ ")
+	if subjectEnd <= 0 {
+		return condition, fmt.Errorf("%w: %q", errPredicateConditionIncorrect, value)
+	}
+	subject := value[:subjectEnd]
+	rest := strings.TrimSpace(value[subjectEnd:])
+
+	for _, operator := range predicateOperators {
+		if strings.HasPrefix(rest, operator) {
+			condition.operator = operator
+			break
+		}
+	}
+	if condition.operator == "" {
+		return condition, fmt.Errorf("%w: %q", errPredicateConditionIncorrect, value)
+	}
+	rawValue := strings.TrimSpace(strings.TrimPrefix(rest, condition.operator))
+	if rawValue == "" {
+		return condition, fmt.Errorf("%w: %q", errPredicateConditionIncorrect, value)
+	}
+	condition.value = unquote(rawValue)
+
+	if condition.operator == "==" {
+		condition.operator = "="
+	}
+
+	switch {
+	case strings.HasPrefix(subject, "$"):
+		path, err := parseJSONPath(subject)
+		if err != nil {
+			return condition, err
+		}
+		condition.subject = predicateSubjectJSONPath
+		condition.path = path
+	case strings.HasPrefix(subject, "@") && len(subject) > 1:
+		condition.subject = predicateSubjectAttribute
+		condition.attribute = subject[1:]
+	case subject == "body":
+		condition.subject = predicateSubjectBody
+		switch condition.operator {
+		case "=", "!=", "~", "!~":
+		default:
+			return condition, fmt.Errorf("%w for the body: %q", errPredicateOperatorUnsupported, condition.operator)
+		}
+	default:
+		return condition, fmt.Errorf("%w: %q", errPredicateSubjectUnknown, value)
+	}
+
+	if condition.operator == "~" || condition.operator == "!~" {
+		regex, err := regexp.Compile(condition.value)
+		if err != nil {
+			return condition, fmt.Errorf("%w: %s", errPredicateRegexIncorrect, err.Error())
+		}
+		condition.regex = regex
+	}
+
+	return condition, nil
+}
+
+func (p MessagePredicate) String() string {
+	return p.raw
+}
+
+// Matches reports whether a message satisfies all of the predicate's
+// conditions.
+func (p MessagePredicate) Matches(message Message) bool {
+	for _, condition := range p.conditions {
+		if !condition.matches(message) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c predicateCondition) matches(message Message) bool {
+	var actual any
+	switch c.subject {
+	case predicateSubjectJSONPath:
+		value, ok := lookupJSONPath(message.Body, c.path)
+		if !ok {
+			return false
+		}
+		actual = value
+	case predicateSubjectAttribute:
+		value, ok := message.Attributes[c.attribute]
+		if !ok {
+			return false
+		}
+		actual = value
+	case predicateSubjectBody:
+		actual = message.Body
+	}
+
+	switch c.operator {
+	case "=":
+		return jsonValueEquals(actual, c.value)
+	case "!=":
+		return !jsonValueEquals(actual, c.value)
+	case "~":
+		return c.regex.MatchString(predicateString(actual))
+	case "!~":
+		return !c.regex.MatchString(predicateString(actual))
+	default:
+		return c.compare(actual)
+	}
+}
+
+// compare handles the ordering operators; values are compared as numbers if
+// both are numeric, and as strings otherwise.
+func (c predicateCondition) compare(actual any) bool {
+	var cmp int
+	actualNum, actualIsNum := predicateNumber(actual)
+	expectedNum, err := strconv.ParseFloat(c.value, 64)
+	switch {
+	case actualIsNum && err == nil:
+		switch {
+		case actualNum < expectedNum:
+			cmp = -1
+		case actualNum > expectedNum:
+			cmp = 1
+		}
+	default:
+		str, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(str, c.value)
+	}
+
+	switch c.operator {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default:
+		return cmp <= 0
+	}
+}
+
+func predicateNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		num, err := strconv.ParseFloat(v, 64)
+		return num, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// predicateString is what regexes are matched against: strings as they are,
+// and everything else as JSON.
+func predicateString(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+
+	return string(bytes)
+}
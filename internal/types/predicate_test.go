@@ -0,0 +1,95 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessagePredicate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		numConditions int
+		err           error
+	}{
+		// success
+		{name: "JSON path equality", value: "$.kind = Created", numConditions: 1},
+		{name: "without spaces", value: "$.seq>=10", numConditions: 1},
+		{name: "attribute", value: "@ApproximateReceiveCount > 3", numConditions: 1},
+		{name: "body regex", value: `body ~ "agg-[0-9]+"`, numConditions: 1},
+		{name: "several conditions", value: "$.kind = Created and @tenant = acme && body !~ test", numConditions: 3},
+		{name: "conjunction is case-insensitive", value: "$.a = 1 AND $.b = 2", numConditions: 2},
+		// failures
+		{name: "empty", value: " ", err: errPredicateEmpty},
+		{name: "no operator", value: "$.kind Created", err: errPredicateConditionIncorrect},
+		{name: "no value", value: "$.kind =", err: errPredicateConditionIncorrect},
+		{name: "no subject", value: "= 1", err: errPredicateConditionIncorrect},
+		{name: "unknown subject", value: "kind = Created", err: errPredicateSubjectUnknown},
+		{name: "empty attribute", value: "@ = 1", err: errPredicateSubjectUnknown},
+		{name: "incorrect JSON path", value: "$..kind = Created", err: errFilterJSONPathInvalid},
+		{name: "ordering the body", value: "body > 1", err: errPredicateOperatorUnsupported},
+		{name: "incorrect regex", value: "body ~ agg-[", err: errPredicateRegexIncorrect},
+		{name: "one incorrect condition", value: "$.kind = Created and nope", err: errPredicateConditionIncorrect},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMessagePredicate(tt.value)
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, got.conditions, tt.numConditions)
+		})
+	}
+}
+
+func TestMessagePredicateMatches(t *testing.T) {
+	message := Message{
+		ID:   "id-001",
+		Body: `{"seq": 12, "kind": "Created", "at": "2024-06-01T10:00:00Z", "order": {"items": [{"sku": "A-1", "quantity": 2}]}}`,
+		Attributes: map[string]string{
+			"ApproximateReceiveCount": "4",
+			"tenant":                  "acme",
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		predicate string
+		expected  bool
+	}{
+		{name: "string equality", predicate: "$.kind = Created", expected: true},
+		{name: "quoted string equality", predicate: `$.kind == "Created"`, expected: true},
+		{name: "inequality", predicate: "$.kind != Deleted", expected: true},
+		{name: "nested path", predicate: "$.order.items[0].sku = A-1", expected: true},
+		{name: "number greater than", predicate: "$.seq > 10", expected: true},
+		{name: "number less than or equal", predicate: "$.order.items[0].quantity <= 1", expected: false},
+		{name: "string ordering", predicate: "$.at >= 2024-06-01", expected: true},
+		{name: "regex on a JSON value", predicate: "$.kind ~ ^Cre", expected: true},
+		{name: "regex on a non-string JSON value", predicate: `$.order ~ "quantity":2`, expected: true},
+		{name: "negated regex", predicate: "$.kind !~ ^Cre", expected: false},
+		{name: "numeric attribute", predicate: "@ApproximateReceiveCount >= 4", expected: true},
+		{name: "string attribute", predicate: "@tenant = acme", expected: true},
+		{name: "missing attribute", predicate: "@absent != x", expected: false},
+		{name: "missing path", predicate: "$.absent != x", expected: false},
+		{name: "body regex", predicate: `body ~ "seq": 1[0-9]`, expected: true},
+		{name: "all conditions match", predicate: "$.kind = Created and @tenant = acme and $.seq < 20", expected: true},
+		{name: "one condition doesn't match", predicate: "$.kind = Created and @tenant = other", expected: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			predicate, err := ParseMessagePredicate(tt.predicate)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, predicate.Matches(message))
+		})
+	}
+}
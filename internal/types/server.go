@@ -0,0 +1,156 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultServerHost  = "127.0.0.1"
+	minAuthTokenLength = 16
+)
+
+var (
+	errServerHostEmpty      = errors.New("host is empty")
+	errServerPortOutOfRange = errors.New("port needs to be between 0 and 65535")
+	errTLSCertWithoutKey    = errors.New("TLS certificate provided without a key")
+	errTLSKeyWithoutCert    = errors.New("TLS key provided without a certificate")
+	errAuthTokenTooShort    = errors.New("auth token is too short")
+	errInvalidAllowedOrigin = errors.New("allowed origin is invalid")
+)
+
+// ServerConfig determines where cueitup's web interface listens.
+type ServerConfig struct {
+	Host string
+	// Port is the port to listen on; 0 means the first open port in cueitup's
+	// default range is used.
+	Port        int
+	TLSCertPath string
+	TLSKeyPath  string
+	// AuthToken is the token needed to access the web interface; if empty, a
+	// token is generated at startup.
+	AuthToken string
+	// AllowedOrigins are origins (other than the server's own) that are
+	// allowed to make cross-origin requests to the API.
+	AllowedOrigins []string
+}
+
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertPath != "" && c.TLSKeyPath != ""
+}
+
+func (c ServerConfig) Scheme() string {
+	if c.TLSEnabled() {
+		return "https"
+	}
+
+	return "http"
+}
+
+// BrowserHost is the host to use in URLs for the web interface. Hosts that
+// mean "all interfaces" (eg. 0.0.0.0 or ::) can't be opened in every browser,
+// so localhost is used for them.
+func (c ServerConfig) BrowserHost() string {
+	if ip := net.ParseIP(c.Host); ip != nil && ip.IsUnspecified() {
+		return "localhost"
+	}
+
+	return c.Host
+}
+
+func (c ServerConfig) Display() string {
+	port := "first open port between 8500-9500"
+	if c.Port != 0 {
+		port = strconv.Itoa(c.Port)
+	}
+
+	tlsCert := notProvided
+	tlsKey := notProvided
+	if c.TLSEnabled() {
+		tlsCert = c.TLSCertPath
+		tlsKey = c.TLSKeyPath
+	}
+
+	authToken := "<GENERATED AT STARTUP>"
+	if c.AuthToken != "" {
+		authToken = "<PROVIDED>"
+	}
+
+	allowedOrigins := notProvided
+	if len(c.AllowedOrigins) > 0 {
+		allowedOrigins = strings.Join(c.AllowedOrigins, ", ")
+	}
+
+	return fmt.Sprintf(`
+- host                    %s
+- port                    %s
+- TLS certificate         %s
+- TLS key                 %s
+- auth token              %s
+- allowed origins         %s
+`,
+		c.Host,
+		port,
+		tlsCert,
+		tlsKey,
+		authToken,
+		allowedOrigins,
+	)
+}
+
+func ParseServerConfig(
+	host string,
+	port int,
+	tlsCertPath,
+	tlsKeyPath,
+	authToken string,
+	allowedOrigins []string,
+) (ServerConfig, []error) {
+	var errors []error
+
+	if host == "" {
+		errors = append(errors, errServerHostEmpty)
+	}
+
+	if port < 0 || port > 65535 {
+		errors = append(errors, fmt.Errorf("%w; got: %d", errServerPortOutOfRange, port))
+	}
+
+	switch {
+	case tlsCertPath != "" && tlsKeyPath == "":
+		errors = append(errors, errTLSCertWithoutKey)
+	case tlsCertPath == "" && tlsKeyPath != "":
+		errors = append(errors, errTLSKeyWithoutCert)
+	}
+
+	if authToken != "" && len(authToken) < minAuthTokenLength {
+		errors = append(errors, fmt.Errorf("%w; needs to be at least %d characters long", errAuthTokenTooShort, minAuthTokenLength))
+	}
+
+	var origins []string
+	for _, origin := range allowedOrigins {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			errors = append(errors, fmt.Errorf("%w: %q; needs to be of the form scheme://host[:port]", errInvalidAllowedOrigin, origin))
+			continue
+		}
+		origins = append(origins, fmt.Sprintf("%s://%s", parsed.Scheme, parsed.Host))
+	}
+
+	if len(errors) > 0 {
+		return ServerConfig{}, errors
+	}
+
+	return ServerConfig{
+		Host:           host,
+		Port:           port,
+		TLSCertPath:    tlsCertPath,
+		TLSKeyPath:     tlsKeyPath,
+		AuthToken:      authToken,
+		AllowedOrigins: origins,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerConfig(t *testing.T) {
+	t.Run("defaults are valid", func(t *testing.T) {
+		got, errs := ParseServerConfig(DefaultServerHost, 0, "", "", "", nil)
+
+		require.Empty(t, errs)
+		assert.Equal(t, ServerConfig{Host: DefaultServerHost}, got)
+		assert.False(t, got.TLSEnabled())
+		assert.Equal(t, "http", got.Scheme())
+	})
+
+	t.Run("TLS is enabled when both a certificate and key are provided", func(t *testing.T) {
+		got, errs := ParseServerConfig("0.0.0.0", 8443, "cert.pem", "key.pem", "", nil)
+
+		require.Empty(t, errs)
+		assert.True(t, got.TLSEnabled())
+		assert.Equal(t, "https", got.Scheme())
+		assert.Equal(t, 8443, got.Port)
+	})
+
+	t.Run("all errors are reported", func(t *testing.T) {
+		_, errs := ParseServerConfig("", 70000, "cert.pem", "", "short", []string{"localhost:1234"})
+
+		require.Len(t, errs, 5)
+		assert.ErrorIs(t, errs[0], errServerHostEmpty)
+		assert.ErrorIs(t, errs[1], errServerPortOutOfRange)
+		assert.ErrorIs(t, errs[2], errTLSCertWithoutKey)
+		assert.ErrorIs(t, errs[3], errAuthTokenTooShort)
+		assert.ErrorIs(t, errs[4], errInvalidAllowedOrigin)
+	})
+
+	t.Run("key without a certificate is rejected", func(t *testing.T) {
+		_, errs := ParseServerConfig(DefaultServerHost, 0, "", "key.pem", "", nil)
+
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], errTLSKeyWithoutCert)
+	})
+
+	t.Run("allowed origins are normalized", func(t *testing.T) {
+		got, errs := ParseServerConfig(DefaultServerHost, 0, "", "", "a-long-enough-static-token", []string{"http://localhost:1234/"})
+
+		require.Empty(t, errs)
+		assert.Equal(t, []string{"http://localhost:1234"}, got.AllowedOrigins)
+		assert.Equal(t, "a-long-enough-static-token", got.AuthToken)
+	})
+}
+
+func TestServerConfigBrowserHost(t *testing.T) {
+	testCases := []struct {
+		host     string
+		expected string
+	}{
+		{host: "127.0.0.1", expected: "127.0.0.1"},
+		{host: "0.0.0.0", expected: "localhost"},
+		{host: "::", expected: "localhost"},
+		{host: "::1", expected: "::1"},
+		{host: "cueitup.internal", expected: "cueitup.internal"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ServerConfig{Host: tt.host}.BrowserHost())
+		})
+	}
+}
@@ -1,9 +1,13 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/dhth/cueitup/internal/utils"
@@ -80,6 +84,7 @@ func (b TUIBehaviours) Display() string {
 
 type WebBehaviours struct {
 	DeleteMessages   bool `json:"delete_messages"`
+	PersistMessages  bool `json:"persist_messages"`
 	SelectOnHover    bool `json:"select_on_hover"`
 	ShowMessageCount bool `json:"show_message_count"`
 }
@@ -87,26 +92,77 @@ type WebBehaviours struct {
 func (b WebBehaviours) Display() string {
 	return fmt.Sprintf(`
 - delete messages         %v
+- persist messages        %v
 - select on hover         %v
 - show message count      %v
 `,
 		b.DeleteMessages,
+		b.PersistMessages,
 		b.SelectOnHover,
 		b.ShowMessageCount,
 	)
 }
 
+// BodyView is a way of showing a message's body.
+type BodyView uint
+
+const (
+	// BodyFormatted is the whole body, formatted (for JSON messages), with
+	// keys in the order they were received in.
+	BodyFormatted BodyView = iota
+	// BodyRaw is the body exactly as it was received.
+	BodyRaw
+	// BodySubset is the object under the profile's subset key.
+	BodySubset
+)
+
+func (v BodyView) String() string {
+	var value string
+	switch v {
+	case BodyFormatted:
+		value = "formatted"
+	case BodyRaw:
+		value = "raw"
+	case BodySubset:
+		value = "subset"
+	}
+
+	return value
+}
+
 type Message struct {
-	ID           string  `json:"id"`
-	Body         string  `json:"body"`
+	ID string `json:"id"`
+	// Body is what's shown for the message by default: the object under the
+	// subset key, if one is configured, or the whole body otherwise.
+	Body string `json:"body"`
+	// RawBody is the body exactly as it was received.
+	RawBody      string  `json:"raw_body"`
 	ContextKey   *string `json:"context_key"`
 	ContextValue *string `json:"context_value"`
-	Err          error   `json:"-"`
+	// Attributes holds the message's system attributes, as well as its
+	// message attributes that have a string value; it's used for filtering.
+	Attributes map[string]string `json:"-"`
+	// ReceiptHandle is the handle the message was received with (if any).
+	ReceiptHandle string `json:"-"`
+	// MessageAttributes are the message attributes as received, so that the
+	// message can be sent again with them.
+	MessageAttributes map[string]sqstypes.MessageAttributeValue `json:"-"`
+	// DeleteError is set if the message was to be deleted after being
+	// fetched, but couldn't be; it's still on the queue.
+	DeleteError *string `json:"delete_error,omitempty"`
+	Err         error   `json:"-"`
 }
 
 type SerializableMessage struct {
 	Message
 	Err *string `json:"error"`
+	// FormattedBody is the whole body, formatted; it's only set if it differs
+	// from Body.
+	FormattedBody *string `json:"formatted_body,omitempty"`
+	// PersistedTo and PersistError are only set when messages are persisted
+	// while being fetched.
+	PersistedTo  *string `json:"persisted_to,omitempty"`
+	PersistError *string `json:"persist_error,omitempty"`
 }
 
 func (m Message) ToSerializable() SerializableMessage {
@@ -116,18 +172,52 @@ func (m Message) ToSerializable() SerializableMessage {
 		err = &errStr
 	}
 
+	var formattedBody *string
+	if formatted := m.ViewBody(BodyFormatted); formatted != m.Body {
+		formattedBody = &formatted
+	}
+
 	return SerializableMessage{
-		Message: m,
-		Err:     err,
+		Message:       m,
+		Err:           err,
+		FormattedBody: formattedBody,
 	}
 }
 
+// ViewBody returns the message's body as per view; bodies that aren't JSON
+// are shown as they are in every view.
+func (m Message) ViewBody(view BodyView) string {
+	switch view {
+	case BodyRaw:
+		if m.RawBody != "" {
+			return m.RawBody
+		}
+	case BodyFormatted:
+		if m.RawBody == "" {
+			break
+		}
+
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(strings.TrimSpace(m.RawBody)), "", "  "); err != nil {
+			return m.RawBody
+		}
+		return buf.String()
+	}
+
+	return m.Body
+}
+
 func (m Message) Title() string {
 	if m.Err != nil {
 		return "error"
 	}
 
-	return fmt.Sprintf("%s: %s", utils.RightPadTrim("message ID", 12), m.ID)
+	title := fmt.Sprintf("%s: %s", utils.RightPadTrim("message ID", 12), m.ID)
+	if m.DeleteError != nil {
+		title += " (not deleted)"
+	}
+
+	return title
 }
 
 func (m Message) Description() string {
@@ -142,17 +232,68 @@ func (m Message) Description() string {
 	return ""
 }
 
+// FilterValue is the text substring and regex filters are matched against:
+// the message's ID, context value, attributes (as "name=value"), error, why it
+// couldn't be deleted (as "not deleted: <reason>"), and body, one per line.
 func (m Message) FilterValue() string {
-	return m.ID
+	parts := []string{m.ID}
+	if m.ContextValue != nil {
+		parts = append(parts, *m.ContextValue)
+	}
+
+	names := slices.Sorted(maps.Keys(m.Attributes))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, m.Attributes[name]))
+	}
+
+	if m.Err != nil {
+		parts = append(parts, m.Err.Error())
+	}
+
+	if m.DeleteError != nil {
+		parts = append(parts, fmt.Sprintf("not deleted: %s", *m.DeleteError))
+	}
+
+	parts = append(parts, m.Body)
+
+	return strings.Join(parts, "\n")
 }
 
 func GetMessageData(message *sqstypes.Message, config Config) Message {
+	var data Message
 	switch config.Format {
 	case JSON:
-		return getJSONMessage(message, config.SubsetKey, config.ContextKey)
+		data = getJSONMessage(message, config.SubsetKey, config.ContextKey)
 	default:
-		return getPlainMessage(message)
+		data = getPlainMessage(message)
 	}
+
+	if message.Body != nil {
+		data.RawBody = *message.Body
+	}
+	data.Attributes = getMessageAttributes(message)
+	data.MessageAttributes = message.MessageAttributes
+	if message.ReceiptHandle != nil {
+		data.ReceiptHandle = *message.ReceiptHandle
+	}
+
+	return data
+}
+
+func getMessageAttributes(message *sqstypes.Message) map[string]string {
+	if len(message.Attributes) == 0 && len(message.MessageAttributes) == 0 {
+		return nil
+	}
+
+	attributes := make(map[string]string, len(message.Attributes)+len(message.MessageAttributes))
+	maps.Copy(attributes, message.Attributes)
+	for name, value := range message.MessageAttributes {
+		if value.StringValue != nil {
+			attributes[name] = *value.StringValue
+		}
+	}
+
+	return attributes
 }
 
 func getJSONMessage(message *sqstypes.Message, subsetKey *string, contextKey *string) Message {
@@ -179,7 +320,7 @@ func getJSONMessage(message *sqstypes.Message, subsetKey *string, contextKey *st
 	}
 
 	if subsetKey == nil && contextKey == nil {
-		return getJSONMessageWithNoSubsetAndContext(messageID, bodyBytes, data)
+		return getJSONMessageWithNoSubsetAndContext(messageID, bodyBytes)
 	}
 
 	if subsetKey == nil && contextKey != nil {
@@ -193,8 +334,11 @@ func getJSONMessage(message *sqstypes.Message, subsetKey *string, contextKey *st
 	return getJSONMessageWithSubsetAndContext(messageID, bodyBytes, *subsetKey, *contextKey)
 }
 
-func getJSONMessageWithNoSubsetAndContext(messageID string, bodyBytes []byte, data map[string]any) Message {
-	bytes, err := json.MarshalIndent(data, "", "  ")
+// getJSONMessageWithNoSubsetAndContext formats the body as is, so that keys stay
+// in the order they were received in.
+func getJSONMessageWithNoSubsetAndContext(messageID string, bodyBytes []byte) Message {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, bytes.TrimSpace(bodyBytes), "", "  ")
 	if err != nil {
 		return Message{
 			Err: wrapErrWithDetails(fmt.Errorf("%w: %s", errCouldntMarshalBytes, err.Error()), messageID, bodyBytes),
@@ -203,7 +347,7 @@ func getJSONMessageWithNoSubsetAndContext(messageID string, bodyBytes []byte, da
 
 	return Message{
 		ID:   messageID,
-		Body: string(bytes),
+		Body: buf.String(),
 	}
 }
 
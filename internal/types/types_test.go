@@ -298,3 +298,65 @@ func TestGetJSONMessage(t *testing.T) {
 		}
 	}
 }
+
+func TestGetMessageDataKeepsReceiptHandle(t *testing.T) {
+	messageID := "7bc4bd4a-f099-4831-952d-5d03006a6a6f"
+	body := `{"aggregateId": "agg-1"}`
+	receiptHandle := "AQEBwJnKyrHigUMZj6rYigCgxlaS3SLy0a"
+	config := Config{Format: JSON}
+
+	got := GetMessageData(&sqstypes.Message{
+		MessageId:     &messageID,
+		Body:          &body,
+		ReceiptHandle: &receiptHandle,
+	}, config)
+
+	require.NoError(t, got.Err)
+	assert.Equal(t, receiptHandle, got.ReceiptHandle)
+}
+
+func TestMessageViewBody(t *testing.T) {
+	messageID := "7bc4bd4a-f099-4831-952d-5d03006a6a6f"
+	body := `{"version": 2, "browserInfo": {"platform": "Linux"}, "aggregateId": "agg-1"}`
+	subsetKey := "browserInfo"
+
+	t.Run("formatted view keeps the order of keys", func(t *testing.T) {
+		got := GetMessageData(&sqstypes.Message{MessageId: &messageID, Body: &body}, Config{Format: JSON})
+
+		require.NoError(t, got.Err)
+		expected := strings.TrimSpace(`
+{
+  "version": 2,
+  "browserInfo": {
+    "platform": "Linux"
+  },
+  "aggregateId": "agg-1"
+}
+`)
+		assert.Equal(t, expected, got.Body)
+		assert.Equal(t, expected, got.ViewBody(BodyFormatted))
+		assert.Equal(t, body, got.ViewBody(BodyRaw))
+		assert.Nil(t, got.ToSerializable().FormattedBody)
+	})
+
+	t.Run("subset view shows the object under the subset key", func(t *testing.T) {
+		got := GetMessageData(&sqstypes.Message{MessageId: &messageID, Body: &body}, Config{Format: JSON, SubsetKey: &subsetKey})
+
+		require.NoError(t, got.Err)
+		assert.Equal(t, "{\n  \"platform\": \"Linux\"\n}", got.ViewBody(BodySubset))
+		assert.Contains(t, got.ViewBody(BodyFormatted), `"aggregateId": "agg-1"`)
+		assert.Equal(t, body, got.ViewBody(BodyRaw))
+		serializable := got.ToSerializable()
+		require.NotNil(t, serializable.FormattedBody)
+		assert.Equal(t, got.ViewBody(BodyFormatted), *serializable.FormattedBody)
+	})
+
+	t.Run("plain messages are shown as they are", func(t *testing.T) {
+		plain := "  some text\n"
+		got := GetMessageData(&sqstypes.Message{MessageId: &messageID, Body: &plain}, Config{Format: None})
+
+		require.NoError(t, got.Err)
+		assert.Equal(t, plain, got.ViewBody(BodyRaw))
+		assert.Equal(t, plain, got.ViewBody(BodyFormatted))
+	})
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+var errNoOtherBodyViews = errors.New("only JSON messages can be shown in other ways")
+
+// cycleBodyView switches to the next way of showing message bodies that's
+// available for the tab's profile (formatted, raw, or subset).
+func (m *Model) cycleBodyView(tab *queueTab) {
+	views := tab.config.BodyViews()
+	if len(views) < 2 {
+		m.errorMsg = errNoOtherBodyViews.Error()
+		return
+	}
+
+	index := slices.Index(views, tab.bodyView)
+	tab.bodyView = views[(index+1)%len(views)]
+	m.message = fmt.Sprintf("showing message bodies %s", bodyViewDescription(tab.bodyView))
+	m.showSelectedMessage(tab)
+}
+
+func bodyViewDescription(view t.BodyView) string {
+	var description string
+	switch view {
+	case t.BodyFormatted:
+		description = "in full, formatted"
+	case t.BodyRaw:
+		description = "as they were received"
+	case t.BodySubset:
+		description = "via the subset key"
+	}
+
+	return description
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+var (
+	errNoMessageSelected      = errors.New("no message selected")
+	errMessageHasError        = errors.New("the selected message couldn't be read")
+	errNoReceiptHandle        = errors.New("the selected message has no receipt handle")
+	errCouldntCopyViaOSC52    = errors.New("couldn't copy via OSC52")
+	errCouldntCopyToClipboard = errors.New("couldn't copy to the clipboard")
+)
+
+// copyToClipboard copies value to the system clipboard; what describes it in
+// the confirmation.
+//
+// Over SSH (or when no clipboard utility is available), the clipboard of the
+// terminal cueitup is running in is set via an OSC52 escape sequence instead;
+// not all terminals support this.
+func copyToClipboard(value, what string) tea.Cmd {
+	return func() tea.Msg {
+		if !isRemoteSession() {
+			err := clipboard.WriteAll(value)
+			if err == nil {
+				return CopiedToClipboardMsg{what: what}
+			}
+			if !clipboard.Unsupported {
+				return CopiedToClipboardMsg{what: what, err: fmt.Errorf("%w: %s", errCouldntCopyToClipboard, err.Error())}
+			}
+		}
+
+		if err := copyViaOSC52(value); err != nil {
+			return CopiedToClipboardMsg{what: what, err: err}
+		}
+
+		return CopiedToClipboardMsg{what: what, viaOSC52: true}
+	}
+}
+
+func isRemoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// copyViaOSC52 writes the sequence to stderr, since stdout is used by the TUI's
+// renderer.
+func copyViaOSC52(value string) error {
+	sequence := osc52.New(value)
+	switch {
+	case os.Getenv("TMUX") != "":
+		sequence = sequence.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		sequence = sequence.Screen()
+	}
+
+	if _, err := sequence.WriteTo(os.Stderr); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntCopyViaOSC52, err.Error())
+	}
+
+	return nil
+}
+
+// copySelectedMessage copies a part of the selected message, as per the key
+// pressed.
+func (m *Model) copySelectedMessage(tab *queueTab, key string) tea.Cmd {
+	message, ok := tab.msgsList.SelectedItem().(t.Message)
+	if !ok {
+		m.errorMsg = errNoMessageSelected.Error()
+		return nil
+	}
+
+	if key == "I" {
+		return copyToClipboard(message.ID, "the message ID")
+	}
+
+	if message.Err != nil {
+		m.errorMsg = errMessageHasError.Error()
+		return nil
+	}
+
+	// bodies are copied the same way whatever the body view, so that "y"
+	// always copies the body exactly as it was received
+	switch key {
+	case "y":
+		return copyToClipboard(message.ViewBody(t.BodyRaw), "the raw message body")
+	case "Y":
+		if tab.config.Format != t.JSON {
+			return copyToClipboard(message.ViewBody(t.BodyRaw), "the message body")
+		}
+		return copyToClipboard(message.ViewBody(t.BodyFormatted), "the formatted message body")
+	case "H":
+		if message.ReceiptHandle == "" {
+			m.errorMsg = errNoReceiptHandle.Error()
+			return nil
+		}
+		return copyToClipboard(message.ReceiptHandle, "the receipt handle")
+	}
+
+	return nil
+}
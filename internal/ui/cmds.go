@@ -2,7 +2,8 @@ package ui
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -12,111 +13,322 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	tea "github.com/charmbracelet/bubbletea"
+	awsconfig "github.com/dhth/cueitup/internal/aws"
+	"github.com/dhth/cueitup/internal/queue"
 	t "github.com/dhth/cueitup/internal/types"
 )
 
-func (m Model) FetchMessages(maxMessages int32, waitTime int32) tea.Cmd {
+func FetchMessages(client *sqs.Client, config t.Config, tabID int, maxMessages int32, waitTime int32) tea.Cmd {
 	return func() tea.Msg {
-		result, err := m.sqsClient.ReceiveMessage(context.TODO(),
+		result, err := client.ReceiveMessage(context.TODO(),
 			// WaitTimeSeconds > 0 enables long polling
 			// https://docs.aws.amazon.com/AWSSimpleQueueService/latest/SQSDeveloperGuide/sqs-short-and-long-polling.html#sqs-long-polling
 			&sqs.ReceiveMessageInput{
-				QueueUrl:            aws.String(m.queueURL),
+				QueueUrl:            aws.String(config.QueueURL),
 				MaxNumberOfMessages: maxMessages,
 				WaitTimeSeconds:     waitTime,
 				VisibilityTimeout:   30,
+				MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{
+					sqstypes.MessageSystemAttributeNameApproximateReceiveCount,
+					sqstypes.MessageSystemAttributeNameMessageGroupId,
+					sqstypes.MessageSystemAttributeNameSentTimestamp,
+				},
+				// message attributes are kept, so that edited messages can be
+				// sent again with them
+				MessageAttributeNames: []string{"All"},
 			})
 		if err != nil {
 			return SQSMsgsFetchedMsg{
-				err: err,
+				tabID: tabID,
+				err:   err,
 			}
 		}
 		messages := make([]t.Message, len(result.Messages))
 		for i, message := range result.Messages {
-			messages[i] = t.GetMessageData(&message, m.config)
+			messages[i] = t.GetMessageData(&message, config)
 		}
 
 		return SQSMsgsFetchedMsg{
+			tabID:       tabID,
 			messages:    messages,
 			sqsMessages: result.Messages,
 		}
 	}
 }
 
-func DeleteMessages(client *sqs.Client, queueURL string, messages []sqstypes.Message) tea.Cmd {
+func FetchMatchingMessages(client *sqs.Client, config t.Config, tabID int, opts queue.FetchOptions) tea.Cmd {
 	return func() tea.Msg {
-		entries := make([]sqstypes.DeleteMessageBatchRequestEntry, len(messages))
-		for i := range messages {
-			entries[i].Id = aws.String(fmt.Sprintf("%v", i))
-			entries[i].ReceiptHandle = messages[i].ReceiptHandle
-		}
-		_, err := client.DeleteMessageBatch(context.TODO(),
-			&sqs.DeleteMessageBatchInput{
-				Entries:  entries,
-				QueueUrl: aws.String(queueURL),
-			})
-		if err != nil {
-			return SQSMsgsDeletedMsg{
-				err: err,
-			}
+		result, err := queue.FetchMatching(context.TODO(), client, config, opts)
+		messages := make([]t.Message, len(result.Messages))
+		for i, message := range result.Messages {
+			messages[i] = t.GetMessageData(&message, config)
+		}
+
+		return MatchingMsgsFetchedMsg{
+			tabID:       tabID,
+			messages:    messages,
+			sqsMessages: result.Messages,
+			scanned:     result.Scanned,
+			timedOut:    result.TimedOut,
+			err:         err,
 		}
+	}
+}
+
+func DeleteMessages(client *sqs.Client, tabID int, queueURL string, messages []sqstypes.Message) tea.Cmd {
+	return func() tea.Msg {
+		result, err := queue.Delete(context.TODO(), client, queueURL, messages)
 
-		return SQSMsgsDeletedMsg{}
+		return SQSMsgsDeletedMsg{
+			tabID:    tabID,
+			queueURL: queueURL,
+			result:   result,
+			err:      err,
+		}
 	}
 }
 
 func GetQueueMsgCount(client *sqs.Client, queueURL string) tea.Cmd {
 	return func() tea.Msg {
-		approxMsgCountType := sqstypes.QueueAttributeNameApproximateNumberOfMessages
-		attribute, err := client.GetQueueAttributes(context.TODO(),
-			&sqs.GetQueueAttributesInput{
-				QueueUrl:       aws.String(queueURL),
-				AttributeNames: []sqstypes.QueueAttributeName{approxMsgCountType},
-			})
-		if err != nil {
-			return QueueMsgCountFetchedMsg{
-				approxMsgCount: -1,
-				err:            err,
-			}
+		return fetchQueueMsgCount(client, queueURL)
+	}
+}
+
+// getListedQueueMsgCount fetches the message count of a queue in the queue
+// browser's list.
+func getListedQueueMsgCount(client *sqs.Client, queueURL string) tea.Cmd {
+	return func() tea.Msg {
+		msg := fetchQueueMsgCount(client, queueURL)
+		msg.listed = true
+		return msg
+	}
+}
+
+func fetchQueueMsgCount(client *sqs.Client, queueURL string) QueueMsgCountFetchedMsg {
+	approxMsgCountType := sqstypes.QueueAttributeNameApproximateNumberOfMessages
+	approxInFlightCountType := sqstypes.QueueAttributeNameApproximateNumberOfMessagesNotVisible
+	attribute, err := client.GetQueueAttributes(context.TODO(),
+		&sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(queueURL),
+			AttributeNames: []sqstypes.QueueAttributeName{approxMsgCountType, approxInFlightCountType},
+		})
+	if err != nil {
+		return QueueMsgCountFetchedMsg{
+			queueURL: queueURL,
+			err:      err,
 		}
+	}
 
-		countStr := attribute.Attributes[string(approxMsgCountType)]
-		count, err := strconv.Atoi(countStr)
-		if err != nil {
-			return QueueMsgCountFetchedMsg{
-				approxMsgCount: -1,
-				err:            err,
-			}
+	count, err := strconv.Atoi(attribute.Attributes[string(approxMsgCountType)])
+	if err != nil {
+		return QueueMsgCountFetchedMsg{
+			queueURL: queueURL,
+			err:      err,
 		}
+	}
+
+	inFlightCount, err := strconv.Atoi(attribute.Attributes[string(approxInFlightCountType)])
+	if err != nil {
 		return QueueMsgCountFetchedMsg{
-			approxMsgCount: count,
+			queueURL: queueURL,
+			err:      err,
 		}
 	}
+
+	return QueueMsgCountFetchedMsg{
+		queueURL: queueURL,
+		sample: t.QueueDepthSample{
+			At: time.Now(),
+			QueueDepth: t.QueueDepth{
+				Visible:  count,
+				InFlight: inFlightCount,
+			},
+		},
+	}
 }
 
-func saveMessageToDisk(id string, value string, format t.MessageFormat, dir string) tea.Cmd {
+func listQueues(client *sqs.Client, prefix string) tea.Cmd {
 	return func() tea.Msg {
-		now := time.Now().Unix()
-		fileName := fmt.Sprintf("%d-%s.%s", now, id, format.Extension())
-		fp := filepath.Join(dir, fileName)
-		dir := filepath.Dir(fp)
-		err := os.MkdirAll(dir, 0o755)
+		var queueURLs []string
+		input := &sqs.ListQueuesInput{
+			MaxResults: aws.Int32(1000),
+		}
+		if prefix != "" {
+			input.QueueNamePrefix = aws.String(prefix)
+		}
+
+		paginator := sqs.NewListQueuesPaginator(client, input)
+		for paginator.HasMorePages() {
+			output, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return QueuesListedMsg{err: err}
+			}
+			queueURLs = append(queueURLs, output.QueueUrls...)
+		}
+
+		return QueuesListedMsg{queueURLs: queueURLs}
+	}
+}
+
+func saveQueueAsProfile(configPath string, profile t.ProfileConfig) tea.Cmd {
+	return func() tea.Msg {
+		configBytes, err := os.ReadFile(configPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return ProfileSavedMsg{err: err}
+		}
+
+		updatedBytes, err := t.AddProfileToConfig(configBytes, profile)
 		if err != nil {
-			return RecordSavedToDiskMsg{err: err}
+			return ProfileSavedMsg{err: err}
 		}
 
-		err = os.WriteFile(fp, []byte(value), 0o644)
+		err = os.MkdirAll(filepath.Dir(configPath), 0o755)
 		if err != nil {
-			return RecordSavedToDiskMsg{err: err}
+			return ProfileSavedMsg{err: err}
 		}
 
-		return RecordSavedToDiskMsg{path: fp}
+		err = os.WriteFile(configPath, updatedBytes, 0o644)
+		if err != nil {
+			return ProfileSavedMsg{err: err}
+		}
+
+		return ProfileSavedMsg{name: profile.Name}
+	}
+}
+
+func saveMessageToDisk(persister queue.Persister, tabID int, queueURL string, message queue.PersistableMessage, deleteAfter bool) tea.Cmd {
+	return func() tea.Msg {
+		location, err := persister.Persist(message)
+
+		return RecordSavedToDiskMsg{
+			tabID:       tabID,
+			queueURL:    queueURL,
+			sqsMessage:  message.SQSMessage,
+			deleteAfter: deleteAfter,
+			path:        location,
+			err:         err,
+		}
+	}
+}
+
+// purgeQueue purges a queue in the background, until purge is cancelled.
+// Progress made while backing up messages is reported via PurgeProgressMsg,
+// and the outcome via QueuePurgedMsg.
+func purgeQueue(ctx context.Context, purge *runningPurge, client *sqs.Client, config t.Config, tabID int, confirmation, backupPath string) tea.Cmd {
+	updates := make(chan tea.Msg)
+
+	go func() {
+		defer purge.cancel()
+		result, err := queue.Purge(ctx, client, config, queue.PurgeOptions{
+			Confirmation: confirmation,
+			BackupPath:   backupPath,
+			TimeLimit:    queue.DefaultDrainTimeLimit,
+			OnProgress: func(backedUp int) {
+				// progress isn't waited for once the purge is cancelled,
+				// since the TUI might have quit
+				select {
+				case updates <- PurgeProgressMsg{
+					tabID:    tabID,
+					backedUp: backedUp,
+					updates:  updates,
+				}:
+				case <-ctx.Done():
+				}
+			},
+		})
+
+		purge.result, purge.err = result, err
+		close(purge.done)
+
+		updates <- QueuePurgedMsg{
+			tabID:  tabID,
+			result: result,
+			err:    err,
+		}
+	}()
+
+	return waitForPurgeUpdate(updates)
+}
+
+func waitForPurgeUpdate(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+func listPersistedMessages(tabID int, dir string) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := queue.ListPersistedMessages(dir, queue.PersistedMessageFilter{})
+
+		return PersistedMessagesListedMsg{
+			tabID:    tabID,
+			dir:      dir,
+			messages: messages,
+			err:      err,
+		}
+	}
+}
+
+// replayMessages sends persisted messages to the queue for a config; the SQS
+// client for the config is loaded first if one isn't available yet.
+func replayMessages(client *sqs.Client, config t.Config, items []replayFileItem) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			sdkConfig, err := awsconfig.GetAWSConfig(config.AWSConfigSource)
+			if err != nil {
+				return MessagesReplayedMsg{config: config, err: err}
+			}
+			client = sqs.NewFromConfig(sdkConfig)
+		}
+
+		messages := make([]queue.ReplayMessage, len(items))
+		for i, item := range items {
+			body := item.body
+			if body == nil {
+				persisted, err := queue.ReadPersistedMessage(item.message.Path)
+				if err != nil {
+					return MessagesReplayedMsg{config: config, client: client, err: err}
+				}
+				body = &persisted
+			}
+
+			messages[i] = queue.ReplayMessage{
+				MessageID:         item.message.MessageID,
+				Path:              item.message.Path,
+				Body:              *body,
+				MessageAttributes: item.messageAttributes,
+				MessageGroupID:    item.messageGroupID,
+			}
+		}
+
+		sent, err := queue.Replay(context.TODO(), client, config, messages, queue.ReplayOptions{})
+
+		return MessagesReplayedMsg{
+			config: config,
+			client: client,
+			sent:   sent,
+			err:    err,
+		}
+	}
+}
+
+func loadSQSClient(config t.Config) tea.Cmd {
+	return func() tea.Msg {
+		sdkConfig, err := awsconfig.GetAWSConfig(config.AWSConfigSource)
+		if err != nil {
+			return SQSClientLoadedMsg{config: config, err: err}
+		}
+
+		return SQSClientLoadedMsg{
+			config: config,
+			client: sqs.NewFromConfig(sdkConfig),
+		}
 	}
 }
 
-func tickEvery(interval time.Duration) tea.Cmd {
+func tickEvery(tabID int, interval time.Duration) tea.Cmd {
 	return tea.Tick(interval, func(time.Time) tea.Msg {
-		return MsgCountTickMsg{}
+		return MsgCountTickMsg{tabID: tabID}
 	})
 }
 
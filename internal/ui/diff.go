@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const (
+	diffMarkerWidth = 2
+	diffColumnGap   = 2
+	// diffMaxPathWidth is the share of the width the path column can take up,
+	// at most
+	diffMaxPathWidth = 3
+)
+
+// compareSelectedMessage marks the selected message as the base for comparing,
+// or, if another message is marked already, shows how the selected message
+// differs from it; the mark is kept, so that the base can be compared with
+// several messages.
+func (m *Model) compareSelectedMessage(tab *queueTab) {
+	message, ok := tab.msgsList.SelectedItem().(t.Message)
+	if !ok {
+		m.errorMsg = errNoMessageSelected.Error()
+		return
+	}
+
+	if message.Err != nil {
+		m.errorMsg = errMessageHasError.Error()
+		return
+	}
+
+	if tab.diffBase == nil {
+		tab.diffBase = &message
+		m.message = fmt.Sprintf("marked message %s for comparing; press c on another message to compare it with this one", message.ID)
+		return
+	}
+
+	if tab.diffBase.ID == message.ID {
+		tab.diffBase = nil
+		m.message = fmt.Sprintf("unmarked message %s", message.ID)
+		return
+	}
+
+	diff := t.DiffMessages(tab.diffBase.ViewBody(tab.bodyView), message.ViewBody(tab.bodyView), tab.config.Format)
+	m.msgDiff = &diff
+	m.diffTitle = fmt.Sprintf("Diff: %s → %s", tab.diffBase.ID, message.ID)
+	m.diffVP.SetContent(renderMessageDiff(diff, m.diffVP.Width))
+	m.diffVP.GotoTop()
+	m.activeView = msgDiffView
+}
+
+func (m *Model) handleDiffKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "q", "esc":
+		m.msgDiff = nil
+		m.activeView = msgsListView
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	}
+
+	return nil
+}
+
+func renderMessageDiff(diff t.MessageDiff, width int) string {
+	if diff.Identical() {
+		if diff.Structural {
+			return "The messages are identical (ignoring formatting and the order of keys)."
+		}
+		return "The messages are identical."
+	}
+
+	if diff.Structural {
+		return renderStructuralDiff(diff.Changes, width)
+	}
+
+	return renderLineDiff(diff.Lines, width)
+}
+
+// renderStructuralDiff shows a row per changed JSON path, with the values
+// before and after side by side; long values are wrapped.
+func renderStructuralDiff(changes []t.DiffChange, width int) string {
+	var added, removed, changed int
+	pathWidth := len("path")
+	for _, change := range changes {
+		switch change.Kind {
+		case t.DiffAdded:
+			added++
+		case t.DiffRemoved:
+			removed++
+		case t.DiffChanged:
+			changed++
+		}
+		pathWidth = max(pathWidth, lipgloss.Width(change.Path))
+	}
+	pathWidth = min(pathWidth, width/diffMaxPathWidth)
+	valueWidth := max((width-diffMarkerWidth-pathWidth-2*diffColumnGap)/2, 1)
+
+	row := func(marker, path, before, after string, style lipgloss.Style) string {
+		return lipgloss.JoinHorizontal(lipgloss.Top,
+			style.Width(diffMarkerWidth).Render(marker),
+			style.Width(pathWidth+diffColumnGap).PaddingRight(diffColumnGap).Render(path),
+			style.Width(valueWidth+diffColumnGap).PaddingRight(diffColumnGap).Render(before),
+			style.Width(valueWidth).Render(after),
+		)
+	}
+
+	rows := []string{
+		fmt.Sprintf("%d changes: %d added, %d removed, %d changed", len(changes), added, removed, changed),
+		"",
+		row("", "path", "before", "after", diffHeaderStyle),
+	}
+	for _, change := range changes {
+		var marker string
+		var style lipgloss.Style
+		switch change.Kind {
+		case t.DiffAdded:
+			marker, style = "+", diffAddedStyle
+		case t.DiffRemoved:
+			marker, style = "-", diffRemovedStyle
+		default:
+			marker, style = "~", diffChangedStyle
+		}
+
+		rows = append(rows, row(marker, change.Path, diffValue(change.Before), diffValue(change.After), style))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+func diffValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+// renderLineDiff shows the lines of both bodies side by side; removed lines
+// are paired up with the lines added in their place.
+func renderLineDiff(lines []t.DiffLine, width int) string {
+	columnWidth := max((width-diffColumnGap)/2, diffMarkerWidth+1)
+
+	cell := func(line *t.DiffLine) string {
+		if line == nil {
+			return lipgloss.NewStyle().Width(columnWidth).Render("")
+		}
+
+		style, marker := diffUnchangedStyle, "  "
+		switch line.Kind {
+		case t.DiffLineAdded:
+			style, marker = diffAddedStyle, "+ "
+		case t.DiffLineRemoved:
+			style, marker = diffRemovedStyle, "- "
+		}
+
+		return style.Width(columnWidth).Render(marker + line.Text)
+	}
+
+	gap := strings.Repeat(" ", diffColumnGap)
+	rows := []string{
+		lipgloss.JoinHorizontal(lipgloss.Top,
+			diffHeaderStyle.Width(columnWidth).Render("before"),
+			gap,
+			diffHeaderStyle.Width(columnWidth).Render("after"),
+		),
+	}
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == t.DiffLineSame {
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cell(&lines[i]), gap, cell(&lines[i])))
+			i++
+			continue
+		}
+
+		var removed, added []*t.DiffLine
+		for ; i < len(lines) && lines[i].Kind == t.DiffLineRemoved; i++ {
+			removed = append(removed, &lines[i])
+		}
+		for ; i < len(lines) && lines[i].Kind == t.DiffLineAdded; i++ {
+			added = append(added, &lines[i])
+		}
+
+		for j := range max(len(removed), len(added)) {
+			var before, after *t.DiffLine
+			if j < len(removed) {
+				before = removed[j]
+			}
+			if j < len(added) {
+				after = added[j]
+			}
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cell(before), gap, cell(after)))
+		}
+	}
+
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dhth/cueitup/internal/queue"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+// maxEvents is the number of events kept in the event log; older ones are
+// dropped.
+const maxEvents = 1000
+
+type eventLevel uint
+
+const (
+	eventInfo eventLevel = iota
+	eventWarning
+	eventError
+)
+
+// event is an outcome of something done in the background (deleting or
+// persisting messages), recorded in the event log.
+type event struct {
+	at      time.Time
+	level   eventLevel
+	queue   string
+	summary string
+	// details holds a line per affected message, if some of them failed
+	details []string
+}
+
+// logEvent records an event; warnings and errors are also counted as unseen
+// problems until the event log is viewed.
+func (m *Model) logEvent(e event) {
+	m.events = append(m.events, e)
+	if len(m.events) > maxEvents {
+		m.events = m.events[len(m.events)-maxEvents:]
+	}
+
+	if m.activeView == eventLogView {
+		atBottom := m.eventsVP.AtBottom()
+		m.eventsVP.SetContent(renderEvents(m.events, m.eventsVP.Width))
+		if atBottom {
+			m.eventsVP.GotoBottom()
+		}
+		return
+	}
+
+	if e.level != eventInfo {
+		m.unseenProblems++
+	}
+}
+
+// handleSQSMsgsDeleted logs the outcome of deleting a batch of messages, and
+// marks the ones that are still on the queue in their tab.
+func (m *Model) handleSQSMsgsDeleted(msg SQSMsgsDeletedMsg) {
+	queueName := utils.QueueNameFromURL(msg.queueURL)
+	failed := msg.result.Failed
+
+	if len(failed) == 0 {
+		m.logEvent(event{
+			at:      time.Now(),
+			level:   eventInfo,
+			queue:   queueName,
+			summary: msg.result.Summary(),
+		})
+		return
+	}
+
+	reasons := make(map[string]string, len(failed))
+	details := make([]string, len(failed))
+	for i, failure := range failed {
+		reasons[failure.MessageID] = failure.Reason()
+		details[i] = failure.String()
+	}
+	if tab := m.tabByID(msg.tabID); tab != nil {
+		tab.markUndeleted(reasons)
+	}
+
+	level := eventWarning
+	if msg.err != nil {
+		level = eventError
+	}
+
+	m.errorMsg = fmt.Sprintf("couldn't delete %d of %d messages from %q; press L for details", len(failed), msg.result.Deleted+len(failed), queueName)
+	m.logEvent(event{
+		at:      time.Now(),
+		level:   level,
+		queue:   queueName,
+		summary: msg.result.Summary(),
+		details: details,
+	})
+}
+
+// handleRecordSavedToDisk logs the outcome of persisting a message, and
+// deletes it if it was persisted and is to be deleted; messages that couldn't
+// be persisted are left on the queue.
+func (m *Model) handleRecordSavedToDisk(msg RecordSavedToDiskMsg) tea.Cmd {
+	queueName := utils.QueueNameFromURL(msg.queueURL)
+	messageID := aws.ToString(msg.sqsMessage.MessageId)
+	tab := m.persistFinished(msg.tabID)
+
+	if msg.err != nil {
+		summary := fmt.Sprintf("couldn't persist message %s: %s", messageID, msg.err.Error())
+		if msg.deleteAfter {
+			summary += "; it wasn't deleted"
+		}
+		// the outcome is still logged for closed tabs, but isn't surfaced
+		// over whatever's being shown now
+		if tab != nil {
+			if msg.deleteAfter {
+				tab.markUndeleted(map[string]string{messageID: queue.ErrNotDeletedUnpersisted.Error()})
+			}
+			m.errorMsg = summary
+		}
+		m.logEvent(event{
+			at:      time.Now(),
+			level:   eventError,
+			queue:   queueName,
+			summary: summary,
+		})
+		return nil
+	}
+
+	m.logEvent(event{
+		at:      time.Now(),
+		level:   eventInfo,
+		queue:   queueName,
+		summary: fmt.Sprintf("persisted message %s to %s", messageID, msg.path),
+	})
+
+	if !msg.deleteAfter || tab == nil {
+		return nil
+	}
+
+	return DeleteMessages(tab.sqsClient, tab.id, tab.queueURL, []sqstypes.Message{msg.sqsMessage})
+}
+
+// handleQueuePurged logs the outcome of a purge. It's only surfaced for tabs
+// that are still open; the purges of closed tabs are cancelled, but their
+// outcome (messages deleted while backing up, at least) is still logged.
+func (m *Model) handleQueuePurged(msg QueuePurgedMsg) {
+	tab, open := m.purgeFinished(msg.tabID)
+	if tab == nil {
+		return
+	}
+
+	queueName := utils.QueueNameFromURL(tab.queueURL)
+	// messages left on the queue after backing up are reported below
+	leftOnQueue := errors.Is(msg.err, queue.ErrPurgeIncomplete) && msg.result.Remaining > 0
+	if msg.err != nil && !leftOnQueue {
+		summary := fmt.Sprintf("couldn't purge queue %q: %s", queueName, msg.err.Error())
+		var backupSummary string
+		if msg.result.BackupPath != "" {
+			backupSummary = fmt.Sprintf("%d messages were backed up to %s", msg.result.BackedUp, msg.result.BackupPath)
+		}
+		if open {
+			m.errorMsg = summary
+			m.message = backupSummary
+		}
+		if backupSummary != "" {
+			summary += "; " + backupSummary
+		}
+		m.logEvent(event{
+			at:      time.Now(),
+			level:   eventError,
+			queue:   queueName,
+			summary: summary,
+		})
+		return
+	}
+
+	// samples from before the purge would skew the rates
+	tab.depthHistory.Reset()
+	summary := fmt.Sprintf("purged queue %q", queueName)
+	if msg.result.BackupPath != "" {
+		// the queue itself isn't purged when backing up; only the messages
+		// that were backed up are deleted
+		summary = fmt.Sprintf("backed up and deleted %d messages from %q (to %s); %d remain",
+			msg.result.BackedUp,
+			queueName,
+			msg.result.BackupPath,
+			msg.result.Remaining,
+		)
+	}
+	if open {
+		m.message = summary
+	}
+	m.logEvent(event{
+		at:      time.Now(),
+		level:   eventInfo,
+		queue:   queueName,
+		summary: summary,
+	})
+}
+
+func (m *Model) showEventLog() {
+	m.unseenProblems = 0
+	m.eventsVP.SetContent(renderEvents(m.events, m.eventsVP.Width))
+	m.eventsVP.GotoBottom()
+	m.activeView = eventLogView
+}
+
+func (m *Model) handleEventLogKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "q", "esc", "L":
+		m.activeView = msgsListView
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	}
+
+	return nil
+}
+
+// renderEvents shows events oldest first, with their details indented below
+// them; long lines are wrapped.
+func renderEvents(events []event, width int) string {
+	if len(events) == 0 {
+		return "Nothing has happened yet; the outcomes of deleting and persisting messages show up here."
+	}
+
+	lines := make([]string, 0, len(events))
+	for _, e := range events {
+		var style lipgloss.Style
+		switch e.level {
+		case eventWarning:
+			style = eventWarningStyle
+		case eventError:
+			style = errorStyle
+		default:
+			style = eventInfoStyle
+		}
+
+		prefix := fmt.Sprintf("%s  %s  ", e.at.Format(time.TimeOnly), utils.Trim(e.queue, tabTitleMaxWidth))
+		summaryWidth := max(width-lipgloss.Width(prefix), 1)
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top,
+			eventPrefixStyle.Render(prefix),
+			style.Width(summaryWidth).Render(e.summary),
+		))
+
+		indent := strings.Repeat(" ", lipgloss.Width(prefix)+2)
+		for _, detail := range e.details {
+			lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top,
+				indent,
+				style.Width(max(summaryWidth-2, 1)).Render(detail),
+			))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
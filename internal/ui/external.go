@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+// openSelectedMessage opens the selected message's body, as per the tab's body
+// view, in the user's pager (or, if edit is set, their editor); since the
+// edited message is sent to a queue, it's edited exactly as it was received,
+// whatever the body view.
+func (m *Model) openSelectedMessage(tab *queueTab, edit bool) tea.Cmd {
+	message, ok := tab.msgsList.SelectedItem().(t.Message)
+	if !ok {
+		m.errorMsg = errNoMessageSelected.Error()
+		return nil
+	}
+
+	if message.Err != nil {
+		m.errorMsg = errMessageHasError.Error()
+		return nil
+	}
+
+	extension := ".txt"
+	if tab.config.Format == t.JSON {
+		extension = ".json"
+	}
+
+	if edit {
+		return editMessage(message, extension)
+	}
+
+	return pageMessage(message.ViewBody(tab.bodyView), extension)
+}
+
+func pageMessage(body, extension string) tea.Cmd {
+	path, err := writeTempMessage(body, extension)
+	if err != nil {
+		return func() tea.Msg {
+			return PagerClosedMsg{err: err}
+		}
+	}
+
+	return tea.ExecProcess(utils.PagerCmd(path), func(err error) tea.Msg {
+		os.Remove(path)
+		return PagerClosedMsg{err: err}
+	})
+}
+
+// editMessage opens a temporary copy of a message's raw body in the user's
+// editor; the edited body can then be sent to a queue, along with the
+// message's original message attributes and message group ID.
+func editMessage(message t.Message, extension string) tea.Cmd {
+	edited := MessageEditedMsg{
+		messageID:         message.ID,
+		messageAttributes: message.MessageAttributes,
+		messageGroupID:    message.Attributes[string(sqstypes.MessageSystemAttributeNameMessageGroupId)],
+	}
+
+	body := message.ViewBody(t.BodyRaw)
+	path, err := writeTempMessage(body, extension)
+	if err != nil {
+		edited.err = err
+		return func() tea.Msg {
+			return edited
+		}
+	}
+
+	return tea.ExecProcess(utils.EditorCmd(path), func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			edited.err = err
+			return edited
+		}
+
+		editedBytes, err := os.ReadFile(path)
+		if err != nil {
+			edited.err = err
+			return edited
+		}
+
+		edited.original = strings.TrimRight(body, "\n")
+		edited.body = strings.TrimRight(string(editedBytes), "\n")
+		return edited
+	})
+}
+
+func writeTempMessage(body, extension string) (string, error) {
+	tempFile, err := os.CreateTemp("", fmt.Sprintf("cueitup-*%s", extension))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tempFile.WriteString(body)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return "", err
+	}
+
+	return tempFile.Name(), nil
+}
+
+// handleMessageEdited offers to send an edited message to a queue, via the
+// replay target view.
+func (m *Model) handleMessageEdited(msg MessageEditedMsg) {
+	switch {
+	case msg.err != nil:
+		m.errorMsg = fmt.Sprintf("couldn't edit message: %s", msg.err.Error())
+		return
+	case strings.TrimSpace(msg.body) == "":
+		m.errorMsg = "edited message was empty; discarded the edit"
+		return
+	case msg.body == msg.original:
+		m.message = "message wasn't changed; not sending it"
+		return
+	}
+
+	body := msg.body
+	m.replayPending = []replayFileItem{{
+		message:           queue.PersistedMessage{MessageID: msg.messageID},
+		body:              &body,
+		messageAttributes: msg.messageAttributes,
+		messageGroupID:    msg.messageGroupID,
+	}}
+	m.replayEdited = true
+	m.showReplayTargets()
+	m.replayTargetsList.Title = "Send the edited message to"
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+func (m *Model) showFilterInput(tab *queueTab) tea.Cmd {
+	var query string
+	if tab.filter != nil {
+		query = tab.filter.String()
+	}
+
+	m.filterInput.SetValue(query)
+	m.filterInput.CursorEnd()
+	m.activeView = msgsFilterView
+
+	return m.filterInput.Focus()
+}
+
+func (m *Model) handleFilterKeys(msg tea.KeyMsg) tea.Cmd {
+	tab := m.tab()
+	if tab == nil {
+		m.activeView = msgsListView
+		return nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.filterInput.Blur()
+		m.activeView = msgsListView
+	case "enter":
+		query := strings.TrimSpace(m.filterInput.Value())
+		if query == "" {
+			m.setFilter(tab, nil)
+		} else {
+			filter, err := t.ParseMessageFilter(query)
+			if err != nil {
+				m.errorMsg = err.Error()
+				return nil
+			}
+			m.setFilter(tab, &filter)
+		}
+
+		m.filterInput.Blur()
+		m.activeView = msgsListView
+	default:
+		var cmd tea.Cmd
+		m.filterInput, cmd = m.filterInput.Update(msg)
+		return cmd
+	}
+
+	return nil
+}
+
+// setFilter shows the tab's messages that match a filter (or all of them, if
+// filter is nil). The selected message stays selected if it matches the
+// filter; otherwise, the first matching one is selected.
+func (m *Model) setFilter(tab *queueTab, filter *t.MessageFilter) {
+	var selectedID string
+	if message, ok := tab.msgsList.SelectedItem().(t.Message); ok {
+		selectedID = message.ID
+	}
+
+	tab.filter = filter
+
+	var items []list.Item
+	var selected int
+	for _, message := range tab.messages {
+		if filter != nil && !filter.Matches(message) {
+			continue
+		}
+
+		if selectedID != "" && message.ID == selectedID {
+			selected = len(items)
+		}
+		items = append(items, message)
+	}
+
+	tab.msgsList.SetItems(items)
+	tab.msgsList.Select(selected)
+	tab.refreshTitle()
+
+	// forces the message value viewport to follow the selection
+	tab.msgListCurrentIndex = -1
+	if len(items) == 0 {
+		m.setMsgValueContent("")
+	}
+}
+
+// addMessage adds a fetched message to the tab; it's only shown if it matches
+// the tab's filter.
+func (tab *queueTab) addMessage(message t.Message) {
+	tab.messages = append(tab.messages, message)
+	if tab.filter == nil || tab.filter.Matches(message) {
+		tab.msgsList.InsertItem(len(tab.msgsList.Items()), message)
+	}
+}
+
+// markUndeleted records why messages couldn't be deleted, keyed by their
+// IDs, on both the tab's messages and the list's items.
+func (tab *queueTab) markUndeleted(reasons map[string]string) {
+	for i, message := range tab.messages {
+		if reason, ok := reasons[message.ID]; ok {
+			tab.messages[i].DeleteError = &reason
+		}
+	}
+
+	for i, item := range tab.msgsList.Items() {
+		message, ok := item.(t.Message)
+		if !ok {
+			continue
+		}
+		if reason, ok := reasons[message.ID]; ok {
+			message.DeleteError = &reason
+			tab.msgsList.SetItem(i, message)
+		}
+	}
+}
+
+func (tab *queueTab) clearMessages() {
+	tab.messages = nil
+	tab.msgsList.SetItems(make([]list.Item, 0))
+	tab.refreshTitle()
+}
+
+func (tab *queueTab) refreshTitle() {
+	var details []string
+	if tab.filter != nil {
+		details = append(details, fmt.Sprintf("%d/%d shown", len(tab.msgsList.Items()), len(tab.messages)))
+	}
+
+	if tab.behaviours.ShowMessageCount {
+		if sample, ok := tab.depthHistory.Latest(); ok {
+			details = append(details, fmt.Sprintf("%d in queue, %d in flight", sample.Visible, sample.InFlight))
+		}
+	}
+
+	tab.msgsList.Title = "Messages"
+	if len(details) > 0 {
+		tab.msgsList.Title += fmt.Sprintf(" (%s)", strings.Join(details, "; "))
+	}
+}
@@ -13,14 +13,46 @@ var HelpText = fmt.Sprintf(`
 %s
   %s
 %s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
+  %s
+%s
 `,
 	helpHeaderStyle.Render("cueitup Reference Manual"),
 	helpSectionStyle.Render(`
   (scroll line by line with j/k/arrow keys or by half a page with <c-d>/<c-u>)
 
-  cueitup has 3 views:
+  cueitup has 13 views:
+  - Profile Picker View
+  - Queue Browser View (only available via "cueitup browse")
   - Message List View
   - Message Value View
+  - Message Filter View
+  - Message Search View
+  - Message Diff View
+  - Event Log View
+  - Fetch Predicate View
+  - Purge Confirmation View
+  - Replay Files View
+  - Replay Target View
   - Help View (this one)
 `),
 	helpHeaderStyle.Render("Keyboard Shortcuts"),
@@ -29,7 +61,34 @@ var HelpText = fmt.Sprintf(`
       <tab>                          Switch focus to next section
       <s-tab>                        Switch focus to previous section
       ?                              Show help view
-      q                              Go back or quit
+      q                              Go back or quit (when browsing queues, go back to
+                                         the queue browser)
+`),
+	helpHeaderStyle.Render("Tabs"),
+	helpSectionStyle.Render(`
+      P                              Open another queue in a new tab (via the profile
+                                         picker or the queue browser)
+      >                              Switch to the next tab
+      <                              Switch to the previous tab
+      1-9                            Switch to a specific tab
+      <ctrl+w>                       Close the current tab (cancels a purge running for
+                                         it; its outcome shows up in the event log)
+`),
+	helpHeaderStyle.Render("Profile Picker View"),
+	helpSectionStyle.Render(`
+      <enter>                        Open the selected profile in a tab (or switch to it,
+                                         if it's already open)
+      /                              Filter profiles by name
+      <esc>                          Go back to the open tabs
+`),
+	helpHeaderStyle.Render("Queue Browser View"),
+	helpSectionStyle.Render(`
+      <enter>                        Open the selected queue in the message list view
+      s                              Save the selected queue as a profile in cueitup's
+                                         config file
+      /                              Filter queues by name
+      <ctrl+r>                       Refresh the list of queues
+      <esc>                          Go back to the open tabs
 `),
 	helpHeaderStyle.Render("Message List View"),
 	helpSectionStyle.Render(`
@@ -38,19 +97,165 @@ var HelpText = fmt.Sprintf(`
       n                              Fetch the next message from the queue
       N                              Fetch up to 10 more messages from the queue
       }                              Fetch up to 100 more messages from the queue
+      F                              Fetch up to 10 messages matching a predicate (eg.
+                                         "$.kind = Created and @ApproximateReceiveCount > 3");
+                                         non-matching messages are released back to the
+                                         queue, and fetching stops after 30 seconds; for
+                                         queues with a redrive policy, the predicate needs
+                                         to be submitted twice, since scanning can move
+                                         messages to the dead-letter queue
       d                              Toggle deletion mode; cueitup will delete messages
-                                         after reading them
-      M                              Toggle polling for message count in queue
+                                         after reading them (not available for read-only
+                                         profiles)
+      M                              Toggle polling for message count in queue; the footer
+                                         shows the recent queue depth and the net rates
+                                         at which it grew and shrank
       p                              Toggle persist mode (cueitup will start persisting
                                          messages, at the location
-                                         messages/<topic-name>/<timestamp>-<message-id>.(json|txt)
+                                         messages/<queue-name>/<timestamp>-<message-id>.(json|txt)
+                                         by default; configurable per profile via
+                                         persist_dir and persist_filename
       s                              Toggle skipping mode; cueitup will consume messages,
                                          but not populate its internal list, effectively
                                          skipping over them
+      X                              Purge the queue (after typing its name to confirm);
+                                         not available for read-only profiles. While
+                                         messages are being backed up before a purge,
+                                         cancel it
+      R                              Replay messages persisted for the queue (via
+                                         persist mode) to any queue
+      /                              Filter messages by their body, context value and
+                                         attributes; filters can be text (case-insensitive),
+                                         a regex (eg. "/agg-[0-9]+/"), or a JSON path and
+                                         a value to compare against (eg. "$.order.status=paid")
+      t                              Toggle the tree view for JSON messages
+      b                              Cycle between showing JSON messages formatted, raw
+                                         (exactly as received), and via the subset key
+                                         (if the profile has one)
+      y                              Copy the message's raw body (exactly as received)
+                                         to the clipboard, whatever the body view
+      Y                              Copy the message's body, formatted (for JSON
+                                         messages), to the clipboard, whatever the body view
+      I                              Copy the message's ID to the clipboard
+      H                              Copy the message's receipt handle to the clipboard
+      o                              Open the message in $PAGER (less, by default); JSON
+                                         messages are formatted first
+      e                              Edit the message in $EDITOR, and optionally send the
+                                         edited version to any queue (via the replay
+                                         target view); the whole body is edited, even if
+                                         only a subset is shown
+      c                              Mark the message for comparing; pressing c on another
+                                         message shows how it differs from the marked one
+                                         (press c on the marked message to unmark it)
+      L                              Show the event log (the outcomes of deleting and
+                                         persisting messages)
+      <esc>                          Clear the filter
 `),
 	helpHeaderStyle.Render("Message Value View   "),
 	helpSectionStyle.Render(`
       [,h                            Show details for the previous entry in the list
       ],l                            Show details for the next entry in the list
+      /                              Search within the message (case-insensitive);
+                                         matches are highlighted, and the pane's title
+                                         shows a match counter
+      n                              Go to the next match (while searching)
+      N                              Go to the previous match (while searching)
+      t                              Toggle the tree view for JSON messages; objects and
+                                         arrays can be expanded and collapsed, and large
+                                         ones start collapsed, with their size shown
+      b                              Cycle between showing JSON messages formatted, raw
+                                         (exactly as received), and via the subset key
+                                         (if the profile has one)
+      j/<Down>                       Move the tree's cursor down (in the tree view)
+      k/<Up>                         Move the tree's cursor up (in the tree view)
+      <enter>                        Expand/collapse the node under the tree's cursor (or
+                                         show more of a large array's items)
+      +                              Expand all nodes (in the tree view)
+      -                              Collapse all nodes (in the tree view)
+      y                              Copy the message's raw body (exactly as received)
+                                         to the clipboard, whatever the body view;
+                                         in the tree view, copy the JSON path of the node
+                                         under the cursor (eg. "$.order.items[2].sku")
+                                         instead
+      v                              Copy the value of the node under the tree's cursor
+                                         to the clipboard (strings are copied unquoted)
+      Y                              Copy the message's body, formatted (for JSON
+                                         messages), to the clipboard, whatever the body view
+      I                              Copy the message's ID to the clipboard
+      H                              Copy the message's receipt handle to the clipboard
+      o                              Open the message in $PAGER (less, by default); JSON
+                                         messages are formatted first
+      e                              Edit the message in $EDITOR, and optionally send the
+                                         edited version to any queue (via the replay
+                                         target view); the whole body is edited, even if
+                                         only a subset is shown
+      c                              Mark the message for comparing; pressing c on another
+                                         message shows how it differs from the marked one
+                                         (press c on the marked message to unmark it)
+      L                              Show the event log (the outcomes of deleting and
+                                         persisting messages)
+      <esc>                          Clear the search
+`),
+	helpHeaderStyle.Render("Message Filter View"),
+	helpSectionStyle.Render(`
+      <enter>                        Apply the filter (an empty filter shows all messages)
+      <esc>                          Cancel
+`),
+	helpHeaderStyle.Render("Message Search View"),
+	helpSectionStyle.Render(`
+      <enter>                        Go back to the message, keeping the search
+      <esc>                          Cancel (restores the previous search)
+`),
+	helpHeaderStyle.Render("Message Diff View"),
+	helpSectionStyle.Render(`
+      j/<Down>                       Scroll down
+      k/<Up>                         Scroll up
+      <esc>                          Go back to the message list view (the marked message
+                                         stays marked, so it can be compared with others)
+`),
+	helpHeaderStyle.Render("Event Log View"),
+	helpSectionStyle.Render(`
+      j/<Down>                       Scroll down
+      k/<Up>                         Scroll up
+      <esc>                          Go back to the message list view
+`),
+	helpHeaderStyle.Render("Fetch Predicate View"),
+	helpSectionStyle.Render(`
+      <enter>                        Fetch messages matching the predicate; conditions
+                                         are joined by "and", and compare a JSON path
+                                         ("$.a.b[0]"), an attribute ("@Name") or "body"
+                                         with a value via =, !=, >, >=, <, <=, ~ (regex)
+                                         or !~
+      <esc>                          Cancel
+`),
+	helpHeaderStyle.Render("Purge Confirmation View"),
+	helpSectionStyle.Render(`
+      <enter>                        Purge the queue, if the typed name matches it
+      <ctrl+b>                       Toggle backing up all messages to
+                                         backups/<queue-name>/<timestamp>.jsonl before
+                                         purging; messages are deleted as they're
+                                         backed up (for up to 30 minutes), and ones
+                                         that arrive meanwhile are left on the queue
+      <esc>                          Cancel
+`),
+	helpHeaderStyle.Render("Replay Files View"),
+	helpSectionStyle.Render(`
+      <space>                        Toggle selecting the message under the cursor
+      a                              Toggle selecting all messages matching the filter
+      e                              Edit the message under the cursor in $EDITOR before
+                                         replaying it (the persisted file is left
+                                         untouched)
+      /                              Filter messages by file name or the time they were
+                                         persisted at (eg. "2024-06-01 14:")
+      <enter>                        Choose the queue to replay the selected messages (or
+                                         the one under the cursor) to
+      <esc>                          Go back to the message list view
+`),
+	helpHeaderStyle.Render("Replay Target View"),
+	helpSectionStyle.Render(`
+      <enter>                        Replay the messages to the selected queue
+      /                              Filter queues by profile name
+      <esc>                          Go back to the replay files view (or discard the
+                                         message edited via "e")
 `),
 )
@@ -2,54 +2,141 @@ package ui
 
 import (
 	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/lipgloss"
 	t "github.com/dhth/cueitup/internal/types"
 )
 
 func InitialModel(
-	sqsClient *sqs.Client,
-	queueURL string,
-	config t.Config,
-	behaviours t.TUIBehaviours,
+	profiles []t.Config,
+	sqsClients map[string]*sqs.Client,
+	behaviourFlags t.TUIBehaviourFlags,
 ) Model {
-	appDelegate := newAppItemDelegate()
-	jobItems := make([]list.Item, 0)
-
-	queueParts := strings.Split(queueURL, "/")
-	queueName := queueParts[len(queueParts)-1]
-	persistDir := filepath.Join("messages", queueName)
-
 	var dbg bool
 	if len(os.Getenv("DEBUG")) > 0 {
 		dbg = true
 	}
 
+	clients := make(map[string]*sqs.Client)
+	for source, client := range sqsClients {
+		clients[source] = client
+	}
+
 	m := Model{
-		sqsClient:           sqsClient,
-		queueURL:            queueURL,
-		config:              config,
-		behaviours:          behaviours,
-		msgsList:            list.New(jobItems, appDelegate, listWidth, 0),
-		msgListCurrentIndex: -1,
-		persistDir:          persistDir,
-		showHelpIndicator:   true,
-		debugMode:           dbg,
-		firstFetch:          true,
+		profiles:          profiles,
+		sqsClients:        clients,
+		behaviourFlags:    behaviourFlags,
+		activeView:        profilePickerView,
+		showHelpIndicator: true,
+		debugMode:         dbg,
 	}
-	m.msgsList.Title = "Messages"
-	m.msgsList.SetStatusBarItemName("message", "messages")
-	m.msgsList.SetFilteringEnabled(false)
-	m.msgsList.DisableQuitKeybindings()
-	m.msgsList.SetShowHelp(false)
-	m.msgsList.Styles.Title = m.msgsList.Styles.Title.
+
+	m.profilesList = newSelectionList("Profiles", "profile", "profiles")
+	m.refreshProfileItems()
+	m.purgeInput = newPurgeInput()
+	m.filterInput = newFilterInput()
+	m.predicateInput = newPredicateInput()
+	m.searchInput = newSearchInput()
+	m.replayFilesList = newSelectionList("Replay", "message", "messages")
+	m.replayTargetsList = newSelectionList("Replay to", "queue", "queues")
+
+	return m
+}
+
+func InitialQueueBrowserModel(
+	sqsClient *sqs.Client,
+	browserConfig t.QueueBrowserConfig,
+	behaviourFlags t.TUIBehaviourFlags,
+) Model {
+	m := InitialModel(nil, map[string]*sqs.Client{
+		browserConfig.AWSConfigSource.String(): sqsClient,
+	}, behaviourFlags)
+	m.browserConfig = &browserConfig
+	m.activeView = queueBrowserView
+	m.queuesList = newSelectionList("Queues", "queue", "queues")
+
+	return m
+}
+
+// InitialOfflineModel returns a model that shows messages loaded from disk in
+// a single tab, without any AWS connection.
+func InitialOfflineModel(config t.Config, messages []sqstypes.Message) Model {
+	m := InitialModel(nil, map[string]*sqs.Client{}, t.TUIBehaviourFlags{})
+	m.offline = true
+	m.openOfflineTab(config, messages)
+
+	return m
+}
+
+func newMessagesList() list.Model {
+	msgsList := list.New(make([]list.Item, 0), newAppItemDelegate(), listWidth, 0)
+	msgsList.Title = "Messages"
+	msgsList.SetStatusBarItemName("message", "messages")
+	msgsList.SetFilteringEnabled(false)
+	msgsList.DisableQuitKeybindings()
+	msgsList.SetShowHelp(false)
+	msgsList.Styles.Title = msgsList.Styles.Title.
 		Background(lipgloss.Color(cueitupColor)).
 		Foreground(lipgloss.Color(defaultForegroundColor)).
 		Bold(true)
 
-	return m
+	return msgsList
+}
+
+func newPurgeInput() textinput.Model {
+	purgeInput := textinput.New()
+	purgeInput.Placeholder = "queue name"
+	purgeInput.Prompt = "> "
+	purgeInput.CharLimit = 80
+	purgeInput.Width = 80
+
+	return purgeInput
+}
+
+func newFilterInput() textinput.Model {
+	filterInput := textinput.New()
+	filterInput.Placeholder = `text, /regex/, or $.json.path=value`
+	filterInput.Prompt = "filter: "
+	filterInput.CharLimit = 200
+	filterInput.Width = 80
+
+	return filterInput
+}
+
+func newPredicateInput() textinput.Model {
+	predicateInput := textinput.New()
+	predicateInput.Placeholder = `$.json.path = value and @Attribute > 1 and body ~ regex`
+	predicateInput.Prompt = "fetch where: "
+	predicateInput.CharLimit = 200
+	predicateInput.Width = 80
+
+	return predicateInput
+}
+
+func newSearchInput() textinput.Model {
+	searchInput := textinput.New()
+	searchInput.Placeholder = "text in the message"
+	searchInput.Prompt = "search: "
+	searchInput.CharLimit = 200
+	searchInput.Width = 80
+
+	return searchInput
+}
+
+func newSelectionList(title, singular, plural string) list.Model {
+	selectionList := list.New(make([]list.Item, 0), newAppItemDelegate(), 0, 0)
+	selectionList.Title = title
+	selectionList.SetStatusBarItemName(singular, plural)
+	selectionList.DisableQuitKeybindings()
+	selectionList.SetShowHelp(false)
+	selectionList.Styles.Title = selectionList.Styles.Title.
+		Background(lipgloss.Color(cueitupColor)).
+		Foreground(lipgloss.Color(defaultForegroundColor)).
+		Bold(true)
+
+	return selectionList
 }
@@ -1,12 +1,16 @@
 package ui
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
 	t "github.com/dhth/cueitup/internal/types"
 )
 
@@ -16,37 +20,213 @@ const (
 	msgsListView stateView = iota
 	msgValueView
 	helpView
+	queueBrowserView
+	profilePickerView
+	purgeConfirmView
+	replayFilesView
+	replayTargetView
+	msgsFilterView
+	fetchPredicateView
+	msgValueSearchView
+	msgDiffView
+	eventLogView
 )
 
 const msgCountTickInterval = time.Second * 3
 
-type Model struct {
+// maxConcurrentQueueCounts is the number of listed queues whose message
+// counts are fetched at a time, so that accounts with many queues don't get
+// throttled.
+const maxConcurrentQueueCounts = 5
+
+type queueItem struct {
+	url   string
+	name  string
+	depth *t.QueueDepth
+	err   error
+}
+
+func (q queueItem) Title() string {
+	return q.name
+}
+
+func (q queueItem) Description() string {
+	if q.err != nil {
+		return "couldn't fetch message count"
+	}
+
+	if q.depth == nil {
+		return "..."
+	}
+
+	return fmt.Sprintf("%d in queue, %d in flight", q.depth.Visible, q.depth.InFlight)
+}
+
+func (q queueItem) FilterValue() string {
+	return q.name
+}
+
+type profileItem struct {
+	config t.Config
+	open   bool
+}
+
+func (p profileItem) Title() string {
+	title := p.config.ProfileName
+	if p.config.ReadOnly {
+		title += " (read-only)"
+	}
+
+	if p.open {
+		title += " (open)"
+	}
+
+	return title
+}
+
+func (p profileItem) Description() string {
+	return p.config.QueueURL
+}
+
+func (p profileItem) FilterValue() string {
+	return p.config.ProfileName
+}
+
+// queueTab holds everything that's specific to a single queue opened in the
+// TUI.
+type queueTab struct {
+	id                  int
 	sqsClient           *sqs.Client
 	queueURL            string
 	config              t.Config
 	behaviours          t.TUIBehaviours
-	activeView          stateView
-	lastView            stateView
 	msgsList            list.Model
 	msgListCurrentIndex int
-	helpVP              viewport.Model
-	showHelpIndicator   bool
-	msgValueVP          viewport.Model
 	persistDir          string
-	msgValueVPReady     bool
-	helpVPReady         bool
-	terminalWidth       int
-	terminalHeight      int
-	message             string
-	errorMsg            string
-	debugMode           bool
+	persister           queue.Persister
+	depthHistory        t.QueueDepthHistory
 	firstFetch          bool
+	// messages holds all messages fetched for the tab; msgsList only holds
+	// the ones matching filter, if one is applied
+	messages []t.Message
+	filter   *t.MessageFilter
+	// predicate is the last one used to fetch matching messages
+	predicate string
+	// fetchingMatches is true while messages matching a predicate are
+	// being fetched
+	fetchingMatches bool
+	// offline tabs show messages loaded from disk, and have no SQS client
+	offline bool
+	// diffBase is the message marked for comparing other messages with
+	diffBase *t.Message
+	bodyView t.BodyView
+	// pendingPersists is the number of messages being persisted via
+	// persister; it's only closed once these are done
+	pendingPersists int
+	// purge is the purge running for the tab, if any
+	purge *runningPurge
+	// refusedPredicate is the last predicate that wasn't used, since the
+	// queue has a redrive policy; submitting it again scans the queue anyway
+	refusedPredicate string
+}
+
+// runningPurge is a purge running in the background for a tab.
+type runningPurge struct {
+	cancel context.CancelFunc
+	// done is closed once the purge has stopped (and its backup, if any, has
+	// been closed); result and err are only set then
+	done   chan struct{}
+	result queue.PurgeResult
+	err    error
+}
+
+// stop cancels the purge, and waits for it to stop.
+func (p *runningPurge) stop() {
+	p.cancel()
+	<-p.done
+}
+
+type Model struct {
+	profiles          []t.Config
+	sqsClients        map[string]*sqs.Client
+	behaviourFlags    t.TUIBehaviourFlags
+	tabs              []*queueTab
+	activeTab         int
+	nextTabID         int
+	activeView        stateView
+	lastView          stateView
+	profilesList      list.Model
+	queuesList        list.Model
+	browserConfig     *t.QueueBrowserConfig
+	offline           bool
+	purgeInput        textinput.Model
+	filterInput       textinput.Model
+	predicateInput    textinput.Model
+	searchInput       textinput.Model
+	purgeBackup       bool
+	replayFilesList   list.Model
+	replayTargetsList list.Model
+	replayDir         string
+	replayPending     []replayFileItem
+	// replayEdited is set when the pending message was edited from the
+	// message list, rather than picked from persisted messages
+	replayEdited bool
+	// pendingCounts holds the listed queues whose message counts are yet to
+	// be fetched
+	pendingCounts     []string
+	helpVP            viewport.Model
+	showHelpIndicator bool
+	msgValueVP        viewport.Model
+	// msgValueContent is what's shown in msgValueVP, before search matches
+	// are highlighted
+	msgValueContent string
+	searchQuery     string
+	searchPrevQuery string
+	searchMatches   []searchMatch
+	searchCurrent   int
+	// treeView shows JSON messages as a collapsible tree; msgTree is the tree
+	// for the message being shown
+	treeView        bool
+	msgTree         *jsonTree
+	msgValueVPReady bool
+	helpVPReady     bool
+	diffVPReady     bool
+	eventsVPReady   bool
+	terminalWidth   int
+	terminalHeight  int
+	message         string
+	errorMsg        string
+	debugMode       bool
+	// msgDiff is the diff being shown in diffVP
+	msgDiff   *t.MessageDiff
+	diffTitle string
+	diffVP    viewport.Model
+	// events is the event log; unseenProblems counts the warnings and
+	// errors logged since it was last viewed
+	events         []event
+	eventsVP       viewport.Model
+	unseenProblems int
+	// closedTabs holds tabs that were closed while messages were still being
+	// persisted for them, until that's done
+	closedTabs map[int]*queueTab
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		GetQueueMsgCount(m.sqsClient, m.queueURL),
-		tickEvery(msgCountTickInterval),
-		hideHelp(time.Minute*1),
-	)
+	cmds := []tea.Cmd{hideHelp(time.Minute * 1)}
+
+	if m.activeView == queueBrowserView {
+		client := m.sqsClients[m.browserConfig.AWSConfigSource.String()]
+		cmds = append(cmds, listQueues(client, m.browserConfig.QueuePrefix))
+	}
+
+	for _, tab := range m.tabs {
+		if tab.behaviours.ShowMessageCount {
+			cmds = append(cmds,
+				GetQueueMsgCount(tab.sqsClient, tab.queueURL),
+				tickEvery(tab.id, msgCountTickInterval),
+			)
+		}
+	}
+
+	return tea.Batch(cmds...)
 }
@@ -1,31 +1,128 @@
 package ui
 
 import (
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
 	t "github.com/dhth/cueitup/internal/types"
 )
 
-type (
-	MsgCountTickMsg struct{}
-	HideHelpMsg     struct{}
-)
+type HideHelpMsg struct{}
+
+type MsgCountTickMsg struct {
+	tabID int
+}
 
 type SQSMsgsFetchedMsg struct {
+	tabID       int
 	messages    []t.Message
 	sqsMessages []sqsTypes.Message
 	err         error
 }
 
+type MatchingMsgsFetchedMsg struct {
+	tabID       int
+	messages    []t.Message
+	sqsMessages []sqsTypes.Message
+	scanned     int
+	timedOut    bool
+	err         error
+}
+
 type QueueMsgCountFetchedMsg struct {
-	approxMsgCount int
-	err            error
+	queueURL string
+	sample   t.QueueDepthSample
+	err      error
+	// listed is true for counts fetched for the queue browser's list
+	listed bool
 }
 
+// SQSMsgsDeletedMsg is the outcome of deleting a batch of messages; the
+// result accounts for every message, even if err is set.
 type SQSMsgsDeletedMsg struct {
-	err error
+	tabID    int
+	queueURL string
+	result   queue.DeleteResult
+	err      error
+}
+
+type CopiedToClipboardMsg struct {
+	what     string
+	viaOSC52 bool
+	err      error
 }
 
 type RecordSavedToDiskMsg struct {
+	tabID      int
+	queueURL   string
+	sqsMessage sqsTypes.Message
+	// deleteAfter is whether the message is to be deleted once it's been
+	// persisted
+	deleteAfter bool
+	path        string
+	err         error
+}
+
+type QueuesListedMsg struct {
+	queueURLs []string
+	err       error
+}
+
+type ProfileSavedMsg struct {
+	name string
+	err  error
+}
+
+// PurgeProgressMsg is sent while messages are being backed up before a purge;
+// updates is where the next update for the purge comes from.
+type PurgeProgressMsg struct {
+	tabID    int
+	backedUp int
+	updates  <-chan tea.Msg
+}
+
+type QueuePurgedMsg struct {
+	tabID  int
+	result queue.PurgeResult
+	err    error
+}
+
+type PersistedMessagesListedMsg struct {
+	tabID    int
+	dir      string
+	messages []queue.PersistedMessage
+	err      error
+}
+
+type ReplayMessageEditedMsg struct {
 	path string
+	body string
 	err  error
 }
+
+type PagerClosedMsg struct {
+	err error
+}
+
+type MessageEditedMsg struct {
+	messageID         string
+	original          string
+	body              string
+	messageAttributes map[string]sqsTypes.MessageAttributeValue
+	messageGroupID    string
+	err               error
+}
+
+type MessagesReplayedMsg struct {
+	config t.Config
+	client *sqs.Client
+	sent   int
+	err    error
+}
+
+type SQSClientLoadedMsg struct {
+	config t.Config
+	client *sqs.Client
+	err    error
+}
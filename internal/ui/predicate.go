@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+// maxPredicateMatches is the number of matching messages after which fetching
+// via a predicate stops.
+const maxPredicateMatches = 10
+
+func (m *Model) showPredicateInput(tab *queueTab) tea.Cmd {
+	m.predicateInput.SetValue(tab.predicate)
+	m.predicateInput.CursorEnd()
+	m.activeView = fetchPredicateView
+
+	return m.predicateInput.Focus()
+}
+
+func (m *Model) handlePredicateKeys(msg tea.KeyMsg) tea.Cmd {
+	tab := m.tab()
+	if tab == nil {
+		m.activeView = msgsListView
+		return nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.predicateInput.Blur()
+		m.activeView = msgsListView
+	case "enter":
+		value := strings.TrimSpace(m.predicateInput.Value())
+		predicate, err := t.ParseMessagePredicate(value)
+		if err != nil {
+			m.errorMsg = err.Error()
+			return nil
+		}
+
+		tab.predicate = value
+		tab.fetchingMatches = true
+		m.predicateInput.Blur()
+		m.activeView = msgsListView
+		m.message = fetchingIndicator
+
+		deleting := tab.behaviours.DeleteMessages && !tab.config.ReadOnly
+		return FetchMatchingMessages(tab.sqsClient, tab.config, tab.id, queue.FetchOptions{
+			Predicate:  &predicate,
+			MaxMatches: maxPredicateMatches,
+			// matching messages are only kept in flight if they're to be
+			// deleted
+			ReleaseMatches:     tab.behaviours.SkipMessages || !deleting,
+			AllowDeadLettering: tab.refusedPredicate == value,
+		})
+	default:
+		var cmd tea.Cmd
+		m.predicateInput, cmd = m.predicateInput.Update(msg)
+		return cmd
+	}
+
+	return nil
+}
+
+// handleFetchedMessages adds fetched messages to a tab, and persists and
+// deletes them as per its behaviours. Messages that are persisted are only
+// deleted once they've been persisted successfully; ones that can't be
+// persisted are left on the queue.
+func (m *Model) handleFetchedMessages(tab *queueTab, messages []t.Message, sqsMessages []sqstypes.Message) []tea.Cmd {
+	var cmds []tea.Cmd
+	if tab.behaviours.SkipMessages || len(messages) == 0 {
+		return cmds
+	}
+
+	deleteMessages := tab.behaviours.DeleteMessages && !tab.config.ReadOnly
+	var toDelete []sqstypes.Message
+	undeleted := make(map[string]string)
+	for i, message := range messages {
+		tab.addMessage(message)
+
+		if !tab.behaviours.PersistMessages {
+			toDelete = append(toDelete, sqsMessages[i])
+			continue
+		}
+
+		persister, err := tab.getPersister()
+		if err != nil {
+			m.errorMsg = err.Error()
+			undeleted[message.ID] = queue.ErrNotDeletedUnpersisted.Error()
+			continue
+		}
+
+		tab.pendingPersists++
+		cmds = append(cmds,
+			saveMessageToDisk(persister, tab.id, tab.queueURL, queue.PersistableMessage{
+				Message:    message,
+				SQSMessage: sqsMessages[i],
+				ReceivedAt: time.Now(),
+			}, deleteMessages),
+		)
+	}
+
+	if !deleteMessages {
+		return cmds
+	}
+
+	if len(undeleted) > 0 {
+		tab.markUndeleted(undeleted)
+	}
+
+	if len(toDelete) > 0 {
+		cmds = append(cmds,
+			DeleteMessages(tab.sqsClient,
+				tab.id,
+				tab.queueURL,
+				toDelete),
+		)
+	}
+
+	return cmds
+}
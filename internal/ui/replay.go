@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+)
+
+const replayFileTimeFormat = "2006-01-02 15:04:05"
+
+// replayFileItem is a persisted message that can be picked for replaying; body
+// is only set once the message has been edited.
+type replayFileItem struct {
+	message  queue.PersistedMessage
+	selected bool
+	body     *string
+	// messageAttributes and messageGroupID are only set for messages edited
+	// from a tab, since persisted files don't hold them
+	messageAttributes map[string]sqstypes.MessageAttributeValue
+	messageGroupID    string
+}
+
+func (r replayFileItem) Title() string {
+	checkbox := "[ ]"
+	if r.selected {
+		checkbox = "[x]"
+	}
+
+	return fmt.Sprintf("%s %s", checkbox, filepath.Base(r.message.Path))
+}
+
+func (r replayFileItem) Description() string {
+	description := fmt.Sprintf("persisted at %s", r.message.PersistedAt.Format(replayFileTimeFormat))
+	if r.body != nil {
+		description += " (edited)"
+	}
+
+	return description
+}
+
+// FilterValue includes the time the message was persisted at, so that messages
+// can be filtered by time as well (eg. "2024-06-01 14:").
+func (r replayFileItem) FilterValue() string {
+	return fmt.Sprintf("%s %s", filepath.Base(r.message.Path), r.message.PersistedAt.Format(replayFileTimeFormat))
+}
+
+func (m *Model) showReplayFiles(dir string, messages []queue.PersistedMessage) {
+	items := make([]list.Item, len(messages))
+	for i, message := range messages {
+		items[i] = replayFileItem{message: message}
+	}
+
+	m.replayFilesList.ResetFilter()
+	m.replayFilesList.SetItems(items)
+	m.replayDir = dir
+	m.refreshReplayFilesTitle()
+	m.activeView = replayFilesView
+}
+
+func (m *Model) refreshReplayFilesTitle() {
+	selected := len(m.selectedReplayFiles())
+	m.replayFilesList.Title = fmt.Sprintf("Replay from %s (%d selected)", m.replayDir, selected)
+}
+
+func (m Model) selectedReplayFiles() []replayFileItem {
+	var selected []replayFileItem
+	for _, listItem := range m.replayFilesList.Items() {
+		item, ok := listItem.(replayFileItem)
+		if ok && item.selected {
+			selected = append(selected, item)
+		}
+	}
+
+	return selected
+}
+
+// showReplayTargets lists the queues messages can be replayed to; these are
+// all profiles, as well as queues opened via the queue browser.
+func (m *Model) showReplayTargets() {
+	var configs []t.Config
+	configs = append(configs, m.profiles...)
+	for _, tab := range m.tabs {
+		configs = append(configs, tab.config)
+	}
+
+	var items []list.Item
+	var seen []string
+	for _, config := range configs {
+		if slices.Contains(seen, config.QueueURL) {
+			continue
+		}
+		seen = append(seen, config.QueueURL)
+
+		open := slices.ContainsFunc(m.tabs, func(tab *queueTab) bool {
+			return tab.queueURL == config.QueueURL
+		})
+		items = append(items, profileItem{config: config, open: open})
+	}
+
+	m.replayTargetsList.ResetFilter()
+	m.replayTargetsList.SetItems(items)
+	m.replayTargetsList.Title = fmt.Sprintf("Replay %d messages to", len(m.replayPending))
+	m.activeView = replayTargetView
+}
+
+func (m *Model) handleReplayFilesKeys(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	if m.replayFilesList.FilterState() == list.Filtering {
+		return nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		if msg.String() == "esc" && m.replayFilesList.FilterState() == list.FilterApplied {
+			return nil
+		}
+		m.activeView = msgsListView
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	case " ":
+		item, ok := m.replayFilesList.SelectedItem().(replayFileItem)
+		if !ok {
+			return nil
+		}
+
+		item.selected = !item.selected
+		cmd := m.replayFilesList.SetItem(m.replayFilesList.GlobalIndex(), item)
+		m.replayFilesList.CursorDown()
+		m.refreshReplayFilesTitle()
+		return cmd
+	case "a":
+		// toggles all messages matching the current filter
+		visible := m.replayFilesList.VisibleItems()
+		allSelected := !slices.ContainsFunc(visible, func(listItem list.Item) bool {
+			item, ok := listItem.(replayFileItem)
+			return ok && !item.selected
+		})
+
+		items := m.replayFilesList.Items()
+		for i, listItem := range items {
+			item, ok := listItem.(replayFileItem)
+			if !ok || !slices.ContainsFunc(visible, func(v list.Item) bool {
+				visibleItem, ok := v.(replayFileItem)
+				return ok && visibleItem.message.Path == item.message.Path
+			}) {
+				continue
+			}
+			item.selected = !allSelected
+			items[i] = item
+		}
+		m.refreshReplayFilesTitle()
+		return m.replayFilesList.SetItems(items)
+	case "e":
+		item, ok := m.replayFilesList.SelectedItem().(replayFileItem)
+		if !ok {
+			return nil
+		}
+
+		return editReplayMessage(item)
+	case "enter":
+		m.replayPending = m.selectedReplayFiles()
+		if len(m.replayPending) == 0 {
+			item, ok := m.replayFilesList.SelectedItem().(replayFileItem)
+			if !ok {
+				return nil
+			}
+			m.replayPending = []replayFileItem{item}
+		}
+
+		m.showReplayTargets()
+	}
+
+	return nil
+}
+
+func (m *Model) handleReplayTargetKeys(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	if m.replayTargetsList.FilterState() == list.Filtering {
+		return nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		if msg.String() == "esc" && m.replayTargetsList.FilterState() == list.FilterApplied {
+			return nil
+		}
+		if m.replayEdited {
+			m.replayPending = nil
+			m.replayEdited = false
+			m.activeView = msgsListView
+			m.message = "discarded the edited message"
+			return nil
+		}
+		m.activeView = replayFilesView
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	case "enter":
+		item, ok := m.replayTargetsList.SelectedItem().(profileItem)
+		if !ok {
+			return nil
+		}
+
+		if err := item.config.EnsureWritable(); err != nil {
+			m.errorMsg = err.Error()
+			return nil
+		}
+
+		pending := m.replayPending
+		m.replayPending = nil
+		m.replayEdited = false
+		m.activeView = msgsListView
+		m.message = fmt.Sprintf("replaying %d messages to %q", len(pending), utils.QueueNameFromURL(item.config.QueueURL)) + fetchingIndicator
+
+		return replayMessages(m.sqsClients[item.config.AWSConfigSource.String()], item.config, pending)
+	}
+
+	return nil
+}
+
+func (m *Model) handleReplayMessageEdited(msg ReplayMessageEditedMsg) {
+	if msg.err != nil {
+		m.errorMsg = fmt.Sprintf("couldn't edit message: %s", msg.err.Error())
+		return
+	}
+
+	if strings.TrimSpace(msg.body) == "" {
+		m.errorMsg = "edited message was empty; discarded the edit"
+		return
+	}
+
+	for i, listItem := range m.replayFilesList.Items() {
+		item, ok := listItem.(replayFileItem)
+		if !ok || item.message.Path != msg.path {
+			continue
+		}
+
+		body := msg.body
+		item.body = &body
+		item.selected = true
+		m.replayFilesList.SetItem(i, item)
+		m.refreshReplayFilesTitle()
+		return
+	}
+}
+
+// editReplayMessage opens a temporary copy of a persisted message in the
+// user's editor; the persisted file itself is left untouched.
+func editReplayMessage(item replayFileItem) tea.Cmd {
+	path := item.message.Path
+
+	var body string
+	if item.body != nil {
+		body = *item.body
+	} else {
+		var err error
+		body, err = queue.ReadPersistedMessage(path)
+		if err != nil {
+			return func() tea.Msg {
+				return ReplayMessageEditedMsg{path: path, err: err}
+			}
+		}
+	}
+
+	tempPath, err := writeTempMessage(body, filepath.Ext(path))
+	if err != nil {
+		return func() tea.Msg {
+			return ReplayMessageEditedMsg{path: path, err: err}
+		}
+	}
+
+	return tea.ExecProcess(utils.EditorCmd(tempPath), func(err error) tea.Msg {
+		defer os.Remove(tempPath)
+		if err != nil {
+			return ReplayMessageEditedMsg{path: path, err: err}
+		}
+
+		edited, err := os.ReadFile(tempPath)
+		if err != nil {
+			return ReplayMessageEditedMsg{path: path, err: err}
+		}
+
+		return ReplayMessageEditedMsg{
+			path: path,
+			body: strings.TrimRight(string(edited), "\n"),
+		}
+	})
+}
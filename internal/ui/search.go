@@ -0,0 +1,260 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const sgrReset = "\x1b[0m"
+
+// sgrSequenceRegex matches the escape sequences pretty.Color (and lipgloss)
+// use to style text.
+var sgrSequenceRegex = regexp.MustCompile(`^\x1b\[[0-9;]*m`)
+
+// searchMatch is a match in the message value viewport's content; start and
+// end are offsets into the line's visible runes (ie. without escape
+// sequences).
+type searchMatch struct {
+	line  int
+	start int
+	end   int
+}
+
+// styledRune is a visible rune, along with the escape sequences in effect for
+// it.
+type styledRune struct {
+	r     rune
+	style string
+}
+
+func (m *Model) showSearchInput() tea.Cmd {
+	m.searchInput.SetValue(m.searchQuery)
+	m.searchInput.CursorEnd()
+	m.searchPrevQuery = m.searchQuery
+	m.activeView = msgValueSearchView
+
+	return m.searchInput.Focus()
+}
+
+func (m *Model) handleSearchKeys(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.search(m.searchPrevQuery)
+		m.searchInput.Blur()
+		m.activeView = msgValueView
+	case "enter":
+		m.searchInput.Blur()
+		m.activeView = msgValueView
+	default:
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		// matches are highlighted while typing
+		if m.searchInput.Value() != m.searchQuery {
+			m.search(m.searchInput.Value())
+		}
+		return cmd
+	}
+
+	return nil
+}
+
+// isSearchNavigationKey reports whether a key moves between search matches,
+// rather than doing what it does otherwise.
+func (m *Model) isSearchNavigationKey(key string) bool {
+	return (key == "n" || key == "N") && m.activeView == msgValueView && m.searchQuery != ""
+}
+
+// setMsgValueContent shows content in the message value viewport, with the
+// current search's matches highlighted; the tree shown (if any) is cleared.
+func (m *Model) setMsgValueContent(content string) {
+	m.msgTree = nil
+	m.msgValueContent = content
+	m.search(m.searchQuery)
+}
+
+// updateMsgValueContent replaces the message value viewport's content (eg.
+// when the tree view changes), without moving to the first search match.
+func (m *Model) updateMsgValueContent(content string) {
+	m.msgValueContent = content
+	m.searchMatches = findSearchMatches(content, m.searchQuery)
+	if m.searchCurrent >= len(m.searchMatches) {
+		m.searchCurrent = 0
+	}
+	m.refreshSearchHighlights()
+}
+
+// search highlights a query's matches in the message value viewport, and
+// scrolls to the first one; an empty query clears the search.
+func (m *Model) search(query string) {
+	m.searchQuery = query
+	m.searchCurrent = 0
+	m.searchMatches = findSearchMatches(m.msgValueContent, query)
+	m.refreshSearchHighlights()
+	m.scrollToSearchMatch()
+}
+
+// cycleSearchMatch moves to the next (or, for a negative delta, previous)
+// match, wrapping around at either end.
+func (m *Model) cycleSearchMatch(delta int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+
+	m.searchCurrent = (m.searchCurrent + delta + len(m.searchMatches)) % len(m.searchMatches)
+	m.refreshSearchHighlights()
+	m.scrollToSearchMatch()
+}
+
+func (m *Model) refreshSearchHighlights() {
+	if len(m.searchMatches) == 0 {
+		m.msgValueVP.SetContent(m.msgValueContent)
+		return
+	}
+
+	m.msgValueVP.SetContent(highlightSearchMatches(m.msgValueContent, m.searchMatches, m.searchCurrent))
+}
+
+// scrollToSearchMatch keeps the current match in the middle of the viewport,
+// if possible.
+func (m *Model) scrollToSearchMatch() {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+
+	m.msgValueVP.SetYOffset(m.searchMatches[m.searchCurrent].line - m.msgValueVP.Height/2)
+}
+
+// findSearchMatches returns the non-overlapping, case-insensitive matches of
+// query in content's visible text, in order.
+func findSearchMatches(content, query string) []searchMatch {
+	needle := []rune(query)
+	for i, r := range needle {
+		needle[i] = unicode.ToLower(r)
+	}
+	if len(needle) == 0 {
+		return nil
+	}
+
+	var matches []searchMatch
+	for i, line := range strings.Split(content, "\n") {
+		runes := parseStyledLine(line)
+		for start := 0; start+len(needle) <= len(runes); {
+			if !styledRunesHavePrefix(runes[start:], needle) {
+				start++
+				continue
+			}
+
+			matches = append(matches, searchMatch{line: i, start: start, end: start + len(needle)})
+			start += len(needle)
+		}
+	}
+
+	return matches
+}
+
+func styledRunesHavePrefix(runes []styledRune, prefix []rune) bool {
+	for i, r := range prefix {
+		if unicode.ToLower(runes[i].r) != r {
+			return false
+		}
+	}
+
+	return true
+}
+
+// highlightSearchMatches styles matches over content's existing styling; only
+// lines with matches are re-rendered.
+func highlightSearchMatches(content string, matches []searchMatch, current int) string {
+	lines := strings.Split(content, "\n")
+	byLine := make(map[int][]int)
+	for i, match := range matches {
+		byLine[match.line] = append(byLine[match.line], i)
+	}
+
+	for lineIndex, matchIndexes := range byLine {
+		runes := parseStyledLine(lines[lineIndex])
+
+		var sb strings.Builder
+		var style string
+		pos := 0
+		for _, i := range matchIndexes {
+			match := matches[i]
+			writeStyledRunes(&sb, runes[pos:match.start], &style)
+
+			matchStyle := searchMatchStyle
+			if i == current {
+				matchStyle = currentSearchMatchStyle
+			}
+			if style != "" {
+				sb.WriteString(sgrReset)
+				style = ""
+			}
+			sb.WriteString(matchStyle.Render(styledRunesText(runes[match.start:match.end])))
+			pos = match.end
+		}
+		writeStyledRunes(&sb, runes[pos:], &style)
+		if style != "" {
+			sb.WriteString(sgrReset)
+		}
+
+		lines[lineIndex] = sb.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// parseStyledLine splits a line into its visible runes; escape sequences are
+// accumulated till a reset, since styles can be layered.
+func parseStyledLine(line string) []styledRune {
+	var runes []styledRune
+	var style string
+	for len(line) > 0 {
+		// the regex is only needed at the start of an escape sequence
+		if line[0] == '\x1b' {
+			if sequence := sgrSequenceRegex.FindString(line); sequence != "" {
+				if sequence == sgrReset || sequence == "\x1b[m" {
+					style = ""
+				} else {
+					style += sequence
+				}
+				line = line[len(sequence):]
+				continue
+			}
+		}
+
+		r, size := utf8.DecodeRuneInString(line)
+		runes = append(runes, styledRune{r: r, style: style})
+		line = line[size:]
+	}
+
+	return runes
+}
+
+// writeStyledRunes writes runes, switching styles only when they change.
+func writeStyledRunes(sb *strings.Builder, runes []styledRune, style *string) {
+	for _, r := range runes {
+		if r.style != *style {
+			if *style != "" {
+				sb.WriteString(sgrReset)
+			}
+			sb.WriteString(r.style)
+			*style = r.style
+		}
+		sb.WriteRune(r.r)
+	}
+}
+
+func styledRunesText(runes []styledRune) string {
+	text := make([]rune, len(runes))
+	for i, r := range runes {
+		text[i] = r.r
+	}
+
+	return string(text)
+}
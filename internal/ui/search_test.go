@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/pretty"
+)
+
+func TestFindSearchMatches(t *testing.T) {
+	coloured := string(pretty.Color(pretty.Pretty([]byte(`{"kind": "Created", "count": 12}`)), nil))
+
+	testCases := []struct {
+		name     string
+		content  string
+		query    string
+		expected []searchMatch
+	}{
+		{
+			name:     "empty query",
+			content:  "created",
+			query:    "",
+			expected: nil,
+		},
+		{
+			name:     "no matches",
+			content:  "created",
+			query:    "deleted",
+			expected: nil,
+		},
+		{
+			name:     "matches are case-insensitive",
+			content:  "Created CREATED",
+			query:    "created",
+			expected: []searchMatch{{line: 0, start: 0, end: 7}, {line: 0, start: 8, end: 15}},
+		},
+		{
+			name:     "matches don't overlap",
+			content:  "aaaaa",
+			query:    "aa",
+			expected: []searchMatch{{line: 0, start: 0, end: 2}, {line: 0, start: 2, end: 4}},
+		},
+		{
+			name:     "matches on several lines",
+			content:  "one\ntwo one\nthree",
+			query:    "one",
+			expected: []searchMatch{{line: 0, start: 0, end: 3}, {line: 1, start: 4, end: 7}},
+		},
+		{
+			name:     "offsets are in runes",
+			content:  "héllo wörld",
+			query:    "wörld",
+			expected: []searchMatch{{line: 0, start: 6, end: 11}},
+		},
+		{
+			name:     "escape sequences are skipped",
+			content:  "\x1b[1;34m\"kind\"\x1b[0m: \x1b[32m\"Created\"\x1b[0m",
+			query:    "created",
+			expected: []searchMatch{{line: 0, start: 9, end: 16}},
+		},
+		{
+			name:     "matches can span differently styled text",
+			content:  "\x1b[1;34m\"kind\"\x1b[0m: \x1b[32m\"Created\"\x1b[0m",
+			query:    `kind": "cr`,
+			expected: []searchMatch{{line: 0, start: 1, end: 11}},
+		},
+		{
+			name:     "escape sequences aren't matched",
+			content:  "\x1b[1;34mkind\x1b[0m",
+			query:    "34m",
+			expected: nil,
+		},
+		{
+			name:     "coloured JSON",
+			content:  coloured,
+			query:    "12",
+			expected: []searchMatch{{line: 2, start: 11, end: 13}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSearchMatches(tt.content, tt.query)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestHighlightSearchMatchesKeepsVisibleText(t *testing.T) {
+	content := string(pretty.Color([]byte(`{"kind": "Created", "other": "created"}`), nil))
+	matches := findSearchMatches(content, "created")
+
+	got := highlightSearchMatches(content, matches, 1)
+
+	assert.Len(t, matches, 2)
+	assert.Equal(t, stripStyles(content), stripStyles(got))
+	assert.Equal(t, matches, findSearchMatches(got, "created"))
+}
+
+func TestCycleSearchMatch(t *testing.T) {
+	testCases := []struct {
+		name     string
+		matches  int
+		current  int
+		delta    int
+		expected int
+	}{
+		{name: "next", matches: 3, current: 0, delta: 1, expected: 1},
+		{name: "next wraps around to the first", matches: 3, current: 2, delta: 1, expected: 0},
+		{name: "previous", matches: 3, current: 2, delta: -1, expected: 1},
+		{name: "previous wraps around to the last", matches: 3, current: 0, delta: -1, expected: 2},
+		{name: "single match", matches: 1, current: 0, delta: 1, expected: 0},
+		{name: "no matches", matches: 0, current: 0, delta: -1, expected: 0},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]string, tt.matches)
+			for i := range lines {
+				lines[i] = "match"
+			}
+			m := Model{searchQuery: "match", searchCurrent: tt.current}
+			m.msgValueContent = strings.Join(lines, "\n")
+			m.searchMatches = findSearchMatches(m.msgValueContent, m.searchQuery)
+
+			m.cycleSearchMatch(tt.delta)
+
+			assert.Equal(t, tt.expected, m.searchCurrent)
+		})
+	}
+}
+
+func stripStyles(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		lines = append(lines, styledRunesText(parseStyledLine(line)))
+	}
+
+	return strings.Join(lines, "\n")
+}
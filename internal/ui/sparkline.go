@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const sparklineWidth = 20
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+func sparkline(values []int, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	lowest, highest := values[0], values[0]
+	for _, v := range values {
+		lowest = min(lowest, v)
+		highest = max(highest, v)
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		level := 0
+		if highest > lowest {
+			level = (v - lowest) * (len(sparklineLevels) - 1) / (highest - lowest)
+		}
+		sb.WriteRune(sparklineLevels[level])
+	}
+
+	return sb.String()
+}
+
+func queueDepthSummary(history t.QueueDepthHistory) string {
+	samples := history.Samples()
+	if len(samples) == 0 {
+		return ""
+	}
+
+	totals := make([]int, len(samples))
+	for i, s := range samples {
+		totals[i] = s.Total()
+	}
+
+	summary := sparkline(totals, sparklineWidth)
+
+	rates := history.Rates()
+	if !rates.Known {
+		return summary
+	}
+
+	summary += fmt.Sprintf(" net change: +%.1f/s, -%.1f/s", rates.Growth, rates.Shrinkage)
+	if rates.Draining() {
+		summary += " (draining)"
+	}
+
+	return summary
+}
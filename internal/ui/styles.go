@@ -3,18 +3,37 @@ package ui
 import "github.com/charmbracelet/lipgloss"
 
 const (
-	defaultForegroundColor = "#282828"
-	inactivePaneColor      = "#928374"
-	listPaneBorderColor    = "#3c3836"
-	helpMsgColor           = "#83a598"
-	helpViewTitleColor     = "#83a598"
-	helpHeaderColor        = "#83a598"
-	helpSectionColor       = "#fabd2f"
-	cueitupColor           = "#d3869b"
-	persistingColor        = "#fb4934"
-	deletingMsgsColor      = "#d3869b"
-	skippingColor          = "#fabd2f"
-	errorColor             = "#fb4934"
+	defaultForegroundColor  = "#282828"
+	inactivePaneColor       = "#928374"
+	listPaneBorderColor     = "#3c3836"
+	helpMsgColor            = "#83a598"
+	helpViewTitleColor      = "#83a598"
+	helpHeaderColor         = "#83a598"
+	helpSectionColor        = "#fabd2f"
+	cueitupColor            = "#d3869b"
+	persistingColor         = "#fb4934"
+	deletingMsgsColor       = "#d3869b"
+	skippingColor           = "#fabd2f"
+	queueDepthColor         = "#b8bb26"
+	browsingColor           = "#83a598"
+	readOnlyColor           = "#8ec07c"
+	errorColor              = "#fb4934"
+	purgeColor              = "#fb4934"
+	filteringColor          = "#fe8019"
+	searchMatchColor        = "#fabd2f"
+	currentSearchMatchColor = "#fe8019"
+	treeKeyColor            = "#83a598"
+	treeIndexColor          = "#928374"
+	treeStringColor         = "#b8bb26"
+	treeNumberColor         = "#fabd2f"
+	treeLiteralColor        = "#d3869b"
+	treeCursorColor         = "#fe8019"
+	diffAddedColor          = "#b8bb26"
+	diffRemovedColor        = "#fb4934"
+	diffChangedColor        = "#fabd2f"
+	eventInfoColor          = "#d5c4a1"
+	eventWarningColor       = "#fabd2f"
+	problemsColor           = "#fb4934"
 )
 
 var (
@@ -32,11 +51,24 @@ var (
 
 	msgValueVPStyle = baseListStyle.PaddingLeft(4)
 
+	selectionListStyle = baseListStyle.PaddingLeft(1)
+
+	activeTabStyle = baseStyle.
+			Bold(true).
+			Background(lipgloss.Color(cueitupColor))
+
+	inactiveTabStyle = baseStyle.
+				Foreground(lipgloss.Color(inactivePaneColor))
+
 	helpVPStyle = lipgloss.NewStyle().
 			PaddingTop(1).
 			PaddingRight(2).
 			PaddingBottom(1)
 
+	diffVPStyle = helpVPStyle.PaddingLeft(2)
+
+	eventsVPStyle = helpVPStyle.PaddingLeft(2)
+
 	modeStyle = baseStyle.
 			Align(lipgloss.Center).
 			Bold(true).
@@ -59,6 +91,81 @@ var (
 			Bold(true).
 			Foreground(lipgloss.Color(skippingColor))
 
+	browsingStyle = baseStyle.
+			Bold(true).
+			Foreground(lipgloss.Color(browsingColor))
+
+	readOnlyStyle = baseStyle.
+			Bold(true).
+			Foreground(lipgloss.Color(readOnlyColor))
+
+	filteringStyle = baseStyle.
+			Bold(true).
+			Foreground(lipgloss.Color(filteringColor))
+
+	searchMatchStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(defaultForegroundColor)).
+				Background(lipgloss.Color(searchMatchColor))
+
+	currentSearchMatchStyle = searchMatchStyle.
+				Bold(true).
+				Background(lipgloss.Color(currentSearchMatchColor))
+
+	treeKeyStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color(treeKeyColor))
+
+	treeIndexStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(treeIndexColor))
+
+	treeSummaryStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(inactivePaneColor))
+
+	treeStringStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(treeStringColor))
+
+	treeNumberStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(treeNumberColor))
+
+	treeLiteralStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(treeLiteralColor))
+
+	treeCursorStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color(treeCursorColor))
+
+	diffHeaderStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color(helpHeaderColor))
+
+	diffAddedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(diffAddedColor))
+
+	diffRemovedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(diffRemovedColor))
+
+	diffChangedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(diffChangedColor))
+
+	diffUnchangedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(inactivePaneColor))
+
+	eventPrefixStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(inactivePaneColor))
+
+	eventInfoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(eventInfoColor))
+
+	eventWarningStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color(eventWarningColor))
+
+	problemsStyle = baseStyle.
+			Bold(true).
+			Foreground(lipgloss.Color(problemsColor))
+
+	queueDepthStyle = baseStyle.
+			Foreground(lipgloss.Color(queueDepthColor))
+
 	helpMsgStyle = baseStyle.
 			Bold(true).
 			Foreground(lipgloss.Color(helpMsgColor))
@@ -77,4 +184,12 @@ var (
 
 	errorStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(errorColor))
+
+	purgeTitleStyle = baseStyle.
+			Bold(true).
+			Background(lipgloss.Color(purgeColor))
+
+	purgeViewStyle = lipgloss.NewStyle().
+			PaddingTop(1).
+			PaddingLeft(2)
 )
@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+// tabBarHeight is the number of lines taken up by the tab bar.
+const tabBarHeight = 1
+
+func (m Model) tab() *queueTab {
+	if len(m.tabs) == 0 {
+		return nil
+	}
+
+	return m.tabs[m.activeTab]
+}
+
+func (m Model) tabByID(id int) *queueTab {
+	for _, tab := range m.tabs {
+		if tab.id == id {
+			return tab
+		}
+	}
+
+	return nil
+}
+
+func (m *Model) openTab(sqsClient *sqs.Client, config t.Config) tea.Cmd {
+	m.activeView = msgsListView
+
+	for i, tab := range m.tabs {
+		if tab.isFor(config) {
+			m.switchTab(i)
+			return nil
+		}
+	}
+
+	// read-only profiles never have deletion turned on
+	behaviours, _ := config.TUIBehaviours(m.behaviourFlags)
+
+	tab := &queueTab{
+		id:                  m.nextTabID,
+		sqsClient:           sqsClient,
+		queueURL:            config.QueueURL,
+		config:              config,
+		behaviours:          behaviours,
+		msgsList:            newMessagesList(),
+		msgListCurrentIndex: -1,
+		persistDir:          config.PersistDirectory(),
+		depthHistory:        t.NewQueueDepthHistory(t.DefaultQueueDepthHistorySize),
+		firstFetch:          true,
+		bodyView:            config.BodyViews()[0],
+	}
+	m.nextTabID++
+
+	if m.terminalHeight > 0 {
+		tab.msgsList.SetHeight(m.msgsListHeight())
+	}
+
+	m.tabs = append(m.tabs, tab)
+	m.switchTab(len(m.tabs) - 1)
+
+	if !tab.behaviours.ShowMessageCount {
+		return nil
+	}
+
+	return tea.Batch(
+		GetQueueMsgCount(tab.sqsClient, tab.queueURL),
+		tickEvery(tab.id, msgCountTickInterval),
+	)
+}
+
+// openOfflineTab opens a tab for messages loaded from disk; the tab is always
+// read-only, and all behaviours that need a queue are turned off.
+func (m *Model) openOfflineTab(config t.Config, messages []sqstypes.Message) {
+	config.ReadOnly = true
+
+	msgsList := newMessagesList()
+	parsed := make([]t.Message, len(messages))
+	items := make([]list.Item, len(messages))
+	for i, message := range messages {
+		parsed[i] = t.GetMessageData(&message, config)
+		items[i] = parsed[i]
+	}
+	msgsList.SetItems(items)
+
+	tab := &queueTab{
+		id:                  m.nextTabID,
+		queueURL:            config.QueueURL,
+		config:              config,
+		msgsList:            msgsList,
+		msgListCurrentIndex: -1,
+		messages:            parsed,
+		depthHistory:        t.NewQueueDepthHistory(t.DefaultQueueDepthHistorySize),
+		offline:             true,
+		bodyView:            config.BodyViews()[0],
+	}
+	m.nextTabID++
+
+	if m.terminalHeight > 0 {
+		tab.msgsList.SetHeight(m.msgsListHeight())
+	}
+
+	m.tabs = append(m.tabs, tab)
+	m.switchTab(len(m.tabs) - 1)
+	m.activeView = msgsListView
+}
+
+func (m *Model) switchTab(index int) {
+	if index < 0 || index >= len(m.tabs) {
+		return
+	}
+
+	m.activeTab = index
+	// forces the message value viewport to be refreshed for the new tab
+	m.tabs[index].msgListCurrentIndex = -1
+	m.setMsgValueContent("")
+	m.msgValueVP.GotoTop()
+}
+
+func (m *Model) cycleTab(delta int) {
+	if len(m.tabs) < 2 {
+		return
+	}
+
+	m.switchTab((m.activeTab + delta + len(m.tabs)) % len(m.tabs))
+}
+
+func (m *Model) closeTab() {
+	if len(m.tabs) == 0 {
+		return
+	}
+
+	tab := m.tabs[m.activeTab]
+	if tab.purge != nil {
+		// the purge's outcome is still logged once it stops
+		tab.purge.cancel()
+	}
+	if tab.pendingPersists > 0 || tab.purge != nil {
+		// messages still being persisted hold on to the persister
+		if m.closedTabs == nil {
+			m.closedTabs = make(map[int]*queueTab)
+		}
+		m.closedTabs[tab.id] = tab
+	} else {
+		tab.closePersister()
+	}
+	m.tabs = slices.Delete(m.tabs, m.activeTab, m.activeTab+1)
+	if len(m.tabs) == 0 {
+		m.activeTab = 0
+		m.setMsgValueContent("")
+		m.showQueuePicker()
+		return
+	}
+
+	m.switchTab(min(m.activeTab, len(m.tabs)-1))
+}
+
+// isFor reports whether the tab was opened for a config. Tabs are matched by
+// profile rather than by queue, since several profiles (with different
+// behaviours, or read-only settings) can point at the same queue; tabs opened
+// from the queue browser are named after their queue.
+func (tab *queueTab) isFor(config t.Config) bool {
+	return tab.config.ProfileName == config.ProfileName && tab.queueURL == config.QueueURL
+}
+
+// getPersister returns the tab's persister, opening it if needed.
+func (tab *queueTab) getPersister() (queue.Persister, error) {
+	if tab.persister != nil {
+		return tab.persister, nil
+	}
+
+	persister, err := queue.NewPersister(tab.config)
+	if err != nil {
+		return nil, err
+	}
+
+	tab.persister = persister
+	return persister, nil
+}
+
+// persistFinished records that a message was persisted (or failed to be) for
+// a tab, and returns the tab if it's still open. The persister of a closed tab
+// is closed once all its messages are done.
+func (m *Model) persistFinished(tabID int) *queueTab {
+	if tab := m.tabByID(tabID); tab != nil {
+		tab.pendingPersists--
+		return tab
+	}
+
+	tab, ok := m.closedTabs[tabID]
+	if !ok {
+		return nil
+	}
+
+	tab.pendingPersists--
+	m.releaseClosedTab(tab)
+
+	return nil
+}
+
+// purgeFinished records that the purge running for a tab has stopped, and
+// returns the tab, along with whether it's still open.
+func (m *Model) purgeFinished(tabID int) (*queueTab, bool) {
+	if tab := m.tabByID(tabID); tab != nil {
+		tab.purge = nil
+		return tab, true
+	}
+
+	tab, ok := m.closedTabs[tabID]
+	if !ok {
+		return nil, false
+	}
+
+	tab.purge = nil
+	m.releaseClosedTab(tab)
+
+	return tab, false
+}
+
+// releaseClosedTab forgets a closed tab (and closes its persister) once
+// nothing running in the background needs it anymore.
+func (m *Model) releaseClosedTab(tab *queueTab) {
+	if tab.pendingPersists > 0 || tab.purge != nil {
+		return
+	}
+
+	tab.closePersister()
+	delete(m.closedTabs, tab.id)
+}
+
+func (tab *queueTab) closePersister() {
+	if tab.persister == nil {
+		return
+	}
+
+	_ = tab.persister.Close()
+	tab.persister = nil
+}
+
+// showQueuePicker shows the view used to open new queues; this is the queue
+// browser when cueitup was started via "browse", and the profile picker
+// otherwise.
+func (m *Model) showQueuePicker() {
+	if m.browserConfig != nil {
+		m.activeView = queueBrowserView
+		return
+	}
+
+	m.refreshProfileItems()
+	m.activeView = profilePickerView
+}
+
+func (m *Model) refreshProfileItems() {
+	items := make([]list.Item, len(m.profiles))
+	for i, profile := range m.profiles {
+		open := slices.ContainsFunc(m.tabs, func(tab *queueTab) bool {
+			return tab.isFor(profile)
+		})
+		items[i] = profileItem{config: profile, open: open}
+	}
+	m.profilesList.SetItems(items)
+}
+
+func (m Model) msgsListHeight() int {
+	_, h := msgListStyle.GetFrameSize()
+	return m.terminalHeight - h - 2 - tabBarHeight
+}
@@ -0,0 +1,388 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
+	types "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testProfile(name string) types.Config {
+	return types.Config{
+		ProfileName: name,
+		QueueURL:    "https://sqs.eu-central-1.amazonaws.com/000000000000/" + name,
+		Format:      types.JSON,
+	}
+}
+
+func modelWithTabs(tb testing.TB, names ...string) Model {
+	tb.Helper()
+	profiles := make([]types.Config, len(names))
+	for i, name := range names {
+		profiles[i] = testProfile(name)
+	}
+
+	m := InitialModel(profiles, map[string]*sqs.Client{}, types.TUIBehaviourFlags{})
+	for _, profile := range profiles {
+		m.openTab(nil, profile)
+	}
+	require.Len(tb, m.tabs, len(names))
+
+	return m
+}
+
+func tabQueueURLs(m Model) []string {
+	urls := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		urls[i] = tab.queueURL
+	}
+
+	return urls
+}
+
+func TestOpenTab(t *testing.T) {
+	t.Run("opening a queue adds a tab and switches to it", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+
+		assert.Equal(t, 1, m.activeTab)
+		assert.Equal(t, msgsListView, m.activeView)
+		assert.NotEqual(t, m.tabs[0].id, m.tabs[1].id)
+	})
+
+	t.Run("opening a queue that's already open switches to its tab", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		m.activeView = profilePickerView
+
+		cmd := m.openTab(nil, testProfile("queue-a"))
+
+		assert.Nil(t, cmd)
+		assert.Len(t, m.tabs, 2)
+		assert.Equal(t, 0, m.activeTab)
+		assert.Equal(t, msgsListView, m.activeView)
+	})
+
+	t.Run("profiles sharing a queue get their own tabs", func(t *testing.T) {
+		writable := testProfile("queue-a")
+		readOnly := writable
+		readOnly.ProfileName = "queue-a-read-only"
+		readOnly.ReadOnly = true
+
+		m := InitialModel([]types.Config{writable, readOnly}, map[string]*sqs.Client{}, types.TUIBehaviourFlags{})
+		m.openTab(nil, writable)
+		m.refreshProfileItems()
+		items := m.profilesList.Items()
+		require.Len(t, items, 2)
+		assert.True(t, items[0].(profileItem).open)
+		assert.False(t, items[1].(profileItem).open)
+
+		m.openTab(nil, readOnly)
+
+		require.Len(t, m.tabs, 2)
+		assert.Equal(t, 1, m.activeTab)
+		assert.True(t, m.tab().config.ReadOnly)
+		assert.False(t, m.tab().behaviours.DeleteMessages)
+		assert.True(t, m.tabs[0].behaviours.DeleteMessages)
+	})
+
+	t.Run("tab ids aren't reused after a tab is closed", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		closedID := m.tab().id
+		m.closeTab()
+
+		m.openTab(nil, testProfile("queue-c"))
+
+		assert.NotEqual(t, closedID, m.tab().id)
+	})
+}
+
+func TestCloseTab(t *testing.T) {
+	testCases := []struct {
+		name         string
+		activeTab    int
+		expectedURLs []string
+		expectedTab  int
+	}{
+		{
+			name:         "closing the first tab keeps the first position active",
+			activeTab:    0,
+			expectedURLs: []string{testProfile("queue-b").QueueURL, testProfile("queue-c").QueueURL},
+			expectedTab:  0,
+		},
+		{
+			name:         "closing a middle tab activates the one after it",
+			activeTab:    1,
+			expectedURLs: []string{testProfile("queue-a").QueueURL, testProfile("queue-c").QueueURL},
+			expectedTab:  1,
+		},
+		{
+			name:         "closing the last tab activates the one before it",
+			activeTab:    2,
+			expectedURLs: []string{testProfile("queue-a").QueueURL, testProfile("queue-b").QueueURL},
+			expectedTab:  1,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m := modelWithTabs(t, "queue-a", "queue-b", "queue-c")
+			m.switchTab(tt.activeTab)
+
+			m.closeTab()
+
+			assert.Equal(t, tt.expectedURLs, tabQueueURLs(m))
+			assert.Equal(t, tt.expectedTab, m.activeTab)
+			assert.Equal(t, msgsListView, m.activeView)
+		})
+	}
+
+	t.Run("closing the only tab shows the profile picker", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+
+		m.closeTab()
+
+		assert.Empty(t, m.tabs)
+		assert.Equal(t, 0, m.activeTab)
+		assert.Nil(t, m.tab())
+		assert.Equal(t, profilePickerView, m.activeView)
+	})
+
+	t.Run("closing without any tabs does nothing", func(t *testing.T) {
+		m := InitialModel(nil, map[string]*sqs.Client{}, types.TUIBehaviourFlags{})
+
+		m.closeTab()
+
+		assert.Empty(t, m.tabs)
+		assert.Equal(t, profilePickerView, m.activeView)
+	})
+}
+
+// fakePersister is a persister that only records whether it was closed.
+type fakePersister struct {
+	closed bool
+}
+
+func (p *fakePersister) Persist(queue.PersistableMessage) (string, error) {
+	return "", nil
+}
+
+func (p *fakePersister) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestClosingTabsWithPendingPersists(t *testing.T) {
+	savedMsg := func(tabID int) RecordSavedToDiskMsg {
+		return RecordSavedToDiskMsg{
+			tabID:      tabID,
+			sqsMessage: sqstypes.Message{MessageId: aws.String("id-1")},
+			path:       "messages/id-1.json",
+		}
+	}
+
+	t.Run("persister is closed right away without pending persists", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		persister := &fakePersister{}
+		m.tab().persister = persister
+
+		m.closeTab()
+
+		assert.True(t, persister.closed)
+		assert.Empty(t, m.closedTabs)
+	})
+
+	t.Run("persister is closed once the last pending persist is done", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		persister := &fakePersister{}
+		closedID := m.tab().id
+		m.tab().persister = persister
+		m.tab().pendingPersists = 2
+
+		m.closeTab()
+		require.False(t, persister.closed)
+
+		updated, cmd := m.Update(savedMsg(closedID))
+		m = updated.(Model)
+		assert.Nil(t, cmd)
+		assert.False(t, persister.closed)
+
+		updated, _ = m.Update(savedMsg(closedID))
+		m = updated.(Model)
+		assert.True(t, persister.closed)
+		assert.Empty(t, m.closedTabs)
+	})
+}
+
+func TestClosingTabsWithRunningPurges(t *testing.T) {
+	t.Run("purge is cancelled, and its outcome is logged once it stops", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		persister := &fakePersister{}
+		var cancelled bool
+		closedID := m.tab().id
+		closedQueue := utils.QueueNameFromURL(m.tab().queueURL)
+		m.tab().persister = persister
+		m.tab().purge = &runningPurge{cancel: func() { cancelled = true }, done: make(chan struct{})}
+
+		m.closeTab()
+		assert.True(t, cancelled)
+		require.Contains(t, m.closedTabs, closedID)
+		assert.False(t, persister.closed)
+
+		updated, _ := m.Update(QueuePurgedMsg{
+			tabID:  closedID,
+			result: queue.PurgeResult{BackupPath: "backups/queue-a/1700000000.jsonl", BackedUp: 12},
+			err:    fmt.Errorf("couldn't back up queue before purging it: %w", context.Canceled),
+		})
+		m = updated.(Model)
+
+		assert.Empty(t, m.errorMsg)
+		assert.True(t, persister.closed)
+		assert.Empty(t, m.closedTabs)
+		require.Len(t, m.events, 1)
+		assert.Equal(t, eventError, m.events[0].level)
+		assert.Equal(t, closedQueue, m.events[0].queue)
+		assert.Contains(t, m.events[0].summary, "12 messages were backed up to backups/queue-a/1700000000.jsonl")
+	})
+
+	t.Run("stopping a purge waits for it to finish", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+		purge := &runningPurge{done: make(chan struct{})}
+		purge.cancel = func() {
+			purge.result = queue.PurgeResult{BackedUp: 3}
+			close(purge.done)
+		}
+		m.tab().purge = purge
+
+		stopPurge(m.tab())
+
+		assert.Equal(t, 3, purge.result.BackedUp)
+	})
+}
+
+func TestTabByIDRouting(t *testing.T) {
+	message := func(id string) types.Message {
+		return types.Message{ID: id, Body: `{"a": 1}`}
+	}
+
+	t.Run("fetched messages go to the tab that fetched them", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		first := m.tabs[0]
+		require.Equal(t, 1, m.activeTab)
+
+		updated, _ := m.Update(SQSMsgsFetchedMsg{
+			tabID:       first.id,
+			messages:    []types.Message{message("id-1")},
+			sqsMessages: []sqstypes.Message{{MessageId: aws.String("id-1")}},
+		})
+		m = updated.(Model)
+
+		require.Len(t, m.tabs[0].messages, 1)
+		assert.Equal(t, "id-1", m.tabs[0].messages[0].ID)
+		assert.Empty(t, m.tabs[1].messages)
+		assert.Equal(t, 1, m.activeTab)
+	})
+
+	t.Run("messages for a closed tab are dropped", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a", "queue-b")
+		closedID := m.tab().id
+		m.closeTab()
+
+		updated, cmd := m.Update(SQSMsgsFetchedMsg{
+			tabID:       closedID,
+			messages:    []types.Message{message("id-1")},
+			sqsMessages: []sqstypes.Message{{MessageId: aws.String("id-1")}},
+		})
+		m = updated.(Model)
+
+		assert.Nil(t, cmd)
+		require.Len(t, m.tabs, 1)
+		assert.Empty(t, m.tabs[0].messages)
+	})
+
+	t.Run("unknown ids don't match any tab", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+
+		assert.Nil(t, m.tabByID(m.nextTabID))
+		assert.Same(t, m.tabs[0], m.tabByID(m.tabs[0].id))
+	})
+}
+
+func TestPurgeUpdates(t *testing.T) {
+	t.Run("progress is shown and the next update is waited for", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+		updates := make(chan tea.Msg, 1)
+		next := QueuePurgedMsg{tabID: m.tab().id}
+		updates <- next
+
+		updated, cmd := m.Update(PurgeProgressMsg{tabID: m.tab().id, backedUp: 20, updates: updates})
+		m = updated.(Model)
+
+		assert.Contains(t, m.message, "backed up and deleted 20 messages")
+		require.NotNil(t, cmd)
+		assert.Equal(t, next, cmd())
+	})
+
+	t.Run("messages left on the queue after backing up are reported", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+		m.tab().purge = &runningPurge{cancel: func() {}, done: make(chan struct{})}
+
+		updated, _ := m.Update(QueuePurgedMsg{
+			tabID: m.tab().id,
+			result: queue.PurgeResult{
+				BackupPath: "backups/queue-a/1700000000.jsonl",
+				BackedUp:   25,
+				Remaining:  3,
+			},
+			err: fmt.Errorf("%w: 3 messages were left on the queue", queue.ErrPurgeIncomplete),
+		})
+		m = updated.(Model)
+
+		assert.Empty(t, m.errorMsg)
+		assert.Equal(t, `backed up and deleted 25 messages from "queue-a" (to backups/queue-a/1700000000.jsonl); 3 remain`, m.message)
+		assert.Nil(t, m.tab().purge)
+	})
+
+	t.Run("failed backups are reported as errors", func(t *testing.T) {
+		m := modelWithTabs(t, "queue-a")
+
+		updated, _ := m.Update(QueuePurgedMsg{
+			tabID: m.tab().id,
+			result: queue.PurgeResult{
+				BackupPath: "backups/queue-a/1700000000.jsonl",
+				BackedUp:   5,
+			},
+			err: context.DeadlineExceeded,
+		})
+		m = updated.(Model)
+
+		assert.Contains(t, m.errorMsg, `couldn't purge queue "queue-a"`)
+		assert.Equal(t, "5 messages were backed up to backups/queue-a/1700000000.jsonl", m.message)
+	})
+}
+
+func TestPredicateRefusedForRedrivePolicy(t *testing.T) {
+	m := modelWithTabs(t, "queue-a")
+	m.tab().predicate = "$.kind = Created"
+
+	updated, _ := m.Update(MatchingMsgsFetchedMsg{
+		tabID: m.tab().id,
+		err:   fmt.Errorf("%w (maxReceiveCount: 3)", queue.ErrScanCouldDeadLetter),
+	})
+	m = updated.(Model)
+
+	assert.Contains(t, m.errorMsg, "submit the same predicate again")
+	assert.Equal(t, "$.kind = Created", m.tab().refusedPredicate)
+
+	updated, _ = m.Update(MatchingMsgsFetchedMsg{tabID: m.tab().id})
+	m = updated.(Model)
+
+	assert.Empty(t, m.tab().refusedPredicate)
+}
@@ -0,0 +1,357 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	t "github.com/dhth/cueitup/internal/types"
+)
+
+const (
+	// treeChildrenPageSize is the number of children of an array (or object)
+	// shown at once; the rest are summarised in a single row, which shows more
+	// of them when toggled.
+	treeChildrenPageSize = 100
+	// treeAutoExpandMaxChildren is the size up to which nodes are expanded
+	// when a tree is built; larger ones start collapsed.
+	treeAutoExpandMaxChildren = 20
+	treeIndent                = "  "
+)
+
+var (
+	errTreeNotJSON      = errors.New("tree view is only available for JSON messages")
+	errCouldntParseTree = errors.New("couldn't parse message as JSON")
+)
+
+type jsonNodeKind uint
+
+const (
+	jsonScalar jsonNodeKind = iota
+	jsonObject
+	jsonArray
+)
+
+// jsonNode is a value in a JSON document; object keys keep the order they
+// appear in.
+type jsonNode struct {
+	kind  jsonNodeKind
+	key   string
+	path  string
+	value string
+	// raw is the node's JSON, as it appears in the message
+	raw      string
+	children []*jsonNode
+	expanded bool
+	// shown is the number of children shown while expanded
+	shown int
+}
+
+// jsonTreeRow is a visible row of a tree; node is nil for rows summarising
+// children that aren't shown.
+type jsonTreeRow struct {
+	node   *jsonNode
+	parent *jsonNode
+	depth  int
+}
+
+type jsonTree struct {
+	root   *jsonNode
+	rows   []jsonTreeRow
+	cursor int
+}
+
+func newJSONTree(body string) (*jsonTree, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(body)))
+	decoder.UseNumber()
+
+	root, err := decodeJSONNode(decoder, body, "", "$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntParseTree, err.Error())
+	}
+
+	root.expanded = true
+	tree := &jsonTree{root: root}
+	tree.refreshRows()
+
+	return tree, nil
+}
+
+func decodeJSONNode(decoder *json.Decoder, body, key, path string) (*jsonNode, error) {
+	start := decoder.InputOffset()
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	node := &jsonNode{key: key, path: path}
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			node.kind = jsonObject
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				childKey, ok := keyToken.(string)
+				if !ok {
+					return nil, fmt.Errorf("unexpected object key: %v", keyToken)
+				}
+				child, err := decodeJSONNode(decoder, body, childKey, t.JSONChildPath(path, childKey))
+				if err != nil {
+					return nil, err
+				}
+				node.children = append(node.children, child)
+			}
+		case '[':
+			node.kind = jsonArray
+			for i := 0; decoder.More(); i++ {
+				child, err := decodeJSONNode(decoder, body, strconv.Itoa(i), fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return nil, err
+				}
+				node.children = append(node.children, child)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected delimiter: %v", token)
+		}
+
+		// consumes the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		node.expanded = len(node.children) <= treeAutoExpandMaxChildren
+		node.shown = min(len(node.children), treeChildrenPageSize)
+	case string:
+		quoted, _ := json.Marshal(token)
+		node.value = string(quoted)
+	case json.Number:
+		node.value = token.String()
+	case bool:
+		node.value = strconv.FormatBool(token)
+	case nil:
+		node.value = "null"
+	}
+
+	// the offset before the token can include the separator preceding it
+	node.raw = strings.TrimLeft(body[start:decoder.InputOffset()], " \t\r\n:,")
+
+	return node, nil
+}
+
+func (t *jsonTree) refreshRows() {
+	t.rows = t.rows[:0]
+	t.addRows(t.root, nil, 0)
+	t.cursor = max(0, min(t.cursor, len(t.rows)-1))
+}
+
+func (t *jsonTree) addRows(node, parent *jsonNode, depth int) {
+	t.rows = append(t.rows, jsonTreeRow{node: node, parent: parent, depth: depth})
+	if node.kind == jsonScalar || !node.expanded {
+		return
+	}
+
+	for _, child := range node.children[:node.shown] {
+		t.addRows(child, node, depth+1)
+	}
+	if node.shown < len(node.children) {
+		t.rows = append(t.rows, jsonTreeRow{parent: node, depth: depth + 1})
+	}
+}
+
+func (t *jsonTree) moveCursor(delta int) {
+	t.cursor = max(0, min(t.cursor+delta, len(t.rows)-1))
+}
+
+// toggle expands or collapses the node under the cursor; for a summary row,
+// more of its parent's children are shown.
+func (t *jsonTree) toggle() {
+	row := t.rows[t.cursor]
+	switch {
+	case row.node == nil:
+		row.parent.shown = min(row.parent.shown+treeChildrenPageSize, len(row.parent.children))
+	case row.node.kind != jsonScalar:
+		row.node.expanded = !row.node.expanded
+	default:
+		return
+	}
+
+	t.refreshRows()
+}
+
+// setExpanded expands (or collapses) all nodes; the root always stays
+// expanded.
+func (t *jsonTree) setExpanded(expanded bool) {
+	var walk func(node *jsonNode)
+	walk = func(node *jsonNode) {
+		if node.kind == jsonScalar {
+			return
+		}
+		node.expanded = expanded
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	walk(t.root)
+	t.root.expanded = true
+	if !expanded {
+		t.cursor = 0
+	}
+
+	t.refreshRows()
+}
+
+// selectedPath is the path of the node under the cursor (or of the parent,
+// for a summary row).
+func (t *jsonTree) selectedPath() string {
+	row := t.rows[t.cursor]
+	if row.node == nil {
+		return row.parent.path
+	}
+
+	return row.node.path
+}
+
+// selectedValue is the value of the node under the cursor (or of the parent,
+// for a summary row); strings are unquoted, and other values are JSON.
+func (t *jsonTree) selectedValue() string {
+	row := t.rows[t.cursor]
+	node := row.node
+	if node == nil {
+		node = row.parent
+	}
+
+	var value string
+	if strings.HasPrefix(node.raw, `"`) && json.Unmarshal([]byte(node.raw), &value) == nil {
+		return value
+	}
+
+	return node.raw
+}
+
+func (t *jsonTree) render() string {
+	lines := make([]string, len(t.rows))
+	for i, row := range t.rows {
+		// the cursor is shown in a gutter, since the rows are already styled
+		gutter := "  "
+		if i == t.cursor {
+			gutter = treeCursorStyle.Render("›") + " "
+		}
+		lines[i] = gutter + strings.Repeat(treeIndent, row.depth) + renderTreeRow(row)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func renderTreeRow(row jsonTreeRow) string {
+	if row.node == nil {
+		hidden := len(row.parent.children) - row.parent.shown
+		return treeSummaryStyle.Render(fmt.Sprintf("… %d more %s (press enter to show more)", hidden, childrenNoun(row.parent, hidden)))
+	}
+
+	node := row.node
+	var label string
+	if row.parent != nil {
+		if row.parent.kind == jsonArray {
+			label = treeIndexStyle.Render(node.key) + ": "
+		} else {
+			label = treeKeyStyle.Render(node.key) + ": "
+		}
+	}
+
+	switch node.kind {
+	case jsonObject, jsonArray:
+		marker := "▾ "
+		if !node.expanded {
+			marker = "▸ "
+		}
+		openDelim, closeDelim := "{", "}"
+		if node.kind == jsonArray {
+			openDelim, closeDelim = "[", "]"
+		}
+		summary := treeSummaryStyle.Render(fmt.Sprintf("%s %d %s %s", openDelim, len(node.children), childrenNoun(node, len(node.children)), closeDelim))
+		return marker + label + summary
+	default:
+		return treeIndent + label + treeScalarStyle(node.value).Render(node.value)
+	}
+}
+
+// treeScalarStyle colours scalars the way pretty.Color does.
+func treeScalarStyle(value string) lipgloss.Style {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		return treeStringStyle
+	case value == "true" || value == "false" || value == "null":
+		return treeLiteralStyle
+	default:
+		return treeNumberStyle
+	}
+}
+
+func childrenNoun(node *jsonNode, count int) string {
+	noun := "item"
+	if node.kind == jsonObject {
+		noun = "key"
+	}
+	if count != 1 {
+		noun += "s"
+	}
+
+	return noun
+}
+
+// showMsgTree renders the tree in the message value viewport, and keeps the
+// cursor in view.
+func (m *Model) showMsgTree() {
+	m.updateMsgValueContent(m.msgTree.render())
+
+	if m.msgTree.cursor < m.msgValueVP.YOffset {
+		m.msgValueVP.SetYOffset(m.msgTree.cursor)
+	} else if m.msgTree.cursor >= m.msgValueVP.YOffset+m.msgValueVP.Height {
+		m.msgValueVP.SetYOffset(m.msgTree.cursor - m.msgValueVP.Height + 1)
+	}
+}
+
+// isTreeKey reports whether a key is handled by the tree view, rather than by
+// the message value viewport.
+func (m *Model) isTreeKey(key string) bool {
+	if m.activeView != msgValueView || m.msgTree == nil {
+		return false
+	}
+
+	switch key {
+	case "j", "down", "k", "up", "enter", "+", "-", "y", "v":
+		return true
+	}
+
+	return false
+}
+
+func (m *Model) handleTreeKeys(key string) tea.Cmd {
+	switch key {
+	case "j", "down":
+		m.msgTree.moveCursor(1)
+	case "k", "up":
+		m.msgTree.moveCursor(-1)
+	case "enter":
+		m.msgTree.toggle()
+	case "+":
+		m.msgTree.setExpanded(true)
+	case "-":
+		m.msgTree.setExpanded(false)
+	case "y":
+		return copyToClipboard(m.msgTree.selectedPath(), "the path")
+	case "v":
+		return copyToClipboard(m.msgTree.selectedValue(), fmt.Sprintf("the value at %s", m.msgTree.selectedPath()))
+	}
+
+	m.showMsgTree()
+	return nil
+}
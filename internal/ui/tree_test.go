@@ -0,0 +1,171 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treeRowPaths returns the paths of a tree's visible rows; summary rows are
+// shown as "…" after their parent's path.
+func treeRowPaths(tree *jsonTree) []string {
+	paths := make([]string, len(tree.rows))
+	for i, row := range tree.rows {
+		if row.node == nil {
+			paths[i] = row.parent.path + "…"
+			continue
+		}
+		paths[i] = row.node.path
+	}
+
+	return paths
+}
+
+// jsonArrayOf returns a JSON array of the numbers 0 to n-1.
+func jsonArrayOf(n int) string {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("%d", i)
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func TestNewJSONTree(t *testing.T) {
+	largeRootPaths := []string{"$"}
+	for i := range treeAutoExpandMaxChildren + 1 {
+		largeRootPaths = append(largeRootPaths, fmt.Sprintf("$[%d]", i))
+	}
+
+	testCases := []struct {
+		name     string
+		body     string
+		expected []string
+		err      error
+	}{
+		// success
+		{
+			name:     "scalar",
+			body:     `"value"`,
+			expected: []string{"$"},
+		},
+		{
+			name:     "nested values",
+			body:     `{"a": 1, "b": {"c": [true, null]}}`,
+			expected: []string{"$", "$.a", "$.b", "$.b.c", "$.b.c[0]", "$.b.c[1]"},
+		},
+		{
+			name:     "object keys keep their order",
+			body:     `{"z": 1, "a": 2}`,
+			expected: []string{"$", "$.z", "$.a"},
+		},
+		{
+			name:     "keys that aren't identifiers are quoted",
+			body:     `{"a key": {"b-c": 1}}`,
+			expected: []string{"$", `$["a key"]`, `$["a key"]["b-c"]`},
+		},
+		{
+			name:     "large values start collapsed",
+			body:     fmt.Sprintf(`{"items": %s, "ok": true}`, jsonArrayOf(treeAutoExpandMaxChildren+1)),
+			expected: []string{"$", "$.items", "$.ok"},
+		},
+		{
+			name:     "large roots are expanded",
+			body:     jsonArrayOf(treeAutoExpandMaxChildren + 1),
+			expected: largeRootPaths,
+		},
+		// failures
+		{name: "incorrect JSON", body: `{"a": }`, err: errCouldntParseTree},
+		{name: "unclosed object", body: `{"a": 1`, err: errCouldntParseTree},
+		{name: "empty body", body: "", err: errCouldntParseTree},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newJSONTree(tt.body)
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "got error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, treeRowPaths(got))
+		})
+	}
+}
+
+func TestJSONTreeSummarisesLargeArrays(t *testing.T) {
+	testCases := []struct {
+		name         string
+		size         int
+		showMore     int
+		expectedRows int
+		summary      bool
+	}{
+		{name: "small arrays aren't summarised", size: treeChildrenPageSize, expectedRows: treeChildrenPageSize + 1},
+		{name: "large arrays show a page of items", size: 250, expectedRows: treeChildrenPageSize + 2, summary: true},
+		{name: "more items can be shown", size: 250, showMore: 1, expectedRows: 2*treeChildrenPageSize + 2, summary: true},
+		{name: "all items can be shown", size: 250, showMore: 2, expectedRows: 251},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := newJSONTree(jsonArrayOf(tt.size))
+			require.NoError(t, err)
+
+			for range tt.showMore {
+				tree.cursor = len(tree.rows) - 1
+				tree.toggle()
+			}
+
+			require.Len(t, tree.rows, tt.expectedRows)
+			last := tree.rows[len(tree.rows)-1]
+			assert.Equal(t, tt.summary, last.node == nil)
+			if tt.summary {
+				hidden := tt.size - (tt.showMore+1)*treeChildrenPageSize
+				assert.Contains(t, renderTreeRow(last), fmt.Sprintf("%d more items", hidden))
+			}
+		})
+	}
+}
+
+func TestJSONTreeSelectedPath(t *testing.T) {
+	body := fmt.Sprintf(`{"a": {"b": "text"}, "n": 1.50, "list": %s}`, jsonArrayOf(treeChildrenPageSize+1))
+
+	testCases := []struct {
+		name          string
+		cursor        int
+		expandList    bool
+		expectedPath  string
+		expectedValue string
+	}{
+		{name: "root", cursor: 0, expectedPath: "$", expectedValue: body},
+		{name: "object", cursor: 1, expectedPath: "$.a", expectedValue: `{"b": "text"}`},
+		{name: "strings are unquoted", cursor: 2, expectedPath: "$.a.b", expectedValue: "text"},
+		{name: "numbers keep their formatting", cursor: 3, expectedPath: "$.n", expectedValue: "1.50"},
+		{name: "collapsed array", cursor: 4, expectedPath: "$.list", expectedValue: jsonArrayOf(treeChildrenPageSize + 1)},
+		{name: "array item", cursor: 6, expandList: true, expectedPath: "$.list[1]", expectedValue: "1"},
+		{name: "summary row", cursor: treeChildrenPageSize + 5, expandList: true, expectedPath: "$.list", expectedValue: jsonArrayOf(treeChildrenPageSize + 1)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := newJSONTree(body)
+			require.NoError(t, err)
+			if tt.expandList {
+				tree.cursor = 4
+				tree.toggle()
+			}
+
+			tree.cursor = tt.cursor
+
+			assert.Equal(t, tt.expectedPath, tree.selectedPath())
+			assert.Equal(t, tt.expectedValue, tree.selectedValue())
+		})
+	}
+}
@@ -3,21 +3,52 @@ package ui
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	tea "github.com/charmbracelet/bubbletea"
 	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
 )
 
 var errFailedToConfigureDebugging = errors.New("failed to configure debugging")
 
+// RenderUI starts the TUI with all the provided profiles available in the
+// profile picker. If initialProfile is provided, it's opened in a tab right
+// away; its SQS client needs to be present in sqsClients.
 func RenderUI(
+	profiles []t.Config,
+	sqsClients map[string]*sqs.Client,
+	initialProfile *t.Config,
+	behaviourFlags t.TUIBehaviourFlags,
+) error {
+	m := InitialModel(profiles, sqsClients, behaviourFlags)
+	if initialProfile != nil {
+		// the message count for the tab is fetched in Init
+		_ = m.openTab(m.sqsClients[initialProfile.AWSConfigSource.String()], *initialProfile)
+	}
+
+	return run(m)
+}
+
+func RenderQueueBrowser(
 	sqsClient *sqs.Client,
-	queueURL string,
-	config t.Config,
-	behaviours t.TUIBehaviours,
+	browserConfig t.QueueBrowserConfig,
+	behaviourFlags t.TUIBehaviourFlags,
 ) error {
+	return run(InitialQueueBrowserModel(sqsClient, browserConfig, behaviourFlags))
+}
+
+// RenderOffline starts the TUI for messages loaded from disk; no AWS
+// connection is needed.
+func RenderOffline(config t.Config, messages []sqstypes.Message) error {
+	return run(InitialOfflineModel(config, messages))
+}
+
+func run(m Model) error {
 	if len(os.Getenv("DEBUG")) > 0 {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
@@ -25,7 +56,34 @@ func RenderUI(
 		}
 		defer f.Close()
 	}
-	p := tea.NewProgram(InitialModel(sqsClient, queueURL, config, behaviours), tea.WithAltScreen())
-	_, err := p.Run()
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	final, err := p.Run()
+	if final, ok := final.(Model); ok {
+		tabs := slices.AppendSeq(slices.Clone(final.tabs), maps.Values(final.closedTabs))
+		for _, tab := range tabs {
+			stopPurge(tab)
+			tab.closePersister()
+		}
+	}
+
 	return err
 }
+
+// stopPurge cancels a purge still running for a tab when the TUI quits, and
+// waits for it to stop, so that its backup is closed properly; messages that
+// were backed up by then have been deleted from the queue.
+func stopPurge(tab *queueTab) {
+	if tab.purge == nil {
+		return
+	}
+
+	tab.purge.stop()
+	result := tab.purge.result
+	if result.BackupPath != "" {
+		fmt.Fprintf(os.Stderr, "purging %q was cancelled; %d messages were backed up to %s and deleted from the queue\n",
+			utils.QueueNameFromURL(tab.queueURL),
+			result.BackedUp,
+			result.BackupPath,
+		)
+	}
+}
@@ -1,20 +1,32 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/cueitup/internal/queue"
 	t "github.com/dhth/cueitup/internal/types"
+	"github.com/dhth/cueitup/internal/utils"
 	"github.com/tidwall/pretty"
 )
 
 const (
 	useHighPerformanceRenderer = false
 	fetchingIndicator          = " ..."
+	errNotAvailableOffline     = "not available when viewing messages offline"
 )
 
+// offlineUnavailableKeys are the keymaps that need a queue, and are thus not
+// available in offline tabs.
+var offlineUnavailableKeys = []string{"n", " ", "N", "}", "F", "d", "p", "s", "M", "ctrl+r", "X", "R", "P", "ctrl+w"}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	m.message = ""
@@ -22,102 +34,54 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			switch m.activeView {
-			case msgsListView:
-				return m, tea.Quit
-			case msgValueView:
-				m.activeView = msgsListView
-			case helpView:
-				m.activeView = m.lastView
-			}
-		case "n", " ":
-			m.message = fetchingIndicator
-			cmds = append(cmds, m.FetchMessages(1, 0))
-		case "N":
-			m.message = fetchingIndicator
-			for range 10 {
-				cmds = append(cmds,
-					m.FetchMessages(1, 0),
-				)
-			}
-		case "}":
-			m.message = fetchingIndicator
-			for range 20 {
-				cmds = append(cmds,
-					m.FetchMessages(5, 0),
-				)
-			}
-		case "?":
-			m.lastView = m.activeView
-			m.activeView = helpView
-		case "d":
-			if m.activeView == msgsListView {
-				m.behaviours.DeleteMessages = !m.behaviours.DeleteMessages
-			}
-		case "p":
-			if m.activeView == msgsListView {
-				m.behaviours.PersistMessages = !m.behaviours.PersistMessages
-			}
-		case "s":
-			if m.activeView == msgsListView {
-				m.behaviours.SkipMessages = !m.behaviours.SkipMessages
-			}
-		case "[", "h":
-			if m.activeView == msgValueView {
-				m.msgsList.CursorUp()
-			}
-		case "]", "l":
-			if m.activeView == msgValueView {
-				m.msgsList.CursorDown()
-			}
-		case "M":
-			m.behaviours.ShowMessageCount = !m.behaviours.ShowMessageCount
-			if m.behaviours.ShowMessageCount {
-				cmds = append(cmds,
-					tea.Batch(GetQueueMsgCount(m.sqsClient, m.queueURL),
-						tickEvery(msgCountTickInterval),
-					),
-				)
-			} else {
-				m.msgsList.Title = "Messages"
-			}
-		case "ctrl+r":
-			if m.activeView == msgsListView {
-				m.msgsList.SetItems(make([]list.Item, 0))
-				m.msgValueVP.SetContent("")
-				m.firstFetch = true
-			}
-		case "tab":
-			switch m.activeView {
-			case msgsListView:
-				m.activeView = msgValueView
-			case msgValueView:
-				m.activeView = msgsListView
-			}
-		case "shift+tab":
-			switch m.activeView {
-			case msgsListView:
-				m.activeView = msgsListView
-			case msgValueView:
-				m.activeView = msgsListView
-			}
+		switch m.activeView {
+		case queueBrowserView:
+			cmds = append(cmds, m.handleQueueBrowserKeys(msg))
+		case profilePickerView:
+			cmds = append(cmds, m.handleProfilePickerKeys(msg))
+		case purgeConfirmView:
+			cmds = append(cmds, m.handlePurgeConfirmKeys(msg))
+		case replayFilesView:
+			cmds = append(cmds, m.handleReplayFilesKeys(msg))
+		case replayTargetView:
+			cmds = append(cmds, m.handleReplayTargetKeys(msg))
+		case msgsFilterView:
+			cmds = append(cmds, m.handleFilterKeys(msg))
+		case fetchPredicateView:
+			cmds = append(cmds, m.handlePredicateKeys(msg))
+		case msgValueSearchView:
+			cmds = append(cmds, m.handleSearchKeys(msg))
+		case msgDiffView:
+			cmds = append(cmds, m.handleDiffKeys(msg))
+		case eventLogView:
+			cmds = append(cmds, m.handleEventLogKeys(msg))
+		default:
+			cmds = append(cmds, m.handleQueueKeys(msg))
 		}
 
 	case tea.WindowSizeMsg:
-		w, h := msgListStyle.GetFrameSize()
+		w, _ := msgListStyle.GetFrameSize()
 		w2, _ := msgValueVPStyle.GetFrameSize()
 		m.terminalHeight = msg.Height
 		m.terminalWidth = msg.Width - 1
-		m.msgsList.SetHeight(msg.Height - h - 2)
+		for _, tab := range m.tabs {
+			tab.msgsList.SetHeight(m.msgsListHeight())
+		}
 
 		if !m.msgValueVPReady {
-			m.msgValueVP = viewport.New(msg.Width-2-w-w2-listWidth, m.terminalHeight-12)
+			m.msgValueVP = viewport.New(msg.Width-2-w-w2-listWidth, m.terminalHeight-12-tabBarHeight)
 			m.msgValueVPReady = true
 		} else {
 			m.msgValueVP.Width = msg.Width - 2 - w - w2 - listWidth
-			m.msgValueVP.Height = msg.Height - 12
+			m.msgValueVP.Height = msg.Height - 12 - tabBarHeight
+		}
+
+		sw, sh := selectionListStyle.GetFrameSize()
+		m.profilesList.SetSize(msg.Width-sw, msg.Height-sh-2)
+		m.replayFilesList.SetSize(msg.Width-sw, msg.Height-sh-2-tabBarHeight)
+		m.replayTargetsList.SetSize(msg.Width-sw, msg.Height-sh-2-tabBarHeight)
+		if m.browserConfig != nil {
+			m.queuesList.SetSize(msg.Width-sw, msg.Height-sh-2)
 		}
 
 		if !m.helpVPReady {
@@ -129,89 +93,702 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.helpVP.Height = msg.Height - 7
 		}
 
+		dw, _ := diffVPStyle.GetFrameSize()
+		if !m.diffVPReady {
+			m.diffVP = viewport.New(msg.Width-1-dw, msg.Height-7-tabBarHeight)
+			m.diffVPReady = true
+		} else {
+			m.diffVP.Width = msg.Width - 1 - dw
+			m.diffVP.Height = msg.Height - 7 - tabBarHeight
+		}
+		if m.msgDiff != nil {
+			m.diffVP.SetContent(renderMessageDiff(*m.msgDiff, m.diffVP.Width))
+		}
+
+		ew, _ := eventsVPStyle.GetFrameSize()
+		if !m.eventsVPReady {
+			m.eventsVP = viewport.New(msg.Width-1-ew, msg.Height-7)
+			m.eventsVPReady = true
+		} else {
+			m.eventsVP.Width = msg.Width - 1 - ew
+			m.eventsVP.Height = msg.Height - 7
+		}
+		m.eventsVP.SetContent(renderEvents(m.events, m.eventsVP.Width))
+
 	case HideHelpMsg:
 		m.showHelpIndicator = false
 
+	case CopiedToClipboardMsg:
+		switch {
+		case msg.err != nil:
+			m.errorMsg = msg.err.Error()
+		case msg.viaOSC52:
+			m.message = fmt.Sprintf("copied %s to the clipboard (via the terminal)", msg.what)
+		default:
+			m.message = fmt.Sprintf("copied %s to the clipboard", msg.what)
+		}
+
 	case SQSMsgsFetchedMsg:
+		tab := m.tabByID(msg.tabID)
+		if tab == nil {
+			break
+		}
+
 		if msg.err != nil {
 			m.errorMsg = msg.err.Error()
 		} else {
-			if !m.behaviours.SkipMessages {
-				for _, message := range msg.messages {
-					m.msgsList.InsertItem(len(m.msgsList.Items()), message)
-
-					if m.behaviours.PersistMessages {
-						cmds = append(cmds,
-							saveMessageToDisk(
-								message.ID,
-								message.Body,
-								m.config.Format,
-								m.persistDir,
-							),
-						)
-					}
-				}
-
-				if m.behaviours.DeleteMessages {
-					cmds = append(cmds,
-						DeleteMessages(m.sqsClient,
-							m.queueURL,
-							msg.sqsMessages),
-					)
-				}
-			}
+			cmds = append(cmds, m.handleFetchedMessages(tab, msg.messages, msg.sqsMessages)...)
 		}
-	case MsgCountTickMsg:
-		cmds = append(cmds, GetQueueMsgCount(m.sqsClient, m.queueURL))
-		if m.behaviours.ShowMessageCount {
-			cmds = append(cmds, tickEvery(msgCountTickInterval))
+	case MatchingMsgsFetchedMsg:
+		tab := m.tabByID(msg.tabID)
+		if tab == nil {
+			break
 		}
-	case QueueMsgCountFetchedMsg:
-		if !m.behaviours.ShowMessageCount {
+
+		tab.fetchingMatches = false
+		tab.refusedPredicate = ""
+		// messages that matched before an error are still handled
+		cmds = append(cmds, m.handleFetchedMessages(tab, msg.messages, msg.sqsMessages)...)
+		if errors.Is(msg.err, queue.ErrScanCouldDeadLetter) {
+			tab.refusedPredicate = tab.predicate
+			m.errorMsg = fmt.Sprintf("%s; submit the same predicate again to scan it anyway", msg.err.Error())
 			break
 		}
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't fetch matching messages: %s", msg.err.Error())
+			break
+		}
+
+		m.message = fmt.Sprintf("found %d matching messages (scanned %d)", len(msg.messages), msg.scanned)
+		if msg.timedOut {
+			m.message += "; stopped at the time limit"
+		}
+	case SQSMsgsDeletedMsg:
+		m.handleSQSMsgsDeleted(msg)
+	case RecordSavedToDiskMsg:
+		if cmd := m.handleRecordSavedToDisk(msg); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case QueuesListedMsg:
 		if msg.err != nil {
 			m.errorMsg = msg.err.Error()
+			break
+		}
+
+		slices.Sort(msg.queueURLs)
+		items := make([]list.Item, len(msg.queueURLs))
+		for i, queueURL := range msg.queueURLs {
+			items[i] = queueItem{
+				url:  queueURL,
+				name: utils.QueueNameFromURL(queueURL),
+			}
+		}
+		cmds = append(cmds, m.queuesList.SetItems(items))
+
+		// counts are fetched a few at a time; every count fetched starts
+		// fetching the next one
+		m.pendingCounts = slices.Clone(msg.queueURLs)
+		for range maxConcurrentQueueCounts {
+			if cmd := m.fetchNextQueueCount(); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+	case ProfileSavedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't save profile: %s", msg.err.Error())
 		} else {
-			m.msgsList.Title = fmt.Sprintf("Messages (%d in queue)", msg.approxMsgCount)
+			m.message = fmt.Sprintf("saved profile %q to %s", msg.name, m.browserConfig.ConfigPath)
+		}
+	case PurgeProgressMsg:
+		cmds = append(cmds, waitForPurgeUpdate(msg.updates))
+		tab := m.tabByID(msg.tabID)
+		if tab == nil {
+			break
+		}
+
+		m.message = fmt.Sprintf("purging queue %q: backed up and deleted %d messages (press X to cancel)", utils.QueueNameFromURL(tab.queueURL), msg.backedUp) + fetchingIndicator
+	case QueuePurgedMsg:
+		m.handleQueuePurged(msg)
+	case PersistedMessagesListedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't list persisted messages: %s", msg.err.Error())
+			break
+		}
+
+		if len(msg.messages) == 0 {
+			m.message = fmt.Sprintf("no persisted messages found in %s", msg.dir)
+			break
+		}
+
+		// the tab might've been switched while the messages were being listed
+		if tab := m.tab(); tab == nil || tab.id != msg.tabID || m.activeView != msgsListView {
+			break
+		}
+
+		m.showReplayFiles(msg.dir, msg.messages)
+	case ReplayMessageEditedMsg:
+		m.handleReplayMessageEdited(msg)
+	case PagerClosedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't open message in pager: %s", msg.err.Error())
+		}
+	case MessageEditedMsg:
+		m.handleMessageEdited(msg)
+	case MessagesReplayedMsg:
+		if msg.client != nil {
+			m.sqsClients[msg.config.AWSConfigSource.String()] = msg.client
+		}
+
+		queueName := utils.QueueNameFromURL(msg.config.QueueURL)
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't replay messages to %q (%d were sent): %s", queueName, msg.sent, msg.err.Error())
+			break
+		}
+
+		m.message = fmt.Sprintf("replayed %d messages to %q", msg.sent, queueName)
+	case SQSClientLoadedMsg:
+		if msg.err != nil {
+			m.errorMsg = fmt.Sprintf("couldn't load AWS config for profile %q: %s", msg.config.ProfileName, msg.err.Error())
+			break
+		}
+
+		m.sqsClients[msg.config.AWSConfigSource.String()] = msg.client
+		cmds = append(cmds, m.openTab(msg.client, msg.config))
+	case MsgCountTickMsg:
+		tab := m.tabByID(msg.tabID)
+		if tab == nil {
+			break
+		}
+		cmds = append(cmds, GetQueueMsgCount(tab.sqsClient, tab.queueURL))
+		if tab.behaviours.ShowMessageCount {
+			cmds = append(cmds, tickEvery(tab.id, msgCountTickInterval))
+		}
+	case QueueMsgCountFetchedMsg:
+		if m.browserConfig != nil {
+			m.updateQueueItem(msg)
+			if msg.listed {
+				if cmd := m.fetchNextQueueCount(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			}
+		}
+
+		for _, tab := range m.tabs {
+			if tab.queueURL != msg.queueURL || !tab.behaviours.ShowMessageCount {
+				continue
+			}
+
+			if msg.err != nil {
+				if tab == m.tab() {
+					m.errorMsg = msg.err.Error()
+				}
+				continue
+			}
+
+			tab.depthHistory.Add(msg.sample)
+			tab.refreshTitle()
 		}
 	}
 
 	var updateCmd tea.Cmd
 	switch m.activeView {
 	case msgsListView:
-		m.msgsList, updateCmd = m.msgsList.Update(msg)
-		cmds = append(cmds, updateCmd)
+		if tab := m.tab(); tab != nil {
+			tab.msgsList, updateCmd = tab.msgsList.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
 	case msgValueView:
-		m.msgValueVP, updateCmd = m.msgValueVP.Update(msg)
-		cmds = append(cmds, updateCmd)
+		// keys that move the tree's cursor don't scroll the viewport
+		if keyMsg, ok := msg.(tea.KeyMsg); !ok || !m.isTreeKey(keyMsg.String()) {
+			m.msgValueVP, updateCmd = m.msgValueVP.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
 	case helpView:
 		m.helpVP, updateCmd = m.helpVP.Update(msg)
 		cmds = append(cmds, updateCmd)
+	case msgDiffView:
+		m.diffVP, updateCmd = m.diffVP.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case eventLogView:
+		m.eventsVP, updateCmd = m.eventsVP.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case queueBrowserView:
+		m.queuesList, updateCmd = m.queuesList.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case profilePickerView:
+		m.profilesList, updateCmd = m.profilesList.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case replayFilesView:
+		m.replayFilesList, updateCmd = m.replayFilesList.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case replayTargetView:
+		m.replayTargetsList, updateCmd = m.replayTargetsList.Update(msg)
+		cmds = append(cmds, updateCmd)
+	case purgeConfirmView:
+		// key presses are forwarded by handlePurgeConfirmKeys, so that the one
+		// that opened this view doesn't end up in the input
+		if _, ok := msg.(tea.KeyMsg); !ok {
+			m.purgeInput, updateCmd = m.purgeInput.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
+	case msgsFilterView:
+		// key presses are forwarded by handleFilterKeys
+		if _, ok := msg.(tea.KeyMsg); !ok {
+			m.filterInput, updateCmd = m.filterInput.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
+	case fetchPredicateView:
+		// key presses are forwarded by handlePredicateKeys
+		if _, ok := msg.(tea.KeyMsg); !ok {
+			m.predicateInput, updateCmd = m.predicateInput.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
+	case msgValueSearchView:
+		// key presses are forwarded by handleSearchKeys
+		if _, ok := msg.(tea.KeyMsg); !ok {
+			m.searchInput, updateCmd = m.searchInput.Update(msg)
+			cmds = append(cmds, updateCmd)
+		}
+	}
+
+	tab := m.tab()
+	if tab != nil && (m.activeView == msgsListView || m.activeView == msgValueView || m.activeView == msgValueSearchView) {
+		// msgListCurrentIndex is reset when a filter is applied, since the
+		// index stays the same when the list's items are replaced
+		if len(tab.msgsList.VisibleItems()) > 0 && tab.msgsList.GlobalIndex() != tab.msgListCurrentIndex {
+			tab.msgListCurrentIndex = tab.msgsList.GlobalIndex()
+			m.showSelectedMessage(tab)
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// showSelectedMessage shows the selected message in the message value
+// viewport; JSON messages are shown as a tree when the tree view is on.
+func (m *Model) showSelectedMessage(tab *queueTab) {
+	message, ok := tab.msgsList.SelectedItem().(t.Message)
+	if !ok {
+		return
+	}
+
+	if message.Err != nil {
+		m.setMsgValueContent(errorStyle.Render(fmt.Sprintf("error: %s", message.Err.Error())))
+		return
+	}
+
+	body := message.ViewBody(tab.bodyView)
+	if tab.config.Format != t.JSON {
+		m.setMsgValueContent(body)
+		return
 	}
 
-	if m.activeView == msgsListView || m.activeView == msgValueView {
-		if len(m.msgsList.Items()) > 0 && m.msgsList.Index() != m.msgListCurrentIndex {
-			m.msgListCurrentIndex = m.msgsList.Index()
-			message, ok := m.msgsList.SelectedItem().(t.Message)
-
-			if ok {
-				var vpContent string
-				if message.Err != nil {
-					vpContent = errorStyle.Render(fmt.Sprintf("error: %s", message.Err.Error()))
-				} else {
-					switch m.config.Format {
-					case t.JSON:
-						vpContent = string(pretty.Color([]byte(message.Body), nil))
-					default:
-						vpContent = message.Body
-					}
+	if m.treeView {
+		tree, err := newJSONTree(body)
+		if err == nil {
+			m.setMsgValueContent(tree.render())
+			m.msgTree = tree
+			return
+		}
+		m.errorMsg = err.Error()
+	}
+
+	m.setMsgValueContent(string(pretty.Color([]byte(body), nil)))
+}
+
+func (m *Model) handleQueueKeys(msg tea.KeyMsg) tea.Cmd {
+	var cmds []tea.Cmd
+
+	tab := m.tab()
+	if tab == nil {
+		switch msg.String() {
+		case "ctrl+c", "q":
+			if m.activeView == helpView {
+				m.activeView = m.lastView
+				return nil
+			}
+			return tea.Quit
+		}
+		return nil
+	}
+
+	if m.isTreeKey(msg.String()) {
+		return m.handleTreeKeys(msg.String())
+	}
+
+	if tab.offline && slices.Contains(offlineUnavailableKeys, msg.String()) && !m.isSearchNavigationKey(msg.String()) {
+		m.errorMsg = errNotAvailableOffline
+		return nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		switch m.activeView {
+		case msgsListView:
+			if m.browserConfig != nil && msg.String() == "q" {
+				m.activeView = queueBrowserView
+				break
+			}
+			return tea.Quit
+		case msgValueView:
+			m.activeView = msgsListView
+		case helpView:
+			m.activeView = m.lastView
+		}
+	case "n", " ":
+		if m.isSearchNavigationKey(msg.String()) {
+			m.cycleSearchMatch(1)
+			break
+		}
+		m.message = fetchingIndicator
+		cmds = append(cmds, FetchMessages(tab.sqsClient, tab.config, tab.id, 1, 0))
+	case "N":
+		if m.isSearchNavigationKey(msg.String()) {
+			m.cycleSearchMatch(-1)
+			break
+		}
+		m.message = fetchingIndicator
+		for range 10 {
+			cmds = append(cmds,
+				FetchMessages(tab.sqsClient, tab.config, tab.id, 1, 0),
+			)
+		}
+	case "}":
+		m.message = fetchingIndicator
+		for range 20 {
+			cmds = append(cmds,
+				FetchMessages(tab.sqsClient, tab.config, tab.id, 5, 0),
+			)
+		}
+	case "F":
+		if m.activeView == msgsListView {
+			if tab.fetchingMatches {
+				m.errorMsg = "already fetching matching messages"
+				break
+			}
+			cmds = append(cmds, m.showPredicateInput(tab))
+		}
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	case "d":
+		if m.activeView == msgsListView {
+			if err := tab.config.EnsureWritable(); err != nil {
+				m.errorMsg = err.Error()
+				break
+			}
+			tab.behaviours.DeleteMessages = !tab.behaviours.DeleteMessages
+		}
+	case "p":
+		if m.activeView == msgsListView {
+			if !tab.behaviours.PersistMessages {
+				if _, err := tab.getPersister(); err != nil {
+					m.errorMsg = err.Error()
+					break
 				}
-				m.msgValueVP.SetContent(vpContent)
 			}
+			tab.behaviours.PersistMessages = !tab.behaviours.PersistMessages
+		}
+	case "s":
+		if m.activeView == msgsListView {
+			tab.behaviours.SkipMessages = !tab.behaviours.SkipMessages
+		}
+	case "[", "h":
+		if m.activeView == msgValueView {
+			tab.msgsList.CursorUp()
+		}
+	case "]", "l":
+		if m.activeView == msgValueView {
+			tab.msgsList.CursorDown()
+		}
+	case "M":
+		tab.behaviours.ShowMessageCount = !tab.behaviours.ShowMessageCount
+		if tab.behaviours.ShowMessageCount {
+			// samples from before polling was paused would skew the rates
+			tab.depthHistory.Reset()
+			cmds = append(cmds,
+				tea.Batch(GetQueueMsgCount(tab.sqsClient, tab.queueURL),
+					tickEvery(tab.id, msgCountTickInterval),
+				),
+			)
+		} else {
+			tab.refreshTitle()
+		}
+	case "ctrl+r":
+		if m.activeView == msgsListView {
+			tab.clearMessages()
+			m.setMsgValueContent("")
+			tab.firstFetch = true
+		}
+	case "tab":
+		switch m.activeView {
+		case msgsListView:
+			m.activeView = msgValueView
+		case msgValueView:
+			m.activeView = msgsListView
+		}
+	case "shift+tab":
+		switch m.activeView {
+		case msgsListView:
+			m.activeView = msgsListView
+		case msgValueView:
+			m.activeView = msgsListView
+		}
+	case ">":
+		if m.activeView != helpView {
+			m.cycleTab(1)
+		}
+	case "<":
+		if m.activeView != helpView {
+			m.cycleTab(-1)
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if m.activeView != helpView {
+			m.switchTab(int(msg.String()[0] - '1'))
+		}
+	case "ctrl+w":
+		if m.activeView != helpView {
+			m.closeTab()
+		}
+	case "P":
+		if m.activeView != helpView {
+			m.showQueuePicker()
+		}
+	case "X":
+		if m.activeView == msgsListView {
+			if tab.purge != nil {
+				tab.purge.cancel()
+				m.message = "cancelling purge" + fetchingIndicator
+				break
+			}
+			if err := tab.config.EnsureWritable(); err != nil {
+				m.errorMsg = err.Error()
+				break
+			}
+			m.purgeInput.Reset()
+			m.purgeBackup = false
+			m.activeView = purgeConfirmView
+			cmds = append(cmds, m.purgeInput.Focus())
+		}
+	case "R":
+		if m.activeView == msgsListView {
+			cmds = append(cmds, listPersistedMessages(tab.id, tab.persistDir))
+		}
+	case "o", "e":
+		if m.activeView == msgsListView || m.activeView == msgValueView {
+			cmds = append(cmds, m.openSelectedMessage(tab, msg.String() == "e"))
+		}
+	case "c":
+		if m.activeView == msgsListView || m.activeView == msgValueView {
+			m.compareSelectedMessage(tab)
+		}
+	case "y", "Y", "I", "H":
+		if m.activeView == msgsListView || m.activeView == msgValueView {
+			cmds = append(cmds, m.copySelectedMessage(tab, msg.String()))
+		}
+	case "b":
+		if m.activeView == msgsListView || m.activeView == msgValueView {
+			m.cycleBodyView(tab)
+		}
+	case "L":
+		if m.activeView == msgsListView || m.activeView == msgValueView {
+			m.showEventLog()
+		}
+	case "t":
+		if m.activeView != msgsListView && m.activeView != msgValueView {
+			break
+		}
+		if tab.config.Format != t.JSON {
+			m.errorMsg = errTreeNotJSON.Error()
+			break
+		}
+		m.treeView = !m.treeView
+		m.showSelectedMessage(tab)
+	case "/":
+		switch m.activeView {
+		case msgsListView:
+			cmds = append(cmds, m.showFilterInput(tab))
+		case msgValueView:
+			cmds = append(cmds, m.showSearchInput())
+		}
+	case "esc":
+		switch {
+		case m.activeView == msgsListView && tab.filter != nil:
+			m.setFilter(tab, nil)
+		case m.activeView == msgValueView && m.searchQuery != "":
+			m.search("")
+		}
+	}
+
+	return tea.Batch(cmds...)
+}
 
+func (m *Model) handleProfilePickerKeys(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	if m.profilesList.FilterState() == list.Filtering {
+		return nil
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		if msg.String() == "esc" && m.profilesList.FilterState() == list.FilterApplied {
+			return nil
+		}
+		if len(m.tabs) == 0 {
+			return tea.Quit
 		}
+		m.activeView = msgsListView
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	case "enter":
+		item, ok := m.profilesList.SelectedItem().(profileItem)
+		if !ok {
+			return nil
+		}
+
+		client, ok := m.sqsClients[item.config.AWSConfigSource.String()]
+		if !ok {
+			m.message = fetchingIndicator
+			return loadSQSClient(item.config)
+		}
+
+		return m.openTab(client, item.config)
 	}
 
-	return m, tea.Batch(cmds...)
+	return nil
+}
+
+func (m *Model) handlePurgeConfirmKeys(msg tea.KeyMsg) tea.Cmd {
+	tab := m.tab()
+	if tab == nil {
+		m.activeView = msgsListView
+		return nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.purgeInput.Blur()
+		m.activeView = msgsListView
+	case "ctrl+b":
+		m.purgeBackup = !m.purgeBackup
+	case "enter":
+		confirmation := strings.TrimSpace(m.purgeInput.Value())
+		if confirmation != utils.QueueNameFromURL(tab.queueURL) {
+			m.errorMsg = queue.ErrPurgeNotConfirmed.Error()
+			return nil
+		}
+
+		var backupPath string
+		if m.purgeBackup {
+			backupPath = queue.DefaultBackupPath(confirmation, queue.ArchiveFormatJSONL, time.Now())
+		}
+
+		m.purgeInput.Blur()
+		m.activeView = msgsListView
+		m.message = "purging queue" + fetchingIndicator
+		ctx, cancel := context.WithTimeout(context.Background(), queue.DefaultDrainTimeLimit)
+		tab.purge = &runningPurge{cancel: cancel, done: make(chan struct{})}
+		return purgeQueue(ctx, tab.purge, tab.sqsClient, tab.config, tab.id, confirmation, backupPath)
+	default:
+		var cmd tea.Cmd
+		m.purgeInput, cmd = m.purgeInput.Update(msg)
+		return cmd
+	}
+
+	return nil
+}
+
+func (m *Model) handleQueueBrowserKeys(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	if m.queuesList.FilterState() == list.Filtering {
+		return nil
+	}
+
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "esc":
+		if m.queuesList.FilterState() != list.FilterApplied && len(m.tabs) > 0 {
+			m.activeView = msgsListView
+		}
+	case "?":
+		m.lastView = m.activeView
+		m.activeView = helpView
+	case "enter":
+		item, ok := m.queuesList.SelectedItem().(queueItem)
+		if !ok {
+			return nil
+		}
+
+		return m.openTab(m.sqsClients[m.browserConfig.AWSConfigSource.String()], t.Config{
+			ProfileName:     item.name,
+			QueueURL:        item.url,
+			AWSConfigSource: m.browserConfig.AWSConfigSource,
+			Format:          m.browserConfig.Format,
+			ReadOnly:        m.browserConfig.ReadOnly,
+		})
+	case "s":
+		item, ok := m.queuesList.SelectedItem().(queueItem)
+		if !ok {
+			return nil
+		}
+		return saveQueueAsProfile(m.browserConfig.ConfigPath, t.ProfileConfig{
+			Name:            item.name,
+			QueueURL:        item.url,
+			AWSConfigSource: m.browserConfig.AWSConfigSource.String(),
+			Format:          m.browserConfig.Format.Display(),
+			ReadOnly:        m.browserConfig.ReadOnly,
+		})
+	case "ctrl+r":
+		// counts still pending for the previous listing aren't fetched
+		m.pendingCounts = nil
+		m.message = fetchingIndicator
+		return tea.Batch(
+			m.queuesList.SetItems(make([]list.Item, 0)),
+			listQueues(m.sqsClients[m.browserConfig.AWSConfigSource.String()], m.browserConfig.QueuePrefix),
+		)
+	}
+
+	return nil
+}
+
+// fetchNextQueueCount fetches the message count of the next listed queue
+// whose count is yet to be fetched, if any.
+func (m *Model) fetchNextQueueCount() tea.Cmd {
+	if len(m.pendingCounts) == 0 {
+		return nil
+	}
+
+	queueURL := m.pendingCounts[0]
+	m.pendingCounts = m.pendingCounts[1:]
+
+	return getListedQueueMsgCount(m.sqsClients[m.browserConfig.AWSConfigSource.String()], queueURL)
+}
+
+func (m *Model) updateQueueItem(msg QueueMsgCountFetchedMsg) {
+	for i, listItem := range m.queuesList.Items() {
+		item, ok := listItem.(queueItem)
+		if !ok || item.url != msg.queueURL {
+			continue
+		}
+
+		if msg.err != nil {
+			item.err = msg.err
+		} else {
+			depth := msg.sample.QueueDepth
+			item.depth = &depth
+			item.err = nil
+		}
+		m.queuesList.SetItem(i, item)
+		return
+	}
 }
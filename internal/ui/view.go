@@ -2,13 +2,21 @@ package ui
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dhth/cueitup/internal/queue"
 	"github.com/dhth/cueitup/internal/utils"
 )
 
 var listWidth = 52
 
+const (
+	tabTitleMaxWidth = 24
+	filterMaxWidth   = 40
+)
+
 func (m Model) View() string {
 	var content string
 	var footer string
@@ -20,28 +28,69 @@ func (m Model) View() string {
 		statusBar = m.message
 	}
 
-	m.msgsList.Styles.Title = m.msgsList.Styles.Title.Background(lipgloss.Color(inactivePaneColor))
-	msgValTitleStyleToUse := msgValueTitleStyle
+	tab := m.tab()
+	activeView := m.activeView
+	if tab == nil && (activeView == msgsListView || activeView == msgValueView || activeView == msgsFilterView || activeView == fetchPredicateView || activeView == msgValueSearchView || activeView == msgDiffView) {
+		activeView = profilePickerView
+	}
 
-	switch m.activeView {
-	case msgsListView:
-		m.msgsList.Styles.Title = m.msgsList.Styles.Title.Background(lipgloss.Color(cueitupColor))
-	case msgValueView:
+	msgValTitleStyleToUse := msgValueTitleStyle
+	if activeView == msgValueView || activeView == msgValueSearchView {
 		msgValTitleStyleToUse = msgValTitleStyleToUse.Background(lipgloss.Color(cueitupColor))
 	}
 
-	if !m.behaviours.DeleteMessages {
-		mode += " " + deletingMsgsStyle.Render("not deleting msgs!")
-	} else {
-		mode += " " + deletingMsgsStyle.Render("deleting msgs!")
-	}
+	switch activeView {
+	case queueBrowserView:
+		mode += " " + browsingStyle.Render(fmt.Sprintf("browsing queues via %q", m.browserConfig.AWSConfigSource.String()))
+		if m.browserConfig.ReadOnly {
+			mode += " " + readOnlyStyle.Render("read-only!")
+		}
+	case profilePickerView:
+		mode += " " + browsingStyle.Render("choose a profile")
+	default:
+		if tab == nil {
+			break
+		}
+
+		switch {
+		case tab.config.ReadOnly:
+			mode += " " + readOnlyStyle.Render("read-only!")
+		case !tab.behaviours.DeleteMessages:
+			mode += " " + deletingMsgsStyle.Render("not deleting msgs!")
+		default:
+			mode += " " + deletingMsgsStyle.Render("deleting msgs!")
+		}
+
+		if tab.behaviours.PersistMessages {
+			mode += " " + persistingStyle.Render("persisting msgs!")
+		}
+
+		if tab.behaviours.SkipMessages {
+			mode += " " + skippingStyle.Render("skipping msgs!")
+		}
+
+		if tab.offline {
+			mode += " " + browsingStyle.Render("viewing offline")
+		}
+
+		if tab.fetchingMatches {
+			mode += " " + filteringStyle.Render(fmt.Sprintf("fetching where: %s", utils.Trim(tab.predicate, filterMaxWidth)))
+		}
+
+		if tab.filter != nil {
+			mode += " " + filteringStyle.Render(fmt.Sprintf("filter: %s", utils.Trim(tab.filter.String(), filterMaxWidth)))
+		}
 
-	if m.behaviours.PersistMessages {
-		mode += " " + persistingStyle.Render("persisting msgs!")
+		if tab.diffBase != nil {
+			mode += " " + browsingStyle.Render(fmt.Sprintf("diff base: %s", utils.Trim(tab.diffBase.ID, filterMaxWidth)))
+		}
 	}
 
-	if m.behaviours.SkipMessages {
-		mode += " " + skippingStyle.Render("skipping msgs!")
+	var queueDepth string
+	if tab != nil && tab.behaviours.ShowMessageCount && activeView != queueBrowserView && activeView != profilePickerView {
+		if summary := queueDepthSummary(tab.depthHistory); summary != "" {
+			queueDepth = " " + queueDepthStyle.Render(summary)
+		}
 	}
 
 	var errorMsg string
@@ -53,7 +102,7 @@ func (m Model) View() string {
 	if !m.msgValueVPReady {
 		msgValueVP = "\n  Initializing..."
 	} else {
-		msgValueVP = msgValueVPStyle.Render(fmt.Sprintf("%s\n\n%s\n", msgValTitleStyleToUse.Render("Message Value"), m.msgValueVP.View()))
+		msgValueVP = msgValueVPStyle.Render(fmt.Sprintf("%s\n\n%s\n", msgValTitleStyleToUse.Render(m.msgValueTitle()), m.msgValueVP.View()))
 	}
 	var helpVP string
 	if !m.helpVPReady {
@@ -61,22 +110,83 @@ func (m Model) View() string {
 	} else {
 		helpVP = helpVPStyle.Render(fmt.Sprintf("  %s\n\n%s\n", helpVPTitleStyle.Render("Help"), m.helpVP.View()))
 	}
+	var diffVP string
+	if !m.diffVPReady {
+		diffVP = "\n  Initializing..."
+	} else {
+		diffVP = diffVPStyle.Render(fmt.Sprintf("%s\n\n%s\n", msgValueTitleStyle.Background(lipgloss.Color(cueitupColor)).Render(m.diffTitle), m.diffVP.View()))
+	}
 
-	switch m.activeView {
-	case msgsListView:
-		content = lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			msgListStyle.Render(m.msgsList.View()),
-			msgValueVP,
-		)
-	case msgValueView:
-		content = lipgloss.JoinHorizontal(
-			lipgloss.Top,
-			msgListStyle.Render(m.msgsList.View()),
-			msgValueVP,
+	var eventsVP string
+	if !m.eventsVPReady {
+		eventsVP = "\n  Initializing..."
+	} else {
+		eventsVP = eventsVPStyle.Render(fmt.Sprintf("%s\n\n%s\n", helpVPTitleStyle.Render("Event Log"), m.eventsVP.View()))
+	}
+
+	var problems string
+	if m.unseenProblems > 0 {
+		problems = " " + problemsStyle.Render(fmt.Sprintf("%d new problems (press L)", m.unseenProblems))
+	}
+
+	switch activeView {
+	case msgsListView, msgValueView, msgsFilterView, fetchPredicateView, msgValueSearchView:
+		switch activeView {
+		case msgsFilterView:
+			statusBar = m.filterInput.View()
+		case fetchPredicateView:
+			statusBar = m.predicateInput.View()
+		case msgValueSearchView:
+			statusBar = m.searchInput.View()
+		}
+
+		msgsList := tab.msgsList
+		msgsList.Styles.Title = msgsList.Styles.Title.Background(lipgloss.Color(inactivePaneColor))
+		if activeView != msgValueView && activeView != msgValueSearchView {
+			msgsList.Styles.Title = msgsList.Styles.Title.Background(lipgloss.Color(cueitupColor))
+		}
+
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.tabBar(),
+			lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				msgListStyle.Render(msgsList.View()),
+				msgValueVP,
+			),
 		)
 	case helpView:
 		content = helpVP
+	case msgDiffView:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.tabBar(),
+			diffVP,
+		)
+	case eventLogView:
+		content = eventsVP
+	case queueBrowserView:
+		content = selectionListStyle.Render(m.queuesList.View())
+	case profilePickerView:
+		content = selectionListStyle.Render(m.profilesList.View())
+	case replayFilesView:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.tabBar(),
+			selectionListStyle.Render(m.replayFilesList.View()),
+		)
+	case replayTargetView:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.tabBar(),
+			selectionListStyle.Render(m.replayTargetsList.View()),
+		)
+	case purgeConfirmView:
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.tabBar(),
+			m.purgeConfirmation(tab),
+		)
 	}
 
 	footerStyle := lipgloss.NewStyle().
@@ -92,11 +202,13 @@ func (m Model) View() string {
 		debugMsg += fmt.Sprintf(" %v", m.activeView)
 	}
 
-	footerStr := fmt.Sprintf("%s%s%s%s%s",
+	footerStr := fmt.Sprintf("%s%s%s%s%s%s%s",
 		modeStyle.Render("cueitup"),
 		debugMsg,
 		helpMsg,
 		mode,
+		queueDepth,
+		problems,
 		errorMsg,
 	)
 	footer = footerStyle.Render(footerStr)
@@ -107,3 +219,71 @@ func (m Model) View() string {
 		footer,
 	)
 }
+
+// msgValueTitle shows the body view (if the profile has several), the path
+// under the tree's cursor (in the tree view), and the search's match counter,
+// if a search is active.
+func (m Model) msgValueTitle() string {
+	title := "Message Value"
+	if m.msgTree != nil {
+		title = "Message Tree"
+	}
+
+	if tab := m.tab(); tab != nil && len(tab.config.BodyViews()) > 1 {
+		title += fmt.Sprintf(" [%s]", tab.bodyView)
+	}
+
+	if m.msgTree != nil {
+		title += fmt.Sprintf(" (%s)", utils.Trim(m.msgTree.selectedPath(), filterMaxWidth))
+	}
+
+	if m.searchQuery == "" {
+		return title
+	}
+
+	if len(m.searchMatches) == 0 {
+		return fmt.Sprintf("%s (no matches for %q)", title, utils.Trim(m.searchQuery, filterMaxWidth))
+	}
+
+	return fmt.Sprintf("%s (match %d/%d for %q)", title, m.searchCurrent+1, len(m.searchMatches), utils.Trim(m.searchQuery, filterMaxWidth))
+}
+
+func (m Model) purgeConfirmation(tab *queueTab) string {
+	queueName := utils.QueueNameFromURL(tab.queueURL)
+
+	backup := "[ ] back up messages before purging"
+	if m.purgeBackup {
+		backup = fmt.Sprintf("[x] back up messages to %s before purging", queue.DefaultBackupPath(queueName, queue.ArchiveFormatJSONL, time.Now()))
+	}
+
+	return purgeViewStyle.Render(fmt.Sprintf(`%s
+
+This will delete ALL messages in the queue %q.
+Type the queue's name to confirm.
+
+%s
+
+%s
+
+<enter> purge    <ctrl+b> toggle backup    <esc> cancel
+`,
+		purgeTitleStyle.Render(fmt.Sprintf("Purge %q", queueName)),
+		queueName,
+		m.purgeInput.View(),
+		backup,
+	))
+}
+
+func (m Model) tabBar() string {
+	titles := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		title := fmt.Sprintf("%d %s", i+1, utils.Trim(tab.config.ProfileName, tabTitleMaxWidth))
+		if i == m.activeTab {
+			titles[i] = activeTabStyle.Render(title)
+		} else {
+			titles[i] = inactiveTabStyle.Render(title)
+		}
+	}
+
+	return strings.Join(titles, " ")
+}
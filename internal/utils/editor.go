@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	defaultEditor = "vi"
+	defaultPager  = "less"
+)
+
+// EditorCmd returns a command that opens path in the user's editor, as set via
+// $VISUAL or $EDITOR (which can include arguments).
+func EditorCmd(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	return commandWithFallback(editor, defaultEditor, path)
+}
+
+// PagerCmd returns a command that opens path in the user's pager, as set via
+// $PAGER (which can include arguments).
+func PagerCmd(path string) *exec.Cmd {
+	return commandWithFallback(os.Getenv("PAGER"), defaultPager, path)
+}
+
+func commandWithFallback(command, fallback, path string) *exec.Cmd {
+	parts := strings.Fields(command)
+	if len(parts) == 0 {
+		parts = []string{fallback}
+	}
+
+	return exec.Command(parts[0], append(parts[1:], path)...)
+}
@@ -0,0 +1,8 @@
+package utils
+
+import "strings"
+
+func QueueNameFromURL(queueURL string) string {
+	queueParts := strings.Split(strings.TrimSuffix(queueURL, "/"), "/")
+	return queueParts[len(queueParts)-1]
+}
@@ -70,4 +70,272 @@ func TestCLI(t *testing.T) {
 `
 		assert.Equal(t, expected, string(outputBytes))
 	})
+
+	t.Run("Serving requires profiles or --all", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "serve", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err)
+		assert.Contains(t, string(outputBytes), "at least one profile needs to be provided (or use --all)")
+	})
+
+	t.Run("Serving all profiles in debug mode", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "serve", "--all", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "profile-a")
+		assert.Contains(t, output, "profile-b")
+		assert.Contains(t, output, "profile-c")
+	})
+
+	t.Run("Serving all profiles warns about invalid ones", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "serve", "--all", "-d", "-c", "static/config-bad.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, `warning: skipping profile at index 1, since its config is invalid:
+  - profile name is empty
+`)
+		assert.Contains(t, output, `warning: skipping profile "profile-b", since its config is invalid:
+  - encoding format is incorrect: "unknown"; possible values: [json, none]
+`)
+		assert.Contains(t, output, `warning: skipping profile "profile-c", since its config is invalid:
+  - context key is empty
+  - subset key is empty
+`)
+	})
+
+	t.Run("Read-only flag marks profiles as read-only", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "tui", "profile-b", "--read-only", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "- read only               true")
+	})
+
+	t.Run("Debug output shows where behaviours come from", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "tui", "profile-c", "-d", "--skip-messages", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "- delete messages         false (profile)")
+		assert.Contains(t, output, "- persist messages        true (profile)")
+		assert.Contains(t, output, "- show message count      true (default)")
+		assert.Contains(t, output, "- skip messages           true (flag)")
+	})
+
+	t.Run("Purging requires the queue name as confirmation", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "purge", "profile-b", "--confirm", "queue-a", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "purge not confirmed")
+	})
+
+	t.Run("Purge debug output shows the backup path", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "purge", "profile-b", "--backup-path", "backup.jsonl", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "- backup path             backup.jsonl")
+	})
+
+	t.Run("Backup debug output shows the archive format from the output path", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "backup", "profile-b", "-o", "backup.tar.gz", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "- mode                    peek")
+		assert.Contains(t, output, "- format                  tar.gz")
+	})
+
+	t.Run("Draining a read-only queue fails", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "backup", "profile-b", "--mode", "drain", "--read-only", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "profile is read-only")
+	})
+
+	t.Run("Fetch debug output shows the predicate", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "fetch", "profile-b", "-w", "$.kind = Created and @ApproximateReceiveCount > 3", "-n", "5", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "- predicate               $.kind = Created and @ApproximateReceiveCount > 3")
+		assert.Contains(t, output, "- max matches             5")
+		assert.Contains(t, output, "- output                  stdout")
+	})
+
+	t.Run("Fetching fails for an invalid predicate", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "fetch", "profile-b", "-w", "kind = Created", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "invalid options for fetching messages")
+	})
+
+	t.Run("Fetching fails for a timeout over the maximum visibility timeout", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "fetch", "profile-b", "-t", "11h59m31s", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "timeout needs to be between 1s and 11h59m30s")
+	})
+
+	t.Run("Fetching works for the maximum timeout", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "fetch", "profile-b", "-t", "11h59m30s", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+	})
+
+	t.Run("Fetching and deleting from a read-only queue fails", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "fetch", "profile-b", "--delete", "--read-only", "-d", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "profile is read-only")
+	})
+
+	t.Run("Destructive commands refuse to run with the global read-only flag", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			args []string
+		}{
+			{name: "purge", args: []string{"--read-only", "purge", "profile-b", "--confirm", "queue-b"}},
+			{name: "restore", args: []string{"restore", "profile-b", "backup.jsonl", "-R"}},
+			{name: "replay", args: []string{"replay", "profile-b", "--dry-run", "--read-only"}},
+		}
+
+		for _, tt := range testCases {
+			t.Run(tt.name, func(t *testing.T) {
+				// GIVEN
+				args := append(tt.args, "-c", "static/config-good.yml")
+
+				// WHEN
+				c := exec.Command(binPath, args...)
+				outputBytes, err := c.CombinedOutput()
+
+				// THEN
+				require.Error(t, err, "output:\n%s", outputBytes)
+				assert.Contains(t, string(outputBytes), "profile is read-only")
+			})
+		}
+	})
+
+	t.Run("Restoring requires a known archive format", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "restore", "profile-b", "backup.zip", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "couldn't determine archive format")
+	})
+
+	t.Run("Replay dry run lists matching persisted messages", func(t *testing.T) {
+		// GIVEN
+		dir := t.TempDir()
+		for _, name := range []string{"1700000000-a.json", "1700000100-b.txt"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644))
+		}
+
+		// WHEN
+		c := exec.Command(binPath, "replay", "profile-b", "--dir", dir, "--glob", "*.json", "--dry-run", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "1700000000-a.json")
+		assert.NotContains(t, output, "1700000100-b.txt")
+	})
+
+	t.Run("Viewing persisted messages doesn't need a config file", func(t *testing.T) {
+		// GIVEN
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1700000000-a.json"), []byte(`{"id": "a"}`), 0o644))
+
+		// WHEN
+		c := exec.Command(binPath, "view", dir, "-k", "id", "-d", "-c", "static/missing.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.NoError(t, err, "output:\n%s", outputBytes)
+		output := string(outputBytes)
+		assert.Contains(t, output, "- context key             id")
+		assert.Contains(t, output, "- messages                1")
+	})
+
+	t.Run("Viewing fails for keys used with plain text messages", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "view", t.TempDir(), "-f", "none", "-k", "id", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "context key can only be used when message format is JSON")
+	})
+
+	t.Run("Replaying fails for an invalid time bound", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "replay", "profile-b", "--since", "yesterday", "-c", "static/config-good.yml")
+		outputBytes, err := c.CombinedOutput()
+
+		// THEN
+		require.Error(t, err, "output:\n%s", outputBytes)
+		assert.Contains(t, string(outputBytes), "invalid time")
+	})
 }